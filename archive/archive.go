@@ -0,0 +1,166 @@
+package archive
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// Record Archived Copy of a Message
+type Record struct {
+	Time            time.Time              `json:"time"`                       // Time Message was Archived
+	Exchange        string                 `json:"exchange,omitempty"`         // Exchange Message was Published To
+	RoutingKey      string                 `json:"routing_key"`                // Routing Key (Queue for Default Exchange)
+	MessageID       string                 `json:"message_id,omitempty"`       // Message ID (if Known)
+	Type            string                 `json:"type,omitempty"`             // AMQP Message Type
+	ContentType     string                 `json:"content_type,omitempty"`     // Body Content Type
+	ContentEncoding string                 `json:"content_encoding,omitempty"` // Body Content Encoding
+	Headers         map[string]interface{} `json:"headers,omitempty"`          // AMQP Headers
+	Redelivered     bool                   `json:"redelivered,omitempty"`      // Broker Redelivery Flag
+	Body            json.RawMessage        `json:"body,omitempty"`             // JSON Body (as is)
+	Raw             []byte                 `json:"raw,omitempty"`              // Non JSON Body (Base64)
+}
+
+// NewRecord Create Record for Delivery
+func NewRecord(d *amqp.Delivery) *Record {
+	r := &Record{
+		Time:            time.Now().UTC(),
+		Exchange:        d.Exchange,
+		RoutingKey:      d.RoutingKey,
+		MessageID:       queue.MessageID(d),
+		Type:            d.Type,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		Redelivered:     d.Redelivered,
+	}
+
+	// Do we have Headers?
+	if len(d.Headers) > 0 { // YES
+		r.Headers = make(map[string]interface{}, len(d.Headers))
+		for k, v := range d.Headers {
+			r.Headers[k] = v
+		}
+	}
+
+	// Can Body be Stored as JSON?
+	if d.ContentEncoding == "" && isJSON(d.ContentType) && json.Valid(d.Body) { // YES
+		r.Body = json.RawMessage(d.Body)
+	} else {
+		r.Raw = d.Body
+	}
+	return r
+}
+
+// Payload Message Body as Originally Published
+func (r *Record) Payload() []byte {
+	// Was Body Stored as JSON?
+	if r.Body != nil { // YES
+		return r.Body
+	}
+	return r.Raw
+}
+
+// Store Persists Archived Records
+type Store interface {
+	Append(ctx context.Context, r *Record) error
+}
+
+// Sink Appends Every Message Seen to a Store
+//
+// Use Middleware to Archive Messages of a Queue while they are Processed, or
+// Consumer to Drain a Dedicated (i.e. Mirrored) Queue into the Archive.
+type Sink struct {
+	store    Store        // Archive Destination
+	archived uint64       // Records Appended
+	failed   uint64       // Records that Failed to Append
+	logger   queue.Logger // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+func NewSink(s Store) *Sink {
+	return &Sink{store: s}
+}
+
+// Logger Sink Logger (Standard Logger if not Set)
+func (s *Sink) Logger() queue.Logger {
+	// Do we have a Logger?
+	if s.logger == nil { // NO: Use Standard Logger
+		return defaultLogger
+	}
+	return s.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (s *Sink) SetLogger(l queue.Logger) *Sink {
+	s.logger = l
+	return s
+}
+
+// Archive Append Delivery to Store
+func (s *Sink) Archive(ctx context.Context, d *amqp.Delivery) error {
+	err := s.store.Append(ctx, NewRecord(d))
+	if err != nil {
+		atomic.AddUint64(&s.failed, 1)
+		return err
+	}
+
+	atomic.AddUint64(&s.archived, 1)
+	return nil
+}
+
+// Handler Archive Messages (Archive Failures Requeue the Message)
+func (s *Sink) Handler() queue.Handler {
+	return s.Archive
+}
+
+// Middleware Archive Messages before Passing them to Handler
+//
+// NOTE: Archive Failures never Affect Message Processing
+func (s *Sink) Middleware() queue.Middleware {
+	return func(next queue.Handler) queue.Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			if err := s.Archive(ctx, d); err != nil {
+				s.Logger().Error("[Archive] Failed Archiving Delivery", queue.F("queue", d.RoutingKey), queue.F("delivery_tag", d.DeliveryTag), queue.F("error", err))
+			}
+			return next(ctx, d)
+		}
+	}
+}
+
+// Consumer Drain Queue into Archive until Cancelled
+func (s *Sink) Consumer(c *queue.AMQPServerConnection, channel string, q string) queue.Consumer {
+	return c.QueueConsumer(channel, q, s.Handler())
+}
+
+// Archived Records Appended
+func (s *Sink) Archived() uint64 {
+	return atomic.LoadUint64(&s.archived)
+}
+
+// Failed Records that could not be Appended
+func (s *Sink) Failed() uint64 {
+	return atomic.LoadUint64(&s.failed)
+}
+
+func isJSON(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
@@ -0,0 +1,158 @@
+package archive
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore ndjson
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Rotation Defaults
+const (
+	DefaultMaxFileSize = 64 << 20  // Bytes Written before File is Rotated
+	DefaultMaxFileAge  = time.Hour // Time File is Open before it is Rotated
+)
+
+// Extension of Archive Files
+const FileExtension = ".ndjson"
+
+// RotatingFile Store Appending Records to Newline Delimited JSON Files
+//
+// Files are Named "<prefix>-<UTC timestamp>.ndjson" and Rotated when they Reach
+// the Maximum Size or Age.
+type RotatingFile struct {
+	lock    sync.Mutex    // Writer Lock
+	dir     string        // Archive Directory
+	prefix  string        // File Name Prefix
+	maxSize int64         // Rotate after Bytes
+	maxAge  time.Duration // Rotate after Time
+	f       *os.File      // Current File
+	w       *bufio.Writer // Buffered Writer for Current File
+	size    int64         // Bytes Written to Current File
+	opened  time.Time     // Time Current File was Opened
+}
+
+func NewRotatingFile(dir string, prefix string) *RotatingFile {
+	return &RotatingFile{
+		dir:     dir,
+		prefix:  prefix,
+		maxSize: DefaultMaxFileSize,
+		maxAge:  DefaultMaxFileAge,
+	}
+}
+
+// SetMaxSize Rotate Files after 'n' Bytes (0: Never Rotate by Size)
+func (o *RotatingFile) SetMaxSize(n int64) *RotatingFile {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.maxSize = n
+	return o
+}
+
+// SetMaxAge Rotate Files after 'd' (0: Never Rotate by Age)
+func (o *RotatingFile) SetMaxAge(d time.Duration) *RotatingFile {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	o.maxAge = d
+	return o
+}
+
+func (o *RotatingFile) Append(_ context.Context, r *Record) error {
+	line, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	// Do we Need a New File?
+	if o.f == nil || o.expired(int64(len(line))) { // YES
+		if err = o.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := o.w.Write(line)
+	o.size += int64(n)
+	if err != nil {
+		return err
+	}
+
+	// NOTE: Flush per Record so a Crash Loses at most the Record being Written
+	return o.w.Flush()
+}
+
+// Rotate Close Current File (Next Append Opens a New One)
+func (o *RotatingFile) Rotate() error {
+	o.lock.Lock()
+	defer o.lock.Unlock()
+	return o.close()
+}
+
+// Close Current File
+func (o *RotatingFile) Close() error {
+	return o.Rotate()
+}
+
+func (o *RotatingFile) expired(next int64) bool {
+	// Would File Grow too Large?
+	if o.maxSize > 0 && o.size > 0 && o.size+next > o.maxSize { // YES
+		return true
+	}
+
+	return o.maxAge > 0 && time.Since(o.opened) >= o.maxAge
+}
+
+func (o *RotatingFile) rotate() error {
+	if err := o.close(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(o.dir, 0750); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	name := filepath.Join(o.dir, o.prefix+"-"+now.Format("20060102T150405.000000000")+FileExtension)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+
+	o.f = f
+	o.w = bufio.NewWriter(f)
+	o.size = 0
+	o.opened = now
+	return nil
+}
+
+func (o *RotatingFile) close() error {
+	// Do we have an Open File?
+	if o.f == nil { // NO
+		return nil
+	}
+
+	err := o.w.Flush()
+	if cerr := o.f.Close(); err == nil {
+		err = cerr
+	}
+	o.f = nil
+	o.w = nil
+	return err
+}
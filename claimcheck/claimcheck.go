@@ -0,0 +1,240 @@
+package claimcheck
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofrs/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// ContentType of Messages Carrying a Claim Check Reference
+const ContentType = "application/vnd.objectvault.claim-check+json"
+
+// DefaultThreshold Bodies Larger than this are Externalized
+const DefaultThreshold = 256 * 1024
+
+var (
+	ErrNoStore          = errors.New("[ClaimCheck] Missing Blob Store")
+	ErrBlobNotFound     = errors.New("[ClaimCheck] Blob not Found")
+	ErrChecksumMismatch = errors.New("[ClaimCheck] Blob Checksum Mismatch")
+	ErrInvalidReference = errors.New("[ClaimCheck] Invalid Reference")
+)
+
+// BlobStore Holds Externalized Payloads
+type BlobStore interface {
+	Put(ctx context.Context, key string, data []byte) error
+	Get(ctx context.Context, key string) ([]byte, error) // ErrBlobNotFound if Missing
+	Delete(ctx context.Context, key string) error
+}
+
+// Reference Published in Place of the Payload
+type Reference struct {
+	Key         string `json:"key"`          // Blob Key
+	Size        int    `json:"size"`         // Payload Size (bytes)
+	SHA256      string `json:"sha256"`       // Payload Checksum (hex)
+	ContentType string `json:"content_type"` // Original Content Type
+}
+
+// Publisher Delivers Encoded Messages (i.e. queue.AMQPServerConnection)
+type Publisher interface {
+	QueuePublish(channel string, queue string, contentType string, body []byte) error
+}
+
+type ClaimCheck struct {
+	store     BlobStore // Payload Storage
+	threshold int       // Size above which Payloads are Externalized
+	prefix    string    // [OPTIONAL] Key Prefix
+}
+
+func NewClaimCheck(s BlobStore) *ClaimCheck {
+	return &ClaimCheck{
+		store:     s,
+		threshold: DefaultThreshold,
+	}
+}
+
+func (c *ClaimCheck) Store() BlobStore {
+	return c.store
+}
+
+// SetThreshold Externalize Bodies Larger than 'n' Bytes
+func (c *ClaimCheck) SetThreshold(n int) *ClaimCheck {
+	// Is Threshold Valid?
+	if n >= 0 { // YES
+		c.threshold = n
+	}
+	return c
+}
+
+// SetKeyPrefix Prefix Added to Generated Blob Keys
+func (c *ClaimCheck) SetKeyPrefix(p string) *ClaimCheck {
+	c.prefix = p
+	return c
+}
+
+// Externalize Store Body if over Threshold, Returning Content Type and Body to Publish
+func (c *ClaimCheck) Externalize(ctx context.Context, contentType string, body []byte) (string, []byte, error) {
+	// Is Body Small Enough to Send Directly?
+	if len(body) <= c.threshold { // YES
+		return contentType, body, nil
+	}
+
+	// Do we have a Store?
+	if c.store == nil { // NO
+		return "", nil, ErrNoStore
+	}
+
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return "", nil, err
+	}
+
+	sum := sha256.Sum256(body)
+	ref := &Reference{
+		Key:         c.prefix + uid.String(),
+		Size:        len(body),
+		SHA256:      hex.EncodeToString(sum[:]),
+		ContentType: contentType,
+	}
+
+	err = c.store.Put(ctx, ref.Key, body)
+	if err != nil {
+		return "", nil, fmt.Errorf("[ClaimCheck] Storing Blob [%s]: %w", ref.Key, err)
+	}
+
+	b, err := json.Marshal(ref)
+	if err != nil {
+		return "", nil, err
+	}
+	return ContentType, b, nil
+}
+
+// IsReference Does Delivery Carry a Claim Check?
+func IsReference(d *amqp.Delivery) bool {
+	return d != nil && d.ContentType == ContentType
+}
+
+// Fetch Retrieve and Verify Payload for Reference
+func (c *ClaimCheck) Fetch(ctx context.Context, ref *Reference) ([]byte, error) {
+	// Do we have a Store?
+	if c.store == nil { // NO
+		return nil, ErrNoStore
+	}
+
+	body, err := c.store.Get(ctx, ref.Key)
+	if err != nil {
+		return nil, fmt.Errorf("[ClaimCheck] Fetching Blob [%s]: %w", ref.Key, err)
+	}
+
+	// Does Payload Match Reference?
+	sum := sha256.Sum256(body)
+	if len(body) != ref.Size || hex.EncodeToString(sum[:]) != ref.SHA256 { // NO
+		return nil, fmt.Errorf("%w [%s]", ErrChecksumMismatch, ref.Key)
+	}
+	return body, nil
+}
+
+// Resolve Replace Claim Check in Delivery with the Original Payload (No-Op for Normal Messages)
+func (c *ClaimCheck) Resolve(ctx context.Context, d *amqp.Delivery) (*Reference, error) {
+	// Is it a Claim Check?
+	if !IsReference(d) { // NO
+		return nil, nil
+	}
+
+	// NOTE: Reference may have been Compressed on Publish
+	b, err := queue.DecodeBody(d)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReference, err)
+	}
+
+	ref := &Reference{}
+	err = json.Unmarshal(b, ref)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReference, err)
+	}
+
+	body, err := c.Fetch(ctx, ref)
+	if err != nil {
+		return ref, err
+	}
+
+	// Payload is Stored Plain
+	d.ContentType = ref.ContentType
+	d.ContentEncoding = ""
+	d.Body = body
+	return ref, nil
+}
+
+// Release Delete Payload once Message has been Handled
+func (c *ClaimCheck) Release(ctx context.Context, ref *Reference) error {
+	// Do we have Something to Release?
+	if ref == nil || c.store == nil { // NO
+		return nil
+	}
+	return c.store.Delete(ctx, ref.Key)
+}
+
+// Publisher Wrap Publisher so Large Bodies are Externalized
+func (c *ClaimCheck) Publisher(p Publisher) Publisher {
+	return &publisher{c, p}
+}
+
+// IsPermanent Can Resolving the Claim Check Never Succeed (Invalid Reference, Missing or Corrupt Blob)?
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrInvalidReference) || errors.Is(err, ErrBlobNotFound) || errors.Is(err, ErrChecksumMismatch)
+}
+
+// Middleware Resolve Claim Checks before Handler (release: Delete Blob once Handled)
+//
+// Messages whose Claim Check can't be Resolved (see IsPermanent) are
+// Quarantined, Store Failures are Returned as is (Message is Retried).
+func (c *ClaimCheck) Middleware(release bool) queue.Middleware {
+	return func(next queue.Handler) queue.Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			ref, err := c.Resolve(ctx, d)
+			// Will Retrying Help?
+			if IsPermanent(err) { // NO: Quarantine
+				return queue.QuarantineError(err)
+			}
+			if err != nil {
+				return err
+			}
+
+			err = next(ctx, d)
+			if err == nil && release {
+				c.Release(ctx, ref)
+			}
+			return err
+		}
+	}
+}
+
+type publisher struct {
+	cc   *ClaimCheck
+	next Publisher
+}
+
+func (p *publisher) QueuePublish(channel string, queue string, contentType string, body []byte) error {
+	ct, b, err := p.cc.Externalize(context.Background(), contentType, body)
+	if err != nil {
+		return err
+	}
+	return p.next.QueuePublish(channel, queue, ct, b)
+}
@@ -0,0 +1,173 @@
+package claimcheck
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// capture Publisher Recording the Last Message
+type capture struct {
+	contentType string
+	body        []byte
+}
+
+func (p *capture) QueuePublish(channel string, queue string, contentType string, body []byte) error {
+	p.contentType = contentType
+	p.body = body
+	return nil
+}
+
+// failingStore Store whose Reads Fail (i.e. Network Error)
+type failingStore struct {
+	*MemoryStore
+}
+
+var errUnavailable = errors.New("store unavailable")
+
+func (failingStore) Get(context.Context, string) ([]byte, error) {
+	return nil, errUnavailable
+}
+
+// offload Publish 'body' through Claim Check, Returning the Delivery and the Blob Key
+func offload(t *testing.T, c *ClaimCheck, body []byte) (*amqp.Delivery, string) {
+	t.Helper()
+
+	p := &capture{}
+	if err := c.Publisher(p).QueuePublish("ch", "q", "application/octet-stream", body); err != nil {
+		t.Fatal(err)
+	}
+	if p.contentType != ContentType {
+		t.Fatalf("expected claim check, got %q", p.contentType)
+	}
+
+	ref := &Reference{}
+	if err := json.Unmarshal(p.body, ref); err != nil {
+		t.Fatal(err)
+	}
+	return &amqp.Delivery{ContentType: p.contentType, Body: p.body}, ref.Key
+}
+
+func TestSmallBodiesPublishedDirectly(t *testing.T) {
+	s := NewMemoryStore()
+	c := NewClaimCheck(s).SetThreshold(8)
+
+	p := &capture{}
+	if err := c.Publisher(p).QueuePublish("ch", "q", "text/plain", []byte("small")); err != nil {
+		t.Fatal(err)
+	}
+	if p.contentType != "text/plain" || string(p.body) != "small" || len(s.blobs) != 0 {
+		t.Fatalf("expected direct publish, got %q [%s]", p.contentType, p.body)
+	}
+}
+
+func TestOffloadResolveRelease(t *testing.T) {
+	s := NewMemoryStore()
+	c := NewClaimCheck(s).SetThreshold(8).SetKeyPrefix("blobs/")
+
+	body := bytes.Repeat([]byte{0, 0xff}, 64)
+	d, key := offload(t, c, body)
+	if _, err := s.Get(context.Background(), key); err != nil {
+		t.Fatalf("expected stored blob [%s]: %v", key, err)
+	}
+
+	var got *amqp.Delivery
+	h := c.Middleware(true)(func(ctx context.Context, d *amqp.Delivery) error {
+		got = d
+		return nil
+	})
+	if err := h(context.Background(), d); err != nil {
+		t.Fatal(err)
+	}
+
+	if got.ContentType != "application/octet-stream" || !bytes.Equal(got.Body, body) {
+		t.Fatalf("expected original payload, got %q (%d bytes)", got.ContentType, len(got.Body))
+	}
+	if _, err := s.Get(context.Background(), key); !errors.Is(err, ErrBlobNotFound) {
+		t.Fatalf("expected blob released, got %v", err)
+	}
+}
+
+func TestBlobKeptWhenHandlerFails(t *testing.T) {
+	s := NewMemoryStore()
+	c := NewClaimCheck(s).SetThreshold(8)
+	d, key := offload(t, c, bytes.Repeat([]byte("x"), 64))
+
+	failed := errors.New("handler failed")
+	h := c.Middleware(true)(func(context.Context, *amqp.Delivery) error {
+		return failed
+	})
+	if err := h(context.Background(), d); !errors.Is(err, failed) {
+		t.Fatalf("expected handler error, got %v", err)
+	}
+	if _, err := s.Get(context.Background(), key); err != nil {
+		t.Fatalf("expected blob kept for redelivery, got %v", err)
+	}
+}
+
+func TestChecksumMismatchQuarantined(t *testing.T) {
+	s := NewMemoryStore()
+	c := NewClaimCheck(s).SetThreshold(8)
+	d, key := offload(t, c, bytes.Repeat([]byte("x"), 64))
+
+	// Corrupt Blob (Same Size)
+	s.Put(context.Background(), key, bytes.Repeat([]byte("y"), 64))
+
+	err := c.Middleware(false)(nop)(context.Background(), d)
+	if !errors.Is(err, ErrChecksumMismatch) || !queue.IsQuarantineError(err) {
+		t.Fatalf("expected quarantined checksum mismatch, got %v", err)
+	}
+}
+
+func TestMissingBlobQuarantined(t *testing.T) {
+	s := NewMemoryStore()
+	c := NewClaimCheck(s).SetThreshold(8)
+	d, key := offload(t, c, bytes.Repeat([]byte("x"), 64))
+	s.Delete(context.Background(), key)
+
+	err := c.Middleware(false)(nop)(context.Background(), d)
+	if !errors.Is(err, ErrBlobNotFound) || !queue.IsQuarantineError(err) {
+		t.Fatalf("expected quarantined missing blob, got %v", err)
+	}
+}
+
+func TestInvalidReferenceQuarantined(t *testing.T) {
+	c := NewClaimCheck(NewMemoryStore())
+	d := &amqp.Delivery{ContentType: ContentType, Body: []byte("not json")}
+
+	err := c.Middleware(false)(nop)(context.Background(), d)
+	if !errors.Is(err, ErrInvalidReference) || !queue.IsQuarantineError(err) {
+		t.Fatalf("expected quarantined invalid reference, got %v", err)
+	}
+}
+
+func TestStoreFailureRetried(t *testing.T) {
+	s := NewMemoryStore()
+	c := NewClaimCheck(s).SetThreshold(8)
+	d, _ := offload(t, c, bytes.Repeat([]byte("x"), 64))
+
+	err := NewClaimCheck(failingStore{s}).Middleware(false)(nop)(context.Background(), d)
+	if !errors.Is(err, errUnavailable) || queue.IsQuarantineError(err) {
+		t.Fatalf("expected retryable store error, got %v", err)
+	}
+}
+
+func nop(context.Context, *amqp.Delivery) error {
+	return nil
+}
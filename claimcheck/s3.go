@@ -0,0 +1,303 @@
+package claimcheck
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// cSpell:ignore minio kms hmac
+
+// Server Side Encryption Modes
+const (
+	SSENone = ""        // No Server Side Encryption
+	SSES3   = "AES256"  // S3 Managed Keys
+	SSEKMS  = "aws:kms" // KMS Managed Keys
+)
+
+var ErrS3Configuration = errors.New("[S3Store] Invalid Configuration")
+
+// S3 Compatible Store Settings (AWS S3, MinIO)
+type S3Config struct {
+	Endpoint     string `json:"endpoint" yaml:"endpoint" toml:"endpoint"`                                                 // i.e. https://s3.eu-west-1.amazonaws.com or http://minio:9000
+	Region       string `json:"region,omitempty" yaml:"region,omitempty" toml:"region,omitempty"`                         // [OPTIONAL] Signing Region (DEFAULT: us-east-1)
+	Bucket       string `json:"bucket" yaml:"bucket" toml:"bucket"`                                                       // Bucket Name
+	Prefix       string `json:"prefix,omitempty" yaml:"prefix,omitempty" toml:"prefix,omitempty"`                         // [OPTIONAL] Key Prefix inside Bucket
+	AccessKey    string `json:"access_key" yaml:"access_key" toml:"access_key"`                                           // Access Key ID
+	SecretKey    string `json:"secret_key" yaml:"secret_key" toml:"secret_key"`                                           // Secret Access Key
+	SessionToken string `json:"session_token,omitempty" yaml:"session_token,omitempty" toml:"session_token,omitempty"`    // [OPTIONAL] Temporary Credentials Token
+	PathStyle    bool   `json:"path_style,omitempty" yaml:"path_style,omitempty" toml:"path_style,omitempty"`             // Use endpoint/bucket/key (Required for MinIO)
+	SSE          string `json:"sse,omitempty" yaml:"sse,omitempty" toml:"sse,omitempty"`                                  // [OPTIONAL] AES256 or aws:kms
+	SSEKMSKeyID  string `json:"sse_kms_key_id,omitempty" yaml:"sse_kms_key_id,omitempty" toml:"sse_kms_key_id,omitempty"` // [OPTIONAL] KMS Key for aws:kms
+}
+
+// S3 Compatible Blob Store (Signature Version 4)
+type S3Store struct {
+	config   S3Config     // Store Settings
+	endpoint *url.URL     // Parsed Endpoint
+	client   *http.Client // HTTP Client
+	now      func() time.Time
+}
+
+func NewS3Store(cfg S3Config) (*S3Store, error) {
+	// Do we have the Required Settings?
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" { // NO
+		return nil, fmt.Errorf("%w: endpoint, bucket, access_key and secret_key are required", ErrS3Configuration)
+	}
+
+	u, err := url.Parse(cfg.Endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("%w: endpoint [%s]", ErrS3Configuration, cfg.Endpoint)
+	}
+
+	switch cfg.SSE {
+	case SSENone, SSES3, SSEKMS:
+	default:
+		return nil, fmt.Errorf("%w: sse [%s]", ErrS3Configuration, cfg.SSE)
+	}
+
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+
+	return &S3Store{
+		config:   cfg,
+		endpoint: u,
+		client:   &http.Client{Timeout: 60 * time.Second},
+		now:      time.Now,
+	}, nil
+}
+
+// SetHTTPClient Replace Default HTTP Client (i.e. Custom TLS)
+func (s *S3Store) SetHTTPClient(c *http.Client) *S3Store {
+	if c != nil {
+		s.client = c
+	}
+	return s
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, data []byte) error {
+	req, err := s.request(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	switch s.config.SSE {
+	case SSES3:
+		req.Header.Set("X-Amz-Server-Side-Encryption", SSES3)
+	case SSEKMS:
+		req.Header.Set("X-Amz-Server-Side-Encryption", SSEKMS)
+		if s.config.SSEKMSKeyID != "" {
+			req.Header.Set("X-Amz-Server-Side-Encryption-Aws-Kms-Key-Id", s.config.SSEKMSKeyID)
+		}
+	}
+
+	_, err = s.do(req, data)
+	return err
+}
+
+func (s *S3Store) Get(ctx context.Context, key string) ([]byte, error) {
+	req, err := s.request(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.do(req, nil)
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	req, err := s.request(ctx, http.MethodDelete, key, nil)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.do(req, nil)
+	// Is the Blob Already Gone?
+	if errors.Is(err, ErrBlobNotFound) { // YES
+		return nil
+	}
+	return err
+}
+
+func (s *S3Store) request(ctx context.Context, method string, key string, body []byte) (*http.Request, error) {
+	// Do we have a Key?
+	if key == "" { // NO
+		return nil, errors.New("[S3Store] Missing Key")
+	}
+
+	u := *s.endpoint
+	object := strings.TrimPrefix(s.config.Prefix+key, "/")
+	if s.config.PathStyle {
+		u.Path = "/" + s.config.Bucket + "/" + object
+	} else {
+		u.Host = s.config.Bucket + "." + u.Host
+		u.Path = "/" + object
+	}
+	u.RawPath = uriEncode(u.Path, false)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+	return req, nil
+}
+
+func (s *S3Store) do(req *http.Request, body []byte) ([]byte, error) {
+	sign(req, body, s.config.Region, s.config.AccessKey, s.config.SecretKey, s.config.SessionToken, s.now())
+
+	res, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		return nil, ErrBlobNotFound
+	case res.StatusCode >= 300:
+		return nil, fmt.Errorf("[S3Store] %s %s failed [%d]: %s", req.Method, req.URL.Path, res.StatusCode, strings.TrimSpace(string(b)))
+	}
+	return b, nil
+}
+
+// sign Add AWS Signature Version 4 Authorization to Request
+func sign(req *http.Request, body []byte, region string, accessKey string, secretKey string, token string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	day := t.Format("20060102")
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	canonical, signedHeaders := canonicalRequest(req, payloadHash)
+	scope := day + "/" + region + "/s3/aws4_request"
+	signature := hex.EncodeToString(hmacSHA256(signingKey(secretKey, day, region), stringToSign(amzDate, scope, canonical)))
+
+	req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential="+accessKey+"/"+scope+", SignedHeaders="+signedHeaders+", Signature="+signature)
+}
+
+// canonicalRequest SigV4 Canonical Request and its Signed Headers
+//
+// Signs host, content-type, content-md5, date, range and x-amz-* Headers.
+func canonicalRequest(req *http.Request, payloadHash string) (string, string) {
+	headers := map[string]string{"host": req.URL.Host}
+	for k, v := range req.Header {
+		lk := strings.ToLower(k)
+		if lk == "content-type" || lk == "content-md5" || lk == "date" || lk == "range" || strings.HasPrefix(lk, "x-amz-") {
+			headers[lk] = strings.TrimSpace(strings.Join(v, ","))
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	return strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n"), signedHeaders
+}
+
+// stringToSign SigV4 String to Sign for Canonical Request
+func stringToSign(amzDate string, scope string, canonical string) string {
+	h := sha256.Sum256([]byte(canonical))
+	return "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(h[:])
+}
+
+// signingKey SigV4 Signing Key for Day and Region (S3 Service)
+func signingKey(secretKey string, day string, region string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secretKey), day)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, "s3")
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+func canonicalQuery(v url.Values) string {
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, k := range keys {
+		values := append([]string(nil), v[k]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, uriEncode(k, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode AWS URI Encoding (Unreserved Characters Kept, Optionally '/')
+func uriEncode(s string, encodeSlash bool) string {
+	const hexDigits = "0123456789ABCDEF"
+
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			b.WriteByte('%')
+			b.WriteByte(hexDigits[c>>4])
+			b.WriteByte(hexDigits[c&15])
+		}
+	}
+	return b.String()
+}
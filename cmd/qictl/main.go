@@ -0,0 +1,444 @@
+package main
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// qictl: Queue Debugging Tool
+//
+//	qictl -config queues.yaml [-section mail] validate
+//	qictl -config queues.yaml [-section mail] publish <queue> <message.json>
+//	qictl -config queues.yaml [-section mail] peek <queue> [count]
+//	qictl -config queues.yaml [-section mail] drain <queue> [count]
+//	qictl -config queues.yaml [-section mail] replay <dlq> [target] [count]
+//	qictl -config queues.yaml [-section mail] export <queue> <file.ndjson> [count]
+//	qictl -config queues.yaml [-section mail] import <file.ndjson> <queue>
+//	qictl decode <message.json>
+//
+// NOTE: peek is not Read Only. Peeked Messages are Held (Unacknowledged) until
+// the Command Ends, then Requeued: they are Hidden from Consumers meanwhile,
+// Redelivered Afterwards (Redelivered Flag Set, Quorum Queue Delivery Count
+// Incremented, which can Dead Letter Messages at the Queue's Delivery Limit)
+// and may Lose their Position in the Queue.
+
+// cSpell:ignore qictl dlq ndjson
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+	"github.com/objectvault/queue-interface/shared"
+)
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `Usage: qictl [options] <command> [arguments]
+
+Commands:
+  validate                      Validate Configuration File
+  publish <queue> <file.json>   Publish JSON Message File to Queue
+  peek <queue> [count]          Show Messages, then Requeue Them (Marked Redelivered)
+  drain <queue> [count]         Show and Remove Messages
+  replay <dlq> [target] [count] Republish Dead Letters (DEFAULT: Original Queue)
+  export <queue> <file> [count] Drain Messages to NDJSON File ("-": stdout)
+  import <file> <queue>         Publish Messages from NDJSON File ("-": stdin)
+  decode <file.json>            Pretty Print Message File
+
+Options:
+`)
+	flag.PrintDefaults()
+}
+
+func main() {
+	config := flag.String("config", "", "Queues configuration file (json, yaml or toml)")
+	section := flag.String("section", "activation", "Queue configuration section (activation or mail)")
+	flag.Usage = usage
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	var err error
+	switch args[0] {
+	case "validate":
+		err = cmdValidate(*config, *section)
+	case "publish":
+		err = cmdPublish(*config, *section, args[1:])
+	case "peek":
+		err = cmdRetrieve(*config, *section, args[1:], false)
+	case "drain":
+		err = cmdRetrieve(*config, *section, args[1:], true)
+	case "replay":
+		err = cmdReplay(*config, *section, args[1:])
+	case "export":
+		err = cmdExport(*config, *section, args[1:])
+	case "import":
+		err = cmdImport(*config, *section, args[1:])
+	case "decode":
+		err = cmdDecode(args[1:])
+	default:
+		usage()
+		os.Exit(2)
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "qictl:", err)
+		os.Exit(1)
+	}
+}
+
+func loadQueue(path string, section string) (*shared.Queue, error) {
+	// Do we have a Configuration File?
+	if path == "" { // NO
+		return nil, errors.New("missing -config")
+	}
+
+	cfg, err := shared.LoadQueuesFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg, err = cfg.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	var q *shared.Queue
+	switch section {
+	case "activation":
+		q = cfg.Activation
+	case "mail":
+		q = cfg.Mail
+	default:
+		return nil, fmt.Errorf("unknown section [%s]", section)
+	}
+
+	// Is the Section Defined?
+	if q == nil { // NO
+		return nil, fmt.Errorf("section [%s] not configured", section)
+	}
+
+	// Does the Section have Servers?
+	if len(q.Servers) == 0 { // NO
+		return nil, fmt.Errorf("section [%s] has no servers", section)
+	}
+
+	return q, nil
+}
+
+func connect(path string, section string) (*queue.AMQPServerConnection, error) {
+	q, err := loadQueue(path, section)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &queue.AMQPServerConnection{}
+	err = c.Configure(q)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = c.OpenConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func cmdValidate(path string, section string) error {
+	q, err := loadQueue(path, section)
+	if err != nil {
+		return err
+	}
+
+	// Can all Servers be Converted to URIs?
+	for i := range q.Servers {
+		_, err = q.Servers[i].URI()
+		if err != nil { // NO
+			return fmt.Errorf("server #%d: %v", i, err)
+		}
+	}
+
+	fmt.Printf("section [%s] prefix [%s]\n", section, q.QueuePrefix)
+	for _, uri := range q.RedactedURIs() {
+		fmt.Println("  server", uri)
+	}
+
+	d := q.Defaults
+	// Is Server Strategy Known?
+	if _, err = queue.ServerStrategyByName(d.ServerStrategy); err != nil { // NO
+		return err
+	}
+
+	// Is Compression Supported?
+	if enc := d.GetCompression(); enc != "" {
+		if _, err = queue.CompressorFor(enc); err != nil { // NO
+			return err
+		}
+	}
+
+	fmt.Printf("  publish timeout %v, consume timeout %v, max requeues %d, reconnect backoff %v\n",
+		d.GetPublishTimeout(), d.GetConsumeTimeout(), d.GetMaxRequeues(), d.GetReconnectBackoff())
+	fmt.Printf("  connect timeout %v, server strategy [%s]\n", d.GetConnectTimeout(), d.GetServerStrategy())
+	if enc := d.GetCompression(); enc != "" {
+		fmt.Printf("  compression [%s] above %d bytes\n", enc, d.GetCompressThreshold())
+	}
+	fmt.Println("OK")
+	return nil
+}
+
+func cmdPublish(path string, section string, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: publish <queue> <file.json>")
+	}
+
+	body, err := os.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+
+	// Is the File Valid JSON?
+	if !json.Valid(body) { // NO
+		return fmt.Errorf("[%s] is not valid JSON", args[1])
+	}
+
+	c, err := connect(path, section)
+	if err != nil {
+		return err
+	}
+	defer c.CloseConnection()
+
+	_, err = c.OpenQueueChannel("qictl", args[0], true)
+	if err != nil {
+		return err
+	}
+
+	return c.QueuePublishJSON("qictl", args[0], json.RawMessage(body))
+}
+
+func cmdRetrieve(path string, section string, args []string, remove bool) error {
+	if len(args) < 1 || len(args) > 2 {
+		return errors.New("usage: peek|drain <queue> [count]")
+	}
+
+	count := 1
+	if len(args) == 2 {
+		n, err := strconv.Atoi(args[1])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count [%s]", args[1])
+		}
+		count = n
+	}
+
+	c, err := connect(path, section)
+	if err != nil {
+		return err
+	}
+	defer c.CloseConnection()
+
+	// Peeked Messages are Held until all are Retrieved, then Requeued
+	// (otherwise the same message would be retrieved again)
+	var peeked []*amqp.Delivery
+	for i := 0; i < count; i++ {
+		d, err := c.QueueRetrieve("qictl", args[0])
+		if err != nil {
+			return err
+		}
+
+		// Is the Queue Empty?
+		if d == nil { // YES
+			break
+		}
+
+		printDelivery(i+1, d)
+
+		if remove {
+			err = d.Ack(false)
+		} else {
+			peeked = append(peeked, d)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, d := range peeked {
+		d.Nack(false, true)
+	}
+
+	return nil
+}
+
+func cmdReplay(path string, section string, args []string) error {
+	if len(args) < 1 || len(args) > 3 {
+		return errors.New("usage: replay <dlq> [target] [count]")
+	}
+
+	target := ""
+	if len(args) >= 2 {
+		target = args[1]
+	}
+
+	count := 0
+	if len(args) == 3 {
+		n, err := strconv.Atoi(args[2])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count [%s]", args[2])
+		}
+		count = n
+	}
+
+	c, err := connect(path, section)
+	if err != nil {
+		return err
+	}
+	defer c.CloseConnection()
+
+	r, err := c.ReplayDeadLetters(args[0], target, nil, count, &queue.ReplayOptions{
+		ClearStatus:   true,
+		ResetRequeues: true,
+	})
+	if r != nil {
+		fmt.Printf("scanned %d, replayed %d, skipped %d\n", r.Scanned, r.Replayed, r.Skipped)
+	}
+	return err
+}
+
+func cmdExport(path string, section string, args []string) error {
+	if len(args) < 2 || len(args) > 3 {
+		return errors.New("usage: export <queue> <file.ndjson> [count]")
+	}
+
+	count := 0
+	if len(args) == 3 {
+		n, err := strconv.Atoi(args[2])
+		if err != nil || n < 1 {
+			return fmt.Errorf("invalid count [%s]", args[2])
+		}
+		count = n
+	}
+
+	c, err := connect(path, section)
+	if err != nil {
+		return err
+	}
+	defer c.CloseConnection()
+
+	w := os.Stdout
+	if args[1] != "-" {
+		w, err = os.OpenFile(args[1], os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+		if err != nil {
+			return err
+		}
+		defer w.Close()
+	}
+
+	n, err := c.Export(args[0], w, count)
+	fmt.Fprintf(os.Stderr, "exported %d\n", n)
+	return err
+}
+
+func cmdImport(path string, section string, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: import <file.ndjson> <queue>")
+	}
+
+	c, err := connect(path, section)
+	if err != nil {
+		return err
+	}
+	defer c.CloseConnection()
+
+	r := os.Stdin
+	if args[0] != "-" {
+		r, err = os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+	}
+
+	n, err := c.Import(r, args[1])
+	fmt.Fprintf(os.Stderr, "imported %d\n", n)
+	return err
+}
+
+func cmdDecode(args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: decode <file.json>")
+	}
+
+	body, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+
+	printMessage(body)
+	return nil
+}
+
+// printDelivery Print Retrieved Message (Decoding Content Encoding)
+func printDelivery(n int, d *amqp.Delivery) {
+	// Is Body Encoded?
+	if d.ContentEncoding == "" { // NO
+		fmt.Printf("--- #%d (%s, %d bytes)\n", n, d.ContentType, len(d.Body))
+		printMessage(d.Body)
+		return
+	}
+
+	fmt.Printf("--- #%d (%s, %s, %d bytes)\n", n, d.ContentType, d.ContentEncoding, len(d.Body))
+
+	// Can we Decode the Body?
+	body, err := queue.DecodeBody(d)
+	if err != nil { // NO: Print Encoded Body
+		fmt.Fprintln(os.Stderr, "qictl:", err)
+		fmt.Printf("%q\n", d.Body)
+		return
+	}
+	printMessage(body)
+}
+
+func printMessage(body []byte) {
+	// Envelope Fields used for Summary
+	var m struct {
+		Header struct {
+			ID      string `json:"id"`
+			Version int    `json:"version"`
+			Created string `json:"created"`
+		} `json:"header"`
+		Body struct {
+			Type string `json:"type"`
+		} `json:"body"`
+	}
+
+	// Is the Message an Envelope?
+	if json.Unmarshal(body, &m) == nil && m.Header.ID != "" { // YES: Print Summary
+		fmt.Printf("type [%s] id [%s] version [%d] created [%s]\n", m.Body.Type, m.Header.ID, m.Header.Version, m.Header.Created)
+	}
+
+	// Pretty Print
+	var out bytes.Buffer
+	if json.Indent(&out, body, "", "  ") != nil {
+		fmt.Println(string(body))
+		return
+	}
+
+	fmt.Println(out.String())
+}
@@ -0,0 +1,202 @@
+package codec
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore msgpack cbor
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// binaryCodecs Codecs Encoding Wire Documents Directly
+var binaryCodecs = []Codec{MsgPack, CBOR}
+
+// testAction Action with Values a JSON Round Trip would Alter
+func testAction(t *testing.T) *messages.ActionMessage {
+	t.Helper()
+
+	m, err := messages.NewQueueActionMessage("test")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = m.SetParameters(map[string]interface{}{
+		"max":  uint64(math.MaxUint64),
+		"min":  int64(math.MinInt64),
+		"blob": []byte{0, 1, 0x7f, 0x80, 0xff},
+		"list": []interface{}{"a", int64(1)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := messages.NewQueueMessageStatus()
+	s.SetAttempt(2)
+	s.SetStartedAt(time.Date(2022, 3, 4, 5, 6, 7, 891011, time.UTC))
+	m.Header().SetStatus(s)
+	return m
+}
+
+// integer Decoded Integer as int64, or uint64 if Out of Range (Codecs Pick their Own Types)
+func integer(v interface{}) interface{} {
+	r := reflect.ValueOf(v)
+	switch r.Kind() {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if r.Uint() > math.MaxInt64 {
+			return r.Uint()
+		}
+		return int64(r.Uint())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return r.Int()
+	}
+	return v
+}
+
+func TestBinaryCodecsRoundTrip(t *testing.T) {
+	for _, c := range binaryCodecs {
+		t.Run(c.Name(), func(t *testing.T) {
+			m := testAction(t)
+
+			b, err := c.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := &messages.ActionMessage{}
+			if err = c.Unmarshal(b, out); err != nil {
+				t.Fatal(err)
+			}
+
+			if out.Header().ID() != m.Header().ID() || out.Type() != "action:test" {
+				t.Fatalf("header or type lost [%s %s]", out.Header().ID(), out.Type())
+			}
+			if !out.Header().Created().Equal(m.Header().Created()) {
+				t.Errorf("created [%v], want [%v]", out.Header().Created(), m.Header().Created())
+			}
+
+			status := out.Header().Status()
+			if status == nil || status.Attempt() != 2 || !status.StartedAt().Equal(*m.Header().Status().StartedAt()) {
+				t.Fatalf("status lost [%+v]", status)
+			}
+
+			params := out.Params().Map()
+			if v := integer(params["max"]); v != uint64(math.MaxUint64) {
+				t.Errorf("max [%v %T], want MaxUint64", params["max"], params["max"])
+			}
+			if v := integer(params["min"]); v != int64(math.MinInt64) {
+				t.Errorf("min [%v %T], want MinInt64", params["min"], params["min"])
+			}
+			if v, ok := params["blob"].([]byte); !ok || !bytes.Equal(v, []byte{0, 1, 0x7f, 0x80, 0xff}) {
+				t.Errorf("blob [%v %T], want binary", params["blob"], params["blob"])
+			}
+			if v, ok := params["list"].([]interface{}); !ok || len(v) != 2 || v[0] != "a" || integer(v[1]) != int64(1) {
+				t.Errorf("list [%v]", params["list"])
+			}
+		})
+	}
+}
+
+func TestBinaryCodecsQueueMessage(t *testing.T) {
+	for _, c := range binaryCodecs {
+		t.Run(c.Name(), func(t *testing.T) {
+			m := messages.NewQueueMessage("7a9c1e3b-5d7f-4a2c-8e4a-6c8e0a2c4e6a", map[string]interface{}{
+				"id":   uint64(1) << 63,
+				"data": []byte("\x00binary\xff"),
+			})
+
+			b, err := c.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			out := &messages.QueueMessage{}
+			if err = c.Unmarshal(b, out); err != nil {
+				t.Fatal(err)
+			}
+
+			body, ok := out.Message().(map[string]interface{})
+			if !ok {
+				t.Fatalf("body [%T], want map", out.Message())
+			}
+			if integer(body["id"]) != uint64(1)<<63 {
+				t.Errorf("id [%v %T], want 1<<63", body["id"], body["id"])
+			}
+			if v, ok := body["data"].([]byte); !ok || string(v) != "\x00binary\xff" {
+				t.Errorf("data [%v %T], want binary", body["data"], body["data"])
+			}
+		})
+	}
+}
+
+func TestBinaryCodecsCheckType(t *testing.T) {
+	for _, c := range binaryCodecs {
+		t.Run(c.Name(), func(t *testing.T) {
+			b, err := c.Marshal(testAction(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var invalid *messages.ErrInvalidMessage
+			if err = c.Unmarshal(b, &messages.EmailMessage{}); !errors.As(err, &invalid) {
+				t.Fatalf("err [%v], want not an email", err)
+			}
+		})
+	}
+}
+
+func TestBinaryCodecsSizeLimit(t *testing.T) {
+	for _, c := range binaryCodecs {
+		t.Run(c.Name(), func(t *testing.T) {
+			m := testAction(t)
+			if err := m.SetParameter("pad", make([]byte, messages.MaxMessageSize)); err != nil {
+				t.Fatal(err)
+			}
+
+			b, err := c.Marshal(m)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err = c.Unmarshal(b, &messages.ActionMessage{}); !errors.Is(err, messages.ErrMessageTooLarge) {
+				t.Fatalf("err [%v], want ErrMessageTooLarge", err)
+			}
+		})
+	}
+}
+
+func TestBinaryCodecsToJSON(t *testing.T) {
+	for _, c := range binaryCodecs {
+		t.Run(c.Name(), func(t *testing.T) {
+			b, err := c.Marshal(testAction(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			j, err := ToJSON(c, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Converted Document Decodes as a JSON Message
+			if err = json.Unmarshal(j, &messages.ActionMessage{}); err != nil {
+				t.Fatalf("converted document rejected [%v]: %s", err, j)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package codec
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// NOTE: Queue Messages Keep their Fields Private. Binary Codecs Encode their
+// Wire Document (see DocumentMarshaler), whose Field Names and Nesting Match
+// the JSON Encoding, so Consumers Decode Messages Whatever the Wire Format.
+// Other Types that Only Encode Themselves as JSON go through a Document Tree.
+
+// DocumentMarshaler Type Encoded as a Wire Document (Structs with JSON Tags, Maps, Slices and Scalars)
+type DocumentMarshaler interface {
+	MarshalDocument() (interface{}, error)
+}
+
+// DocumentUnmarshaler Type Decoded from a Wire Document of 'size' Bytes ('decode' Fills its Argument from the Document)
+type DocumentUnmarshaler interface {
+	UnmarshalDocument(size int, decode func(v interface{}) error) error
+}
+
+// document Value Encoded by Binary Codecs for v
+func document(v interface{}) (interface{}, error) {
+	// Does the Message have a Wire Document?
+	if m, ok := v.(DocumentMarshaler); ok { // YES
+		return m.MarshalDocument()
+	}
+
+	// Does it Encode Itself (as JSON)?
+	tree, ok, err := jsonTree(v)
+	if err != nil {
+		return nil, err
+	}
+	if ok { // YES: Encode its Document Tree
+		return tree, nil
+	}
+	return v, nil
+}
+
+// fromDocument Decode into v with 'decode' (Decodes the Whole Document into its Argument)
+func fromDocument(data []byte, v interface{}, decode func(v interface{}) error) error {
+	// Does the Message Decode its Wire Document?
+	if u, ok := v.(DocumentUnmarshaler); ok { // YES
+		return u.UnmarshalDocument(len(data), decode)
+	}
+
+	// Does it Decode Itself (from JSON)?
+	if u, ok := v.(json.Unmarshaler); ok { // YES: Decode its Document Tree
+		var tree interface{}
+		if err := decode(&tree); err != nil {
+			return err
+		}
+		return fromTree(tree, u)
+	}
+
+	return decode(v)
+}
+
+// jsonTree Document Tree of Types that Encode Themselves as JSON (false: Encode v Directly)
+func jsonTree(v interface{}) (interface{}, bool, error) {
+	m, ok := v.(json.Marshaler)
+	if !ok {
+		return nil, false, nil
+	}
+
+	b, err := m.MarshalJSON()
+	if err != nil {
+		return nil, true, err
+	}
+
+	// Keep Integers Exact (Not float64)
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var tree interface{}
+	if err = d.Decode(&tree); err != nil {
+		return nil, true, err
+	}
+	return numbers(tree), true, nil
+}
+
+// numbers Replace json.Number with int64 or float64
+func numbers(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		for k, e := range t {
+			t[k] = numbers(e)
+		}
+	case []interface{}:
+		for i, e := range t {
+			t[i] = numbers(e)
+		}
+	}
+	return v
+}
+
+// fromTree Decode Document Tree into Types that Decode Themselves from JSON
+func fromTree(tree interface{}, u json.Unmarshaler) error {
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return fmt.Errorf("[codec] Document can't be Converted to JSON: %w", err)
+	}
+	return u.UnmarshalJSON(b)
+}
+
+// ToJSON Re-Encode Data Encoded by Codec as JSON
+//
+// Only Codecs that Decode into a Generic Document (JSON, MessagePack, CBOR)
+// can be Converted.
+func ToJSON(c Codec, data []byte) ([]byte, error) {
+	// Is Data JSON Already?
+	if c.ContentType() == ContentTypeJSON { // YES
+		return data, nil
+	}
+
+	var tree interface{}
+	if err := c.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+
+	b, err := json.Marshal(tree)
+	if err != nil {
+		return nil, fmt.Errorf("[codec] %s Document can't be Converted to JSON: %w", c.Name(), err)
+	}
+	return b, nil
+}
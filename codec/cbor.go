@@ -0,0 +1,71 @@
+package codec
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore cbor fxamacker
+
+import (
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// CBOR Concise Binary Object Representation Codec (RFC 8949, Structs use their JSON Field Names)
+var CBOR Codec = newCBORCodec()
+
+type cborCodec struct {
+	enc cbor.EncMode
+	dec cbor.DecMode
+}
+
+func newCBORCodec() Codec {
+	// Deterministic Encoding (Sorted Map Keys), Maps Decode with String Keys (as JSON)
+	// NOTE: Times are Tagged RFC 3339 Strings (Default Unix Seconds Drop Sub-Second Precision)
+	opts := cbor.CoreDetEncOptions()
+	opts.Time = cbor.TimeRFC3339Nano
+	opts.TimeTag = cbor.EncTagRequired
+
+	enc, err := opts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+
+	dec, err := cbor.DecOptions{
+		DefaultMapType: reflect.TypeOf(map[string]interface{}(nil)),
+	}.DecMode()
+	if err != nil {
+		panic(err)
+	}
+
+	return &cborCodec{enc: enc, dec: dec}
+}
+
+func (*cborCodec) Name() string {
+	return "cbor"
+}
+
+func (*cborCodec) ContentType() string {
+	return ContentTypeCBOR
+}
+
+func (c *cborCodec) Marshal(v interface{}) ([]byte, error) {
+	v, err := document(v)
+	if err != nil {
+		return nil, err
+	}
+	return c.enc.Marshal(v)
+}
+
+func (c *cborCodec) Unmarshal(data []byte, v interface{}) error {
+	return fromDocument(data, v, func(v interface{}) error {
+		return c.dec.Unmarshal(data, v)
+	})
+}
@@ -0,0 +1,133 @@
+package codec
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Content Types of Built-in Codecs
+const (
+	ContentTypeJSON     = "application/json"
+	ContentTypeProtobuf = "application/x-protobuf"
+	ContentTypeMsgPack  = "application/msgpack"
+	ContentTypeCBOR     = "application/cbor"
+)
+
+// ErrUnsupportedContentType No Registered Codec Decodes the Content Type (use errors.As)
+type ErrUnsupportedContentType struct {
+	ContentType string
+}
+
+func (e *ErrUnsupportedContentType) Error() string {
+	return fmt.Sprintf("[codec] Unsupported Content Type [%s]", e.ContentType)
+}
+
+// Codec Converts Messages to and from their Wire Encoding
+type Codec interface {
+	Name() string        // Registry Name (i.e. "json")
+	ContentType() string // AMQP Content Type Set on Publish
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// Appender Codecs that can Encode Directly into a Caller Supplied Buffer
+type Appender interface {
+	AppendMarshal(dst []byte, v interface{}) ([]byte, error)
+}
+
+// Codec Registry
+var (
+	lock          sync.RWMutex
+	byName        = map[string]Codec{}
+	byContentType = map[string]Codec{}
+)
+
+// Register Add Codec to Registry (Replaces Codec with Same Name).
+// The first codec registered for a content type is used to decode it.
+func Register(c Codec) {
+	lock.Lock()
+	defer lock.Unlock()
+
+	byName[strings.ToLower(c.Name())] = c
+
+	ct := normalizeContentType(c.ContentType())
+	if _, ok := byContentType[ct]; !ok {
+		byContentType[ct] = c
+	}
+}
+
+// Lookup Find Codec by Name
+func Lookup(name string) (Codec, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	c, ok := byName[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("[codec] Unknown Codec [%s]", name)
+	}
+
+	return c, nil
+}
+
+// ForContentType Find Codec that Decodes Content Type
+//
+// Structured Syntax Suffixes (i.e. "application/vnd.example+json") Fall Back
+// to the Codec for the Base Type ("application/json").
+func ForContentType(ct string) (Codec, error) {
+	lock.RLock()
+	defer lock.RUnlock()
+
+	nct := normalizeContentType(ct)
+	c, ok := byContentType[nct]
+	if !ok && strings.HasSuffix(nct, "+json") {
+		c, ok = byContentType[ContentTypeJSON]
+	}
+	if !ok {
+		return nil, &ErrUnsupportedContentType{ContentType: ct}
+	}
+
+	return c, nil
+}
+
+// AppendMarshal Encode into dst (using the Codec's Appender if Available)
+func AppendMarshal(c Codec, dst []byte, v interface{}) ([]byte, error) {
+	// Can the Codec Write into the Buffer?
+	if a, ok := c.(Appender); ok { // YES
+		return a.AppendMarshal(dst, v)
+	}
+
+	b, err := c.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, b...), nil
+}
+
+// normalizeContentType Remove Parameters (i.e. "; charset=utf-8") and Case
+func normalizeContentType(ct string) string {
+	if i := strings.IndexByte(ct, ';'); i >= 0 {
+		ct = ct[:i]
+	}
+
+	return strings.ToLower(strings.TrimSpace(ct))
+}
+
+func init() {
+	Register(JSON)
+	Register(FastJSON)
+	Register(Protobuf)
+	Register(MsgPack)
+	Register(CBOR)
+}
@@ -0,0 +1,78 @@
+package codec
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// JSONAppender Types with a Hand Written JSON Encoder
+type JSONAppender interface {
+	AppendJSON(dst []byte) ([]byte, error)
+}
+
+// JSON Standard Library Codec
+var JSON Codec = jsonCodec{}
+
+// FastJSON JSON Codec that uses Hand Written Encoders when the Message
+// Provides One (falls back to the Standard Library Otherwise)
+var FastJSON Codec = fastJSONCodec{}
+
+type jsonCodec struct{}
+
+func (jsonCodec) Name() string {
+	return "json"
+}
+
+func (jsonCodec) ContentType() string {
+	return ContentTypeJSON
+}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (c jsonCodec) AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	b := bytes.NewBuffer(dst)
+	err := json.NewEncoder(b).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	// Remove Newline Added by Encoder
+	return bytes.TrimSuffix(b.Bytes(), []byte("\n")), nil
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+type fastJSONCodec struct {
+	jsonCodec
+}
+
+func (fastJSONCodec) Name() string {
+	return "json-fast"
+}
+
+func (c fastJSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.AppendMarshal(nil, v)
+}
+
+func (c fastJSONCodec) AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	// Does the Message Encode Itself?
+	if a, ok := v.(JSONAppender); ok { // YES
+		return a.AppendJSON(dst)
+	}
+
+	return c.jsonCodec.AppendMarshal(dst, v)
+}
@@ -0,0 +1,60 @@
+package codec
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore msgpack vmihailenco
+
+import (
+	"bytes"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MsgPack MessagePack Codec (Structs use their JSON Field Names)
+var MsgPack Codec = msgpackCodec{}
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Name() string {
+	return "msgpack"
+}
+
+func (msgpackCodec) ContentType() string {
+	return ContentTypeMsgPack
+}
+
+func (c msgpackCodec) Marshal(v interface{}) ([]byte, error) {
+	return c.AppendMarshal(nil, v)
+}
+
+func (msgpackCodec) AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	v, err := document(v)
+	if err != nil {
+		return nil, err
+	}
+
+	b := bytes.NewBuffer(dst)
+	e := msgpack.NewEncoder(b)
+	e.SetCustomStructTag("json")
+	e.SetSortMapKeys(true)
+	if err = e.Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (c msgpackCodec) Unmarshal(data []byte, v interface{}) error {
+	return fromDocument(data, v, func(v interface{}) error {
+		d := msgpack.NewDecoder(bytes.NewReader(data))
+		d.SetCustomStructTag("json")
+		return d.Decode(v)
+	})
+}
@@ -0,0 +1,108 @@
+package fixtures
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Canonical Wire Format for every Message Type and Version.
+// Producer and Consumer Repositories use the same Corpus to Assert
+// Wire Compatibility (files are organized as <version>/<name>.json).
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+//go:embed v1/*.json
+var corpus embed.FS
+
+// Versions List of Wire Format Versions in Corpus
+func Versions() []string {
+	entries, _ := fs.ReadDir(corpus, ".")
+
+	l := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			l = append(l, e.Name())
+		}
+	}
+
+	sort.Strings(l)
+	return l
+}
+
+// Names List of Fixtures for Version (without .json extension)
+func Names(version string) []string {
+	entries, _ := fs.ReadDir(corpus, version)
+
+	l := []string{}
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".json") {
+			l = append(l, strings.TrimSuffix(e.Name(), ".json"))
+		}
+	}
+
+	sort.Strings(l)
+	return l
+}
+
+// Load Raw Fixture Bytes
+func Load(version string, name string) ([]byte, error) {
+	b, err := corpus.ReadFile(path.Join(version, name+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("[fixtures] Unknown Fixture [%s/%s]", version, name)
+	}
+
+	return b, nil
+}
+
+// MustLoad Raw Fixture Bytes (Panics if Fixture does not Exist)
+func MustLoad(version string, name string) []byte {
+	b, err := Load(version, name)
+	if err != nil {
+		panic(err)
+	}
+
+	return b
+}
+
+// FS Corpus File System (for Tools that Walk the Fixtures)
+func FS() fs.FS {
+	return corpus
+}
+
+// EqualJSON Are the Two Documents Semantically Equal (Ignores Formatting and Key Order)?
+func EqualJSON(a []byte, b []byte) (bool, error) {
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		return false, fmt.Errorf("[fixtures] Invalid JSON [%v]", err)
+	}
+
+	if err := json.Unmarshal(b, &vb); err != nil {
+		return false, fmt.Errorf("[fixtures] Invalid JSON [%v]", err)
+	}
+
+	return reflect.DeepEqual(va, vb), nil
+}
+
+// MatchesFixture Does the Document Match the Fixture?
+func MatchesFixture(version string, name string, doc []byte) (bool, error) {
+	b, err := Load(version, name)
+	if err != nil {
+		return false, err
+	}
+
+	return EqualJSON(b, doc)
+}
@@ -0,0 +1,130 @@
+package fixtures_test
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/objectvault/queue-interface/fixtures"
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// Rewrite Fixtures from the Current Encoders (go test ./fixtures -update)
+var update = flag.Bool("update", false, "rewrite golden fixtures")
+
+// decode Fixture into its Registered Type (Plain Queue Message if not an Action)
+func decode(b []byte) (interface{}, error) {
+	m, err := messages.Decode(b)
+	if err == nil {
+		return m, nil
+	}
+
+	q := &messages.QueueMessage{}
+	if qerr := json.Unmarshal(b, q); qerr != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// golden Canonical Fixture Form of Encoded Message
+func golden(t *testing.T, b []byte) []byte {
+	t.Helper()
+
+	var out bytes.Buffer
+	if err := json.Indent(&out, b, "", "  "); err != nil {
+		t.Fatal(err)
+	}
+	out.WriteByte('\n')
+	return out.Bytes()
+}
+
+func TestFixturesRoundTrip(t *testing.T) {
+	for _, v := range fixtures.Versions() {
+		for _, n := range fixtures.Names(v) {
+			t.Run(v+"/"+n, func(t *testing.T) {
+				want := fixtures.MustLoad(v, n)
+
+				m, err := decode(want)
+				if err != nil {
+					t.Fatalf("decode failed [%v]", err)
+				}
+
+				first, err := json.Marshal(m)
+				if err != nil {
+					t.Fatalf("marshal failed [%v]", err)
+				}
+
+				if *update {
+					if err = os.WriteFile(filepath.Join(v, n+".json"), golden(t, first), 0644); err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				// Does Encoding Match the Golden Fixture?
+				if got := golden(t, first); !bytes.Equal(got, want) { // NO
+					t.Errorf("encoding differs from fixture\n got: %s\nwant: %s", got, want)
+				}
+
+				// Is Encoding Stable?
+				m, err = decode(first)
+				if err != nil {
+					t.Fatalf("decode of re-encoded message failed [%v]", err)
+				}
+
+				second, err := json.Marshal(m)
+				if err != nil {
+					t.Fatalf("marshal failed [%v]", err)
+				}
+
+				if !bytes.Equal(first, second) {
+					t.Errorf("encoding not stable\n first: %s\nsecond: %s", first, second)
+				}
+			})
+		}
+	}
+}
+
+// TestFixturesCoverRegisteredTypes Every Registered Message Type has a Fixture
+func TestFixturesCoverRegisteredTypes(t *testing.T) {
+	covered := map[string]bool{}
+	for _, v := range fixtures.Versions() {
+		for _, n := range fixtures.Names(v) {
+			m, err := messages.Decode(fixtures.MustLoad(v, n))
+			if err != nil {
+				continue
+			}
+			covered[registeredType(m.Type())] = true
+		}
+	}
+
+	for _, mt := range messages.MessageTypes() {
+		if !covered[mt] {
+			t.Errorf("no fixture for message type [%s]", mt)
+		}
+	}
+}
+
+// registeredType Registered Type Matching Message Type (Longest Prefix)
+func registeredType(t string) string {
+	best := ""
+	for _, mt := range messages.MessageTypes() {
+		if (t == mt || len(t) > len(mt) && t[:len(mt)+1] == mt+":") && len(mt) > len(best) {
+			best = mt
+		}
+	}
+	return best
+}
@@ -1,9 +1,30 @@
 module github.com/objectvault/queue-interface
 
-go 1.17
+go 1.22
 
 require (
-	github.com/gofrs/uuid v4.2.0+incompatible // indirect
-	github.com/objectvault/common v0.0.3 // indirect
-	github.com/rabbitmq/amqp091-go v1.8.0 // indirect
+	github.com/BurntSushi/toml v1.2.1
+	github.com/fxamacker/cbor/v2 v2.7.0
+	github.com/gofrs/uuid v4.2.0+incompatible
+	github.com/klauspost/compress v1.18.0
+	github.com/mattn/go-sqlite3 v1.14.16
+	github.com/nats-io/nats.go v1.11.0
+	github.com/objectvault/common v0.0.3
+	github.com/rabbitmq/amqp091-go v1.8.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
+	google.golang.org/grpc v1.64.1
+	google.golang.org/protobuf v1.33.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/nats-io/nkeys v0.3.0 // indirect
+	github.com/nats-io/nuid v1.0.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/x448/float16 v0.8.4 // indirect
+	golang.org/x/crypto v0.24.0 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+	golang.org/x/text v0.16.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240318140521-94a12d6c2237 // indirect
 )
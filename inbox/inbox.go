@@ -0,0 +1,123 @@
+package inbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// cSpell:ignore dedup
+
+// Dedup Defaults
+const (
+	DefaultTTL        = 24 * time.Hour  // How Long a Processed Message ID is Remembered
+	DefaultClaimLease = 5 * time.Minute // Time a Handler has before its Claim can be Taken Over
+)
+
+// ErrInProgress Message is being Handled by Another Consumer (Delivery is Retried)
+var ErrInProgress = errors.New("[Dedup] Message Handling in Progress")
+
+// logger Reports Dedup Failures (see SetLogger)
+var (
+	loggerLock    sync.RWMutex
+	logger        queue.Logger
+	defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+)
+
+// Logger Inbox Logger (Standard Logger if not Set)
+func Logger() queue.Logger {
+	loggerLock.RLock()
+	defer loggerLock.RUnlock()
+
+	if logger == nil {
+		return defaultLogger
+	}
+	return logger
+}
+
+// SetLogger Structured Logger for Dedup Failures (nil: Standard Logger)
+func SetLogger(l queue.Logger) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	logger = l
+}
+
+// ProcessedStore Remembers IDs of Messages Claimed or Already Handled
+//
+// Claim must be Atomic: of Concurrent Claims for an ID, only One Succeeds.
+type ProcessedStore interface {
+	Claim(ctx context.Context, id string, lease time.Duration) (bool, error) // Reserve Message (false: Processed, ErrInProgress: Claimed by Another)
+	Release(ctx context.Context, id string) error                            // Drop Claim (Handler Failed)
+	MarkProcessed(ctx context.Context, id string, ttl time.Duration) error   // Remember Message for 'ttl'
+}
+
+// Dedup Consumer Middleware Skipping Messages Already Processed
+func Dedup(s ProcessedStore, ttl time.Duration) queue.Middleware {
+	return DedupWithLease(s, ttl, DefaultClaimLease)
+}
+
+// DedupWithLease Dedup Middleware, Claims Expire after 'lease' if the Handler Never Finishes (i.e. Crash)
+//
+// The Message is Claimed before the Handler Runs, so Concurrent Deliveries of
+// the Same Message are Handled Once: Others are Skipped if it was Processed,
+// or Fail with ErrInProgress (and are Redelivered) while it is Being Handled.
+func DedupWithLease(s ProcessedStore, ttl time.Duration, lease time.Duration) queue.Middleware {
+	// Do we have a TTL?
+	if ttl <= 0 { // NO: Use Default
+		ttl = DefaultTTL
+	}
+
+	// Do we have a Lease?
+	if lease <= 0 { // NO: Use Default
+		lease = DefaultClaimLease
+	}
+
+	return func(next queue.Handler) queue.Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			// Can the Message be Identified?
+			id := queue.MessageID(d)
+			if id == "" { // NO: Can't Dedup
+				return next(ctx, d)
+			}
+
+			// Did we Claim the Message?
+			claimed, err := s.Claim(ctx, id, lease)
+			if err != nil {
+				return err
+			}
+			if !claimed { // NO: Already Processed, Skip (Acknowledge)
+				return nil
+			}
+
+			err = next(ctx, d)
+			if err != nil {
+				// NOTE: Failure to Release Delays Redelivery until the Lease Expires
+				if rerr := s.Release(ctx, id); rerr != nil {
+					Logger().Warn("[Dedup] Failed Releasing Message Claim", queue.F("message_id", id), queue.F("error", rerr))
+				}
+				return err
+			}
+
+			// NOTE: Failure to Mark only Risks a Duplicate once the Lease Expires, Message was Handled
+			if err = s.MarkProcessed(ctx, id, ttl); err != nil {
+				Logger().Warn("[Dedup] Failed Marking Message as Processed", queue.F("message_id", id), queue.F("error", err))
+			}
+			return nil
+		}
+	}
+}
@@ -0,0 +1,197 @@
+package inbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore dedup mattn sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/sqlstore"
+)
+
+// store Store Under Test (Created per Test)
+type store interface {
+	ProcessedStore
+	WasProcessed(ctx context.Context, id string) (bool, error)
+}
+
+// stores Stores Under Test
+var stores = map[string]func(t *testing.T) store{
+	"memory": func(*testing.T) store { return NewMemoryStore() },
+	"sql":    func(t *testing.T) store { return testSQLStore(t) },
+}
+
+// testSQLStore SQLite Store with an Empty Table
+func testSQLStore(t *testing.T) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // NOTE: Every Connection Opens its Own In Memory Database
+	t.Cleanup(func() { db.Close() })
+
+	s := NewSQLStore(db, "", sqlstore.DialectSQLite)
+	if err = s.CreateTable(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestDedupDuplicateDelivery(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			ctx := context.Background()
+
+			var calls int32
+			h := Dedup(s, time.Hour)(func(context.Context, *amqp.Delivery) error {
+				atomic.AddInt32(&calls, 1)
+				return nil
+			})
+
+			d := &amqp.Delivery{MessageId: "m-1"}
+			for i := 0; i < 3; i++ {
+				if err := h(ctx, d); err != nil {
+					t.Fatalf("delivery %d: %v", i, err)
+				}
+			}
+
+			if calls != 1 {
+				t.Fatalf("handler called %d times, want 1", calls)
+			}
+			if ok, err := s.WasProcessed(ctx, "m-1"); err != nil || !ok {
+				t.Fatalf("WasProcessed = %v, %v, want true", ok, err)
+			}
+		})
+	}
+}
+
+func TestDedupFailureReleasesClaim(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			ctx := context.Background()
+
+			failure := errors.New("handler failed")
+			var calls int32
+			h := Dedup(s, time.Hour)(func(context.Context, *amqp.Delivery) error {
+				// Fail First Delivery Only
+				if atomic.AddInt32(&calls, 1) == 1 {
+					return failure
+				}
+				return nil
+			})
+
+			d := &amqp.Delivery{MessageId: "m-1"}
+			if err := h(ctx, d); !errors.Is(err, failure) {
+				t.Fatalf("first delivery: %v, want %v", err, failure)
+			}
+			if ok, _ := s.WasProcessed(ctx, "m-1"); ok {
+				t.Fatal("failed message marked as processed")
+			}
+
+			// Redelivery is Handled
+			if err := h(ctx, d); err != nil {
+				t.Fatalf("redelivery: %v", err)
+			}
+			if calls != 2 {
+				t.Fatalf("handler called %d times, want 2", calls)
+			}
+		})
+	}
+}
+
+func TestDedupConcurrentDelivery(t *testing.T) {
+	const deliveries = 8
+
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			ctx := context.Background()
+
+			var calls int32
+			release := make(chan struct{})
+			h := Dedup(s, time.Hour)(func(context.Context, *amqp.Delivery) error {
+				atomic.AddInt32(&calls, 1)
+				<-release // Hold Claim until Other Deliveries have Returned
+				return nil
+			})
+
+			results := make(chan error, deliveries)
+			for i := 0; i < deliveries; i++ {
+				go func() {
+					results <- h(ctx, &amqp.Delivery{MessageId: "m-1"})
+				}()
+			}
+
+			// Every Delivery but the Claiming One Returns while it is Handled
+			for i := 0; i < deliveries-1; i++ {
+				select {
+				case err := <-results:
+					if !errors.Is(err, ErrInProgress) {
+						t.Fatalf("concurrent delivery: %v, want %v", err, ErrInProgress)
+					}
+				case <-time.After(5 * time.Second):
+					t.Fatal("timed out waiting for concurrent deliveries")
+				}
+			}
+
+			close(release)
+			if err := <-results; err != nil {
+				t.Fatalf("claiming delivery: %v", err)
+			}
+			if calls != 1 {
+				t.Fatalf("handler called %d times, want 1", calls)
+			}
+
+			// Later Redelivery is Skipped
+			if err := h(ctx, &amqp.Delivery{MessageId: "m-1"}); err != nil {
+				t.Fatalf("redelivery: %v", err)
+			}
+			if calls != 1 {
+				t.Fatalf("handler called %d times after redelivery, want 1", calls)
+			}
+		})
+	}
+}
+
+func TestClaimTakesOverExpiredLease(t *testing.T) {
+	for name, newStore := range stores {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			ctx := context.Background()
+
+			if ok, err := s.Claim(ctx, "m-1", 10*time.Millisecond); err != nil || !ok {
+				t.Fatalf("first claim = %v, %v, want true", ok, err)
+			}
+			if _, err := s.Claim(ctx, "m-1", time.Minute); !errors.Is(err, ErrInProgress) {
+				t.Fatalf("live claim: %v, want %v", err, ErrInProgress)
+			}
+
+			// Consumer Holding the Claim Crashed: Lease Expires
+			time.Sleep(20 * time.Millisecond)
+			if ok, err := s.Claim(ctx, "m-1", time.Minute); err != nil || !ok {
+				t.Fatalf("claim after expiry = %v, %v, want true", ok, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package inbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// In Memory Store (Single Process Only)
+type MemoryStore struct {
+	lock    sync.Mutex              // Map Lock
+	entries map[string]*memoryEntry // Message ID to Claim or Processed Entry
+	calls   int                     // Calls since Last Purge
+}
+
+// memoryEntry Claimed or Processed Message
+type memoryEntry struct {
+	expires   time.Time // Lease or TTL Expiry Time
+	processed bool      // Message Handled (false: Claim in Progress)
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: map[string]*memoryEntry{},
+	}
+}
+
+func (s *MemoryStore) WasProcessed(_ context.Context, id string) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.entries[id]
+	return ok && e.processed && time.Now().Before(e.expires), nil
+}
+
+func (s *MemoryStore) Claim(_ context.Context, id string, lease time.Duration) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Do we have a Live Entry?
+	now := time.Now()
+	if e, ok := s.entries[id]; ok && now.Before(e.expires) { // YES
+		if e.processed {
+			return false, nil
+		}
+		return false, ErrInProgress
+	}
+
+	// Insert (or Take Over Expired) Claim
+	s.entries[id] = &memoryEntry{expires: now.Add(lease)}
+	s.purgeEvery(now)
+	return true, nil
+}
+
+func (s *MemoryStore) Release(_ context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Is Message Claimed?
+	if e, ok := s.entries[id]; ok && !e.processed { // YES: Drop Claim
+		delete(s.entries, id)
+	}
+	return nil
+}
+
+func (s *MemoryStore) MarkProcessed(_ context.Context, id string, ttl time.Duration) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	s.entries[id] = &memoryEntry{expires: now.Add(ttl), processed: true}
+	s.purgeEvery(now)
+	return nil
+}
+
+// Len Number of Remembered IDs (Including Claims and Expired not yet Purged)
+func (s *MemoryStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.entries)
+}
+
+// Purge Drop Expired Entries
+func (s *MemoryStore) Purge() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.purge(time.Now())
+}
+
+// purgeEvery Periodically Drop Expired Entries
+func (s *MemoryStore) purgeEvery(now time.Time) {
+	s.calls++
+	if s.calls >= 1024 {
+		s.calls = 0
+		s.purge(now)
+	}
+}
+
+func (s *MemoryStore) purge(now time.Time) {
+	for id, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, id)
+		}
+	}
+}
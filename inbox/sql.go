@@ -0,0 +1,165 @@
+package inbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/objectvault/queue-interface/sqlstore"
+)
+
+// cSpell:ignore sqlstore varchar
+
+// DefaultTable Processed Messages Table Name
+const DefaultTable = "queue_inbox"
+
+// SQL Store Implementation (database/sql)
+type SQLStore struct {
+	db      *sql.DB           // Database Connection
+	table   string            // Processed Messages Table
+	dialect *sqlstore.Dialect // Database Dialect
+}
+
+func NewSQLStore(db *sql.DB, table string, d *sqlstore.Dialect) *SQLStore {
+	// Do we have a Table Name?
+	if table == "" { // NO: Use Default
+		table = DefaultTable
+	}
+
+	// Do we have a Dialect?
+	if d == nil { // NO: Use "?" Placeholders
+		d = sqlstore.DialectMySQL
+	}
+
+	return &SQLStore{
+		db:      db,
+		table:   table,
+		dialect: d,
+	}
+}
+
+func (s *SQLStore) p(n int) string {
+	return s.dialect.Placeholder(n)
+}
+
+// CreateTable Create Processed Messages Table if it does not Exist
+//
+// Rows with a NULL processed_at are Claims of Messages being Handled.
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	q := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(255) NOT NULL PRIMARY KEY,
+	expires_at TIMESTAMP NOT NULL,
+	processed_at TIMESTAMP NULL
+)`, s.table)
+
+	_, err := s.db.ExecContext(ctx, q)
+	return err
+}
+
+func (s *SQLStore) WasProcessed(ctx context.Context, id string) (bool, error) {
+	q := fmt.Sprintf("SELECT expires_at FROM %s WHERE id = %s AND processed_at IS NOT NULL", s.table, s.p(1))
+
+	var expires time.Time
+	err := s.db.QueryRowContext(ctx, q, id).Scan(&expires)
+	if errors.Is(err, sql.ErrNoRows) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	return time.Now().UTC().Before(expires), nil
+}
+
+// Claim Reserve Message with a Single Conditional Insert (or Take Over of an Expired Row)
+func (s *SQLStore) Claim(ctx context.Context, id string, lease time.Duration) (bool, error) {
+	now := time.Now().UTC()
+
+	// Did we Insert the Claim?
+	q := fmt.Sprintf(s.dialect.InsertIgnore, s.table, "id, expires_at", s.p(1)+", "+s.p(2))
+	claimed, err := s.exec(ctx, q, id, now.Add(lease))
+	if err != nil || claimed {
+		return claimed, err
+	}
+
+	// NO: Row Exists, Did we Take Over an Expired Claim or Entry?
+	q = fmt.Sprintf("UPDATE %s SET expires_at = %s, processed_at = NULL WHERE id = %s AND expires_at < %s", s.table, s.p(1), s.p(2), s.p(3))
+	claimed, err = s.exec(ctx, q, now.Add(lease), id, now)
+	if err != nil || claimed {
+		return claimed, err
+	}
+
+	// NO: Live Row, Was the Message Processed?
+	q = fmt.Sprintf("SELECT processed_at FROM %s WHERE id = %s", s.table, s.p(1))
+
+	var processed sql.NullTime
+	err = s.db.QueryRowContext(ctx, q, id).Scan(&processed)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+
+	// NOTE: Row Removed since the Insert (Claim Released) is Retried on Redelivery
+	if !processed.Valid { // NO: Claimed by Another Consumer
+		return false, ErrInProgress
+	}
+	return false, nil
+}
+
+// Release Drop Claim, Processed Entries are Kept
+func (s *SQLStore) Release(ctx context.Context, id string) error {
+	q := fmt.Sprintf("DELETE FROM %s WHERE id = %s AND processed_at IS NULL", s.table, s.p(1))
+	_, err := s.db.ExecContext(ctx, q, id)
+	return err
+}
+
+func (s *SQLStore) MarkProcessed(ctx context.Context, id string, ttl time.Duration) error {
+	now := time.Now().UTC()
+
+	// Did we Update the Claim?
+	q := fmt.Sprintf("UPDATE %s SET expires_at = %s, processed_at = %s WHERE id = %s", s.table, s.p(1), s.p(2), s.p(3))
+	updated, err := s.exec(ctx, q, now.Add(ttl), now, id)
+	if err != nil || updated {
+		return err
+	}
+
+	// NO: Claim was Purged, Insert Entry
+	q = fmt.Sprintf(s.dialect.InsertIgnore, s.table, "id, expires_at, processed_at", s.p(1)+", "+s.p(2)+", "+s.p(3))
+	_, err = s.exec(ctx, q, id, now.Add(ttl), now)
+	return err
+}
+
+// exec Execute Statement, Returns true if it Affected a Row
+func (s *SQLStore) exec(ctx context.Context, q string, args ...interface{}) (bool, error) {
+	res, err := s.db.ExecContext(ctx, q, args...)
+	if err != nil {
+		return false, err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// Purge Remove Expired Entries
+func (s *SQLStore) Purge(ctx context.Context) (int64, error) {
+	q := fmt.Sprintf("DELETE FROM %s WHERE expires_at < %s", s.table, s.p(1))
+	res, err := s.db.ExecContext(ctx, q, time.Now().UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
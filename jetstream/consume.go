@@ -0,0 +1,270 @@
+package jetstream
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore jetstream nats
+
+import (
+	"context"
+	"errors"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+func (t *Transport) DefaultQueueRetrieve(channel string) (*amqp.Delivery, error) {
+	return t.QueueRetrieve(channel, "")
+}
+
+// QueueRetrieve Fetch Next Message from Queue's Durable Consumer (nil if None
+// Arrives within the Fetch Wait)
+//
+// Delivery must be Settled (Ack, Nack or Reject) before the Consumer's Ack
+// Wait Expires, otherwise it is Redelivered.
+func (t *Transport) QueueRetrieve(channel string, name string) (*amqp.Delivery, error) {
+	sub, err := t.subscription(name)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	wait := t.wait
+	t.lock.Unlock()
+
+	l, err := sub.Fetch(1, nats.MaxWait(wait))
+	if err != nil {
+		// Did we Time Out Waiting?
+		if errors.Is(err, nats.ErrTimeout) || errors.Is(err, context.DeadlineExceeded) { // YES: Queue is Empty
+			return nil, nil
+		}
+
+		t.Logger().Error("[JetStreamTransport] Failed Fetching Message", queue.F("queue", sub.Subject), queue.F("error", err))
+		return nil, err
+	}
+
+	// Is Queue Empty?
+	if len(l) == 0 { // YES
+		return nil, nil
+	}
+
+	m := l[0]
+	t.lock.Lock()
+	t.tag++
+	tag := t.tag
+	t.pending[tag] = m
+	t.lock.Unlock()
+
+	d := deliveryFromMsg(m)
+	d.Acknowledger = t
+	d.DeliveryTag = tag
+	return &d, nil
+}
+
+// subscription Open (Cached) Pull Subscription to Queue's Durable Consumer (Consumer Created if Missing)
+func (t *Transport) subscription(name string) (*nats.Subscription, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	name, err := t.queueName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// Is Subscription Open?
+	if sub, ok := t.subs[name]; ok { // YES
+		return sub, nil
+	}
+
+	// Does Queue Exist?
+	if _, err = t.openStream(name, false); err != nil { // NO
+		return nil, err
+	}
+
+	sub, err := t.js.PullSubscribe(name, t.durable, nats.BindStream(name), nats.AckExplicit())
+	if err != nil {
+		t.loggerLocked().Error("[JetStreamTransport] Failed Subscribing to Queue", queue.F("queue", name), queue.F("error", err))
+		return nil, err
+	}
+
+	t.subs[name] = sub
+	return sub, nil
+}
+
+// Ack amqp.Acknowledger
+func (t *Transport) Ack(tag uint64, multiple bool) error {
+	l, err := t.settle(tag, multiple)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range l {
+		if err := m.Ack(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Nack amqp.Acknowledger (Requeue: Redelivered by JetStream, Otherwise Moved to Dead Letter Queue)
+func (t *Transport) Nack(tag uint64, multiple bool, requeue bool) error {
+	l, err := t.settle(tag, multiple)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range l {
+		// Should Message be Requeued?
+		if requeue { // YES
+			if err := m.Nak(); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// NOTE: Message is Redelivered if it can't be Dead Lettered
+		if err := t.deadLetter(m); err != nil {
+			m.Nak()
+			return err
+		}
+		if err := m.Term(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reject amqp.Acknowledger
+func (t *Transport) Reject(tag uint64, requeue bool) error {
+	return t.Nack(tag, false, requeue)
+}
+
+// deadLetter Publish Copy of Message to its Dead Letter Queue (Created if Missing)
+func (t *Transport) deadLetter(m *nats.Msg) error {
+	name := m.Subject + DeadLetterSuffix
+
+	t.lock.Lock()
+	_, err := t.openStream(name, true)
+	t.lock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	c := nats.NewMsg(name)
+	c.Data = m.Data
+	for k, v := range m.Header {
+		// Let Dead Letter Queue Keep Messages Deduplicated on Publish
+		if k != nats.MsgIdHdr {
+			c.Header[k] = v
+		}
+	}
+	return t.write(c)
+}
+
+// settle Remove Pending Messages up to Tag (Returns Messages in Tag Order)
+func (t *Transport) settle(tag uint64, multiple bool) ([]*nats.Msg, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Is Delivery Known?
+	m, ok := t.pending[tag]
+	if !ok { // NO: Already Settled (or Transport Closed)
+		return nil, ErrUnknownDelivery
+	}
+
+	// Settle Single Delivery?
+	if !multiple { // YES
+		delete(t.pending, tag)
+		return []*nats.Msg{m}, nil
+	}
+
+	l := []*nats.Msg{}
+	for p := uint64(1); p <= tag; p++ {
+		if m, ok := t.pending[p]; ok {
+			l = append(l, m)
+			delete(t.pending, p)
+		}
+	}
+	return l, nil
+}
+
+// QueueProcess Retrieve a Single Message and Pass it to Handler (Returns false if Queue was Empty)
+//
+// Handled Messages are Acked. Failed Messages are Requeued, unless the Error
+// is a Dead Letter or Quarantine Error.
+func (t *Transport) QueueProcess(ctx context.Context, channel string, name string, h queue.Handler, mw ...queue.Middleware) (bool, error) {
+	d, err := t.QueueRetrieve(channel, name)
+	if err != nil || d == nil {
+		return false, err
+	}
+
+	// Did Handler Fail?
+	ctx, settled := queue.WithSettlement(ctx)
+	err = queue.Chain(h, mw...)(ctx, d)
+	if err == nil { // NO
+		settled(queue.OutcomeAcked)
+		return true, d.Ack(false)
+	}
+
+	requeue := !queue.IsDeadLetterError(err) && !queue.IsQuarantineError(err)
+	if requeue {
+		settled(queue.OutcomeRequeued)
+	} else {
+		settled(queue.OutcomeDeadLettered)
+	}
+	if nerr := d.Nack(false, requeue); nerr != nil {
+		t.Logger().Error("[JetStreamTransport] Failed Settling Message", queue.F("queue", d.RoutingKey), queue.F("error", nerr))
+	}
+	return true, err
+}
+
+// QueueConsumer Consumer Processing Queue Messages with Handler until Cancelled
+func (t *Transport) QueueConsumer(channel string, name string, h queue.Handler, mw ...queue.Middleware) queue.Consumer {
+	return func(ctx context.Context) error {
+		for {
+			// Has Shutdown Started?
+			if err := ctx.Err(); err != nil { // YES
+				return nil
+			}
+
+			// NOTE: Empty Queues Wait in QueueRetrieve (Fetch Wait)
+			ok, err := t.QueueProcess(context.Background(), channel, name, h, mw...)
+			if err != nil {
+				// Did Retrieve Fail?
+				if !ok { // YES: Abort
+					return err
+				}
+				t.Logger().Warn("[JetStreamTransport] Message Failed", queue.F("queue", name), queue.F("error", err))
+			}
+		}
+	}
+}
+
+// deliveryFromMsg Delivery for JetStream Message (AMQP Properties Restored from Message Headers)
+func deliveryFromMsg(m *nats.Msg) amqp.Delivery {
+	h := map[string]string{}
+	for k := range m.Header {
+		h[k] = m.Header.Get(k)
+	}
+
+	d := queue.DeliveryFromHeaders(h, m.Data)
+	d.RoutingKey = m.Subject
+
+	// Has Message been Delivered Before?
+	if meta, err := m.Metadata(); err == nil {
+		d.Redelivered = meta.NumDelivered > 1
+		if d.Timestamp.IsZero() {
+			d.Timestamp = meta.Timestamp
+		}
+	}
+	return d
+}
@@ -0,0 +1,435 @@
+package jetstream
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore jetstream nats
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/codec"
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// DefaultDurable Durable Consumer Shared by Transports Reading a Queue
+const DefaultDurable = "queue-interface"
+
+// DeadLetterSuffix Appended to Queue Name to Form its Dead Letter Queue
+const DeadLetterSuffix = "-dlq"
+
+// DefaultFetchWait Time QueueRetrieve Waits for a Message before Reporting an Empty Queue
+const DefaultFetchWait = 100 * time.Millisecond
+
+var (
+	ErrNoConnection     = errors.New("[JetStreamTransport] Missing NATS Connection")
+	ErrQueueNameMissing = errors.New("[JetStreamTransport] Missing Queue Name")
+	ErrQueueNotFound    = errors.New("[JetStreamTransport] Queue Stream does not Exist")
+	ErrUnknownDelivery  = errors.New("[JetStreamTransport] Unknown Delivery Tag")
+)
+
+// Transport NATS JetStream Backed Queue Transport
+//
+// Every Queue is a Work Queue Stream (Named after the Queue, Prefix Applied
+// as for AMQP Queues) Capturing the Subject of the Same Name, Read through a
+// Durable Pull Consumer Shared by all Transports. Deliveries are amqp.Delivery
+// values that can be Decoded and Handled as if Received from RabbitMQ.
+type Transport struct {
+	lock    sync.Mutex                    // Transport State Lock
+	conn    *nats.Conn                    // NATS Connection
+	js      nats.JetStreamContext         // JetStream API
+	streams map[string]*nats.StreamInfo   // Opened Queue Streams by Queue Name
+	subs    map[string]*nats.Subscription // Pull Subscriptions by Queue Name
+	pending map[uint64]*nats.Msg          // Retrieved Messages by Delivery Tag
+	tag     uint64                        // Last Delivery Tag Issued
+
+	prefix   string           // [OPTIONAL] Prefix to Queue Name
+	queue    string           // [OPTIONAL] Queue Used when Name is ""
+	durable  string           // Durable Consumer Name
+	storage  nats.StorageType // Stream Storage (DEFAULT: File)
+	replicas int              // Stream Replicas (DEFAULT: 1)
+	wait     time.Duration    // Time QueueRetrieve Waits for a Message
+	codec    codec.Codec      // Codec Used by QueuePublishEncoded
+	appID    string           // [OPTIONAL] AppId Stamped on Published Messages
+	logger   queue.Logger     // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+func NewTransport(nc *nats.Conn) (*Transport, error) {
+	if nc == nil {
+		return nil, ErrNoConnection
+	}
+
+	js, err := nc.JetStream()
+	if err != nil {
+		return nil, fmt.Errorf("[JetStreamTransport] JetStream not Available [%v]", err)
+	}
+
+	return &Transport{
+		conn:     nc,
+		js:       js,
+		streams:  map[string]*nats.StreamInfo{},
+		subs:     map[string]*nats.Subscription{},
+		pending:  map[uint64]*nats.Msg{},
+		durable:  DefaultDurable,
+		storage:  nats.FileStorage,
+		replicas: 1,
+		wait:     DefaultFetchWait,
+		codec:    codec.JSON,
+	}, nil
+}
+
+func (t *Transport) Prefix() string {
+	return t.prefix
+}
+
+// SetPrefix Prefix to Queue Name (Drops Opened Streams)
+func (t *Transport) SetPrefix(p string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.reset()
+	t.prefix = p
+	return nil
+}
+
+func (t *Transport) DefaultQueue() string {
+	return t.queue
+}
+
+func (t *Transport) SetDefaultQueue(name string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.queue = name
+	return nil
+}
+
+func (t *Transport) Durable() string {
+	return t.durable
+}
+
+// SetDurable Durable Consumer Name (Transports Sharing it Share the Queue's Messages)
+func (t *Transport) SetDurable(name string) error {
+	// Is Name Valid?
+	if name == "" || strings.ContainsAny(name, ". *>") { // NO
+		return fmt.Errorf("[JetStreamTransport] Invalid Durable Name [%s]", name)
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.reset()
+	t.durable = name
+	return nil
+}
+
+// SetStorage Storage and Replicas of Streams Created by OpenQueueStream
+func (t *Transport) SetStorage(storage nats.StorageType, replicas int) error {
+	// Are Replicas Valid?
+	if replicas < 1 { // NO: Single Replica
+		replicas = 1
+	}
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.storage = storage
+	t.replicas = replicas
+	return nil
+}
+
+// SetFetchWait Time QueueRetrieve Waits for a Message before Reporting an Empty Queue
+func (t *Transport) SetFetchWait(d time.Duration) error {
+	// Is Wait Valid?
+	if d > 0 { // YES
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		t.wait = d
+	}
+	return nil
+}
+
+// SetCodec Codec Used by QueuePublishEncoded (unless Overridden by queue.WithCodec)
+func (t *Transport) SetCodec(c codec.Codec) error {
+	// Do we have a Codec?
+	if c != nil { // YES
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		t.codec = c
+	}
+	return nil
+}
+
+// SetAppID Application Identifier Stamped on Published Messages
+func (t *Transport) SetAppID(id string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.appID = id
+	return nil
+}
+
+// Logger Transport Logger (Standard Logger if not Set)
+func (t *Transport) Logger() queue.Logger {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.logger == nil {
+		return defaultLogger
+	}
+	return t.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (t *Transport) SetLogger(l queue.Logger) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.logger = l
+	return nil
+}
+
+// QueueName Name of Queue Stream and Subject (Prefix Applied, "" for Default Queue)
+func (t *Transport) QueueName(name string) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.queueName(name)
+}
+
+func (t *Transport) queueName(name string) (string, error) {
+	if name == "" {
+		name = t.queue
+	}
+
+	if name == "" {
+		return "", ErrQueueNameMissing
+	}
+
+	if t.prefix != "" {
+		name = t.prefix + "-" + name
+	}
+
+	// Stream Names can't Contain Subject Separators or Wildcards
+	if strings.ContainsAny(name, ". *>") {
+		return "", fmt.Errorf("[JetStreamTransport] Invalid Queue Name [%s]", name)
+	}
+	return name, nil
+}
+
+// OpenQueueStream Open (Cached) Stream for Queue, Creating Stream if 'create'
+// (Equivalent of AMQPServerConnection.OpenQueueChannel)
+//
+// Streams are Created with Work Queue Retention: Messages are Removed once
+// Acked. Returns ErrQueueNotFound if the Stream does not Exist and can't be
+// Created.
+func (t *Transport) OpenQueueStream(channel string, name string, create bool) (*nats.StreamInfo, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	name, err := t.queueName(name)
+	if err != nil {
+		return nil, err
+	}
+	return t.openStream(name, create)
+}
+
+// openStream Open (Cached) Stream (Lock Held)
+func (t *Transport) openStream(name string, create bool) (*nats.StreamInfo, error) {
+	// Is the Stream Open?
+	if si, ok := t.streams[name]; ok { // YES
+		return si, nil
+	}
+
+	// Does the Stream Exist?
+	si, err := t.js.StreamInfo(name)
+	if err != nil { // NO (or Lookup Failed)
+		// Should we Try to Create the Stream?
+		if !create { // NO
+			return nil, fmt.Errorf("%w [%s: %v]", ErrQueueNotFound, name, err)
+		}
+
+		si, err = t.js.AddStream(&nats.StreamConfig{
+			Name:      name,
+			Subjects:  []string{name},
+			Retention: nats.WorkQueuePolicy,
+			Storage:   t.storage,
+			Replicas:  t.replicas,
+		})
+
+		// Was Stream Created?
+		if err != nil { // NO
+			t.loggerLocked().Error("[OpenQueueStream] Failed to Create Stream", queue.F("queue", name), queue.F("error", err))
+			return nil, err
+		}
+	}
+
+	t.streams[name] = si
+	return si, nil
+}
+
+// QueuePublish Publish Pre-Encoded Message Body with the Given Content Type
+func (t *Transport) QueuePublish(channel string, name string, contentType string, body []byte) error {
+	return t.publish(name, &amqp.Publishing{ContentType: contentType, Body: body})
+}
+
+// QueuePublishDelivery Republish Delivery Keeping its Properties, Headers and Content Encoding
+func (t *Transport) QueuePublishDelivery(channel string, name string, d *amqp.Delivery) error {
+	return t.publish(name, queue.PublishingFromDelivery(d))
+}
+
+func (t *Transport) QueuePublishString(channel string, name string, msg string, opts ...queue.PublishOption) error {
+	p := &amqp.Publishing{ContentType: "text/plain", Body: []byte(msg)}
+	if err := queue.PreparePublishing(nil, p, opts...); err != nil {
+		return err
+	}
+	return t.publish(name, p)
+}
+
+func (t *Transport) DefaultQueuePublishJSON(channel string, msg interface{}, opts ...queue.PublishOption) error {
+	return t.QueuePublishJSON(channel, "", msg, opts...)
+}
+
+func (t *Transport) QueuePublishJSON(channel string, name string, msg interface{}, opts ...queue.PublishOption) error {
+	return t.publishEncoded(name, codec.JSON, msg, opts)
+}
+
+// QueuePublishEncoded Publish Message Encoded with the Publish Codec (see
+// queue.WithCodec), or the Transport Codec, Setting the Matching Content Type
+func (t *Transport) QueuePublishEncoded(channel string, name string, msg interface{}, opts ...queue.PublishOption) error {
+	o := &queue.PublishOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cdc := o.Codec
+	if cdc == nil {
+		t.lock.Lock()
+		cdc = t.codec
+		t.lock.Unlock()
+	}
+	return t.publishEncoded(name, cdc, msg, opts)
+}
+
+func (t *Transport) publishEncoded(name string, cdc codec.Codec, msg interface{}, opts []queue.PublishOption) error {
+	p := &amqp.Publishing{}
+	if err := queue.PreparePublishing(msg, p, opts...); err != nil {
+		return err
+	}
+
+	body, err := cdc.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.ContentType = cdc.ContentType()
+	p.Body = body
+	return t.publish(name, p)
+}
+
+// publish Publish to Queue's Stream (Message ID Deduplicates Retried Publishes)
+func (t *Transport) publish(name string, p *amqp.Publishing) error {
+	t.lock.Lock()
+	name, err := t.queueName(name)
+	if err == nil {
+		_, err = t.openStream(name, false)
+	}
+	appID := t.appID
+	t.lock.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	// Stamp Identity and Time (unless Set by Caller)
+	if p.AppId == "" {
+		p.AppId = appID
+	}
+	if p.Timestamp.IsZero() {
+		p.Timestamp = time.Now()
+	}
+
+	return t.write(msgFromPublishing(name, p))
+}
+
+func (t *Transport) write(m *nats.Msg) error {
+	_, err := t.js.PublishMsg(m)
+	if err != nil {
+		t.Logger().Error("[JetStreamTransport] Failed Publishing Message", queue.F("queue", m.Subject), queue.F("error", err))
+	}
+	return err
+}
+
+// DeleteQueue Delete Queue's Stream (and its Messages)
+func (t *Transport) DeleteQueue(name string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	name, err := t.queueName(name)
+	if err != nil {
+		return err
+	}
+
+	if sub, ok := t.subs[name]; ok {
+		sub.Unsubscribe()
+		delete(t.subs, name)
+	}
+	delete(t.streams, name)
+	return t.js.DeleteStream(name)
+}
+
+// Close Drop Pull Subscriptions (Unsettled Deliveries are Redelivered once their Ack Wait Expires)
+//
+// The NATS Connection is Left Open.
+func (t *Transport) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.reset()
+	return nil
+}
+
+// reset Drop Subscriptions and Opened Streams (Lock Held)
+func (t *Transport) reset() {
+	for _, sub := range t.subs {
+		sub.Unsubscribe()
+	}
+	t.subs = map[string]*nats.Subscription{}
+	t.streams = map[string]*nats.StreamInfo{}
+	t.pending = map[uint64]*nats.Msg{}
+}
+
+// loggerLocked Logger (Lock Held)
+func (t *Transport) loggerLocked() queue.Logger {
+	if t.logger == nil {
+		return defaultLogger
+	}
+	return t.logger
+}
+
+// msgFromPublishing NATS Message Carrying Publishing
+func msgFromPublishing(subject string, p *amqp.Publishing) *nats.Msg {
+	m := nats.NewMsg(subject)
+	m.Data = p.Body
+	for k, v := range queue.PublishingHeaders(p) {
+		m.Header.Set(k, v)
+	}
+
+	// Let JetStream Drop Duplicate Publishes
+	if p.MessageId != "" {
+		m.Header.Set(nats.MsgIdHdr, p.MessageId)
+	}
+	return m
+}
@@ -0,0 +1,77 @@
+package journal
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// File Journal (One JSON Entry per Line)
+type FileJournal struct {
+	lock sync.Mutex    // Writer Lock
+	w    io.Writer     // Destination
+	enc  *json.Encoder // Line Encoder
+	f    *os.File      // [OPTIONAL] Owned File
+}
+
+// OpenFile Open (Append) Journal File
+func OpenFile(path string) (*FileJournal, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	j := NewWriterJournal(f)
+	j.f = f
+	return j, nil
+}
+
+// NewWriterJournal Journal Writing to Any Writer (i.e. os.Stdout)
+func NewWriterJournal(w io.Writer) *FileJournal {
+	return &FileJournal{
+		w:   w,
+		enc: json.NewEncoder(w),
+	}
+}
+
+func (j *FileJournal) Record(_ context.Context, e *Entry) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	return j.enc.Encode(e)
+}
+
+// Sync Flush Journal File to Disk
+func (j *FileJournal) Sync() error {
+	// Do we Own a File?
+	if j.f == nil { // NO
+		return nil
+	}
+	return j.f.Sync()
+}
+
+// Close Journal File
+func (j *FileJournal) Close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+
+	// Do we Own a File?
+	if j.f == nil { // NO
+		return nil
+	}
+
+	err := j.f.Close()
+	j.f = nil
+	return err
+}
@@ -0,0 +1,122 @@
+package journal
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// Entry Audit Record for a Consumed Message
+type Entry struct {
+	Time        time.Time     `json:"time"`            // Time Handler Finished
+	Queue       string        `json:"queue"`           // Queue (Routing Key) Message was Published To
+	DeliveryTag uint64        `json:"delivery_tag"`    // Channel Delivery Tag
+	MessageID   string        `json:"message_id"`      // Message ID (if Known)
+	Redelivered bool          `json:"redelivered"`     // Broker Redelivery Flag
+	Outcome     string        `json:"outcome"`         // What Happened to the Message (i.e. queue.OutcomeQuarantined)
+	Error       string        `json:"error,omitempty"` // Handler Error
+	Duration    time.Duration `json:"duration"`        // Time Spent in Handler
+}
+
+// Journal Persists Entries
+type Journal interface {
+	Record(ctx context.Context, e *Entry) error
+}
+
+// logger Reports Journal Failures (see SetLogger)
+var (
+	loggerLock    sync.RWMutex
+	logger        queue.Logger
+	defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+)
+
+// Logger Journal Logger (Standard Logger if not Set)
+func Logger() queue.Logger {
+	loggerLock.RLock()
+	defer loggerLock.RUnlock()
+
+	if logger == nil {
+		return defaultLogger
+	}
+	return logger
+}
+
+// SetLogger Structured Logger for Journal Failures (nil: Standard Logger)
+func SetLogger(l queue.Logger) {
+	loggerLock.Lock()
+	defer loggerLock.Unlock()
+	logger = l
+}
+
+// NewEntry Create Entry for Delivery Settled with Outcome (queue.Outcome* Values)
+func NewEntry(d *amqp.Delivery, outcome string, err error, elapsed time.Duration) *Entry {
+	e := &Entry{
+		Time:        time.Now().UTC(),
+		Queue:       d.RoutingKey,
+		DeliveryTag: d.DeliveryTag,
+		MessageID:   queue.MessageID(d),
+		Redelivered: d.Redelivered,
+		Outcome:     outcome,
+		Duration:    elapsed,
+	}
+
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// Middleware Record an Entry for Every Message Handled
+//
+// Entries are Recorded once the Transport has Settled the Message, with the
+// Outcome it Reports (see queue.OnSettled). Transports that don't Report
+// Outcomes get the Outcome Expected from the Handler Error.
+func Middleware(j Journal) queue.Middleware {
+	return func(next queue.Handler) queue.Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			start := time.Now()
+			err := next(ctx, d)
+			elapsed := time.Since(start)
+
+			// NOTE: Journal Failures never Affect Message Processing
+			record := func(outcome string) {
+				if jerr := j.Record(ctx, NewEntry(d, outcome, err, elapsed)); jerr != nil {
+					Logger().Error("[Journal] Failed Recording Delivery", queue.F("queue", d.RoutingKey), queue.F("delivery_tag", d.DeliveryTag), queue.F("error", jerr))
+				}
+			}
+
+			// Will the Transport Report the Outcome?
+			if !queue.OnSettled(ctx, record) { // NO
+				record(expectedOutcome(err))
+			}
+			return err
+		}
+	}
+}
+
+// expectedOutcome Outcome of Default Settlement for Handler Error
+func expectedOutcome(err error) string {
+	switch {
+	case err == nil:
+		return queue.OutcomeAcked
+	case queue.IsQuarantineError(err):
+		return queue.OutcomeQuarantined
+	case queue.IsDeadLetterError(err):
+		return queue.OutcomeDeadLettered
+	}
+	return queue.OutcomeRequeued
+}
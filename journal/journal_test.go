@@ -0,0 +1,85 @@
+package journal
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/memqueue"
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// memJournal Journal Keeping Entries in Memory
+type memJournal struct {
+	lock    sync.Mutex
+	entries []*Entry
+}
+
+func (j *memJournal) Record(ctx context.Context, e *Entry) error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	j.entries = append(j.entries, e)
+	return nil
+}
+
+func TestMiddlewareRecordsSettledOutcome(t *testing.T) {
+	failure := errors.New("failed")
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"handled", nil, queue.OutcomeAcked},
+		{"failed", failure, queue.OutcomeRequeued},
+		{"dead letter", queue.DeadLetterError(failure), queue.OutcomeDeadLettered},
+		// NOTE: Broker has no Quarantine Queues, Message is Dead Lettered
+		{"quarantine", queue.QuarantineError(failure), queue.OutcomeDeadLettered},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := memqueue.NewBroker().SetDefaultQueue("jobs")
+			if err := b.QueuePublishString("", "", "message"); err != nil {
+				t.Fatal(err)
+			}
+
+			j := &memJournal{}
+			h := func(ctx context.Context, d *amqp.Delivery) error { return tt.err }
+			if _, err := b.QueueProcess(context.Background(), "", "", h, Middleware(j)); !errors.Is(err, tt.err) {
+				t.Fatalf("process err [%v], want [%v]", err, tt.err)
+			}
+
+			if len(j.entries) != 1 {
+				t.Fatalf("%d entries, want 1", len(j.entries))
+			}
+			if j.entries[0].Outcome != tt.want {
+				t.Errorf("outcome [%s], want [%s]", j.entries[0].Outcome, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddlewareWithoutSettlement(t *testing.T) {
+	j := &memJournal{}
+	h := Middleware(j)(func(ctx context.Context, d *amqp.Delivery) error {
+		return queue.QuarantineError(errors.New("failed"))
+	})
+
+	h(context.Background(), &amqp.Delivery{RoutingKey: "jobs"})
+	if len(j.entries) != 1 || j.entries[0].Outcome != queue.OutcomeQuarantined {
+		t.Errorf("entries %+v, want single quarantined entry", j.entries)
+	}
+}
@@ -0,0 +1,84 @@
+package journal
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/objectvault/queue-interface/sqlstore"
+)
+
+// cSpell:ignore sqlstore varchar
+
+// DefaultTable Journal Table Name
+const DefaultTable = "queue_journal"
+
+// SQL Journal (database/sql)
+type SQLJournal struct {
+	db      *sql.DB           // Database Connection
+	table   string            // Journal Table
+	dialect *sqlstore.Dialect // Database Dialect
+}
+
+func NewSQLJournal(db *sql.DB, table string, d *sqlstore.Dialect) *SQLJournal {
+	// Do we have a Table Name?
+	if table == "" { // NO: Use Default
+		table = DefaultTable
+	}
+
+	// Do we have a Dialect?
+	if d == nil { // NO: Use "?" Placeholders
+		d = sqlstore.DialectMySQL
+	}
+
+	return &SQLJournal{
+		db:      db,
+		table:   table,
+		dialect: d,
+	}
+}
+
+// CreateTable Create Journal Table if it does not Exist
+func (j *SQLJournal) CreateTable(ctx context.Context) error {
+	q := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	recorded_at TIMESTAMP NOT NULL,
+	queue VARCHAR(255) NOT NULL,
+	delivery_tag BIGINT NOT NULL,
+	message_id VARCHAR(255) NOT NULL,
+	redelivered BOOLEAN NOT NULL,
+	outcome VARCHAR(32) NOT NULL,
+	error VARCHAR(1024) NULL,
+	duration_ms BIGINT NOT NULL
+)`, j.table)
+
+	_, err := j.db.ExecContext(ctx, q)
+	return err
+}
+
+func (j *SQLJournal) Record(ctx context.Context, e *Entry) error {
+	p := j.dialect.Placeholder
+	q := fmt.Sprintf("INSERT INTO %s (recorded_at, queue, delivery_tag, message_id, redelivered, outcome, error, duration_ms) VALUES (%s, %s, %s, %s, %s, %s, %s, %s)",
+		j.table, p(1), p(2), p(3), p(4), p(5), p(6), p(7), p(8))
+
+	var msg sql.NullString
+	if e.Error != "" {
+		msg.Valid = true
+		msg.String = e.Error
+		if len(msg.String) > 1024 {
+			msg.String = msg.String[:1024]
+		}
+	}
+
+	_, err := j.db.ExecContext(ctx, q, e.Time, e.Queue, int64(e.DeliveryTag), e.MessageID, e.Redelivered, e.Outcome, msg, e.Duration.Milliseconds())
+	return err
+}
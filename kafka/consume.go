@@ -0,0 +1,251 @@
+package kafka
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// HeaderRedelivered Record Header Marking Records Republished by Nack/Reject with Requeue
+const HeaderRedelivered = "x-redelivered"
+
+func (t *Transport) DefaultQueueRetrieve(channel string) (*amqp.Delivery, error) {
+	return t.QueueRetrieve(channel, "")
+}
+
+// QueueRetrieve Fetch Next Record of Queue's Topic for the Consumer Group (nil if
+// None Arrives within the Fetch Wait)
+//
+// Delivery must be Settled (Ack, Nack or Reject): Settling Commits the Record.
+func (t *Transport) QueueRetrieve(channel string, name string) (*amqp.Delivery, error) {
+	topic, err := t.TopicName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	r, err := t.reader(topic)
+	if err != nil {
+		return nil, err
+	}
+
+	t.lock.Lock()
+	wait := t.wait
+	t.lock.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), wait)
+	defer cancel()
+
+	m, err := r.FetchMessage(ctx)
+	if err != nil {
+		// Did we Time Out Waiting?
+		if ctx.Err() != nil { // YES: Topic is Empty
+			return nil, nil
+		}
+
+		t.Logger().Error("[KafkaTransport] Failed Fetching Message", queue.F("topic", topic), queue.F("error", err))
+		return nil, err
+	}
+
+	t.lock.Lock()
+	t.tag++
+	tag := t.tag
+	t.pending[tag] = &fetch{reader: r, message: m}
+	t.lock.Unlock()
+
+	d := deliveryFromRecord(m)
+	d.Acknowledger = t
+	d.DeliveryTag = tag
+	return &d, nil
+}
+
+// reader Open (or Cached) Group Reader for Topic
+func (t *Transport) reader(topic string) (Reader, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Is Reader Open?
+	if r, ok := t.open[topic]; ok { // YES
+		return r, nil
+	}
+
+	r, err := t.readers(topic, t.group)
+	if err != nil {
+		return nil, err
+	}
+
+	t.open[topic] = r
+	return r, nil
+}
+
+// Ack amqp.Acknowledger (Commits Record)
+func (t *Transport) Ack(tag uint64, multiple bool) error {
+	l, err := t.settle(tag, multiple)
+	if err != nil {
+		return err
+	}
+	return commit(l)
+}
+
+// Nack amqp.Acknowledger (Republishes Record to its Topic or Dead Letter Topic, then Commits it)
+func (t *Transport) Nack(tag uint64, multiple bool, requeue bool) error {
+	l, err := t.settle(tag, multiple)
+	if err != nil {
+		return err
+	}
+
+	for _, f := range l {
+		m := Message{
+			Topic: f.message.Topic,
+			Key:   f.message.Key,
+			Value: f.message.Value,
+			Time:  f.message.Time,
+		}
+
+		// Should Record be Requeued?
+		if requeue { // YES: Back to its Topic
+			m.Headers = withHeader(f.message.Headers, HeaderRedelivered, "true")
+		} else { // NO: Dead Letter
+			m.Topic += DeadLetterSuffix
+			m.Headers = f.message.Headers
+		}
+
+		// NOTE: Record is left Uncommitted (Redelivered to the Group) if it can't be Republished
+		if err := t.write(context.Background(), m); err != nil {
+			return err
+		}
+	}
+	return commit(l)
+}
+
+// Reject amqp.Acknowledger
+func (t *Transport) Reject(tag uint64, requeue bool) error {
+	return t.Nack(tag, false, requeue)
+}
+
+// settle Remove Pending Records up to Tag (Returns Records in Tag Order)
+func (t *Transport) settle(tag uint64, multiple bool) ([]*fetch, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Is Delivery Known?
+	f, ok := t.pending[tag]
+	if !ok { // NO: Already Settled (or Transport Closed)
+		return nil, ErrUnknownDelivery
+	}
+
+	// Settle Single Delivery?
+	if !multiple { // YES
+		delete(t.pending, tag)
+		return []*fetch{f}, nil
+	}
+
+	l := []*fetch{}
+	for p := uint64(1); p <= tag; p++ {
+		if f, ok := t.pending[p]; ok {
+			l = append(l, f)
+			delete(t.pending, p)
+		}
+	}
+	return l, nil
+}
+
+// commit Commit Records with the Reader that Fetched them
+func commit(l []*fetch) error {
+	for _, f := range l {
+		if err := f.reader.CommitMessages(context.Background(), f.message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// QueueProcess Retrieve a Single Message and Pass it to Handler (Returns false if Topic was Empty)
+//
+// Handled Messages are Acked. Failed Messages are Requeued, unless the Error
+// is a Dead Letter or Quarantine Error.
+func (t *Transport) QueueProcess(ctx context.Context, channel string, name string, h queue.Handler, mw ...queue.Middleware) (bool, error) {
+	d, err := t.QueueRetrieve(channel, name)
+	if err != nil || d == nil {
+		return false, err
+	}
+
+	// Did Handler Fail?
+	ctx, settled := queue.WithSettlement(ctx)
+	err = queue.Chain(h, mw...)(ctx, d)
+	if err == nil { // NO
+		settled(queue.OutcomeAcked)
+		return true, d.Ack(false)
+	}
+
+	requeue := !queue.IsDeadLetterError(err) && !queue.IsQuarantineError(err)
+	if requeue {
+		settled(queue.OutcomeRequeued)
+	} else {
+		settled(queue.OutcomeDeadLettered)
+	}
+	if nerr := d.Nack(false, requeue); nerr != nil {
+		t.Logger().Error("[KafkaTransport] Failed Settling Message", queue.F("topic", d.RoutingKey), queue.F("error", nerr))
+	}
+	return true, err
+}
+
+// QueueConsumer Consumer Processing Queue Messages with Handler until Cancelled
+func (t *Transport) QueueConsumer(channel string, name string, h queue.Handler, mw ...queue.Middleware) queue.Consumer {
+	return func(ctx context.Context) error {
+		for {
+			// Has Shutdown Started?
+			if err := ctx.Err(); err != nil { // YES
+				return nil
+			}
+
+			// NOTE: Empty Topics Wait in QueueRetrieve (Fetch Wait)
+			ok, err := t.QueueProcess(context.Background(), channel, name, h, mw...)
+			if err != nil {
+				// Did Retrieve Fail?
+				if !ok { // YES: Abort
+					return err
+				}
+				t.Logger().Warn("[KafkaTransport] Message Failed", queue.F("queue", name), queue.F("error", err))
+			}
+		}
+	}
+}
+
+// deliveryFromRecord Delivery for Record (AMQP Properties Restored from Record Headers)
+func deliveryFromRecord(m Message) amqp.Delivery {
+	h := map[string]string{}
+	for _, e := range m.Headers {
+		h[e.Key] = string(e.Value)
+	}
+
+	d := queue.DeliveryFromHeaders(h, m.Value)
+	d.RoutingKey = m.Topic
+	d.Redelivered = h[HeaderRedelivered] == "true"
+	if d.Timestamp.IsZero() {
+		d.Timestamp = m.Time
+	}
+	return d
+}
+
+// withHeader Copy of Headers with Header Set
+func withHeader(l []Header, key string, value string) []Header {
+	c := make([]Header, 0, len(l)+1)
+	for _, e := range l {
+		if e.Key != key {
+			c = append(c, e)
+		}
+	}
+	return append(c, Header{Key: key, Value: []byte(value)})
+}
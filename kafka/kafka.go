@@ -0,0 +1,340 @@
+package kafka
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/codec"
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// DeadLetterSuffix Appended to Topic Name to Form its Dead Letter Topic
+const DeadLetterSuffix = "-dlq"
+
+// DefaultFetchWait Time QueueRetrieve Waits for a Message before Reporting an Empty Topic
+const DefaultFetchWait = 100 * time.Millisecond
+
+var (
+	ErrNoWriter         = errors.New("[KafkaTransport] Missing Writer")
+	ErrNoReaderFactory  = errors.New("[KafkaTransport] Missing Reader Factory")
+	ErrNoGroup          = errors.New("[KafkaTransport] Missing Consumer Group")
+	ErrQueueNameMissing = errors.New("[KafkaTransport] Missing Queue Name")
+	ErrUnknownDelivery  = errors.New("[KafkaTransport] Unknown Delivery Tag")
+)
+
+// Header Kafka Record Header
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Message Kafka Record (Shaped after github.com/segmentio/kafka-go Message)
+type Message struct {
+	Topic     string
+	Partition int
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+	Time      time.Time
+}
+
+// Writer Produces Records (i.e. a Wrapped kafka-go Writer without a Fixed Topic)
+type Writer interface {
+	WriteMessages(ctx context.Context, msgs ...Message) error
+}
+
+// Reader Consumes a Topic as Member of a Consumer Group (i.e. a Wrapped kafka-go Reader)
+//
+// FetchMessage must Block until a Record Arrives or the Context Ends, and must
+// not Commit Offsets: Records are Committed when their Delivery is Acked.
+type Reader interface {
+	FetchMessage(ctx context.Context) (Message, error)
+	CommitMessages(ctx context.Context, msgs ...Message) error
+	Close() error
+}
+
+// ReaderFactory Opens a Reader for Topic in Consumer Group
+type ReaderFactory func(topic string, group string) (Reader, error)
+
+// Transport Kafka Backed Queue Transport
+//
+// Queue Names are Mapped to Topics (Prefix Applied as for AMQP Queues) and
+// Retrieve/Consume Read through a Consumer Group, so every Message is Handled
+// by a Single Member of the Group. Deliveries are amqp.Delivery values that
+// can be Decoded and Handled as if Received from RabbitMQ.
+//
+// Kafka can't Requeue: Nack/Reject with Requeue Republishes the Record to
+// the End of its Topic, without Requeue to the Dead Letter Topic, before
+// Committing it.
+type Transport struct {
+	lock    sync.Mutex        // Transport State Lock
+	writer  Writer            // Record Producer
+	readers ReaderFactory     // Opens Group Readers
+	open    map[string]Reader // Open Readers by Topic
+	pending map[uint64]*fetch // Retrieved Records by Delivery Tag
+	tag     uint64            // Last Delivery Tag Issued
+
+	group  string        // Consumer Group
+	prefix string        // [OPTIONAL] Prefix to Topic Name
+	queue  string        // [OPTIONAL] Queue Used when Name is ""
+	wait   time.Duration // Time QueueRetrieve Waits for a Record
+	codec  codec.Codec   // Codec Used by QueuePublishEncoded
+	appID  string        // [OPTIONAL] AppId Stamped on Published Messages
+	logger queue.Logger  // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+// fetch Retrieved Record not yet Settled
+type fetch struct {
+	reader  Reader  // Reader that Fetched the Record
+	message Message // Fetched Record
+}
+
+func NewTransport(w Writer, rf ReaderFactory, group string) (*Transport, error) {
+	if w == nil {
+		return nil, ErrNoWriter
+	}
+	if rf == nil {
+		return nil, ErrNoReaderFactory
+	}
+	if group == "" {
+		return nil, ErrNoGroup
+	}
+
+	return &Transport{
+		writer:  w,
+		readers: rf,
+		open:    map[string]Reader{},
+		pending: map[uint64]*fetch{},
+		group:   group,
+		wait:    DefaultFetchWait,
+		codec:   codec.JSON,
+	}, nil
+}
+
+func (t *Transport) Group() string {
+	return t.group
+}
+
+func (t *Transport) Prefix() string {
+	return t.prefix
+}
+
+func (t *Transport) SetPrefix(p string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.prefix = p
+	return nil
+}
+
+func (t *Transport) DefaultQueue() string {
+	return t.queue
+}
+
+func (t *Transport) SetDefaultQueue(name string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.queue = name
+	return nil
+}
+
+// SetFetchWait Time QueueRetrieve Waits for a Record before Reporting an Empty Topic
+func (t *Transport) SetFetchWait(d time.Duration) error {
+	// Is Wait Valid?
+	if d > 0 { // YES
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		t.wait = d
+	}
+	return nil
+}
+
+// SetCodec Codec Used by QueuePublishEncoded (unless Overridden by queue.WithCodec)
+func (t *Transport) SetCodec(c codec.Codec) error {
+	// Do we have a Codec?
+	if c != nil { // YES
+		t.lock.Lock()
+		defer t.lock.Unlock()
+		t.codec = c
+	}
+	return nil
+}
+
+// SetAppID Application Identifier Stamped on Published Messages
+func (t *Transport) SetAppID(id string) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.appID = id
+	return nil
+}
+
+// Logger Transport Logger (Standard Logger if not Set)
+func (t *Transport) Logger() queue.Logger {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if t.logger == nil {
+		return defaultLogger
+	}
+	return t.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (t *Transport) SetLogger(l queue.Logger) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.logger = l
+	return nil
+}
+
+// TopicName Name of Topic for Queue (Prefix Applied, "" for Default Queue)
+func (t *Transport) TopicName(name string) (string, error) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if name == "" {
+		name = t.queue
+	}
+
+	if name == "" {
+		return "", ErrQueueNameMissing
+	}
+
+	if t.prefix == "" {
+		return name, nil
+	}
+	return t.prefix + "-" + name, nil
+}
+
+// QueuePublish Publish Pre-Encoded Message Body with the Given Content Type
+func (t *Transport) QueuePublish(channel string, name string, contentType string, body []byte) error {
+	return t.publish(name, &amqp.Publishing{ContentType: contentType, Body: body})
+}
+
+// QueuePublishDelivery Republish Delivery Keeping its Properties, Headers and Content Encoding
+func (t *Transport) QueuePublishDelivery(channel string, name string, d *amqp.Delivery) error {
+	return t.publish(name, queue.PublishingFromDelivery(d))
+}
+
+func (t *Transport) QueuePublishString(channel string, name string, msg string, opts ...queue.PublishOption) error {
+	p := &amqp.Publishing{ContentType: "text/plain", Body: []byte(msg)}
+	if err := queue.PreparePublishing(nil, p, opts...); err != nil {
+		return err
+	}
+	return t.publish(name, p)
+}
+
+func (t *Transport) DefaultQueuePublishJSON(channel string, msg interface{}, opts ...queue.PublishOption) error {
+	return t.QueuePublishJSON(channel, "", msg, opts...)
+}
+
+func (t *Transport) QueuePublishJSON(channel string, name string, msg interface{}, opts ...queue.PublishOption) error {
+	return t.publishEncoded(name, codec.JSON, msg, opts)
+}
+
+// QueuePublishEncoded Publish Message Encoded with the Publish Codec (see
+// queue.WithCodec), or the Transport Codec, Setting the Matching Content Type
+func (t *Transport) QueuePublishEncoded(channel string, name string, msg interface{}, opts ...queue.PublishOption) error {
+	o := &queue.PublishOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cdc := o.Codec
+	if cdc == nil {
+		t.lock.Lock()
+		cdc = t.codec
+		t.lock.Unlock()
+	}
+	return t.publishEncoded(name, cdc, msg, opts)
+}
+
+func (t *Transport) publishEncoded(name string, cdc codec.Codec, msg interface{}, opts []queue.PublishOption) error {
+	p := &amqp.Publishing{}
+	if err := queue.PreparePublishing(msg, p, opts...); err != nil {
+		return err
+	}
+
+	body, err := cdc.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.ContentType = cdc.ContentType()
+	p.Body = body
+	return t.publish(name, p)
+}
+
+func (t *Transport) publish(name string, p *amqp.Publishing) error {
+	topic, err := t.TopicName(name)
+	if err != nil {
+		return err
+	}
+
+	// Stamp Identity and Time (unless Set by Caller)
+	if p.AppId == "" {
+		p.AppId = t.appID
+	}
+	if p.Timestamp.IsZero() {
+		p.Timestamp = time.Now()
+	}
+
+	return t.write(context.Background(), recordFromPublishing(topic, p))
+}
+
+func (t *Transport) write(ctx context.Context, m Message) error {
+	err := t.writer.WriteMessages(ctx, m)
+	if err != nil {
+		t.Logger().Error("[KafkaTransport] Failed Publishing Message", queue.F("topic", m.Topic), queue.F("error", err))
+	}
+	return err
+}
+
+// Close Close Open Readers (Unsettled Deliveries are Redelivered to the Group)
+func (t *Transport) Close() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	var first error
+	for topic, r := range t.open {
+		if err := r.Close(); err != nil && first == nil {
+			first = err
+		}
+		delete(t.open, topic)
+	}
+	t.pending = map[uint64]*fetch{}
+	return first
+}
+
+// recordFromPublishing Kafka Record Carrying Publishing (Message ID is the Record Key)
+func recordFromPublishing(topic string, p *amqp.Publishing) Message {
+	m := Message{
+		Topic: topic,
+		Value: p.Body,
+		Time:  p.Timestamp,
+	}
+	if p.MessageId != "" {
+		m.Key = []byte(p.MessageId)
+	}
+	for k, v := range queue.PublishingHeaders(p) {
+		m.Headers = append(m.Headers, Header{Key: k, Value: []byte(v)})
+	}
+	return m
+}
@@ -0,0 +1,88 @@
+package memqueue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore memqueue
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// QueueProcess Retrieve a Single Message and Pass it to Handler (Returns false if Queue was Empty)
+//
+// Handled Messages are Acked. Failed Messages are Requeued, unless the Error
+// is a Dead Letter or Quarantine Error (see DeadLetters).
+func (b *Broker) QueueProcess(ctx context.Context, channel string, name string, h queue.Handler, mw ...queue.Middleware) (bool, error) {
+	d, err := b.QueueRetrieve(channel, name)
+	if err != nil || d == nil {
+		return false, err
+	}
+
+	ctx, settled := queue.WithSettlement(ctx)
+	return true, b.settleDelivery(d, queue.Chain(h, mw...)(ctx, d), settled)
+}
+
+// QueueConsumer Consumer Processing Queue Messages with Handler until Cancelled
+func (b *Broker) QueueConsumer(channel string, name string, h queue.Handler, mw ...queue.Middleware) queue.Consumer {
+	return func(ctx context.Context) error {
+		for {
+			// Has Shutdown Started?
+			if err := ctx.Err(); err != nil { // YES
+				return nil
+			}
+
+			ready := b.ready()
+			ok, err := b.QueueProcess(context.Background(), channel, name, h, mw...)
+			if err != nil {
+				// Did Retrieve Fail?
+				if !ok { // YES: Abort
+					return err
+				}
+				b.Logger().Warn("[MemQueue] Message Failed", queue.F("queue", name), queue.F("error", err))
+			}
+
+			// Was Queue Empty?
+			if !ok { // YES: Wait for Next Message
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-ready:
+				}
+			}
+		}
+	}
+}
+
+// settleDelivery Ack Handled Delivery, Requeue or Dead Letter Failed One (Returns Handler Error)
+func (b *Broker) settleDelivery(d *amqp.Delivery, err error, settled queue.OutcomeFunc) error {
+	// Did Handler Fail?
+	if err == nil { // NO
+		settled(queue.OutcomeAcked)
+		return d.Ack(false)
+	}
+
+	// Should Message be Dead Lettered?
+	requeue := !queue.IsDeadLetterError(err) && !queue.IsQuarantineError(err)
+	if nerr := d.Nack(false, requeue); nerr != nil {
+		b.Logger().Error("[MemQueue] Failed Settling Message", queue.F("queue", d.RoutingKey), queue.F("error", nerr))
+	}
+
+	if requeue {
+		settled(queue.OutcomeRequeued)
+	} else {
+		settled(queue.OutcomeDeadLettered)
+	}
+	return err
+}
@@ -0,0 +1,509 @@
+package memqueue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore memqueue
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/codec"
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// Operations Passed to Failure Injectors
+const (
+	OpPublish  = "publish"
+	OpRetrieve = "retrieve"
+)
+
+// DefaultWaitTimeout Time QueueRetrieveWait Waits for a Message
+const DefaultWaitTimeout = 5 * time.Second
+
+var (
+	ErrQueueNameMissing = errors.New("[MemQueue] Missing Queue Name")
+	ErrInjectedFailure  = errors.New("[MemQueue] Injected Failure")
+	ErrUnknownDelivery  = errors.New("[MemQueue] Unknown Delivery Tag")
+)
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+// FailureFunc Decides if an Operation on a Queue Fails (nil: Operation Proceeds)
+type FailureFunc func(op string, queue string) error
+
+// FailureRate Fail a Fraction (0..1) of Operations with ErrInjectedFailure
+func FailureRate(rate float64) FailureFunc {
+	var lock sync.Mutex
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return func(string, string) error {
+		lock.Lock()
+		defer lock.Unlock()
+
+		if r.Float64() < rate {
+			return ErrInjectedFailure
+		}
+		return nil
+	}
+}
+
+// FailOperation Fail Every 'op' Operation with ErrInjectedFailure
+func FailOperation(op string) FailureFunc {
+	return func(o string, _ string) error {
+		if o == op {
+			return ErrInjectedFailure
+		}
+		return nil
+	}
+}
+
+type message struct {
+	queue       string          // Queue Holding Message
+	publishing  amqp.Publishing // Message Properties and Body
+	redelivered bool            // Message was Requeued
+}
+
+// Broker In Memory Queues (Single Process Only)
+//
+// Mirrors the Publish/Retrieve/Consume Methods of queue.AMQPServerConnection
+// so Code Written Against them can be Unit Tested without a Broker. Every
+// Queue is a FIFO, Requeued Messages Return to the Head of their Queue.
+type Broker struct {
+	lock    sync.Mutex            // Broker State Lock
+	queues  map[string][]*message // Ready Messages by Queue
+	unacked map[uint64]*message   // Retrieved Messages by Delivery Tag
+	dead    map[string][]*message // Messages Rejected without Requeue by Queue
+	tag     uint64                // Last Delivery Tag Issued
+	signal  chan struct{}         // Closed (and Replaced) when a Message becomes Ready
+
+	defaultQueue string        // [OPTIONAL] Queue Used when Name is ""
+	latency      time.Duration // [OPTIONAL] Delay Added to Publish and Retrieve
+	failure      FailureFunc   // [OPTIONAL] Failure Injector
+	wait         time.Duration // Time QueueRetrieveWait Waits for a Message
+	codec        codec.Codec   // Codec Used by QueuePublishEncoded
+	logger       queue.Logger  // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+func NewBroker() *Broker {
+	return &Broker{
+		queues:  map[string][]*message{},
+		unacked: map[uint64]*message{},
+		dead:    map[string][]*message{},
+		signal:  make(chan struct{}),
+		wait:    DefaultWaitTimeout,
+		codec:   codec.JSON,
+	}
+}
+
+// SetDefaultQueue Queue Used when Queue Name is ""
+func (b *Broker) SetDefaultQueue(name string) *Broker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.defaultQueue = name
+	return b
+}
+
+// SetLatency Delay Added to every Publish and Retrieve (0: None)
+func (b *Broker) SetLatency(d time.Duration) *Broker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.latency = d
+	return b
+}
+
+// SetFailure Failure Injector Consulted before every Publish and Retrieve (nil: Never Fail)
+func (b *Broker) SetFailure(f FailureFunc) *Broker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.failure = f
+	return b
+}
+
+// SetWaitTimeout Time QueueRetrieveWait Waits for a Message
+func (b *Broker) SetWaitTimeout(d time.Duration) *Broker {
+	// Is Timeout Valid?
+	if d > 0 { // YES
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.wait = d
+	}
+	return b
+}
+
+// SetCodec Codec Used by QueuePublishEncoded (unless Overridden by queue.WithCodec)
+func (b *Broker) SetCodec(c codec.Codec) *Broker {
+	// Do we have a Codec?
+	if c != nil { // YES
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.codec = c
+	}
+	return b
+}
+
+// Logger Broker Logger (Standard Logger if not Set)
+func (b *Broker) Logger() queue.Logger {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// Do we have a Logger?
+	if b.logger == nil { // NO: Use Standard Logger
+		return defaultLogger
+	}
+	return b.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (b *Broker) SetLogger(l queue.Logger) *Broker {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.logger = l
+	return b
+}
+
+// Len Number of Ready (Not Retrieved) Messages in Queue
+func (b *Broker) Len(name string) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.queues[b.queueName(name)])
+}
+
+// Unacked Number of Retrieved Messages not yet Settled (All Queues)
+func (b *Broker) Unacked() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return len(b.unacked)
+}
+
+// Purge Drop Ready Messages in Queue (Returns Number Dropped)
+func (b *Broker) Purge(name string) int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	name = b.queueName(name)
+	n := len(b.queues[name])
+	delete(b.queues, name)
+	return n
+}
+
+// Messages Copy of Ready Messages in Queue (Head First, Not Retrieved)
+func (b *Broker) Messages(name string) []amqp.Delivery {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	l := []amqp.Delivery{}
+	for _, m := range b.queues[b.queueName(name)] {
+		l = append(l, m.delivery(nil, 0))
+	}
+	return l
+}
+
+// DeadLetters Copy of Messages Rejected (or Nacked) without Requeue from Queue
+func (b *Broker) DeadLetters(name string) []amqp.Delivery {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	l := []amqp.Delivery{}
+	for _, m := range b.dead[b.queueName(name)] {
+		l = append(l, m.delivery(nil, 0))
+	}
+	return l
+}
+
+// QueueName Name of Queue ("" for Default Queue)
+func (b *Broker) QueueName(name string) (string, error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	name = b.queueName(name)
+	if name == "" {
+		return "", ErrQueueNameMissing
+	}
+	return name, nil
+}
+
+func (b *Broker) queueName(name string) string {
+	if name == "" {
+		return b.defaultQueue
+	}
+	return name
+}
+
+// QueuePublish Publish Pre-Encoded Message Body with the Given Content Type
+func (b *Broker) QueuePublish(channel string, name string, contentType string, body []byte) error {
+	return b.publish(name, amqp.Publishing{ContentType: contentType, Body: body})
+}
+
+// QueuePublishDelivery Republish Delivery Keeping its Properties, Headers and Content Encoding
+func (b *Broker) QueuePublishDelivery(channel string, name string, d *amqp.Delivery) error {
+	return b.publish(name, *queue.PublishingFromDelivery(d))
+}
+
+func (b *Broker) QueuePublishString(channel string, name string, msg string, opts ...queue.PublishOption) error {
+	p := amqp.Publishing{ContentType: "text/plain", Body: []byte(msg)}
+	if err := queue.PreparePublishing(nil, &p, opts...); err != nil {
+		return err
+	}
+	return b.publish(name, p)
+}
+
+func (b *Broker) DefaultQueuePublishJSON(channel string, msg interface{}, opts ...queue.PublishOption) error {
+	return b.QueuePublishJSON(channel, "", msg, opts...)
+}
+
+func (b *Broker) QueuePublishJSON(channel string, name string, msg interface{}, opts ...queue.PublishOption) error {
+	return b.publishEncoded(name, codec.JSON, msg, opts)
+}
+
+// QueuePublishEncoded Publish Message Encoded with the Publish Codec (see
+// queue.WithCodec), or the Broker Codec, Setting the Matching Content Type
+func (b *Broker) QueuePublishEncoded(channel string, name string, msg interface{}, opts ...queue.PublishOption) error {
+	o := &queue.PublishOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cdc := o.Codec
+	if cdc == nil {
+		b.lock.Lock()
+		cdc = b.codec
+		b.lock.Unlock()
+	}
+	return b.publishEncoded(name, cdc, msg, opts)
+}
+
+func (b *Broker) publishEncoded(name string, cdc codec.Codec, msg interface{}, opts []queue.PublishOption) error {
+	p := amqp.Publishing{}
+	if err := queue.PreparePublishing(msg, &p, opts...); err != nil {
+		return err
+	}
+
+	body, err := cdc.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	p.ContentType = cdc.ContentType()
+	p.Body = body
+	return b.publish(name, p)
+}
+
+func (b *Broker) publish(name string, p amqp.Publishing) error {
+	name, err := b.QueueName(name)
+	if err != nil {
+		return err
+	}
+
+	if err = b.inject(OpPublish, name); err != nil {
+		return err
+	}
+
+	if p.Timestamp.IsZero() {
+		p.Timestamp = time.Now()
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.queues[name] = append(b.queues[name], &message{queue: name, publishing: p})
+	b.notify()
+	return nil
+}
+
+func (b *Broker) DefaultQueueRetrieve(channel string) (*amqp.Delivery, error) {
+	return b.QueueRetrieve(channel, "")
+}
+
+// QueueRetrieve Get Next Message in Queue (nil if Queue is Empty)
+//
+// Delivery must be Settled (Ack, Nack or Reject). Nack/Reject with Requeue
+// Returns the Message to the Head of the Queue.
+func (b *Broker) QueueRetrieve(channel string, name string) (*amqp.Delivery, error) {
+	name, err := b.QueueName(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = b.inject(OpRetrieve, name); err != nil {
+		return nil, err
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	// Is Queue Empty?
+	l := b.queues[name]
+	if len(l) == 0 { // YES
+		return nil, nil
+	}
+
+	m := l[0]
+	b.queues[name] = l[1:]
+
+	b.tag++
+	b.unacked[b.tag] = m
+
+	d := m.delivery(b, b.tag)
+	return &d, nil
+}
+
+func (b *Broker) DefaultQueueRetrieveWait(channel string) (*amqp.Delivery, error) {
+	return b.QueueRetrieveWait(channel, "")
+}
+
+// QueueRetrieveWait Wait until a Message Arrives or the Wait Timeout Expires (nil: No Message)
+func (b *Broker) QueueRetrieveWait(channel string, name string) (*amqp.Delivery, error) {
+	b.lock.Lock()
+	timer := time.NewTimer(b.wait)
+	b.lock.Unlock()
+	defer timer.Stop()
+
+	for {
+		ready := b.ready()
+
+		// Did we Receive a Message (or an Error)?
+		d, err := b.QueueRetrieve(channel, name)
+		if err != nil || d != nil { // YES: Return it
+			return d, err
+		}
+
+		select {
+		case <-ready:
+		case <-timer.C:
+			return nil, nil
+		}
+	}
+}
+
+// ready Channel Closed when the Next Message becomes Ready
+func (b *Broker) ready() <-chan struct{} {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.signal
+}
+
+// notify Wake Waiting Retrievers (Lock Held)
+func (b *Broker) notify() {
+	close(b.signal)
+	b.signal = make(chan struct{})
+}
+
+// inject Apply Latency and Failure Injection to Operation
+func (b *Broker) inject(op string, name string) error {
+	b.lock.Lock()
+	latency, failure := b.latency, b.failure
+	b.lock.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	// Do we have a Failure Injector?
+	if failure != nil { // YES
+		return failure(op, name)
+	}
+	return nil
+}
+
+// Ack amqp.Acknowledger
+func (b *Broker) Ack(tag uint64, multiple bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	_, err := b.settle(tag, multiple)
+	return err
+}
+
+// Nack amqp.Acknowledger
+func (b *Broker) Nack(tag uint64, multiple bool, requeue bool) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	l, err := b.settle(tag, multiple)
+	if err != nil {
+		return err
+	}
+
+	// Should Messages be Requeued?
+	if requeue { // YES: Back to Head of Queue (Original Order)
+		for i := len(l) - 1; i >= 0; i-- {
+			m := l[i]
+			m.redelivered = true
+			b.queues[m.queue] = append([]*message{m}, b.queues[m.queue]...)
+		}
+		b.notify()
+		return nil
+	}
+
+	for _, m := range l {
+		b.dead[m.queue] = append(b.dead[m.queue], m)
+	}
+	return nil
+}
+
+// Reject amqp.Acknowledger
+func (b *Broker) Reject(tag uint64, requeue bool) error {
+	return b.Nack(tag, false, requeue)
+}
+
+// settle Remove Unacked Messages up to Tag (Lock Held, Returns Messages in Tag Order)
+func (b *Broker) settle(tag uint64, multiple bool) ([]*message, error) {
+	// Is Delivery Known?
+	m, ok := b.unacked[tag]
+	if !ok { // NO: Already Settled (or Never Retrieved)
+		return nil, ErrUnknownDelivery
+	}
+
+	// Settle Single Delivery?
+	if !multiple { // YES
+		delete(b.unacked, tag)
+		return []*message{m}, nil
+	}
+
+	l := []*message{}
+	for t := uint64(1); t <= tag; t++ {
+		if m, ok := b.unacked[t]; ok {
+			l = append(l, m)
+			delete(b.unacked, t)
+		}
+	}
+	return l, nil
+}
+
+// delivery Delivery for Message (Acknowledger nil: Copy that can't be Settled)
+func (m *message) delivery(ack amqp.Acknowledger, tag uint64) amqp.Delivery {
+	p := m.publishing
+	return amqp.Delivery{
+		Acknowledger:    ack,
+		Headers:         p.Headers,
+		ContentType:     p.ContentType,
+		ContentEncoding: p.ContentEncoding,
+		DeliveryMode:    p.DeliveryMode,
+		Priority:        p.Priority,
+		CorrelationId:   p.CorrelationId,
+		ReplyTo:         p.ReplyTo,
+		Expiration:      p.Expiration,
+		MessageId:       p.MessageId,
+		Timestamp:       p.Timestamp,
+		Type:            p.Type,
+		UserId:          p.UserId,
+		AppId:           p.AppId,
+		DeliveryTag:     tag,
+		Redelivered:     m.redelivered,
+		RoutingKey:      m.queue,
+		Body:            p.Body,
+	}
+}
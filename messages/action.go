@@ -13,13 +13,15 @@ package messages
 // cSpell:ignore gofrs, atype
 import (
 	"encoding/json"
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 
 	"github.com/objectvault/common/maps"
+
+	"github.com/objectvault/queue-interface/shared"
 )
 
 type ActionMessageContent struct {
@@ -57,20 +59,44 @@ func (o *ActionMessageContent) SetProperties(m map[string]interface{}) {
 	o.props = *maps.NewMapWrapper(m)
 }
 
+func (o *ActionMessageContent) Params() *maps.MapWrapper {
+	return &o.params
+}
+
+func (o *ActionMessageContent) Props() *maps.MapWrapper {
+	return &o.props
+}
+
 func (o *ActionMessageContent) MarshalJSON() ([]byte, error) {
 	if !o.IsValid() {
-		return nil, errors.New("[ActionMessageContent] Is not valid")
+		return nil, invalidMessage("ActionMessageContent", "missing action type")
 	}
 
 	// JSON Structure
-	j := &struct {
-		Type   string      `json:"type"`
-		Params interface{} `json:"params,omitempty"`
-		Props  interface{} `json:"props,omitempty"`
-	}{
-		Type: o.atype,
+	j := getActionContentJSON()
+	defer putActionContentJSON(j)
+
+	o.fill(j)
+
+	// Convert Structure to JSON
+	return json.Marshal(j)
+}
+
+// MarshalDocument Action Content Wire Document (see MarshalJSON)
+func (o *ActionMessageContent) MarshalDocument() (interface{}, error) {
+	if !o.IsValid() {
+		return nil, invalidMessage("ActionMessageContent", "missing action type")
 	}
 
+	j := &actionContentJSON{}
+	o.fill(j)
+	return j, nil
+}
+
+// fill Copy Action Content into Wire Structure
+func (o *ActionMessageContent) fill(j *actionContentJSON) {
+	j.Type = o.atype
+
 	// Parameters Set?
 	if !o.params.IsEmpty() {
 		j.Params = o.params.Map()
@@ -80,9 +106,6 @@ func (o *ActionMessageContent) MarshalJSON() ([]byte, error) {
 	if !o.props.IsEmpty() {
 		j.Props = o.props.Map()
 	}
-
-	// Convert Structure to JSON
-	return json.Marshal(j)
 }
 
 type ActionMessage struct {
@@ -149,6 +172,15 @@ func (o *ActionMessage) IsValid() bool {
 	return false
 }
 
+func (o *ActionMessage) Type() string {
+	c := GetActionMessageContent(o)
+	if c != nil {
+		return c.Type()
+	}
+
+	return ""
+}
+
 func (o *ActionMessage) Params() *maps.MapWrapper {
 	c := GetActionMessageContent(o)
 	if c != nil {
@@ -165,7 +197,7 @@ func (o *ActionMessage) SetParameters(m map[string]interface{}) error {
 		return nil
 	}
 
-	return errors.New("[ActionMessage] Initialize Message before using")
+	return ErrNotInitialized
 }
 
 func (o *ActionMessage) Props() *maps.MapWrapper {
@@ -184,7 +216,7 @@ func (o *ActionMessage) SetProperties(m map[string]interface{}) error {
 		return nil
 	}
 
-	return errors.New("[ActionMessage] Initialize Message before using")
+	return ErrNotInitialized
 }
 
 func (o *ActionMessage) SetParameter(path string, v interface{}) error {
@@ -193,20 +225,20 @@ func (o *ActionMessage) SetParameter(path string, v interface{}) error {
 		return p.Set(path, v, true)
 	}
 
-	return errors.New("[ActionMessage] Initialize Message before using")
+	return ErrNotInitialized
 }
 
 func (o *ActionMessage) SetStringParameter(path string, s string, clear bool) error {
 	p := o.Params()
 	if p != nil {
 		if s == "" && clear {
-			return p.Clear(p)
+			return p.Clear(path)
 		}
 
 		return p.Set(path, s, true)
 	}
 
-	return errors.New("[ActionMessage] Initialize Message before using")
+	return ErrNotInitialized
 }
 
 func (o *ActionMessage) SetProperty(path string, v interface{}) error {
@@ -215,7 +247,7 @@ func (o *ActionMessage) SetProperty(path string, v interface{}) error {
 		return p.Set(path, v, true)
 	}
 
-	return errors.New("[ActionMessage] Initialize Message before using")
+	return ErrNotInitialized
 }
 
 func (o *ActionMessage) SetStringProperty(path string, s string, clear bool) error {
@@ -223,11 +255,72 @@ func (o *ActionMessage) SetStringProperty(path string, s string, clear bool) err
 	p := o.Props()
 	if p != nil {
 		if s == "" && clear {
-			return p.Clear(p)
+			return p.Clear(path)
 		}
 
 		return p.Set(path, s, true)
 	}
 
-	return errors.New("[ActionMessage] Initialize Message before using")
+	return ErrNotInitialized
+}
+
+// stringParameter String Parameter ("" if not Set)
+func (o *ActionMessage) stringParameter(path string) string {
+	p := o.Params()
+	if p != nil {
+		v, e := p.GetDefault(path, "")
+		if e == nil {
+			s, _ := v.(string)
+			return s
+		}
+	}
+
+	return ""
+}
+
+// timeParameter Time Parameter (nil if not Set or Invalid, see shared.ParseTimeValue)
+func (o *ActionMessage) timeParameter(path string) *time.Time {
+	p := o.Params()
+	if p == nil {
+		return nil
+	}
+
+	v, e := p.GetDefault(path, nil)
+	if e != nil || v == nil {
+		return nil
+	}
+
+	t, err := shared.ParseTimeValue(v)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// setTimeParameter Store Time Parameter as RFC 3339 (UTC)
+func (o *ActionMessage) setTimeParameter(path string, t time.Time) error {
+	return o.SetParameter(path, shared.FormatTimeStamp(t))
+}
+
+// intParameter Integer Parameter (0 if not Set or not a Number)
+func (o *ActionMessage) intParameter(path string) int64 {
+	p := o.Params()
+	if p != nil {
+		v, e := p.GetDefault(path, nil)
+		if e == nil {
+			switch n := v.(type) {
+			case int:
+				return int64(n)
+			case int64:
+				return n
+			case float64:
+				return int64(n)
+			case json.Number:
+				i, _ := n.Int64()
+				return i
+			}
+		}
+	}
+
+	return 0
 }
@@ -0,0 +1,263 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Common Administrative Commands
+const (
+	AdminPause      = "pause"       // Stop Consuming
+	AdminResume     = "resume"      // Resume Consuming
+	AdminFlushCache = "flush-cache" // Drop Local Caches
+	AdminRotateLogs = "rotate-logs" // Reopen Log Files
+)
+
+// AdminAnyService Allow-List Service Matching every Target
+const AdminAnyService = "*"
+
+// AdminCommandMessage Operator Instruction to a Processor (Check with AdminAllowList before Acting)
+type AdminCommandMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+// NewAdminCommandMessage i.e. NewAdminCommandMessage(AdminPause, "mailer", "admin-id", time.Now().Add(5*time.Minute))
+func NewAdminCommandMessage(command string, target string, by string, expires time.Time) (*AdminCommandMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[AdminCommandMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewAdminCommandMessageWithGUID(uid.String(), command, target, by, expires)
+}
+
+func NewAdminCommandMessageWithGUID(guid string, command string, target string, by string, expires time.Time) (*AdminCommandMessage, error) {
+	m := &AdminCommandMessage{}
+	err := InitAdminCommandMessage(m, guid, command, target, by, expires)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitAdminCommandMessage(m *AdminCommandMessage, guid string, command string, target string, by string, expires time.Time) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "admin:command")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetCommand(command, target)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetIssuedBy(by)
+	if err != nil {
+		return err
+	}
+
+	return m.SetExpires(expires)
+}
+
+func (m *AdminCommandMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Command() != "") && (m.Target() != "") && (m.IssuedBy() != "") && (m.Expires() != nil)
+}
+
+// Command Command Verb (i.e. AdminPause)
+func (m *AdminCommandMessage) Command() string {
+	return m.stringParameter("command")
+}
+
+// Target Service that should Execute Command
+func (m *AdminCommandMessage) Target() string {
+	return m.stringParameter("target")
+}
+
+func (m *AdminCommandMessage) SetCommand(command string, target string) error {
+	// Is Command Empty?
+	command = strings.ToLower(strings.TrimSpace(command))
+	if command == "" {
+		return invalidField("AdminCommandMessage", "command", "required")
+	}
+
+	// Is Target Empty?
+	target = strings.ToLower(strings.TrimSpace(target))
+	if target == "" {
+		return invalidField("AdminCommandMessage", "target", "required")
+	}
+
+	err := m.SetParameter("command", command)
+	if err != nil {
+		return err
+	}
+	return m.SetParameter("target", target)
+}
+
+// Args Command Arguments (nil if None)
+func (m *AdminCommandMessage) Args() map[string]interface{} {
+	p := m.Params()
+	if p != nil {
+		v, e := p.Get("args")
+		if e == nil {
+			if a, ok := v.(map[string]interface{}); ok {
+				return a
+			}
+		}
+	}
+
+	return nil
+}
+
+// Arg String Argument ("" if not Set)
+func (m *AdminCommandMessage) Arg(name string) string {
+	return m.stringParameter("args." + name)
+}
+
+func (m *AdminCommandMessage) SetArg(name string, v interface{}) error {
+	// Is Name Empty?
+	name = strings.TrimSpace(name)
+	if name == "" || strings.Contains(name, ".") {
+		return invalidField("AdminCommandMessage", "args", "invalid argument name ["+name+"]")
+	}
+
+	return m.SetParameter("args."+name, v)
+}
+
+// IssuedBy Identifier of Admin Issuing Command
+func (m *AdminCommandMessage) IssuedBy() string {
+	return m.stringParameter("by-user")
+}
+
+func (m *AdminCommandMessage) SetIssuedBy(user string) error {
+	// Is User Empty?
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return invalidField("AdminCommandMessage", "by-user", "required")
+	}
+
+	return m.SetParameter("by-user", user)
+}
+
+// Expires Time after which Command must not be Executed
+func (m *AdminCommandMessage) Expires() *time.Time {
+	return m.timeParameter("expires")
+}
+
+// SetExpires Set Expiry (Must be in the Future, Stale Commands are Dangerous)
+func (m *AdminCommandMessage) SetExpires(t time.Time) error {
+	// Is Expiry in the Future?
+	if !t.After(time.Now()) { // NO
+		return invalidField("AdminCommandMessage", "expires", "expiry must be in the future")
+	}
+
+	return m.setTimeParameter("expires", t)
+}
+
+// Expired Has Command Expired at 'now'?
+func (m *AdminCommandMessage) Expired(now time.Time) bool {
+	e := m.Expires()
+	return e == nil || !now.Before(*e)
+}
+
+// AdminAllowList Commands a Processor Accepts, by Target Service
+type AdminAllowList struct {
+	lock     sync.RWMutex
+	commands map[string]map[string]bool // Command -> Services (AdminAnyService: All)
+}
+
+func NewAdminAllowList() *AdminAllowList {
+	return &AdminAllowList{
+		commands: make(map[string]map[string]bool),
+	}
+}
+
+// DefaultAdminAllowList Common Commands (Pause, Resume, Flush Cache, Rotate Logs) for every Service
+func DefaultAdminAllowList() *AdminAllowList {
+	return NewAdminAllowList().
+		Allow(AdminPause).
+		Allow(AdminResume).
+		Allow(AdminFlushCache).
+		Allow(AdminRotateLogs)
+}
+
+// Allow Accept 'command' for 'services' (None: every Service)
+func (l *AdminAllowList) Allow(command string, services ...string) *AdminAllowList {
+	command = strings.ToLower(strings.TrimSpace(command))
+	if command == "" {
+		return l
+	}
+
+	if len(services) == 0 {
+		services = []string{AdminAnyService}
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	s := l.commands[command]
+	if s == nil {
+		s = make(map[string]bool)
+		l.commands[command] = s
+	}
+	for _, service := range services {
+		service = strings.ToLower(strings.TrimSpace(service))
+		if service != "" {
+			s[service] = true
+		}
+	}
+	return l
+}
+
+// Allowed Is 'command' Accepted for 'service'?
+func (l *AdminAllowList) Allowed(command string, service string) bool {
+	l.lock.RLock()
+	defer l.lock.RUnlock()
+
+	s := l.commands[strings.ToLower(command)]
+	return s[AdminAnyService] || s[strings.ToLower(service)]
+}
+
+// Check Can Message be Executed by 'service'? (Valid, not Expired, Allowed, Addressed to Service)
+func (l *AdminAllowList) Check(m *AdminCommandMessage, service string) error {
+	// Is Message Valid?
+	if !m.IsValid() { // NO
+		return invalidMessage("AdminCommandMessage", "missing command, target, issuer or expiry")
+	}
+
+	// Has Command Expired?
+	if m.Expired(time.Now()) { // YES
+		return fmt.Errorf("%w [%s] Expired at [%s]", ErrCommandExpired, m.Command(), m.Expires().Format(time.RFC3339))
+	}
+
+	// Is Command Addressed to Service?
+	service = strings.ToLower(strings.TrimSpace(service))
+	if m.Target() != service && m.Target() != AdminAnyService { // NO
+		return fmt.Errorf("%w [%s] Targets [%s] not [%s]", ErrCommandNotAllowed, m.Command(), m.Target(), service)
+	}
+
+	// Is Command Allowed?
+	if !l.Allowed(m.Command(), service) { // NO
+		return fmt.Errorf("%w [%s] for [%s]", ErrCommandNotAllowed, m.Command(), service)
+	}
+
+	return nil
+}
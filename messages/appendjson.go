@@ -0,0 +1,378 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Hand Written JSON Encoders for the Hot Message Types. Output is identical
+// to MarshalJSON but is written straight into the caller's buffer (see the
+// "json-fast" codec).
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+const hexDigits = "0123456789abcdef"
+
+// appendJSONString Quote and Escape String (same rules as encoding/json)
+func appendJSONString(dst []byte, s string) []byte {
+	dst = append(dst, '"')
+	start := 0
+	for i := 0; i < len(s); {
+		if b := s[i]; b < utf8.RuneSelf {
+			// Safe Character?
+			if b >= 0x20 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&' { // YES
+				i++
+				continue
+			}
+
+			dst = append(dst, s[start:i]...)
+			switch b {
+			case '\\', '"':
+				dst = append(dst, '\\', b)
+			case '\n':
+				dst = append(dst, '\\', 'n')
+			case '\r':
+				dst = append(dst, '\\', 'r')
+			case '\t':
+				dst = append(dst, '\\', 't')
+			default:
+				dst = append(dst, '\\', 'u', '0', '0', hexDigits[b>>4], hexDigits[b&0xF])
+			}
+			i++
+			start = i
+			continue
+		}
+
+		c, size := utf8.DecodeRuneInString(s[i:])
+		if c == utf8.RuneError && size == 1 {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, "\ufffd"...)
+			i += size
+			start = i
+			continue
+		}
+
+		// Line and Paragraph Separators are Escaped for JSONP Safety
+		if c == '\u2028' || c == '\u2029' {
+			dst = append(dst, s[start:i]...)
+			dst = append(dst, '\\', 'u', '2', '0', '2', hexDigits[c&0xF])
+			i += size
+			start = i
+			continue
+		}
+		i += size
+	}
+
+	dst = append(dst, s[start:]...)
+	return append(dst, '"')
+}
+
+// appendJSONFloat Format Float (same rules as encoding/json)
+func appendJSONFloat(dst []byte, f float64, bits int) ([]byte, error) {
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return nil, errors.New("[appendJSON] Unsupported Float Value")
+	}
+
+	abs := math.Abs(f)
+	format := byte('f')
+	if abs != 0 {
+		if bits == 64 && (abs < 1e-6 || abs >= 1e21) || bits == 32 && (float32(abs) < 1e-6 || float32(abs) >= 1e21) {
+			format = 'e'
+		}
+	}
+
+	dst = strconv.AppendFloat(dst, f, format, -1, bits)
+	if format == 'e' {
+		// Clean up e-09 to e-9
+		n := len(dst)
+		if n >= 4 && dst[n-4] == 'e' && dst[n-3] == '-' && dst[n-2] == '0' {
+			dst[n-2] = dst[n-1]
+			dst = dst[:n-1]
+		}
+	}
+
+	return dst, nil
+}
+
+// appendJSONTime Format Time as encoding/json Does (RFC 3339 with Nanoseconds)
+func appendJSONTime(dst []byte, t time.Time) []byte {
+	dst = append(dst, '"')
+	dst = t.AppendFormat(dst, time.RFC3339Nano)
+	return append(dst, '"')
+}
+
+// appendJSONMap Encode Map with Sorted Keys
+func appendJSONMap(dst []byte, m map[string]interface{}) ([]byte, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var err error
+	dst = append(dst, '{')
+	for i, k := range keys {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+
+		dst = appendJSONString(dst, k)
+		dst = append(dst, ':')
+		dst, err = appendJSONValue(dst, m[k])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return append(dst, '}'), nil
+}
+
+// appendJSONValue Encode Generic Value (Types Found in Decoded Maps are Handled Inline)
+func appendJSONValue(dst []byte, v interface{}) ([]byte, error) {
+	var err error
+	switch t := v.(type) {
+	case nil:
+		return append(dst, "null"...), nil
+	case string:
+		return appendJSONString(dst, t), nil
+	case bool:
+		return strconv.AppendBool(dst, t), nil
+	case float64:
+		return appendJSONFloat(dst, t, 64)
+	case float32:
+		return appendJSONFloat(dst, float64(t), 32)
+	case int:
+		return strconv.AppendInt(dst, int64(t), 10), nil
+	case int64:
+		return strconv.AppendInt(dst, t, 10), nil
+	case int32:
+		return strconv.AppendInt(dst, int64(t), 10), nil
+	case uint:
+		return strconv.AppendUint(dst, uint64(t), 10), nil
+	case uint64:
+		return strconv.AppendUint(dst, t, 10), nil
+	case map[string]interface{}:
+		if t == nil {
+			return append(dst, "null"...), nil
+		}
+		return appendJSONMap(dst, t)
+	case []interface{}:
+		if t == nil {
+			return append(dst, "null"...), nil
+		}
+
+		dst = append(dst, '[')
+		for i, e := range t {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+
+			dst, err = appendJSONValue(dst, e)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return append(dst, ']'), nil
+	case []string:
+		if t == nil {
+			return append(dst, "null"...), nil
+		}
+
+		dst = append(dst, '[')
+		for i, e := range t {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = appendJSONString(dst, e)
+		}
+		return append(dst, ']'), nil
+	case time.Time:
+		return appendJSONTime(dst, t), nil
+	case interface{ AppendJSON([]byte) ([]byte, error) }:
+		return t.AppendJSON(dst)
+	}
+
+	// Everything Else: Standard Library
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, b...), nil
+}
+
+// AppendJSON Encode Status into dst (same output as MarshalJSON)
+func (o *QueueMessageStatus) AppendJSON(dst []byte) ([]byte, error) {
+	dst = append(dst, `{"error_code":`...)
+	dst = strconv.AppendInt(dst, int64(o.errorCode), 10)
+
+	if o.errorMessage != "" {
+		dst = append(dst, `,"error_message":`...)
+		dst = appendJSONString(dst, o.errorMessage)
+	}
+
+	if o.errorMessageI18N != "" {
+		dst = append(dst, `,"error_message_i18n":`...)
+		dst = appendJSONString(dst, o.errorMessageI18N)
+	}
+
+	// Extras Set?
+	if !o.extras.IsEmpty() {
+		var err error
+		dst = append(dst, `,"extras":`...)
+		dst, err = appendJSONMap(dst, o.extras.Map())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if o.attempt != 0 {
+		dst = append(dst, `,"attempt":`...)
+		dst = strconv.AppendInt(dst, int64(o.attempt), 10)
+	}
+
+	if o.startedAt != nil {
+		dst = append(dst, `,"started_at":`...)
+		dst = appendJSONTime(dst, *o.startedAt)
+	}
+
+	if o.finishedAt != nil {
+		dst = append(dst, `,"finished_at":`...)
+		dst = appendJSONTime(dst, *o.finishedAt)
+
+		if ms := o.Duration().Milliseconds(); ms != 0 {
+			dst = append(dst, `,"duration_ms":`...)
+			dst = strconv.AppendInt(dst, ms, 10)
+		}
+	}
+
+	return append(dst, '}'), nil
+}
+
+// AppendJSON Encode Header into dst (same output as MarshalJSON)
+func (o *QueueMessageHeader) AppendJSON(dst []byte) ([]byte, error) {
+	if !o.IsValid() {
+		return nil, invalidMessage("QueueMessageHeader", "missing version or id")
+	}
+
+	var err error
+	dst = append(dst, `{"version":`...)
+	dst = strconv.AppendInt(dst, int64(o.version), 10)
+	dst = append(dst, `,"id":`...)
+	dst = appendJSONString(dst, o.id)
+
+	if o.parent != "" {
+		dst = append(dst, `,"parent":`...)
+		dst = appendJSONString(dst, o.parent)
+	}
+
+	// Properties Set?
+	if !o.props.IsEmpty() {
+		dst = append(dst, `,"props":`...)
+		dst, err = appendJSONMap(dst, o.props.Map())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Status Set?
+	if o.status != nil {
+		dst = append(dst, `,"status":`...)
+		dst, err = o.status.AppendJSON(dst)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// History Set?
+	if len(o.history) > 0 {
+		dst = append(dst, `,"history":[`...)
+		for i := range o.history {
+			if i > 0 {
+				dst = append(dst, ',')
+			}
+			dst = o.history[i].appendJSON(dst)
+		}
+		dst = append(dst, ']')
+	}
+
+	// Tracing Set?
+	if o.tracing != nil {
+		dst = append(dst, `,"tracing":`...)
+		dst = o.tracing.appendJSON(dst)
+	}
+
+	dst = append(dst, `,"created":`...)
+	dst = appendJSONTime(dst, o.Created())
+	return append(dst, '}'), nil
+}
+
+// AppendJSON Encode Action Content into dst (same output as MarshalJSON)
+func (o *ActionMessageContent) AppendJSON(dst []byte) ([]byte, error) {
+	if !o.IsValid() {
+		return nil, invalidMessage("ActionMessageContent", "missing action type")
+	}
+
+	var err error
+	dst = append(dst, `{"type":`...)
+	dst = appendJSONString(dst, o.atype)
+
+	// Parameters Set?
+	if !o.params.IsEmpty() {
+		dst = append(dst, `,"params":`...)
+		dst, err = appendJSONMap(dst, o.params.Map())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Properties Set?
+	if !o.props.IsEmpty() {
+		dst = append(dst, `,"props":`...)
+		dst, err = appendJSONMap(dst, o.props.Map())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return append(dst, '}'), nil
+}
+
+// AppendJSON Encode Message into dst (same output as MarshalJSON)
+func (o *QueueMessage) AppendJSON(dst []byte) ([]byte, error) {
+	if !o.IsValid() {
+		return nil, invalidMessage("QueueMessage", "missing header or body")
+	}
+
+	err := runHooks(HookBeforeMarshal, o)
+	if err != nil {
+		return nil, err
+	}
+
+	dst = append(dst, `{"header":`...)
+	dst, err = o.header.AppendJSON(dst)
+	if err != nil {
+		return nil, err
+	}
+
+	dst = append(dst, `,"body":`...)
+	dst, err = appendJSONValue(dst, o.body)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(dst, '}'), nil
+}
@@ -0,0 +1,202 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/objectvault/queue-interface/codec"
+	"github.com/objectvault/queue-interface/fixtures"
+)
+
+// appender Message with a Hand Written JSON Encoder
+type appender interface {
+	AppendJSON(dst []byte) ([]byte, error)
+}
+
+// assertAppendJSON AppendJSON Output must be Identical to json.Marshal Output
+func assertAppendJSON(t *testing.T, name string, v appender) {
+	t.Helper()
+
+	want, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("%s: json.Marshal failed [%v]", name, err)
+	}
+
+	// NOTE: Prefix Checks that the Encoder Appends (and doesn't Overwrite)
+	got, err := v.AppendJSON([]byte("prefix:"))
+	if err != nil {
+		t.Fatalf("%s: AppendJSON failed [%v]", name, err)
+	}
+
+	if !bytes.Equal(got, append([]byte("prefix:"), want...)) {
+		t.Errorf("%s: AppendJSON differs from MarshalJSON\n got: %s\nwant: prefix:%s", name, got, want)
+	}
+
+	// "json-fast" and "json" Codecs must Agree
+	fast, err := codec.AppendMarshal(codec.FastJSON, nil, v)
+	if err != nil {
+		t.Fatalf("%s: FastJSON failed [%v]", name, err)
+	}
+
+	std, err := codec.AppendMarshal(codec.JSON, nil, v)
+	if err != nil {
+		t.Fatalf("%s: JSON failed [%v]", name, err)
+	}
+
+	if !bytes.Equal(fast, std) {
+		t.Errorf("%s: FastJSON differs from JSON\n got: %s\nwant: %s", name, fast, std)
+	}
+}
+
+// sampleHeader Header with Every Optional Field Set
+func sampleHeader(t *testing.T) *QueueMessageHeader {
+	t.Helper()
+
+	at := time.Date(2022, 6, 1, 12, 0, 0, 123456789, time.UTC)
+
+	s := NewQueueMessageStatus()
+	s.SetError(500, "Failed <\"quoted\"> &   \xff", "error.failed")
+	s.SetAttempt(3)
+	s.SetStartedAt(at)
+	s.SetFinishedAt(at.Add(time.Second))
+
+	h := NewQueueMessageHeader("5d3f1e0a-8c7b-4c1e-9a55-0f7b2c9e4a10", "0b6c2f4e-1d7a-4f3b-8e2c-6a9d5b1c3e70")
+	h.SetCreated(at)
+	h.SetStatus(s)
+	h.SetRequeueCount(2)
+	h.SetPriority(7)
+	h.SetCorrelationID("corr\t1")
+	h.SetSequence("publisher", 42)
+	if err := h.SetProperty("custom.ratio", 0.125); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.AppendHistory(HistoryEntry{Node: "worker-1", At: at, Outcome: "acked", Note: "ok\n"}, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	tr, err := NewTracing("4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = h.SetTracing(tr); err != nil {
+		t.Fatal(err)
+	}
+	return h
+}
+
+func TestAppendJSONStatus(t *testing.T) {
+	s := NewQueueMessageStatus()
+	assertAppendJSON(t, "empty status", s)
+
+	assertAppendJSON(t, "full status", sampleHeader(t).Status())
+}
+
+func TestAppendJSONHeader(t *testing.T) {
+	assertAppendJSON(t, "minimal header", NewQueueMessageHeader("id", ""))
+	assertAppendJSON(t, "full header", sampleHeader(t))
+}
+
+func TestAppendJSONActionContent(t *testing.T) {
+	c := NewActionMessageContent("action:test")
+	assertAppendJSON(t, "type only", c)
+
+	c.SetParameters(map[string]interface{}{
+		"string": "<html> & \"quotes\"",
+		"int":    42,
+		"float":  1e21,
+		"bool":   true,
+		"null":   nil,
+		"list":   []interface{}{"a", 1.5, map[string]interface{}{"z": 1, "a": 2}},
+	})
+	c.SetProperties(map[string]interface{}{"user": "admin"})
+	assertAppendJSON(t, "params and props", c)
+}
+
+func TestAppendJSONQueueMessage(t *testing.T) {
+	m := NewQueueMessage("id", map[string]interface{}{"text": "plain"})
+	assertAppendJSON(t, "plain body", m)
+
+	m = &QueueMessage{header: sampleHeader(t), body: []interface{}{"a", 1}}
+	assertAppendJSON(t, "full header", m)
+}
+
+// TestAppendJSONFixtures Every Fixture Decoded into its Registered Type
+func TestAppendJSONFixtures(t *testing.T) {
+	for _, v := range fixtures.Versions() {
+		for _, n := range fixtures.Names(v) {
+			b := fixtures.MustLoad(v, n)
+
+			var m interface{}
+			m, err := Decode(b)
+			if err != nil {
+				// Not an Action Message: Decode as Plain Queue Message
+				q := &QueueMessage{}
+				if err = json.Unmarshal(b, q); err != nil {
+					t.Fatalf("%s/%s: decode failed [%v]", v, n, err)
+				}
+				m = q
+			}
+
+			a, ok := m.(appender)
+			if !ok {
+				t.Fatalf("%s/%s: %T has no AppendJSON", v, n, m)
+			}
+			assertAppendJSON(t, v+"/"+n, a)
+		}
+	}
+}
+
+// TestAppendJSONRegisteredTypes Every Registered Message Type
+func TestAppendJSONRegisteredTypes(t *testing.T) {
+	for _, mt := range MessageTypes() {
+		// NOTE: Some Types only Accept Sub Types (i.e. "action:email:invite:<object>")
+		m, err := Decode(sampleAction(t, mt))
+		if err != nil {
+			m, err = Decode(sampleAction(t, mt+":test"))
+		}
+		if err != nil {
+			t.Errorf("%s: decode failed [%v]", mt, err)
+			continue
+		}
+
+		a, ok := m.(appender)
+		if !ok {
+			t.Fatalf("%s: %T has no AppendJSON", mt, m)
+		}
+		assertAppendJSON(t, mt, a)
+	}
+}
+
+// sampleAction Encoded Action Message of Type
+func sampleAction(t *testing.T, at string) []byte {
+	t.Helper()
+
+	b, err := json.Marshal(map[string]interface{}{
+		"header": map[string]interface{}{
+			"version": 1,
+			"id":      "9f1b7a3c-2e4d-4b6f-8a1c-3d5e7f9a1b2c",
+			"created": "2022-06-01T12:00:00Z",
+		},
+		"body": map[string]interface{}{
+			"type":   at,
+			"params": map[string]interface{}{"to": "user@example.com", "count": 3},
+			"props":  map[string]interface{}{"note": "<b>bold</b>"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,172 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/base64"
+	"strings"
+)
+
+// Attachment Dispositions
+const (
+	DispositionAttachment = "attachment" // Listed as a Downloadable File
+	DispositionInline     = "inline"     // Embedded, Referenced from HTML as cid:<content-id>
+)
+
+// Attachment File Sent with an Email
+//
+// Content is either Embedded (Data) or Fetched by the Processor (URL, i.e. a
+// Claim Check Reference for Large Files).
+type Attachment struct {
+	Filename    string // File Name Shown to Recipient
+	ContentType string // MIME Type (i.e. "image/png")
+	Disposition string // DispositionAttachment (DEFAULT) or DispositionInline
+	ContentID   string // [INLINE] Content-ID Referenced by HTML (without <>)
+	Data        []byte // [OPTIONAL] File Content
+	URL         string // [OPTIONAL] Location of File Content
+}
+
+// IsInline Is Attachment Embedded in the HTML Body?
+func (a *Attachment) IsInline() bool {
+	return a.Disposition == DispositionInline
+}
+
+// CID URL Referencing Inline Attachment from HTML (i.e. <img src="cid:logo">)
+func (a *Attachment) CID() string {
+	return "cid:" + a.ContentID
+}
+
+func (a *Attachment) validate() error {
+	switch {
+	case strings.TrimSpace(a.Filename) == "":
+		return invalidField("EmailMessage", "attachments", "filename required")
+	case a.Disposition != DispositionAttachment && a.Disposition != DispositionInline:
+		return invalidField("EmailMessage", "attachments", "invalid disposition ["+a.Disposition+"]")
+	case a.IsInline() && a.ContentID == "":
+		return invalidField("EmailMessage", "attachments", "inline attachment ["+a.Filename+"] requires content id")
+	case strings.ContainsAny(a.ContentID, "<> \t\r\n"):
+		return invalidField("EmailMessage", "attachments", "invalid content id ["+a.ContentID+"]")
+	case len(a.Data) == 0 && a.URL == "":
+		return invalidField("EmailMessage", "attachments", "attachment ["+a.Filename+"] has no content")
+	}
+	return nil
+}
+
+func (a *Attachment) toMap() map[string]interface{} {
+	m := map[string]interface{}{
+		"filename":    a.Filename,
+		"disposition": a.Disposition,
+	}
+	if a.ContentType != "" {
+		m["content-type"] = a.ContentType
+	}
+	if a.ContentID != "" {
+		m["content-id"] = a.ContentID
+	}
+	if len(a.Data) > 0 {
+		m["data"] = base64.StdEncoding.EncodeToString(a.Data)
+	}
+	if a.URL != "" {
+		m["url"] = a.URL
+	}
+	return m
+}
+
+func attachmentFromMap(m map[string]interface{}) Attachment {
+	a := Attachment{}
+	a.Filename, _ = m["filename"].(string)
+	a.ContentType, _ = m["content-type"].(string)
+	a.Disposition, _ = m["disposition"].(string)
+	a.ContentID, _ = m["content-id"].(string)
+	a.URL, _ = m["url"].(string)
+	if d, ok := m["data"].(string); ok {
+		a.Data, _ = base64.StdEncoding.DecodeString(d)
+	}
+	if a.Disposition == "" {
+		a.Disposition = DispositionAttachment
+	}
+	return a
+}
+
+// Attachments Files Sent with the Email (in Order Added)
+func (m *EmailMessage) Attachments() []Attachment {
+	p := m.Params()
+	if p == nil {
+		return nil
+	}
+
+	l, e := p.Get("attachments")
+	if e != nil || l == nil {
+		return nil
+	}
+
+	v, ok := l.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	list := make([]Attachment, 0, len(v))
+	for _, i := range v {
+		if entry, ok := i.(map[string]interface{}); ok {
+			list = append(list, attachmentFromMap(entry))
+		}
+	}
+	return list
+}
+
+// AddAttachment Add File (Inline Attachments need a Content ID Unique within the Email)
+func (m *EmailMessage) AddAttachment(a Attachment) error {
+	a.Filename = strings.TrimSpace(a.Filename)
+	a.ContentID = strings.Trim(strings.TrimSpace(a.ContentID), "<>")
+	if a.Disposition == "" {
+		a.Disposition = DispositionAttachment
+	}
+	a.Disposition = strings.ToLower(a.Disposition)
+
+	// Is Attachment Valid?
+	if err := a.validate(); err != nil { // NO
+		return err
+	}
+
+	// NOTE: Stored as Generic Values (Same Shape as Decoded JSON)
+	list := []interface{}{}
+	for _, e := range m.Attachments() {
+		// Is Content ID Already Used?
+		if a.ContentID != "" && e.ContentID == a.ContentID { // YES
+			return invalidField("EmailMessage", "attachments", "duplicate content id ["+a.ContentID+"]")
+		}
+		list = append(list, e.toMap())
+	}
+	list = append(list, a.toMap())
+
+	return m.SetParameter("attachments", list)
+}
+
+// AddInline Embed File Referenced from the HTML Template as cid:<contentID>
+func (m *EmailMessage) AddInline(contentID string, filename string, contentType string, data []byte) error {
+	return m.AddAttachment(Attachment{
+		Filename:    filename,
+		ContentType: contentType,
+		Disposition: DispositionInline,
+		ContentID:   contentID,
+		Data:        data,
+	})
+}
+
+// ClearAttachments Remove all Attachments
+func (m *EmailMessage) ClearAttachments() error {
+	p := m.Params()
+	if p != nil {
+		return p.Clear("attachments")
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Billing Event Kinds
+const (
+	BillingTrialExpiring = "trial-expiring"
+	BillingInvoiceDue    = "invoice-due"
+	BillingPlanChanged   = "plan-changed"
+)
+
+// BillingEventMessage Organization Billing Event (Shared by Billing Processor and Email Pipeline)
+//
+// Amounts are in Minor Units of the Currency (i.e. Cents) to Avoid Rounding.
+type BillingEventMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewBillingEventMessage(org string, kind string) (*BillingEventMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[BillingEventMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewBillingEventMessageWithGUID(uid.String(), org, kind)
+}
+
+func NewBillingEventMessageWithGUID(guid string, org string, kind string) (*BillingEventMessage, error) {
+	m := &BillingEventMessage{}
+	err := InitBillingEventMessage(m, guid, org, kind)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitBillingEventMessage(m *BillingEventMessage, guid string, org string, kind string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "billing:event")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetOrganization(org)
+	if err != nil {
+		return err
+	}
+
+	return m.SetKind(kind)
+}
+
+func (m *BillingEventMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Validate() == nil)
+}
+
+// Validate Check Fields Required by Event Kind
+func (m *BillingEventMessage) Validate() error {
+	// Do we have an Organization?
+	if m.Organization() == "" { // NO
+		return invalidField("BillingEventMessage", "org", "required")
+	}
+
+	switch m.Kind() {
+	case BillingTrialExpiring:
+		if m.DueDate() == nil {
+			return invalidField("BillingEventMessage", "due-date", "trial expiration date required")
+		}
+	case BillingInvoiceDue:
+		if m.DueDate() == nil {
+			return invalidField("BillingEventMessage", "due-date", "invoice due date required")
+		}
+		if m.Currency() == "" {
+			return invalidField("BillingEventMessage", "amount", "invoice amount required")
+		}
+	case BillingPlanChanged:
+		if m.Plan() == "" {
+			return invalidField("BillingEventMessage", "plan", "new plan required")
+		}
+	default:
+		return invalidField("BillingEventMessage", "kind", "invalid event kind ["+m.Kind()+"]")
+	}
+
+	return nil
+}
+
+// Organization Identifier of Billed Organization
+func (m *BillingEventMessage) Organization() string {
+	return m.stringParameter("org")
+}
+
+func (m *BillingEventMessage) SetOrganization(org string) error {
+	// Is Organization Empty?
+	org = strings.TrimSpace(org)
+	if org == "" {
+		return invalidField("BillingEventMessage", "org", "required")
+	}
+
+	return m.SetParameter("org", org)
+}
+
+// Kind Billing Event Kind (i.e. BillingInvoiceDue)
+func (m *BillingEventMessage) Kind() string {
+	return m.stringParameter("kind")
+}
+
+func (m *BillingEventMessage) SetKind(kind string) error {
+	// Is Event Kind Known?
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	switch kind {
+	case BillingTrialExpiring, BillingInvoiceDue, BillingPlanChanged: // YES
+		return m.SetParameter("kind", kind)
+	}
+
+	return invalidField("BillingEventMessage", "kind", "invalid event kind ["+kind+"]")
+}
+
+// Amount Amount in Minor Units of Currency (0 if not Set)
+func (m *BillingEventMessage) Amount() int64 {
+	return m.intParameter("amount.value")
+}
+
+// Currency ISO 4217 Currency Code ("" if Amount not Set)
+func (m *BillingEventMessage) Currency() string {
+	return m.stringParameter("amount.currency")
+}
+
+// SetAmount Set Amount in Minor Units of ISO 4217 'currency' (i.e. 1999, "EUR")
+func (m *BillingEventMessage) SetAmount(minor int64, currency string) error {
+	// Is Amount Valid?
+	if minor < 0 { // NO
+		return invalidField("BillingEventMessage", "amount", "negative amount")
+	}
+
+	// Is Currency an ISO 4217 Code?
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) != 3 || strings.Trim(currency, "ABCDEFGHIJKLMNOPQRSTUVWXYZ") != "" { // NO
+		return invalidField("BillingEventMessage", "amount", "invalid currency ["+currency+"]")
+	}
+
+	err := m.SetParameter("amount.value", minor)
+	if err != nil {
+		return err
+	}
+	return m.SetParameter("amount.currency", currency)
+}
+
+// DueDate Trial Expiration or Invoice Due Date (nil if not Set)
+func (m *BillingEventMessage) DueDate() *time.Time {
+	return m.timeParameter("due-date")
+}
+
+func (m *BillingEventMessage) SetDueDate(t time.Time) error {
+	// Is Date Set?
+	if t.IsZero() { // NO
+		return invalidField("BillingEventMessage", "due-date", "required")
+	}
+
+	return m.setTimeParameter("due-date", t)
+}
+
+// Plan Current Plan (For BillingPlanChanged: New Plan)
+func (m *BillingEventMessage) Plan() string {
+	return m.stringParameter("plan.current")
+}
+
+// PreviousPlan Plan before Change ("" if not a Plan Change)
+func (m *BillingEventMessage) PreviousPlan() string {
+	return m.stringParameter("plan.previous")
+}
+
+// SetPlan Set Current Plan (and Previous Plan for BillingPlanChanged, "": None)
+func (m *BillingEventMessage) SetPlan(current string, previous string) error {
+	// Is Plan Empty?
+	current = strings.TrimSpace(current)
+	if current == "" {
+		return invalidField("BillingEventMessage", "plan", "required")
+	}
+
+	err := m.SetParameter("plan.current", current)
+	if err != nil {
+		return err
+	}
+	return m.SetStringParameter("plan.previous", strings.TrimSpace(previous), true)
+}
@@ -0,0 +1,241 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs, objectname, storename
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// InviteEntry Single Invitation in a Bulk Invite
+type InviteEntry struct {
+	Email      string    // Invitee Address
+	Code       string    // Activation Code (Unique within the Bulk Invite)
+	Expiration time.Time // Time Invitation Expires
+}
+
+// BulkInviteMessage Invitations to Many People Sharing a Common Context
+//
+// Parameters: Shared Context (by-name, by-email, objectname, storename, message)
+// and invites (List of Entries) the Processor Fans Out.
+type BulkInviteMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewBulkInviteMessage(ot string) (*BulkInviteMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[BulkInviteMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewBulkInviteMessageWithGUID(uid.String(), ot)
+}
+
+func NewBulkInviteMessageWithGUID(guid string, ot string) (*BulkInviteMessage, error) {
+	m := &BulkInviteMessage{}
+	err := InitBulkInviteMessage(m, guid, ot)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitBulkInviteMessage(m *BulkInviteMessage, guid string, ot string) error {
+	ot = strings.TrimSpace(ot)
+	if ot == "" {
+		return invalidField("BulkInviteMessage", "type", "invitation object type required")
+	}
+
+	// Initialize Action Message
+	return InitQueueAction(&(m.ActionMessage), guid, "invite:bulk:"+strings.ToLower(ot))
+}
+
+func (m *BulkInviteMessage) IsValid() bool {
+	return m.Validate() == nil
+}
+
+// Validate Check Shared Context and Every Entry
+func (m *BulkInviteMessage) Validate() error {
+	switch {
+	case !m.ActionMessage.IsValid():
+		return invalidMessage("BulkInviteMessage", "not initialized")
+	case m.ByUser() == "":
+		return invalidField("BulkInviteMessage", "by-name", "required")
+	case m.ObjectName() == "":
+		return invalidField("BulkInviteMessage", "objectname", "required")
+	}
+
+	entries := m.Invites()
+	if len(entries) == 0 {
+		return invalidField("BulkInviteMessage", "invites", "required")
+	}
+
+	codes := map[string]bool{}
+	for i, e := range entries {
+		if err := validateInviteEntry(i, e, codes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObjectType Type of Object Invited To (i.e. "store")
+func (m *BulkInviteMessage) ObjectType() string {
+	return strings.TrimPrefix(m.Type(), "action:invite:bulk:")
+}
+
+func (m *BulkInviteMessage) ByUser() string {
+	return m.stringParameter("by-name")
+}
+
+func (m *BulkInviteMessage) SetByUser(name string) error {
+	// Is Name Empty?
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return invalidField("BulkInviteMessage", "by-name", "required")
+	}
+
+	return m.SetParameter("by-name", name)
+}
+
+func (m *BulkInviteMessage) ByEmail() string {
+	return m.stringParameter("by-email")
+}
+
+func (m *BulkInviteMessage) SetByEmail(email string) error {
+	return m.SetStringParameter("by-email", strings.ToLower(strings.TrimSpace(email)), true)
+}
+
+func (m *BulkInviteMessage) ObjectName() string {
+	return m.stringParameter("objectname")
+}
+
+func (m *BulkInviteMessage) SetObjectName(name string) error {
+	// Is Name Empty?
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return invalidField("BulkInviteMessage", "objectname", "required")
+	}
+
+	return m.SetParameter("objectname", name)
+}
+
+func (m *BulkInviteMessage) StoreName() string {
+	return m.stringParameter("storename")
+}
+
+func (m *BulkInviteMessage) SetStoreName(name string) error {
+	return m.SetStringParameter("storename", strings.TrimSpace(name), true)
+}
+
+// Message [OPTIONAL] Personal Note Included in every Invitation
+func (m *BulkInviteMessage) Message() string {
+	return m.stringParameter("message")
+}
+
+func (m *BulkInviteMessage) SetMessage(msg string) error {
+	return m.SetStringParameter("message", msg, true)
+}
+
+// Invites Entries (in Order Added)
+func (m *BulkInviteMessage) Invites() []InviteEntry {
+	p := m.Params()
+	if p == nil {
+		return nil
+	}
+
+	l, e := p.Get("invites")
+	if e != nil || l == nil {
+		return nil
+	}
+
+	v, ok := l.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	list := make([]InviteEntry, 0, len(v))
+	for _, i := range v {
+		entry, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		ie := InviteEntry{}
+		ie.Email, _ = entry["email"].(string)
+		ie.Code, _ = entry["code"].(string)
+		if v, ok := entry["expiration"]; ok {
+			ie.Expiration, _ = shared.ParseTimeValue(v)
+		}
+		list = append(list, ie)
+	}
+	return list
+}
+
+// AddInvite Add Entry (Validated: Address, Unique Code, Future Expiration)
+func (m *BulkInviteMessage) AddInvite(e InviteEntry) error {
+	e.Email = strings.ToLower(strings.TrimSpace(e.Email))
+	e.Code = strings.ToLower(strings.TrimSpace(e.Code))
+
+	entries := m.Invites()
+	codes := map[string]bool{}
+	for _, c := range entries {
+		codes[c.Code] = true
+	}
+
+	// Is Entry Valid?
+	if err := validateInviteEntry(len(entries), e, codes); err != nil { // NO
+		return err
+	}
+
+	// Has Entry Already Expired?
+	if !e.Expiration.After(time.Now()) { // YES
+		return invalidField("BulkInviteMessage", fmt.Sprintf("invites[%d]", len(entries)), "expiration must be in the future")
+	}
+
+	// NOTE: Stored as Generic Values (Same Shape as Decoded JSON)
+	list := []interface{}{}
+	for _, c := range append(entries, e) {
+		list = append(list, map[string]interface{}{
+			"email":      c.Email,
+			"code":       c.Code,
+			"expiration": shared.FormatTimeStamp(c.Expiration),
+		})
+	}
+	return m.SetParameter("invites", list)
+}
+
+// validateInviteEntry Check Entry 'i' (codes: Codes Already Used, Updated)
+func validateInviteEntry(i int, e InviteEntry, codes map[string]bool) error {
+	field := fmt.Sprintf("invites[%d]", i)
+	switch {
+	case e.Email == "" || !strings.Contains(e.Email, "@"):
+		return invalidField("BulkInviteMessage", field, "invalid email ["+e.Email+"]")
+	case e.Code == "":
+		return invalidField("BulkInviteMessage", field, "code required")
+	case codes[e.Code]:
+		return invalidField("BulkInviteMessage", field, "duplicate code ["+e.Code+"]")
+	case e.Expiration.IsZero():
+		return invalidField("BulkInviteMessage", field, "expiration required")
+	}
+
+	codes[e.Code] = true
+	return nil
+}
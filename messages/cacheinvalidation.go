@@ -0,0 +1,118 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// CacheInvalidationMessage Tells every Node to Drop Cached Entities (Broadcast)
+type CacheInvalidationMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewCacheInvalidationMessage(entity string, ids ...string) (*CacheInvalidationMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[CacheInvalidationMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewCacheInvalidationMessageWithGUID(uid.String(), entity, ids...)
+}
+
+func NewCacheInvalidationMessageWithGUID(guid string, entity string, ids ...string) (*CacheInvalidationMessage, error) {
+	m := &CacheInvalidationMessage{}
+	err := InitCacheInvalidationMessage(m, guid, entity, ids...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitCacheInvalidationMessage(m *CacheInvalidationMessage, guid string, entity string, ids ...string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "cache:invalidate")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetEntity(entity)
+	if err != nil {
+		return err
+	}
+
+	return m.SetIDs(ids)
+}
+
+func (m *CacheInvalidationMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Entity() != "") && (len(m.IDs()) > 0)
+}
+
+// Entity Type of Cached Entity (i.e. "org", "store")
+func (m *CacheInvalidationMessage) Entity() string {
+	return m.stringParameter("entity")
+}
+
+func (m *CacheInvalidationMessage) SetEntity(entity string) error {
+	// Is Entity Type Empty?
+	entity = strings.ToLower(strings.TrimSpace(entity))
+	if entity == "" {
+		return invalidField("CacheInvalidationMessage", "entity", "required")
+	}
+
+	return m.SetParameter("entity", entity)
+}
+
+// IDs Identifiers of Entities to Drop
+func (m *CacheInvalidationMessage) IDs() []string {
+	p := m.Params()
+	if p != nil {
+		ids, e := p.Get("ids")
+		if e == nil && ids != nil {
+			return toStringList(ids)
+		}
+	}
+
+	return nil
+}
+
+func (m *CacheInvalidationMessage) SetIDs(ids []string) error {
+	list := []string{}
+	for _, id := range ids {
+		id = strings.TrimSpace(id)
+		if id != "" {
+			list = append(list, id)
+		}
+	}
+
+	// Do we have IDs?
+	if len(list) == 0 { // NO
+		return invalidField("CacheInvalidationMessage", "ids", "required")
+	}
+
+	return m.SetParameter("ids", list)
+}
+
+// Reason [OPTIONAL] Why Entities Changed (i.e. "org-updated")
+func (m *CacheInvalidationMessage) Reason() string {
+	return m.stringParameter("reason")
+}
+
+func (m *CacheInvalidationMessage) SetReason(reason string) error {
+	return m.SetStringParameter("reason", strings.TrimSpace(reason), true)
+}
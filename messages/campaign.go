@@ -0,0 +1,86 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+)
+
+// Standard Message Streams (Transactional and Bulk Mail are Usually Sent Separately)
+const (
+	StreamTransactional = "transactional"
+	StreamBroadcast     = "broadcast"
+)
+
+// Campaign Identifier the Email Provider Tags the Send With
+func (m *EmailMessage) Campaign() string {
+	p := m.Params()
+	if p != nil {
+		c, e := p.GetDefault("campaign", "")
+		if e == nil {
+			return c.(string)
+		}
+	}
+
+	return ""
+}
+
+func (m *EmailMessage) SetCampaign(id string) error {
+	return m.SetStringParameter("campaign", strings.TrimSpace(id), true)
+}
+
+// Stream Message Stream (i.e. StreamTransactional)
+func (m *EmailMessage) Stream() string {
+	p := m.Params()
+	if p != nil {
+		s, e := p.GetDefault("stream", "")
+		if e == nil {
+			return s.(string)
+		}
+	}
+
+	return ""
+}
+
+func (m *EmailMessage) SetStream(s string) error {
+	return m.SetStringParameter("stream", strings.ToLower(strings.TrimSpace(s)), true)
+}
+
+// TrackOpens Should Provider Track Opens?
+func (m *EmailMessage) TrackOpens() bool {
+	return m.trackingFlag("opens")
+}
+
+func (m *EmailMessage) SetTrackOpens(on bool) error {
+	return m.SetParameter("tracking.opens", on)
+}
+
+// TrackClicks Should Provider Rewrite Links to Track Clicks?
+func (m *EmailMessage) TrackClicks() bool {
+	return m.trackingFlag("clicks")
+}
+
+func (m *EmailMessage) SetTrackClicks(on bool) error {
+	return m.SetParameter("tracking.clicks", on)
+}
+
+func (m *EmailMessage) trackingFlag(name string) bool {
+	p := m.Params()
+	if p != nil {
+		f, e := p.GetDefault("tracking."+name, false)
+		if e == nil {
+			on, _ := f.(bool)
+			return on
+		}
+	}
+
+	return false
+}
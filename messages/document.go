@@ -0,0 +1,79 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"time"
+)
+
+// NOTE: Binary Codecs (MessagePack, CBOR) Encode the Wire Structures Directly
+// (Struct Fields use their JSON Names, see codec.DocumentMarshaler), so
+// Integers Keep their Full Range and Binary Values Stay Binary. Decoding
+// Applies the Same Checks and Limits as UnmarshalJSON.
+
+// documentMarshaler Body Encoded as a Wire Document (i.e. ActionMessageContent)
+type documentMarshaler interface {
+	MarshalDocument() (interface{}, error)
+}
+
+// Wire Structures used while Unmarshaling
+
+type statusDocument struct {
+	ErrorCode        int                    `json:"error_code"`
+	ErrorMessage     string                 `json:"error_message"`
+	ErrorMessageI18N string                 `json:"error_message_i18n"`
+	Extras           map[string]interface{} `json:"extras"`
+	Attempt          int                    `json:"attempt"`
+	StartedAt        *time.Time             `json:"started_at"`
+	FinishedAt       *time.Time             `json:"finished_at"`
+}
+
+type headerDocument struct {
+	Version int                    `json:"version"`
+	ID      string                 `json:"id"`
+	Parent  string                 `json:"parent"`
+	Props   map[string]interface{} `json:"props"`
+	Status  *statusDocument        `json:"status"`
+	History []HistoryEntry         `json:"history"`
+	Tracing *Tracing               `json:"tracing"`
+	Created *time.Time             `json:"created"`
+}
+
+type actionContentDocument struct {
+	Type   string                 `json:"type"`
+	Params map[string]interface{} `json:"params"`
+	Props  map[string]interface{} `json:"props"`
+}
+
+// checkDocumentSize Is the Encoded Document Within Size Limits?
+func checkDocumentSize(source string, size int) error {
+	if size > MaxMessageSize {
+		return fmt.Errorf("%w [%s]", ErrMessageTooLarge, source)
+	}
+	return nil
+}
+
+// decodeHeader Decode Envelope Header from Document (Body Checked by Caller)
+func decodeHeader(source string, j *headerDocument, hasBody bool) (*QueueMessageHeader, error) {
+	// Do we have a Header?
+	if j == nil { // NO
+		return nil, invalidField(source, "header", "missing")
+	}
+
+	// Do we have a Body?
+	if !hasBody { // NO
+		return nil, invalidField(source, "body", "missing")
+	}
+
+	h := &QueueMessageHeader{}
+	return h, h.fromDocument(j)
+}
@@ -13,9 +13,9 @@ package messages
 // cSpell:ignore mtype, msubtype
 
 import (
-	"errors"
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/gofrs/uuid"
 )
@@ -51,7 +51,7 @@ func InitEmailMessage(m *EmailMessage, guid string, et string, template string)
 
 	if et == "" {
 		if template == "" {
-			return errors.New("[EmailMessage] Untyped email requires template")
+			return invalidField("EmailMessage", "template", "untyped email requires template")
 		}
 
 		et = "email"
@@ -75,7 +75,50 @@ func InitEmailMessage(m *EmailMessage, guid string, et string, template string)
 }
 
 func (m *EmailMessage) IsValid() bool {
-	return m.IsValid() && (m.Template() != "") && (m.To() != "")
+	return m.ActionMessage.IsValid() && (m.Template() != "") && (m.To() != "" || m.HasPersonalizations())
+}
+
+// Validate Check Message and Template Context (against Registered Template Manifest)
+//
+// Returns nil or *ValidationError Listing every Violation.
+func (m *EmailMessage) Validate() error {
+	// Is Message Initialized?
+	if !m.ActionMessage.IsValid() { // NO: Nothing else to Check
+		return invalidMessage("EmailMessage", "not initialized")
+	}
+
+	l := []Violation{}
+	if m.Template() == "" {
+		l = append(l, Violation{Field: "template", Code: ViolationRequired, Message: "missing template name"})
+	}
+	if m.To() == "" && !m.HasPersonalizations() {
+		l = append(l, Violation{Field: "to", Code: ViolationRequired, Message: "missing recipient"})
+	}
+
+	// Is Template Known?
+	if m.Template() != "" { // YES: Check Context
+		// Is Message Personalized?
+		if !m.HasPersonalizations() { // NO
+			l = appendViolations(l, ValidateTemplateContext("EmailMessage", m.Template(), m.TemplateContext()))
+		}
+
+		// Every Recipient's Context must be Complete
+		for _, p := range m.Personalizations() {
+			l = appendViolations(l, ValidateTemplateContext("EmailMessage", m.Template(), m.PersonalizedContext(p)))
+		}
+	}
+
+	return validationError("EmailMessage", l)
+}
+
+// TemplateContext Variables Available to the Template (Message Properties)
+func (m *EmailMessage) TemplateContext() map[string]interface{} {
+	p := m.Props()
+	if p != nil && p.Map() != nil {
+		return p.Map()
+	}
+
+	return map[string]interface{}{}
 }
 
 func (m *EmailMessage) Template() string {
@@ -94,7 +137,7 @@ func (m *EmailMessage) SetTemplate(t string) error {
 	// Is Template Name Empty?
 	t = strings.TrimSpace(t)
 	if t == "" {
-		return errors.New("Email Template is Required")
+		return invalidField("EmailMessage", "template", "required")
 	}
 
 	return m.SetParameter("template", strings.ToLower(t))
@@ -113,7 +156,7 @@ func (m *EmailMessage) Locale() string {
 }
 
 func (m *EmailMessage) SetLocale(l string) error {
-	return m.SetStringParameter("template", strings.ToLower(l), true)
+	return m.SetStringParameter("locale", strings.ToLower(strings.TrimSpace(l)), true)
 }
 
 func (m *EmailMessage) To() string {
@@ -132,7 +175,7 @@ func (m *EmailMessage) SetTo(to string) error {
 	// Is Template Name Empty?
 	to = strings.TrimSpace(to)
 	if to == "" {
-		return errors.New("Email Destination is Required")
+		return invalidField("EmailMessage", "to", "required")
 	}
 
 	return m.SetParameter("to", strings.ToLower(to))
@@ -240,3 +283,28 @@ func (m *EmailMessage) ClearHeaders() error {
 
 	return nil
 }
+
+// SendAt Time Processor should Deliver Email (nil: Immediately)
+func (m *EmailMessage) SendAt() *time.Time {
+	return m.timeParameter("send-at")
+}
+
+// SetSendAt Schedule Delivery (Must be in the Future)
+func (m *EmailMessage) SetSendAt(t time.Time) error {
+	// Is Time in the Future?
+	if !t.After(time.Now()) { // NO
+		return invalidField("EmailMessage", "send-at", "must be in the future")
+	}
+
+	return m.setTimeParameter("send-at", t)
+}
+
+// ClearSendAt Deliver Immediately
+func (m *EmailMessage) ClearSendAt() error {
+	p := m.Params()
+	if p != nil {
+		return p.Clear("send-at")
+	}
+
+	return nil
+}
@@ -0,0 +1,235 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// EmailBatchMessage One Logical Email Sent to Many Recipients Grouped by Locale
+//
+// Parameters: template, recipients (locale -> list of addresses)
+// Properties: Template Context Shared by all Recipients
+type EmailBatchMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewEmailBatchMessage(template string) (*EmailBatchMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[EmailBatchMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewEmailBatchMessageWithGUID(uid.String(), template)
+}
+
+func NewEmailBatchMessageWithGUID(guid string, template string) (*EmailBatchMessage, error) {
+	m := &EmailBatchMessage{}
+	err := InitEmailBatchMessage(m, guid, template)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitEmailBatchMessage(m *EmailBatchMessage, guid string, template string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "email-batch")
+	if err != nil {
+		return err
+	}
+
+	return m.SetTemplate(template)
+}
+
+func (m *EmailBatchMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Template() != "") && (m.RecipientCount() > 0)
+}
+
+// Validate Check Message and Shared Context (against Registered Template Manifest)
+func (m *EmailBatchMessage) Validate() error {
+	switch {
+	case !m.ActionMessage.IsValid():
+		return invalidMessage("EmailBatchMessage", "not initialized")
+	case m.Template() == "":
+		return invalidField("EmailBatchMessage", "template", "required")
+	case m.RecipientCount() == 0:
+		return invalidField("EmailBatchMessage", "recipients", "required")
+	}
+
+	ctx := m.Context()
+	if ctx == nil {
+		ctx = map[string]interface{}{}
+	}
+	return ValidateTemplateContext("EmailBatchMessage", m.Template(), ctx)
+}
+
+func (m *EmailBatchMessage) Template() string {
+	p := m.Params()
+	if p != nil {
+		t, e := p.GetDefault("template", "")
+		if e == nil {
+			return t.(string)
+		}
+	}
+
+	return ""
+}
+
+func (m *EmailBatchMessage) SetTemplate(t string) error {
+	// Is Template Name Empty?
+	t = strings.TrimSpace(t)
+	if t == "" {
+		return invalidField("EmailBatchMessage", "template", "required")
+	}
+
+	return m.SetParameter("template", strings.ToLower(t))
+}
+
+// Locales Locales with Recipients (Sorted)
+func (m *EmailBatchMessage) Locales() []string {
+	locales := []string{}
+
+	p := m.Params()
+	if p != nil {
+		r, e := p.Get("recipients")
+		if e == nil && r != nil {
+			for l := range r.(map[string]interface{}) {
+				locales = append(locales, l)
+			}
+		}
+	}
+
+	sort.Strings(locales)
+	return locales
+}
+
+// Recipients Addresses for Locale
+func (m *EmailBatchMessage) Recipients(locale string) []string {
+	p := m.Params()
+	if p != nil {
+		r, e := p.Get("recipients." + strings.ToLower(strings.TrimSpace(locale)))
+		if e == nil && r != nil {
+			return toStringList(r)
+		}
+	}
+
+	return nil
+}
+
+// AddRecipients Add Addresses to Locale's Recipient List
+func (m *EmailBatchMessage) AddRecipients(locale string, to ...string) error {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if locale == "" {
+		return invalidField("EmailBatchMessage", "recipients", "locale required")
+	}
+
+	list := m.Recipients(locale)
+	for _, a := range to {
+		// Is Address Empty?
+		a = strings.ToLower(strings.TrimSpace(a))
+		if a == "" { // YES
+			return invalidField("EmailBatchMessage", "recipients", "empty address for locale ["+locale+"]")
+		}
+		list = append(list, a)
+	}
+
+	return m.SetParameter("recipients."+locale, list)
+}
+
+// ClearRecipients Remove Locale's Recipient List
+func (m *EmailBatchMessage) ClearRecipients(locale string) error {
+	p := m.Params()
+	if p != nil {
+		return p.Clear("recipients." + strings.ToLower(strings.TrimSpace(locale)))
+	}
+
+	return nil
+}
+
+// RecipientCount Total Recipients across all Locales
+func (m *EmailBatchMessage) RecipientCount() int {
+	n := 0
+	for _, l := range m.Locales() {
+		n += len(m.Recipients(l))
+	}
+	return n
+}
+
+// Context Template Context Shared by all Recipients
+func (m *EmailBatchMessage) Context() map[string]interface{} {
+	p := m.Props()
+	if p != nil {
+		return p.Map()
+	}
+
+	return nil
+}
+
+// SetContextValue Set Shared Template Variable
+func (m *EmailBatchMessage) SetContextValue(name string, v interface{}) error {
+	return m.SetProperty(name, v)
+}
+
+// Expand Individual Email Messages (One per Recipient, Parented to the Batch)
+func (m *EmailBatchMessage) Expand() ([]*EmailMessage, error) {
+	emails := make([]*EmailMessage, 0, m.RecipientCount())
+	for _, l := range m.Locales() {
+		for _, to := range m.Recipients(l) {
+			e, err := NewEmailMessage("", m.Template())
+			if err != nil {
+				return nil, err
+			}
+
+			e.QueueMessage.Header().SetParent(m.Header().ID())
+			if err = e.SetTo(to); err != nil {
+				return nil, err
+			}
+			if err = e.SetLocale(l); err != nil {
+				return nil, err
+			}
+			for k, v := range m.Context() {
+				if err = e.SetProperty(k, v); err != nil {
+					return nil, err
+				}
+			}
+
+			emails = append(emails, e)
+		}
+	}
+
+	return emails, nil
+}
+
+func toStringList(v interface{}) []string {
+	switch l := v.(type) {
+	case []string:
+		return append([]string(nil), l...)
+	case []interface{}:
+		s := make([]string, 0, len(l))
+		for _, e := range l {
+			if str, ok := e.(string); ok {
+				s = append(s, str)
+			}
+		}
+		return s
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"time"
+)
+
+// Wire Structures used while Marshaling (Pooled to Reduce per Message Allocations)
+
+type envelopeJSON struct {
+	Header  interface{} `json:"header"`
+	Message interface{} `json:"body"`
+}
+
+type headerJSON struct {
+	Version int            `json:"version"`
+	ID      string         `json:"id"`
+	Parent  string         `json:"parent,omitempty"`
+	Props   interface{}    `json:"props,omitempty"`
+	Status  interface{}    `json:"status,omitempty"`
+	History []HistoryEntry `json:"history,omitempty"`
+	Tracing *Tracing       `json:"tracing,omitempty"`
+	Created time.Time      `json:"created"`
+}
+
+type statusJSON struct {
+	ErrorCode        int         `json:"error_code"`
+	ErrorMessage     string      `json:"error_message,omitempty"`
+	ErrorMessageI18N string      `json:"error_message_i18n,omitempty"`
+	Extras           interface{} `json:"extras,omitempty"`
+	Attempt          int         `json:"attempt,omitempty"`
+	StartedAt        *time.Time  `json:"started_at,omitempty"`
+	FinishedAt       *time.Time  `json:"finished_at,omitempty"`
+	DurationMS       int64       `json:"duration_ms,omitempty"` // Derived (Ignored on Decode)
+}
+
+type actionContentJSON struct {
+	Type   string      `json:"type"`
+	Params interface{} `json:"params,omitempty"`
+	Props  interface{} `json:"props,omitempty"`
+}
+
+var envelopePool = sync.Pool{New: func() interface{} { return &envelopeJSON{} }}
+var headerPool = sync.Pool{New: func() interface{} { return &headerJSON{} }}
+var statusPool = sync.Pool{New: func() interface{} { return &statusJSON{} }}
+var actionContentPool = sync.Pool{New: func() interface{} { return &actionContentJSON{} }}
+
+func getEnvelopeJSON() *envelopeJSON {
+	return envelopePool.Get().(*envelopeJSON)
+}
+
+func putEnvelopeJSON(j *envelopeJSON) {
+	*j = envelopeJSON{}
+	envelopePool.Put(j)
+}
+
+func getHeaderJSON() *headerJSON {
+	return headerPool.Get().(*headerJSON)
+}
+
+func putHeaderJSON(j *headerJSON) {
+	*j = headerJSON{}
+	headerPool.Put(j)
+}
+
+func getStatusJSON() *statusJSON {
+	return statusPool.Get().(*statusJSON)
+}
+
+func putStatusJSON(j *statusJSON) {
+	*j = statusJSON{}
+	statusPool.Put(j)
+}
+
+func getActionContentJSON() *actionContentJSON {
+	return actionContentPool.Get().(*actionContentJSON)
+}
+
+func putActionContentJSON(j *actionContentJSON) {
+	*j = actionContentJSON{}
+	actionContentPool.Put(j)
+}
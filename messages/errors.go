@@ -0,0 +1,67 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Failure Classes (use errors.Is)
+var (
+	ErrNotInitialized     = errors.New("[messages] Initialize Message before using")
+	ErrUnsupportedVersion = errors.New("[messages] Unsupported Message Version")
+	ErrMessageTooLarge    = errors.New("[messages] Message Exceeds Size Limit")
+	ErrCommandNotAllowed  = errors.New("[AdminAllowList] Command not Allowed")
+	ErrCommandExpired     = errors.New("[AdminAllowList] Command Expired")
+	ErrUnknownMessageType = errors.New("[messages] Unknown Message Type")
+)
+
+// ErrInvalidMessage Message (or one of its Fields) Failed Validation (use errors.As)
+type ErrInvalidMessage struct {
+	Message string // Message Type (i.e. "EmailMessage")
+	Field   string // [OPTIONAL] Offending Field ("" if the Message as a Whole)
+	Reason  string // [OPTIONAL] Description of Problem
+}
+
+func (e *ErrInvalidMessage) Error() string {
+	var s string
+	if e.Field == "" {
+		s = fmt.Sprintf("[%s] Is not valid", e.Message)
+	} else {
+		s = fmt.Sprintf("[%s] Invalid Field [%s]", e.Message, e.Field)
+	}
+
+	if e.Reason != "" {
+		s += ": " + e.Reason
+	}
+
+	return s
+}
+
+// Is Matches any ErrInvalidMessage whose Set Fields (Message, Field) are Equal,
+// so errors.Is(err, &ErrInvalidMessage{Field: "to"}) Tests for a Specific Field
+func (e *ErrInvalidMessage) Is(target error) bool {
+	t, ok := target.(*ErrInvalidMessage)
+	if !ok {
+		return false
+	}
+
+	return (t.Message == "" || t.Message == e.Message) && (t.Field == "" || t.Field == e.Field)
+}
+
+func invalidMessage(message string, reason string) error {
+	return &ErrInvalidMessage{Message: message, Reason: reason}
+}
+
+func invalidField(message string, field string, reason string) error {
+	return &ErrInvalidMessage{Message: message, Field: field, Reason: reason}
+}
@@ -0,0 +1,125 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultHistoryLimit Entries Kept by Stamp (Oldest Entries are Dropped First)
+const DefaultHistoryLimit = 16
+
+// HistoryEntry Record of a Processor Handling the Message
+type HistoryEntry struct {
+	Node    string    `json:"node"`           // [REQUIRED] Processing Service / Host
+	At      time.Time `json:"at"`             // [REQUIRED] When Message was Handled
+	Outcome string    `json:"outcome"`        // [REQUIRED] i.e. queue.OutcomeAcked
+	Note    string    `json:"note,omitempty"` // [OPTIONAL] Free Text (i.e. Error Message)
+}
+
+// History Processing Trail (Oldest First)
+func (o *QueueMessageHeader) History() []HistoryEntry {
+	// Do we have a History?
+	if len(o.history) == 0 { // NO
+		return nil
+	}
+
+	l := make([]HistoryEntry, len(o.history))
+	copy(l, o.history)
+	return l
+}
+
+// LastHistory Most Recent History Entry (nil if None)
+func (o *QueueMessageHeader) LastHistory() *HistoryEntry {
+	if len(o.history) == 0 {
+		return nil
+	}
+
+	e := o.history[len(o.history)-1]
+	return &e
+}
+
+// Stamp Append Entry, for 'now', to History (Bounded to DefaultHistoryLimit)
+func (o *QueueMessageHeader) Stamp(node string, outcome string, note string) error {
+	return o.AppendHistory(HistoryEntry{
+		Node:    node,
+		At:      time.Now(),
+		Outcome: outcome,
+		Note:    note,
+	}, DefaultHistoryLimit)
+}
+
+// AppendHistory Append Entry to History Keeping, at Most, 'limit' Entries (<= 0: DefaultHistoryLimit)
+func (o *QueueMessageHeader) AppendHistory(e HistoryEntry, limit int) error {
+	e, err := normalizeHistoryEntry(e)
+	if err != nil {
+		return err
+	}
+
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+
+	o.history = append(o.history, e)
+
+	// Is History Over the Limit?
+	if n := len(o.history); n > limit { // YES: Drop Oldest Entries
+		l := make([]HistoryEntry, limit)
+		copy(l, o.history[n-limit:])
+		o.history = l
+	}
+	return nil
+}
+
+// ClearHistory Remove Processing Trail
+func (o *QueueMessageHeader) ClearHistory() {
+	o.history = nil
+}
+
+func normalizeHistoryEntry(e HistoryEntry) (HistoryEntry, error) {
+	e.Node = strings.TrimSpace(e.Node)
+	e.Outcome = strings.ToLower(strings.TrimSpace(e.Outcome))
+	e.Note = strings.TrimSpace(e.Note)
+
+	// Do we have a Node and Outcome?
+	if e.Node == "" { // NO
+		return e, invalidField("QueueMessageHeader", "history.node", "required")
+	}
+	if e.Outcome == "" { // NO
+		return e, invalidField("QueueMessageHeader", "history.outcome", "required")
+	}
+
+	// Is Timestamp Set?
+	if e.At.IsZero() { // NO
+		return e, invalidField("QueueMessageHeader", "history.at", "required")
+	}
+
+	e.At = e.At.UTC()
+	return e, nil
+}
+
+// appendJSON Encode Entry into dst (same output as encoding/json)
+func (e *HistoryEntry) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"node":`...)
+	dst = appendJSONString(dst, e.Node)
+	dst = append(dst, `,"at":`...)
+	dst = appendJSONTime(dst, e.At)
+	dst = append(dst, `,"outcome":`...)
+	dst = appendJSONString(dst, e.Outcome)
+
+	if e.Note != "" {
+		dst = append(dst, `,"note":`...)
+		dst = appendJSONString(dst, e.Note)
+	}
+
+	return append(dst, '}')
+}
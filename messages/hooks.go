@@ -0,0 +1,137 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+)
+
+// HookStage Point in a Message's Life where Hooks Run
+type HookStage int
+
+const (
+	HookBeforeMarshal  HookStage = iota // Before Encoding to JSON
+	HookAfterUnmarshal                  // After Decoding from JSON (before Type Checks)
+	HookBeforePublish                   // Before Publishing (queue Package, before Encoding)
+	HookAfterConsume                    // After Decoding a Delivery (queue Package, before Validation)
+	hookStages
+)
+
+func (s HookStage) String() string {
+	switch s {
+	case HookBeforeMarshal:
+		return "before-marshal"
+	case HookAfterUnmarshal:
+		return "after-unmarshal"
+	case HookBeforePublish:
+		return "before-publish"
+	case HookAfterConsume:
+		return "after-consume"
+	}
+	return fmt.Sprintf("stage-%d", int(s))
+}
+
+// Hook Cross Cutting Mutation (i.e. Stamp Tenant ID, Normalize Locale, Scrub Fields)
+//
+// Hooks see the Envelope Shared by every Message Type: Header Properties are
+// Available through Header(), Action Parameters through ActionContent().
+// An Error Aborts the Operation.
+type Hook func(stage HookStage, m *QueueMessage) error
+
+var hooks struct {
+	lock  sync.RWMutex
+	count int32 // Registered Hooks (Fast Path when None)
+	list  [hookStages][]Hook
+}
+
+// RegisterHook Run 'h' at 'stage' for every Message (Hooks Run in Registration Order)
+func RegisterHook(stage HookStage, h Hook) {
+	// Is Stage Known?
+	if stage < 0 || stage >= hookStages || h == nil { // NO
+		return
+	}
+
+	hooks.lock.Lock()
+	hooks.list[stage] = append(hooks.list[stage], h)
+	hooks.lock.Unlock()
+	atomic.AddInt32(&hooks.count, 1)
+}
+
+// ClearHooks Remove every Hook Registered for 'stage'
+func ClearHooks(stage HookStage) {
+	// Is Stage Known?
+	if stage < 0 || stage >= hookStages { // NO
+		return
+	}
+
+	hooks.lock.Lock()
+	atomic.AddInt32(&hooks.count, -int32(len(hooks.list[stage])))
+	hooks.list[stage] = nil
+	hooks.lock.Unlock()
+}
+
+// RunHooks Run Hooks Registered for 'stage' on Message (Messages without Envelope are Skipped)
+func RunHooks(stage HookStage, msg interface{}) error {
+	// Do we have Hooks?
+	if atomic.LoadInt32(&hooks.count) == 0 { // NO
+		return nil
+	}
+
+	// Does Message have an Envelope?
+	e, ok := msg.(interface{ envelope() *QueueMessage })
+	if !ok { // NO
+		return nil
+	}
+
+	return runHooks(stage, e.envelope())
+}
+
+func runHooks(stage HookStage, m *QueueMessage) error {
+	// Do we have Hooks?
+	if m == nil || atomic.LoadInt32(&hooks.count) == 0 { // NO
+		return nil
+	}
+
+	hooks.lock.RLock()
+	list := hooks.list[stage]
+	hooks.lock.RUnlock()
+
+	for _, h := range list {
+		if err := h(stage, m); err != nil {
+			return fmt.Errorf("[messages] Hook %s Failed: %w", stage, err)
+		}
+	}
+	return nil
+}
+
+// envelope Envelope Shared by every Message Type (Promoted to Derived Types)
+func (o *QueueMessage) envelope() *QueueMessage {
+	return o
+}
+
+// EnvelopeOf Queue Envelope of Message (nil if msg is not a Queue Message)
+//
+// NOTE: Reaches the Header of Types that Shadow Header() (i.e. EmailMessage).
+func EnvelopeOf(msg interface{}) *QueueMessage {
+	e, ok := msg.(interface{ envelope() *QueueMessage })
+	if !ok {
+		return nil
+	}
+	return e.envelope()
+}
+
+// ActionContent Action Content of Message (nil if not an Action)
+func (o *QueueMessage) ActionContent() *ActionMessageContent {
+	c, _ := o.body.(*ActionMessageContent)
+	return c
+}
@@ -0,0 +1,78 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import "time"
+
+// IMessage Accessors (Header Shortcuts Shared by all Queue Messages)
+
+func (o *QueueMessage) ID() string {
+	return o.Header().ID()
+}
+
+// Created Message Creation Time (nil if not Set)
+func (o *QueueMessage) Created() *time.Time {
+	h := o.Header()
+	if !h.HasCreated() {
+		return nil
+	}
+
+	t := h.Created()
+	return &t
+}
+
+// Requeue Count a Requeue, Returns New Requeue Count
+func (o *QueueMessage) Requeue() int {
+	h := o.Header()
+	h.SetRequeueCount(h.RequeueCount() + 1)
+	return h.RequeueCount()
+}
+
+func (o *QueueMessage) RequeueCount() int {
+	return o.Header().RequeueCount()
+}
+
+// ResetCount Clear Requeue Count, Returns Previous Count
+func (o *QueueMessage) ResetCount() int {
+	h := o.Header()
+	n := h.RequeueCount()
+	h.SetRequeueCount(0)
+	return n
+}
+
+func (o *QueueMessage) ErrorCode() int {
+	s := o.Header().Status()
+	if s == nil {
+		return 0
+	}
+	return s.ErrorCode()
+}
+
+func (o *QueueMessage) ErrorMessage() string {
+	s := o.Header().Status()
+	if s == nil {
+		return ""
+	}
+	return s.ErrorMessage()
+}
+
+// ErrorTime Time Processing Failed (Status Finish Time, nil if not in Error)
+func (o *QueueMessage) ErrorTime() *time.Time {
+	if !o.IsError() {
+		return nil
+	}
+	return o.Header().Status().FinishedAt()
+}
+
+func (o *QueueMessage) IsError() bool {
+	s := o.Header().Status()
+	return s != nil && s.InError()
+}
@@ -89,3 +89,17 @@ type IInviteEmailMessage interface {
 	Expiration() *time.Time
 	SetExpiration(t time.Time) error
 }
+
+type IPasswordResetMessage interface {
+	IEmailMessage
+
+	User() string
+	SetUser(user string) error
+	Code() string
+	SetCode(code string) error
+	Expiration() *time.Time
+	SetExpiration(t time.Time) error
+	Expired(now time.Time) bool
+	RequestIP() string
+	SetRequestIP(ip string) error
+}
@@ -12,12 +12,12 @@ package messages
 
 // cSpell:ignore gofrs, objectname, storename
 import (
-	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gofrs/uuid"
+	"github.com/objectvault/common/maps"
 	"github.com/objectvault/queue-interface/shared"
 )
 
@@ -49,7 +49,7 @@ func NewInviteMessage(ot string, code string) (*InviteMessage, error) {
 func InitInviteMessage(m *InviteMessage, guid string, ot string, code string) error {
 	ot = strings.TrimSpace(ot)
 	if ot == "" {
-		return errors.New("[InviteMessage] Invitation Object Type Required")
+		return invalidField("InviteMessage", "type", "invitation object type required")
 	}
 
 	// Initialize Email Message
@@ -87,7 +87,7 @@ func (m *InviteMessage) SetCode(code string) error {
 	// Is Invitation Activation Code Empty?
 	code = strings.TrimSpace(code)
 	if code == "" {
-		return errors.New("[InviteMessage] Invitation Code is Required")
+		return invalidField("InviteMessage", "code", "required")
 	}
 
 	return m.SetProperty("code", strings.ToLower(code))
@@ -109,7 +109,7 @@ func (m *InviteMessage) SetByUser(name string) error {
 	// Is Name Empty?
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return errors.New("[InviteMessage] From User Name is Required")
+		return invalidField("InviteMessage", "by-name", "required")
 	}
 
 	return m.SetProperty("by-name", name)
@@ -131,7 +131,7 @@ func (m *InviteMessage) SetByEmail(email string) error {
 	// Is Email Empty?
 	email = strings.TrimSpace(email)
 	if email == "" {
-		return errors.New("[InviteMessage] From User Email is Required")
+		return invalidField("InviteMessage", "by-email", "required")
 	}
 
 	return m.SetProperty("by-email", strings.ToLower(email))
@@ -169,7 +169,7 @@ func (m *InviteMessage) SetObjectName(name string) error {
 	// Is Name Empty?
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return errors.New("[InviteMessage] Object Name is Required")
+		return invalidField("InviteMessage", "objectname", "required")
 	}
 
 	return m.SetProperty("objectname", name)
@@ -191,18 +191,27 @@ func (m *InviteMessage) SetStoreName(name string) error {
 	// Is Name Empty?
 	name = strings.TrimSpace(name)
 	if name == "" {
-		return errors.New("[InviteMessage] Object Name is Required")
+		return invalidField("InviteMessage", "storename", "required")
 	}
 
 	return m.SetProperty("storename", name)
 }
 
+// Expiration Invitation Expiration (nil if not Set or Invalid)
 func (m *InviteMessage) Expiration() *time.Time {
-	p := m.Params()
-	if p != nil {
+	// NOTE: SetExpiration Stores it as a Property, Older Messages as a Parameter
+	for _, p := range []*maps.MapWrapper{m.Props(), m.Params()} {
+		if p == nil {
+			continue
+		}
+
 		t, e := p.Get("expiration")
-		if e == nil || t != nil {
-			return shared.FromJSONTimeStamp(t.(string))
+		if e == nil && t != nil {
+			ts, err := shared.ParseTimeValue(t)
+			if err != nil {
+				return nil
+			}
+			return &ts
 		}
 	}
 
@@ -0,0 +1,140 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Invitation Decisions
+const (
+	InviteAccepted = "accepted"
+	InviteDeclined = "declined"
+)
+
+// InviteResponseMessage Invitee's Answer to an Invitation
+type InviteResponseMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewInviteResponseMessage(code string, decision string, byUser string) (*InviteResponseMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[InviteResponseMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewInviteResponseMessageWithGUID(uid.String(), code, decision, byUser)
+}
+
+func NewInviteResponseMessageWithGUID(guid string, code string, decision string, byUser string) (*InviteResponseMessage, error) {
+	m := &InviteResponseMessage{}
+	err := InitInviteResponseMessage(m, guid, code, decision, byUser)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitInviteResponseMessage(m *InviteResponseMessage, guid string, code string, decision string, byUser string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "invite:response")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetCode(code)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetDecision(decision)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetByUser(byUser)
+	if err != nil {
+		return err
+	}
+
+	return m.SetRespondedAt(time.Now())
+}
+
+func (m *InviteResponseMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Code() != "") && (m.Decision() != "") && (m.ByUser() != "") && (m.RespondedAt() != nil)
+}
+
+// Code Activation Code of Invitation Answered
+func (m *InviteResponseMessage) Code() string {
+	return m.stringParameter("code")
+}
+
+func (m *InviteResponseMessage) SetCode(code string) error {
+	// Is Invitation Code Empty?
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return invalidField("InviteResponseMessage", "code", "required")
+	}
+
+	return m.SetParameter("code", strings.ToLower(code))
+}
+
+// Decision InviteAccepted or InviteDeclined
+func (m *InviteResponseMessage) Decision() string {
+	return m.stringParameter("decision")
+}
+
+func (m *InviteResponseMessage) SetDecision(decision string) error {
+	// Is Decision Known?
+	decision = strings.ToLower(strings.TrimSpace(decision))
+	if decision != InviteAccepted && decision != InviteDeclined { // NO
+		return invalidField("InviteResponseMessage", "decision", "expected accepted or declined ["+decision+"]")
+	}
+
+	return m.SetParameter("decision", decision)
+}
+
+// Accepted Was Invitation Accepted?
+func (m *InviteResponseMessage) Accepted() bool {
+	return m.Decision() == InviteAccepted
+}
+
+// ByUser User that Answered the Invitation
+func (m *InviteResponseMessage) ByUser() string {
+	return m.stringParameter("by-user")
+}
+
+func (m *InviteResponseMessage) SetByUser(user string) error {
+	// Is User Empty?
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return invalidField("InviteResponseMessage", "by-user", "required")
+	}
+
+	return m.SetParameter("by-user", user)
+}
+
+// RespondedAt Time Invitation was Answered
+func (m *InviteResponseMessage) RespondedAt() *time.Time {
+	return m.timeParameter("responded-at")
+}
+
+func (m *InviteResponseMessage) SetRespondedAt(t time.Time) error {
+	return m.setTimeParameter("responded-at", t)
+}
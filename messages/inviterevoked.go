@@ -0,0 +1,118 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// InviteRevokedMessage Invitation Withdrawn before it was Answered
+type InviteRevokedMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewInviteRevokedMessage(code string, byUser string) (*InviteRevokedMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[InviteRevokedMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewInviteRevokedMessageWithGUID(uid.String(), code, byUser)
+}
+
+func NewInviteRevokedMessageWithGUID(guid string, code string, byUser string) (*InviteRevokedMessage, error) {
+	m := &InviteRevokedMessage{}
+	err := InitInviteRevokedMessage(m, guid, code, byUser)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitInviteRevokedMessage(m *InviteRevokedMessage, guid string, code string, byUser string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "invite:revoked")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetCode(code)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetByUser(byUser)
+	if err != nil {
+		return err
+	}
+
+	return m.SetRevokedAt(time.Now())
+}
+
+func (m *InviteRevokedMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Code() != "") && (m.ByUser() != "")
+}
+
+// Code Activation Code of Revoked Invitation
+func (m *InviteRevokedMessage) Code() string {
+	return m.stringParameter("code")
+}
+
+func (m *InviteRevokedMessage) SetCode(code string) error {
+	// Is Invitation Code Empty?
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return invalidField("InviteRevokedMessage", "code", "required")
+	}
+
+	return m.SetParameter("code", strings.ToLower(code))
+}
+
+// ByUser User that Revoked the Invitation
+func (m *InviteRevokedMessage) ByUser() string {
+	return m.stringParameter("by-user")
+}
+
+func (m *InviteRevokedMessage) SetByUser(user string) error {
+	// Is User Empty?
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return invalidField("InviteRevokedMessage", "by-user", "required")
+	}
+
+	return m.SetParameter("by-user", user)
+}
+
+// Reason [OPTIONAL] Explanation Shown to Invitee
+func (m *InviteRevokedMessage) Reason() string {
+	return m.stringParameter("reason")
+}
+
+func (m *InviteRevokedMessage) SetReason(reason string) error {
+	return m.SetStringParameter("reason", strings.TrimSpace(reason), true)
+}
+
+// RevokedAt Time Invitation was Revoked
+func (m *InviteRevokedMessage) RevokedAt() *time.Time {
+	return m.timeParameter("revoked-at")
+}
+
+func (m *InviteRevokedMessage) SetRevokedAt(t time.Time) error {
+	return m.setTimeParameter("revoked-at", t)
+}
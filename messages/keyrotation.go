@@ -0,0 +1,180 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// DefaultRotationBatchSize Objects Re-Encrypted per Batch when no Hint is Given
+const DefaultRotationBatchSize = 100
+
+// KeyRotationMessage Re-Encrypt Objects in Scope from Old Key to New Key
+//
+// Long Running: Worker Records a Checkpoint, so an Interrupted Rotation
+// can be Requeued and Resume where it Stopped.
+type KeyRotationMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+// NewKeyRotationMessage i.e. NewKeyRotationMessage("store:1234", "key-1", "key-2")
+func NewKeyRotationMessage(scope string, oldKey string, newKey string) (*KeyRotationMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[KeyRotationMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewKeyRotationMessageWithGUID(uid.String(), scope, oldKey, newKey)
+}
+
+func NewKeyRotationMessageWithGUID(guid string, scope string, oldKey string, newKey string) (*KeyRotationMessage, error) {
+	m := &KeyRotationMessage{}
+	err := InitKeyRotationMessage(m, guid, scope, oldKey, newKey)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitKeyRotationMessage(m *KeyRotationMessage, guid string, scope string, oldKey string, newKey string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "key:rotate")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetScope(scope)
+	if err != nil {
+		return err
+	}
+
+	return m.SetKeys(oldKey, newKey)
+}
+
+func (m *KeyRotationMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Scope() != "") && (m.OldKey() != "") && (m.NewKey() != "") && (m.OldKey() != m.NewKey())
+}
+
+// Scope Objects Affected by Rotation (i.e. "store:1234", "org:5678")
+func (m *KeyRotationMessage) Scope() string {
+	return m.stringParameter("scope")
+}
+
+func (m *KeyRotationMessage) SetScope(scope string) error {
+	// Is Scope Empty?
+	scope = strings.TrimSpace(scope)
+	if scope == "" {
+		return invalidField("KeyRotationMessage", "scope", "required")
+	}
+
+	return m.SetParameter("scope", scope)
+}
+
+// OldKey Identifier of Key being Retired
+func (m *KeyRotationMessage) OldKey() string {
+	return m.stringParameter("keys.old")
+}
+
+// NewKey Identifier of Replacement Key
+func (m *KeyRotationMessage) NewKey() string {
+	return m.stringParameter("keys.new")
+}
+
+func (m *KeyRotationMessage) SetKeys(oldKey string, newKey string) error {
+	oldKey = strings.TrimSpace(oldKey)
+	newKey = strings.TrimSpace(newKey)
+
+	// Do we have Both Keys?
+	if oldKey == "" || newKey == "" { // NO
+		return invalidField("KeyRotationMessage", "keys", "old and new key required")
+	}
+
+	// Are the Keys Different?
+	if oldKey == newKey { // NO
+		return invalidField("KeyRotationMessage", "keys", "old and new key are the same ["+oldKey+"]")
+	}
+
+	err := m.SetParameter("keys.old", oldKey)
+	if err != nil {
+		return err
+	}
+	return m.SetParameter("keys.new", newKey)
+}
+
+// BatchSize Hint: Objects to Re-Encrypt per Batch (DEFAULT: DefaultRotationBatchSize)
+func (m *KeyRotationMessage) BatchSize() int {
+	n := m.intParameter("batch-size")
+	if n <= 0 {
+		return DefaultRotationBatchSize
+	}
+	return int(n)
+}
+
+// SetBatchSize Set Batch Size Hint (0: Use Default)
+func (m *KeyRotationMessage) SetBatchSize(n int) error {
+	// Is Batch Size Valid?
+	if n < 0 { // NO
+		return invalidField("KeyRotationMessage", "batch-size", "negative batch size")
+	}
+
+	p := m.Params()
+	if p == nil {
+		return ErrNotInitialized
+	}
+
+	if n == 0 {
+		return p.Clear("batch-size")
+	}
+	return p.Set("batch-size", n, true)
+}
+
+// Checkpoint Last Object Re-Encrypted ("": Rotation not Started)
+func (m *KeyRotationMessage) Checkpoint() string {
+	return m.stringParameter("progress.checkpoint")
+}
+
+// Processed Objects Re-Encrypted so Far
+func (m *KeyRotationMessage) Processed() int64 {
+	return m.intParameter("progress.processed")
+}
+
+// SetProgress Record Checkpoint (Last Object Re-Encrypted) and Running Count
+//
+// Republish the Message with the Progress so Rotation Resumes from Checkpoint.
+func (m *KeyRotationMessage) SetProgress(checkpoint string, processed int64) error {
+	// Is Processed Count Valid?
+	if processed < 0 { // NO
+		return invalidField("KeyRotationMessage", "progress", "negative processed count")
+	}
+
+	err := m.SetStringParameter("progress.checkpoint", strings.TrimSpace(checkpoint), true)
+	if err != nil {
+		return err
+	}
+	return m.SetParameter("progress.processed", processed)
+}
+
+// ClearProgress Restart Rotation from the Beginning
+func (m *KeyRotationMessage) ClearProgress() error {
+	p := m.Params()
+	if p == nil {
+		return ErrNotInitialized
+	}
+
+	return p.Clear("progress")
+}
@@ -0,0 +1,236 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Maintenance Severities (Increasing Impact)
+const (
+	SeverityInfo     = "info"      // Banner Only
+	SeverityDegraded = "degraded"  // Banner, Service may be Slow
+	SeverityReadOnly = "read-only" // Nodes Reject Writes
+	SeverityOutage   = "outage"    // Service Unavailable
+)
+
+var severityLevels = map[string]int{
+	SeverityInfo:     1,
+	SeverityDegraded: 2,
+	SeverityReadOnly: 3,
+	SeverityOutage:   4,
+}
+
+// MaintenanceMessage Announce Maintenance Window to every Node (Broadcast)
+type MaintenanceMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewMaintenanceMessage(start time.Time, end time.Time, severity string) (*MaintenanceMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[MaintenanceMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewMaintenanceMessageWithGUID(uid.String(), start, end, severity)
+}
+
+func NewMaintenanceMessageWithGUID(guid string, start time.Time, end time.Time, severity string) (*MaintenanceMessage, error) {
+	m := &MaintenanceMessage{}
+	err := InitMaintenanceMessage(m, guid, start, end, severity)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitMaintenanceMessage(m *MaintenanceMessage, guid string, start time.Time, end time.Time, severity string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "maintenance")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetWindow(start, end)
+	if err != nil {
+		return err
+	}
+
+	return m.SetSeverity(severity)
+}
+
+func (m *MaintenanceMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Start() != nil) && (m.End() != nil) && (severityLevels[m.Severity()] > 0) && (m.TextKey() != "")
+}
+
+// Start Start of Maintenance Window
+func (m *MaintenanceMessage) Start() *time.Time {
+	return m.timeParameter("window.start")
+}
+
+// End End of Maintenance Window
+func (m *MaintenanceMessage) End() *time.Time {
+	return m.timeParameter("window.end")
+}
+
+func (m *MaintenanceMessage) SetWindow(start time.Time, end time.Time) error {
+	// Is Window Valid?
+	if start.IsZero() || !end.After(start) { // NO
+		return invalidField("MaintenanceMessage", "window", "end must be after start")
+	}
+
+	err := m.setTimeParameter("window.start", start)
+	if err != nil {
+		return err
+	}
+	return m.setTimeParameter("window.end", end)
+}
+
+// Active Is 't' within the Maintenance Window?
+func (m *MaintenanceMessage) Active(t time.Time) bool {
+	s, e := m.Start(), m.End()
+	if s == nil || e == nil {
+		return false
+	}
+
+	return !t.Before(*s) && t.Before(*e)
+}
+
+// Severity Impact of Maintenance (i.e. SeverityReadOnly)
+func (m *MaintenanceMessage) Severity() string {
+	return m.stringParameter("severity")
+}
+
+func (m *MaintenanceMessage) SetSeverity(severity string) error {
+	// Is Severity Known?
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if severityLevels[severity] == 0 { // NO
+		return invalidField("MaintenanceMessage", "severity", "invalid severity ["+severity+"]")
+	}
+
+	return m.SetParameter("severity", severity)
+}
+
+// ReadOnly Should Nodes Reject Writes during the Window?
+func (m *MaintenanceMessage) ReadOnly() bool {
+	return severityLevels[m.Severity()] >= severityLevels[SeverityReadOnly]
+}
+
+// Components Affected Components (Empty: Whole System)
+func (m *MaintenanceMessage) Components() []string {
+	p := m.Params()
+	if p != nil {
+		c, e := p.Get("components")
+		if e == nil && c != nil {
+			return toStringList(c)
+		}
+	}
+
+	return nil
+}
+
+// Affects Is 'component' Affected by Maintenance?
+func (m *MaintenanceMessage) Affects(component string) bool {
+	l := m.Components()
+	if len(l) == 0 {
+		return true
+	}
+
+	component = strings.ToLower(strings.TrimSpace(component))
+	for _, c := range l {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// SetComponents Set Affected Components (None: Whole System)
+func (m *MaintenanceMessage) SetComponents(components ...string) error {
+	p := m.Params()
+	if p == nil {
+		return ErrNotInitialized
+	}
+
+	list := []string{}
+	for _, c := range components {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if c != "" {
+			list = append(list, c)
+		}
+	}
+
+	if len(list) == 0 {
+		return p.Clear("components")
+	}
+	return p.Set("components", list, true)
+}
+
+// TextKey I18N Key of User Facing Banner Text
+func (m *MaintenanceMessage) TextKey() string {
+	return m.stringParameter("text.key")
+}
+
+// Text Default (Untranslated) Banner Text
+func (m *MaintenanceMessage) Text() string {
+	return m.stringParameter("text.default")
+}
+
+// TextParams Values for I18N Text Placeholders
+func (m *MaintenanceMessage) TextParams() map[string]interface{} {
+	p := m.Params()
+	if p != nil {
+		v, e := p.Get("text.params")
+		if e == nil {
+			if params, ok := v.(map[string]interface{}); ok {
+				return params
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetText Set Banner I18N Key, Default Text and Placeholder Values (nil: None)
+func (m *MaintenanceMessage) SetText(key string, fallback string, params map[string]interface{}) error {
+	// Is Key Empty?
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return invalidField("MaintenanceMessage", "text", "i18n key required")
+	}
+
+	p := m.Params()
+	if p == nil {
+		return ErrNotInitialized
+	}
+
+	err := p.Clear("text")
+	if err != nil {
+		return err
+	}
+
+	err = p.Set("text.key", key, true)
+	if err == nil {
+		err = m.SetStringParameter("text.default", strings.TrimSpace(fallback), true)
+	}
+	if err == nil && len(params) > 0 {
+		err = p.Set("text.params", params, true)
+	}
+	return err
+}
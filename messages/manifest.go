@@ -0,0 +1,188 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// Template Variable Types
+const (
+	VarAny    = "any"
+	VarString = "string"
+	VarNumber = "number"
+	VarBool   = "bool"
+	VarTime   = "time" // RFC 3339 String
+	VarList   = "list"
+	VarMap    = "map"
+)
+
+// TemplateManifest Variables an Email Template Expects in its Context
+type TemplateManifest struct {
+	Template string            `json:"template" yaml:"template" toml:"template"`                               // Template Name
+	Required map[string]string `json:"required,omitempty" yaml:"required,omitempty" toml:"required,omitempty"` // Variable -> Type (Must be Present)
+	Optional map[string]string `json:"optional,omitempty" yaml:"optional,omitempty" toml:"optional,omitempty"` // Variable -> Type (Checked if Present)
+}
+
+// Manifest Registry (Template Name -> Manifest)
+var (
+	manifestLock sync.RWMutex
+	manifests    = map[string]*TemplateManifest{}
+)
+
+// RegisterTemplateManifest Add Manifest to Registry (Replaces Manifest for Same Template)
+func RegisterTemplateManifest(m *TemplateManifest) error {
+	// Is Manifest Valid?
+	name := strings.ToLower(strings.TrimSpace(m.Template))
+	if name == "" { // NO
+		return invalidField("TemplateManifest", "template", "required")
+	}
+
+	for v, t := range m.Required {
+		if !validVarType(t) {
+			return invalidField("TemplateManifest", v, "unknown type ["+t+"]")
+		}
+	}
+	for v, t := range m.Optional {
+		if !validVarType(t) {
+			return invalidField("TemplateManifest", v, "unknown type ["+t+"]")
+		}
+	}
+
+	manifestLock.Lock()
+	defer manifestLock.Unlock()
+	manifests[name] = m
+	return nil
+}
+
+// LookupTemplateManifest Find Manifest for Template (nil if None Registered)
+func LookupTemplateManifest(template string) *TemplateManifest {
+	manifestLock.RLock()
+	defer manifestLock.RUnlock()
+	return manifests[strings.ToLower(strings.TrimSpace(template))]
+}
+
+// ValidateTemplateContext Check Context against Template's Manifest (nil if no Manifest Registered)
+func ValidateTemplateContext(source string, template string, ctx map[string]interface{}) error {
+	m := LookupTemplateManifest(template)
+	if m == nil {
+		return nil
+	}
+	return m.Validate(source, ctx)
+}
+
+// Validate Check Context has Required Variables and Variables have Expected Types
+//
+// Returns nil or *ValidationError Listing every Offending Variable.
+func (m *TemplateManifest) Validate(source string, ctx map[string]interface{}) error {
+	return validationError(source, m.violations(ctx))
+}
+
+// violations Offending Variables (in Stable Order)
+func (m *TemplateManifest) violations(ctx map[string]interface{}) []Violation {
+	l := []Violation{}
+	for _, name := range sortedKeys(m.Required) {
+		v, ok := lookupVar(ctx, name)
+		if !ok {
+			l = append(l, Violation{Field: name, Code: ViolationRequired, Message: "required by template [" + m.Template + "]"})
+		} else if !isVarType(v, m.Required[name]) {
+			l = append(l, Violation{Field: name, Code: ViolationType, Message: fmt.Sprintf("expected %s found %T", m.Required[name], v)})
+		}
+	}
+
+	for _, name := range sortedKeys(m.Optional) {
+		v, ok := lookupVar(ctx, name)
+		if ok && !isVarType(v, m.Optional[name]) {
+			l = append(l, Violation{Field: name, Code: ViolationType, Message: fmt.Sprintf("expected %s found %T", m.Optional[name], v)})
+		}
+	}
+
+	return l
+}
+
+// lookupVar Find Variable by Dotted Path (i.e. "user.name")
+func lookupVar(ctx map[string]interface{}, path string) (interface{}, bool) {
+	parts := strings.Split(path, ".")
+	var v interface{} = ctx
+	for _, p := range parts {
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+
+		v, ok = m[p]
+		if !ok {
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+func isVarType(v interface{}, t string) bool {
+	switch t {
+	case VarAny:
+		return v != nil
+	case VarString:
+		_, ok := v.(string)
+		return ok
+	case VarNumber:
+		switch v.(type) {
+		case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64, interface{ Float64() (float64, error) }:
+			return true
+		}
+		return false
+	case VarBool:
+		_, ok := v.(bool)
+		return ok
+	case VarTime:
+		switch tv := v.(type) {
+		case time.Time, *time.Time:
+			return true
+		case string:
+			_, err := shared.ParseTimeStamp(tv)
+			return err == nil
+		}
+		return false
+	case VarList:
+		switch v.(type) {
+		case []interface{}, []string:
+			return true
+		}
+		return false
+	case VarMap:
+		_, ok := v.(map[string]interface{})
+		return ok
+	}
+	return false
+}
+
+func validVarType(t string) bool {
+	switch t {
+	case VarAny, VarString, VarNumber, VarBool, VarTime, VarList, VarMap:
+		return true
+	}
+	return false
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
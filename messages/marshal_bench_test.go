@@ -0,0 +1,74 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/objectvault/queue-interface/fixtures"
+)
+
+// benchMessage Fixture Decoded into its Registered Type
+func benchMessage(b *testing.B, name string) IMessage {
+	b.Helper()
+
+	m, err := Decode(fixtures.MustLoad("v1", name))
+	if err != nil {
+		b.Fatal(err)
+	}
+	return m
+}
+
+func benchmarkMarshal(b *testing.B, v interface{}) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := json.Marshal(v); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkAppendJSON(b *testing.B, v appender) {
+	buf := make([]byte, 0, 4096)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		var err error
+		if buf, err = v.AppendJSON(buf[:0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkMarshalQueueMessage(b *testing.B) {
+	benchmarkMarshal(b, NewQueueMessage("5d3f1e0a-8c7b-4c1e-9a55-0f7b2c9e4a10", map[string]interface{}{"text": "plain queue message"}))
+}
+
+func BenchmarkMarshalActionMessage(b *testing.B) {
+	benchmarkMarshal(b, benchMessage(b, "action-message"))
+}
+
+func BenchmarkMarshalEmailMessage(b *testing.B) {
+	benchmarkMarshal(b, benchMessage(b, "email-message"))
+}
+
+func BenchmarkMarshalInviteMessage(b *testing.B) {
+	benchmarkMarshal(b, benchMessage(b, "invite-message"))
+}
+
+func BenchmarkMarshalActionMessageAppendJSON(b *testing.B) {
+	benchmarkAppendJSON(b, benchMessage(b, "action-message").(appender))
+}
+
+func BenchmarkMarshalEmailMessageAppendJSON(b *testing.B) {
+	benchmarkAppendJSON(b, benchMessage(b, "email-message").(appender))
+}
@@ -0,0 +1,149 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+)
+
+// Password Reset Action Type and Default Template
+const (
+	PasswordResetType     = "action:email:password-reset"
+	PasswordResetTemplate = "password-reset"
+)
+
+// PasswordResetMessage Email Carrying a Password Reset Code to a User
+type PasswordResetMessage struct {
+	EmailMessage // DERIVED FROM
+}
+
+// NewPasswordResetMessage i.e. NewPasswordResetMessage("user-id", code, time.Now().Add(time.Hour))
+func NewPasswordResetMessage(user string, code string, expires time.Time) (*PasswordResetMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[PasswordResetMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewPasswordResetMessageWithGUID(uid.String(), user, code, expires)
+}
+
+func NewPasswordResetMessageWithGUID(guid string, user string, code string, expires time.Time) (*PasswordResetMessage, error) {
+	m := &PasswordResetMessage{}
+	err := InitPasswordResetMessage(m, guid, user, code, expires)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitPasswordResetMessage(m *PasswordResetMessage, guid string, user string, code string, expires time.Time) error {
+	// Initialize Email Message (Template can be Replaced with SetTemplate)
+	err := InitEmailMessage(&(m.EmailMessage), guid, "password-reset", PasswordResetTemplate)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetUser(user)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetCode(code)
+	if err != nil {
+		return err
+	}
+
+	return m.SetExpiration(expires)
+}
+
+func (m *PasswordResetMessage) IsValid() bool {
+	return m.EmailMessage.IsValid() && (m.User() != "") && (m.Code() != "") && (m.Expiration() != nil)
+}
+
+// User Account whose Password is Reset
+func (m *PasswordResetMessage) User() string {
+	return m.stringParameter("user")
+}
+
+func (m *PasswordResetMessage) SetUser(user string) error {
+	// Is User Empty?
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return invalidField("PasswordResetMessage", "user", "required")
+	}
+
+	return m.SetParameter("user", user)
+}
+
+// Code Reset Code / Token (Case Sensitive)
+func (m *PasswordResetMessage) Code() string {
+	return m.stringParameter("code")
+}
+
+func (m *PasswordResetMessage) SetCode(code string) error {
+	// Is Reset Code Empty?
+	code = strings.TrimSpace(code)
+	if code == "" {
+		return invalidField("PasswordResetMessage", "code", "required")
+	}
+
+	return m.SetParameter("code", code)
+}
+
+// Expiration Time Reset Code Stops Working (nil if not Set or Invalid)
+func (m *PasswordResetMessage) Expiration() *time.Time {
+	return m.timeParameter("expiration")
+}
+
+func (m *PasswordResetMessage) SetExpiration(t time.Time) error {
+	// Do we have an Expiration?
+	if t.IsZero() { // NO
+		return invalidField("PasswordResetMessage", "expiration", "required")
+	}
+
+	return m.setTimeParameter("expiration", t)
+}
+
+// Expired Has Reset Code Expired at 'now'? (No Expiration: Expired)
+func (m *PasswordResetMessage) Expired(now time.Time) bool {
+	e := m.Expiration()
+	return e == nil || !now.Before(*e)
+}
+
+// RequestIP Address the Reset was Requested From ("" if Unknown)
+func (m *PasswordResetMessage) RequestIP() string {
+	return m.stringParameter("request-ip")
+}
+
+// SetRequestIP Set Requesting Address ("": Clear)
+func (m *PasswordResetMessage) SetRequestIP(ip string) error {
+	ip = strings.TrimSpace(ip)
+
+	// Is Address Valid?
+	if ip != "" {
+		parsed := net.ParseIP(ip)
+		if parsed == nil { // NO
+			return invalidField("PasswordResetMessage", "request-ip", "invalid address ["+ip+"]")
+		}
+		ip = parsed.String()
+	}
+
+	return m.SetStringParameter("request-ip", ip, true)
+}
@@ -0,0 +1,109 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+)
+
+// Personalization Recipient with Template Variable Overrides
+type Personalization struct {
+	To   string                 `json:"to"`             // Recipient Address
+	Vars map[string]interface{} `json:"vars,omitempty"` // [OPTIONAL] Overrides of Shared Template Context
+}
+
+// HasPersonalizations Does Message Carry Per Recipient Personalizations?
+func (m *EmailMessage) HasPersonalizations() bool {
+	p := m.Params()
+	if p != nil {
+		l, e := p.Get("personalizations")
+		if e == nil && l != nil {
+			v, ok := l.([]interface{})
+			return ok && len(v) > 0
+		}
+	}
+
+	return false
+}
+
+// Personalizations Per Recipient Overrides (in Order Added)
+func (m *EmailMessage) Personalizations() []Personalization {
+	p := m.Params()
+	if p == nil {
+		return nil
+	}
+
+	l, e := p.Get("personalizations")
+	if e != nil || l == nil {
+		return nil
+	}
+
+	v, ok := l.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	list := make([]Personalization, 0, len(v))
+	for _, i := range v {
+		entry, ok := i.(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		to, _ := entry["to"].(string)
+		vars, _ := entry["vars"].(map[string]interface{})
+		list = append(list, Personalization{To: to, Vars: vars})
+	}
+	return list
+}
+
+// AddPersonalization Add Recipient with Variable Overrides (vars can be nil)
+func (m *EmailMessage) AddPersonalization(to string, vars map[string]interface{}) error {
+	// Is Recipient Empty?
+	to = strings.ToLower(strings.TrimSpace(to))
+	if to == "" { // YES
+		return invalidField("EmailMessage", "personalizations", "recipient required")
+	}
+
+	// NOTE: Stored as Generic Values (Same Shape as Decoded JSON)
+	list := []interface{}{}
+	for _, e := range append(m.Personalizations(), Personalization{To: to, Vars: vars}) {
+		entry := map[string]interface{}{"to": e.To}
+		if len(e.Vars) > 0 {
+			entry["vars"] = e.Vars
+		}
+		list = append(list, entry)
+	}
+	return m.SetParameter("personalizations", list)
+}
+
+// ClearPersonalizations Remove all Personalizations
+func (m *EmailMessage) ClearPersonalizations() error {
+	p := m.Params()
+	if p != nil {
+		return p.Clear("personalizations")
+	}
+
+	return nil
+}
+
+// PersonalizedContext Template Context for Recipient (Shared Context with Overrides Applied)
+func (m *EmailMessage) PersonalizedContext(p Personalization) map[string]interface{} {
+	shared := m.TemplateContext()
+	ctx := make(map[string]interface{}, len(shared)+len(p.Vars))
+	for k, v := range shared {
+		ctx[k] = v
+	}
+	for k, v := range p.Vars {
+		ctx[k] = v
+	}
+	return ctx
+}
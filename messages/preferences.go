@@ -0,0 +1,172 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// Notification Channels
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+	ChannelPush  = "push"
+	ChannelInApp = "in-app"
+)
+
+// Digest Frequencies
+const (
+	DigestImmediate = "immediate" // Send Each Notification
+	DigestDaily     = "daily"
+	DigestWeekly    = "weekly"
+	DigestNever     = "never" // No Digest (Notifications Suppressed)
+)
+
+// PreferenceUpdateMessage User Changed Notification Preferences
+//
+// Only Channels Present in the Message Changed, others Keep their Setting.
+type PreferenceUpdateMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+func NewPreferenceUpdateMessage(user string) (*PreferenceUpdateMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[PreferenceUpdateMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewPreferenceUpdateMessageWithGUID(uid.String(), user)
+}
+
+func NewPreferenceUpdateMessageWithGUID(guid string, user string) (*PreferenceUpdateMessage, error) {
+	m := &PreferenceUpdateMessage{}
+	err := InitPreferenceUpdateMessage(m, guid, user)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitPreferenceUpdateMessage(m *PreferenceUpdateMessage, guid string, user string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "preferences:update")
+	if err != nil {
+		return err
+	}
+
+	return m.SetUser(user)
+}
+
+// IsValid Message has a User and Changes at least One Preference
+func (m *PreferenceUpdateMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.User() != "") && ((len(m.Channels()) > 0) || (m.Digest() != ""))
+}
+
+// User Identifier of User whose Preferences Changed
+func (m *PreferenceUpdateMessage) User() string {
+	return m.stringParameter("user")
+}
+
+func (m *PreferenceUpdateMessage) SetUser(user string) error {
+	// Is User Empty?
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return invalidField("PreferenceUpdateMessage", "user", "required")
+	}
+
+	return m.SetParameter("user", user)
+}
+
+// Channels Channels whose Setting Changed (Sorted)
+func (m *PreferenceUpdateMessage) Channels() []string {
+	p := m.Params()
+	if p != nil {
+		v, e := p.Get("channels")
+		if e == nil {
+			if c, ok := v.(map[string]interface{}); ok {
+				l := make([]string, 0, len(c))
+				for k := range c {
+					l = append(l, k)
+				}
+				sort.Strings(l)
+				return l
+			}
+		}
+	}
+
+	return nil
+}
+
+// OptIn Set Channel Opt In
+func (m *PreferenceUpdateMessage) OptIn(channel string) error {
+	return m.setChannel(channel, true)
+}
+
+// OptOut Set Channel Opt Out
+func (m *PreferenceUpdateMessage) OptOut(channel string) error {
+	return m.setChannel(channel, false)
+}
+
+// OptedIn Did User Opt In to Channel? (changed: false if Message doesn't Change Channel)
+func (m *PreferenceUpdateMessage) OptedIn(channel string) (in bool, changed bool) {
+	p := m.Params()
+	if p != nil {
+		v, e := p.Get("channels." + strings.ToLower(strings.TrimSpace(channel)))
+		if e == nil {
+			in, changed = v.(bool)
+		}
+	}
+
+	return in, changed
+}
+
+// Suppress Should Sends on Channel be Suppressed? (Opted Out or Digest Never)
+func (m *PreferenceUpdateMessage) Suppress(channel string) bool {
+	if in, changed := m.OptedIn(channel); changed && !in {
+		return true
+	}
+
+	return m.Digest() == DigestNever
+}
+
+func (m *PreferenceUpdateMessage) setChannel(channel string, in bool) error {
+	// Is Channel Empty?
+	channel = strings.ToLower(strings.TrimSpace(channel))
+	if channel == "" || strings.Contains(channel, ".") {
+		return invalidField("PreferenceUpdateMessage", "channels", "invalid channel ["+channel+"]")
+	}
+
+	return m.SetParameter("channels."+channel, in)
+}
+
+// Digest Digest Frequency ("" if Unchanged)
+func (m *PreferenceUpdateMessage) Digest() string {
+	return m.stringParameter("digest")
+}
+
+func (m *PreferenceUpdateMessage) SetDigest(frequency string) error {
+	// Is Frequency Known?
+	frequency = strings.ToLower(strings.TrimSpace(frequency))
+	switch frequency {
+	case DigestImmediate, DigestDaily, DigestWeekly, DigestNever: // YES
+		return m.SetParameter("digest", frequency)
+	}
+
+	return invalidField("PreferenceUpdateMessage", "digest", "invalid frequency ["+frequency+"]")
+}
@@ -0,0 +1,115 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/objectvault/common/maps"
+)
+
+// Header Properties by Dotted Path (Mirrors ActionMessage Parameters)
+//
+// Properties Set with SetProperties may Share their Map with the Caller, so
+// Changes by Path are Made on a Copy.
+
+// Properties Copy of Header Properties (nil if None)
+func (o *QueueMessageHeader) Properties() map[string]interface{} {
+	if o.props.IsEmpty() {
+		return nil
+	}
+	return copyProperties(o.props.Map())
+}
+
+// HasProperty Is Property Set?
+func (o *QueueMessageHeader) HasProperty(path string) bool {
+	if o.props.IsEmpty() {
+		return false
+	}
+	return o.props.Has(path)
+}
+
+// GetProperty Property Value (nil if not Set)
+func (o *QueueMessageHeader) GetProperty(path string) (interface{}, error) {
+	if o.props.IsEmpty() {
+		return nil, nil
+	}
+	return o.props.Get(path)
+}
+
+// GetStringProperty String Property ("" if not Set or not a String)
+func (o *QueueMessageHeader) GetStringProperty(path string) string {
+	v, err := o.GetProperty(path)
+	if err != nil {
+		return ""
+	}
+	s, _ := v.(string)
+	return s
+}
+
+// SetProperty Set Property (Intermediate Maps Created)
+func (o *QueueMessageHeader) SetProperty(path string, v interface{}) error {
+	w := maps.NewMapWrapper(copyProperties(o.props.Map()))
+	err := w.Set(path, v, true)
+	if err != nil {
+		return err
+	}
+
+	o.props = *w
+	return nil
+}
+
+// SetStringProperty Set String Property ("" and clear: Remove Property)
+func (o *QueueMessageHeader) SetStringProperty(path string, s string, clear bool) error {
+	if s == "" && clear {
+		return o.ClearProperty(path)
+	}
+
+	return o.SetProperty(path, s)
+}
+
+// ClearProperty Remove Property
+func (o *QueueMessageHeader) ClearProperty(path string) error {
+	// Do we have Properties?
+	if o.props.IsEmpty() { // NO
+		return nil
+	}
+
+	w := maps.NewMapWrapper(copyProperties(o.props.Map()))
+	err := w.Clear(path)
+	if err != nil {
+		return err
+	}
+
+	o.props = *w
+	return nil
+}
+
+// copyProperties Deep Copy of Nested Maps and Lists (Scalars Shared)
+func copyProperties(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = copyPropertyValue(v)
+	}
+	return c
+}
+
+func copyPropertyValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		return copyProperties(t)
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, e := range t {
+			l[i] = copyPropertyValue(e)
+		}
+		return l
+	}
+	return v
+}
@@ -13,7 +13,7 @@ package messages
 // cSpell:ignore mtype
 import (
 	"encoding/json"
-	"errors"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,6 +26,9 @@ type QueueMessageStatus struct {
 	errorMessage     string          // [OPTIONAL] Error Message Text
 	errorMessageI18N string          // [OPTIONAL] Error Message I18N Code
 	extras           maps.MapWrapper // [OPTIONAL] Optional Information
+	attempt          int             // [OPTIONAL] Processing Attempt (1 = First)
+	startedAt        *time.Time      // [OPTIONAL] Processing Start
+	finishedAt       *time.Time      // [OPTIONAL] Processing End
 }
 
 // Constructor
@@ -59,19 +62,95 @@ func (o *QueueMessageStatus) Extras() map[string]interface{} {
 	return o.extras.Map()
 }
 
+// Attempt Processing Attempt Number (0: Not Recorded)
+func (o *QueueMessageStatus) Attempt() int {
+	return o.attempt
+}
+
+func (o *QueueMessageStatus) SetAttempt(n int) {
+	// Is Attempt Valid?
+	if n < 0 { // NO: Clear
+		n = 0
+	}
+	o.attempt = n
+}
+
+// StartedAt Time Processing Started (nil if not Recorded)
+func (o *QueueMessageStatus) StartedAt() *time.Time {
+	return o.startedAt
+}
+
+// SetStartedAt Record Processing Start (Clears any Previous Finish Time)
+func (o *QueueMessageStatus) SetStartedAt(t time.Time) {
+	t = t.UTC()
+	o.startedAt = &t
+	o.finishedAt = nil
+}
+
+// FinishedAt Time Processing Finished (nil if not Recorded)
+func (o *QueueMessageStatus) FinishedAt() *time.Time {
+	return o.finishedAt
+}
+
+// SetFinishedAt Record Processing End
+func (o *QueueMessageStatus) SetFinishedAt(t time.Time) {
+	t = t.UTC()
+	o.finishedAt = &t
+}
+
+// Duration Time Taken to Process (0 if Start or Finish not Recorded)
+func (o *QueueMessageStatus) Duration() time.Duration {
+	// Do we have Start and Finish?
+	if o.startedAt == nil || o.finishedAt == nil { // NO
+		return 0
+	}
+
+	d := o.finishedAt.Sub(*o.startedAt)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// ClearTiming Remove Attempt, Start and Finish Times
+func (o *QueueMessageStatus) ClearTiming() {
+	o.attempt = 0
+	o.startedAt = nil
+	o.finishedAt = nil
+}
+
 func (o *QueueMessageStatus) MarshalJSON() ([]byte, error) {
 	// Convert to JSON
-	return json.Marshal(&struct {
-		ErrorCode        int         `json:"error_code"`
-		ErrorMessage     string      `json:"error_message,omitempty"`
-		ErrorMessageI18N string      `json:"error_message_i18n,omitempty"`
-		Extras           interface{} `json:"extras,omitempty"`
-	}{
-		ErrorCode:        o.errorCode,
-		ErrorMessage:     o.errorMessage,
-		ErrorMessageI18N: o.errorMessageI18N,
-		Extras:           o.extras.Map(),
-	})
+	j := getStatusJSON()
+	defer putStatusJSON(j)
+
+	o.fill(j)
+	return json.Marshal(j)
+}
+
+// MarshalDocument Status Wire Document (see MarshalJSON)
+func (o *QueueMessageStatus) MarshalDocument() (interface{}, error) {
+	j := &statusJSON{}
+	o.fill(j)
+	return j, nil
+}
+
+// fill Copy Status into Wire Structure
+func (o *QueueMessageStatus) fill(j *statusJSON) {
+	j.ErrorCode = o.errorCode
+	j.ErrorMessage = o.errorMessage
+	j.ErrorMessageI18N = o.errorMessageI18N
+	j.Attempt = o.attempt
+	j.StartedAt = o.startedAt
+	j.FinishedAt = o.finishedAt
+	if o.finishedAt != nil {
+		j.DurationMS = o.Duration().Milliseconds()
+	}
+
+	// Extras Set?
+	if !o.extras.IsEmpty() {
+		j.Extras = o.extras.Map()
+	}
 }
 
 type QueueMessageHeader struct {
@@ -81,6 +160,8 @@ type QueueMessageHeader struct {
 	props   maps.MapWrapper     // [OPTIONAL] Message Processing Properties
 	status  *QueueMessageStatus // [OPTIONAL] Message Processing Status
 	created *time.Time          // [OPTIONAL] Message Creation Date
+	history []HistoryEntry      // [OPTIONAL] Processing Trail (Oldest First)
+	tracing *Tracing            // [OPTIONAL] W3C Trace Context
 }
 
 // Constructor
@@ -129,10 +210,159 @@ func (o *QueueMessageHeader) SetProperties(m map[string]interface{}) {
 	o.props = *maps.NewMapWrapper(m)
 }
 
+// Standard Header Properties
+const (
+	PropertyRequestID     = "request_id"     // Caller Supplied Request/Trace ID
+	PropertyPriority      = "priority"       // Message Priority (0-9)
+	PropertyCorrelationID = "correlation_id" // ID Correlating Request and Reply Messages
+	PropertyPublisher     = "publisher"      // Publisher Instance Stamping Sequence
+	PropertySequence      = "sequence"       // Per Publisher Monotonic Sequence Number
+)
+
+func (o *QueueMessageHeader) property(name string) interface{} {
+	m := o.props.Map()
+	if m == nil {
+		return nil
+	}
+	return m[name]
+}
+
+func (o *QueueMessageHeader) setProperty(name string, v interface{}) {
+	// Copy Properties (Header may Share Map with Caller)
+	m := map[string]interface{}{}
+	for k, pv := range o.props.Map() {
+		m[k] = pv
+	}
+
+	// Clear Property?
+	if v == nil { // YES
+		delete(m, name)
+	} else {
+		m[name] = v
+	}
+	o.SetProperties(m)
+}
+
+// RequestID Request/Trace ID Message was Published Under
+func (o *QueueMessageHeader) RequestID() string {
+	s, _ := o.property(PropertyRequestID).(string)
+	return s
+}
+
+func (o *QueueMessageHeader) SetRequestID(id string) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		o.setProperty(PropertyRequestID, nil)
+		return
+	}
+	o.setProperty(PropertyRequestID, id)
+}
+
+// Priority Message Priority (0: Normal)
+func (o *QueueMessageHeader) Priority() uint8 {
+	var p float64
+	switch v := o.property(PropertyPriority).(type) {
+	case float64:
+		p = v
+	case int:
+		p = float64(v)
+	case uint8:
+		p = float64(v)
+	case json.Number:
+		p, _ = v.Float64()
+	}
+
+	// Clamp to AMQP Range
+	if p < 0 {
+		return 0
+	} else if p > 9 {
+		return 9
+	}
+	return uint8(p)
+}
+
+func (o *QueueMessageHeader) SetPriority(p uint8) {
+	if p == 0 {
+		o.setProperty(PropertyPriority, nil)
+		return
+	}
+	if p > 9 {
+		p = 9
+	}
+	o.setProperty(PropertyPriority, int(p))
+}
+
+// CorrelationID ID Correlating Request and Reply Messages
+func (o *QueueMessageHeader) CorrelationID() string {
+	s, _ := o.property(PropertyCorrelationID).(string)
+	return s
+}
+
+func (o *QueueMessageHeader) SetCorrelationID(id string) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		o.setProperty(PropertyCorrelationID, nil)
+		return
+	}
+	o.setProperty(PropertyCorrelationID, id)
+}
+
+// Publisher Publisher Instance that Stamped the Sequence ("" if not Sequenced)
+func (o *QueueMessageHeader) Publisher() string {
+	s, _ := o.property(PropertyPublisher).(string)
+	return s
+}
+
+// Sequence Per Publisher Sequence Number (0: not Sequenced)
+func (o *QueueMessageHeader) Sequence() uint64 {
+	switch v := o.property(PropertySequence).(type) {
+	case float64:
+		if v > 0 {
+			return uint64(v)
+		}
+	case int:
+		if v > 0 {
+			return uint64(v)
+		}
+	case uint64:
+		return v
+	case json.Number:
+		n, _ := strconv.ParseUint(v.String(), 10, 64)
+		return n
+	}
+	return 0
+}
+
+// SetSequence Stamp Publisher and Sequence Number (publisher "" or 0: Clear)
+func (o *QueueMessageHeader) SetSequence(publisher string, seq uint64) {
+	publisher = strings.TrimSpace(publisher)
+	if publisher == "" || seq == 0 {
+		o.setProperty(PropertyPublisher, nil)
+		o.setProperty(PropertySequence, nil)
+		return
+	}
+	o.setProperty(PropertyPublisher, publisher)
+	o.setProperty(PropertySequence, seq)
+}
+
 func (o *QueueMessageHeader) Status() *QueueMessageStatus {
 	return o.status
 }
 
+func (o *QueueMessageHeader) SetStatus(s *QueueMessageStatus) {
+	o.status = s
+}
+
+// HasCreated Has the Creation Time been Set (Created() Sets it on First Use)?
+func (o *QueueMessageHeader) HasCreated() bool {
+	return o.created != nil
+}
+
+func (o *QueueMessageHeader) SetCreated(t time.Time) {
+	t = t.UTC()
+	o.created = &t
+}
+
 func (o *QueueMessageHeader) Created() time.Time {
 	if o.created == nil {
 		now := time.Now().UTC()
@@ -144,28 +374,14 @@ func (o *QueueMessageHeader) Created() time.Time {
 
 func (o *QueueMessageHeader) MarshalJSON() ([]byte, error) {
 	if !o.IsValid() {
-		return nil, errors.New("[QueueMessageHeader] Is not valid")
+		return nil, invalidMessage("QueueMessageHeader", "missing version or id")
 	}
 
 	// Convert to JSON
-	j := &struct {
-		Version int         `json:"version"`
-		ID      string      `json:"id"`
-		Parent  string      `json:"parent,omitempty"`
-		Props   interface{} `json:"props,omitempty"`
-		Status  interface{} `json:"status,omitempty"`
-		Created time.Time   `json:"created"`
-	}{
-		Version: o.version,
-		ID:      o.id,
-		Parent:  o.parent,
-		Created: o.Created(),
-	}
+	j := getHeaderJSON()
+	defer putHeaderJSON(j)
 
-	// Properties Set?
-	if !o.props.IsEmpty() {
-		j.Props = o.props.Map()
-	}
+	o.fill(j)
 
 	// Status Set?
 	if o.status != nil {
@@ -176,6 +392,38 @@ func (o *QueueMessageHeader) MarshalJSON() ([]byte, error) {
 	return json.Marshal(j)
 }
 
+// MarshalDocument Header Wire Document (see MarshalJSON)
+func (o *QueueMessageHeader) MarshalDocument() (interface{}, error) {
+	if !o.IsValid() {
+		return nil, invalidMessage("QueueMessageHeader", "missing version or id")
+	}
+
+	j := &headerJSON{}
+	o.fill(j)
+
+	// Status Set?
+	if o.status != nil {
+		j.Status, _ = o.status.MarshalDocument()
+	}
+	return j, nil
+}
+
+// fill Copy Header (except Status) into Wire Structure
+func (o *QueueMessageHeader) fill(j *headerJSON) {
+	j.Version = o.version
+	j.ID = o.id
+	j.Parent = o.parent
+	j.Created = o.Created()
+
+	// Properties Set?
+	if !o.props.IsEmpty() {
+		j.Props = o.props.Map()
+	}
+
+	j.History = o.history
+	j.Tracing = o.tracing
+}
+
 type QueueMessage struct {
 	header *QueueMessageHeader // [REQUIRED] Message Header
 	body   interface{}         // [REQUIRED] Message Content
@@ -212,15 +460,46 @@ func (o *QueueMessage) SetMessage(message interface{}) {
 
 func (o *QueueMessage) MarshalJSON() ([]byte, error) {
 	if !o.IsValid() {
-		return nil, errors.New("[QueueMessage] Is not valid")
+		return nil, invalidMessage("QueueMessage", "missing header or body")
+	}
+
+	if err := runHooks(HookBeforeMarshal, o); err != nil {
+		return nil, err
 	}
 
 	// Convert to JSON
-	return json.Marshal(&struct {
-		Header  interface{} `json:"header"`
-		Message interface{} `json:"body"`
-	}{
-		Header:  o.header,
-		Message: o.body,
-	})
+	j := getEnvelopeJSON()
+	defer putEnvelopeJSON(j)
+
+	j.Header = o.header
+	j.Message = o.body
+	return json.Marshal(j)
+}
+
+// MarshalDocument Message Wire Document (see MarshalJSON)
+//
+// Bodies with a Wire Document (i.e. Action Content) are Replaced by it,
+// Others are Encoded as is.
+func (o *QueueMessage) MarshalDocument() (interface{}, error) {
+	if !o.IsValid() {
+		return nil, invalidMessage("QueueMessage", "missing header or body")
+	}
+
+	if err := runHooks(HookBeforeMarshal, o); err != nil {
+		return nil, err
+	}
+
+	header, err := o.header.MarshalDocument()
+	if err != nil {
+		return nil, err
+	}
+
+	body := o.body
+	if m, ok := body.(documentMarshaler); ok {
+		if body, err = m.MarshalDocument(); err != nil {
+			return nil, err
+		}
+	}
+
+	return &envelopeJSON{Header: header, Message: body}, nil
 }
@@ -0,0 +1,142 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"net/mail"
+	"strings"
+)
+
+// Recipient Kinds
+const (
+	RecipientTo  = "to"
+	RecipientCC  = "cc"
+	RecipientBCC = "bcc"
+)
+
+// Recipient Parsed Email Recipient
+type Recipient struct {
+	Kind    string // RecipientTo, RecipientCC or RecipientBCC
+	Name    string // [OPTIONAL] Display Name
+	Address string // Address (i.e. "jane@example.com")
+}
+
+// String RFC 5322 Form (i.e. "\"Doe, Jane\" <jane@example.com>")
+func (r Recipient) String() string {
+	a := mail.Address{Name: r.Name, Address: r.Address}
+	return a.String()
+}
+
+// ParseRecipients Parse Address List Separated by ',' or ';' (Quoted Names may Contain Either)
+func ParseRecipients(kind string, list string) ([]Recipient, error) {
+	list = strings.TrimSpace(list)
+	if list == "" {
+		return nil, nil
+	}
+
+	addresses, err := mail.ParseAddressList(normalizeSeparators(list))
+	if err != nil {
+		return nil, invalidField("EmailMessage", kind, "invalid address list ["+err.Error()+"]")
+	}
+
+	r := make([]Recipient, 0, len(addresses))
+	for _, a := range addresses {
+		r = append(r, Recipient{Kind: kind, Name: a.Name, Address: strings.ToLower(a.Address)})
+	}
+	return r, nil
+}
+
+// FormatRecipients Format Recipients as RFC 5322 Address List
+func FormatRecipients(r []Recipient) string {
+	l := make([]string, 0, len(r))
+	for _, a := range r {
+		l = append(l, a.String())
+	}
+	return strings.Join(l, ", ")
+}
+
+// normalizeSeparators Replace ';' Separators (Outside Quotes and Comments) with ','
+func normalizeSeparators(s string) string {
+	b := []byte(s)
+	quoted, escaped, comment := false, false, 0
+	for i, c := range b {
+		switch {
+		case escaped:
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case c == '"' && comment == 0:
+			quoted = !quoted
+		case c == '(' && !quoted:
+			comment++
+		case c == ')' && !quoted && comment > 0:
+			comment--
+		case c == ';' && !quoted && comment == 0:
+			b[i] = ','
+		}
+	}
+
+	// Drop Trailing Separator (i.e. "a@b.c;")
+	return strings.TrimRight(strings.TrimSpace(string(b)), ",")
+}
+
+// ToRecipients Parsed 'to' Recipients
+func (m *EmailMessage) ToRecipients() ([]Recipient, error) {
+	return ParseRecipients(RecipientTo, m.stringParameter("to"))
+}
+
+// CCRecipients Parsed 'cc' Recipients
+func (m *EmailMessage) CCRecipients() ([]Recipient, error) {
+	return ParseRecipients(RecipientCC, m.stringParameter("cc"))
+}
+
+// BCCRecipients Parsed 'bcc' Recipients
+func (m *EmailMessage) BCCRecipients() ([]Recipient, error) {
+	return ParseRecipients(RecipientBCC, m.stringParameter("bcc"))
+}
+
+// Recipients Every Recipient (to, cc then bcc)
+func (m *EmailMessage) Recipients() ([]Recipient, error) {
+	all := []Recipient{}
+	for _, f := range []func() ([]Recipient, error){m.ToRecipients, m.CCRecipients, m.BCCRecipients} {
+		r, err := f()
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, r...)
+	}
+	return all, nil
+}
+
+// SetRecipients Replace Recipients of 'kind' (Display Names Preserved, None: Clear)
+func (m *EmailMessage) SetRecipients(kind string, r ...Recipient) error {
+	// Is Kind Known?
+	switch kind {
+	case RecipientTo, RecipientCC, RecipientBCC: // YES
+	default:
+		return invalidField("EmailMessage", kind, "unknown recipient kind")
+	}
+
+	// Are Addresses Valid?
+	for i := range r {
+		a, err := mail.ParseAddress(r[i].Address)
+		if err != nil { // NO
+			return invalidField("EmailMessage", kind, "invalid address ["+r[i].Address+"]")
+		}
+		r[i].Address = strings.ToLower(a.Address)
+	}
+
+	if kind == RecipientTo && len(r) == 0 {
+		return invalidField("EmailMessage", "to", "required")
+	}
+
+	return m.SetStringParameter(kind, FormatRecipients(r), true)
+}
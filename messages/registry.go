@@ -0,0 +1,128 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MessageFactory Allocates an Empty Message of a Registered Type
+type MessageFactory func() IMessage
+
+// Message Type Registry (Action Type -> Factory)
+var (
+	typesLock sync.RWMutex
+	types     = map[string]MessageFactory{}
+)
+
+func init() {
+	RegisterMessageType("action", func() IMessage { return &ActionMessage{} })
+	RegisterMessageType("action:email", func() IMessage { return &EmailMessage{} })
+	RegisterMessageType("action:email:invite", func() IMessage { return &InviteMessage{} })
+	RegisterMessageType(PasswordResetType, func() IMessage { return &PasswordResetMessage{} })
+	RegisterMessageType("action:email-batch", func() IMessage { return &EmailBatchMessage{} })
+	RegisterMessageType("action:invite:revoked", func() IMessage { return &InviteRevokedMessage{} })
+	RegisterMessageType("action:invite:response", func() IMessage { return &InviteResponseMessage{} })
+	RegisterMessageType("action:invite:bulk", func() IMessage { return &BulkInviteMessage{} })
+	RegisterMessageType("action:cache:invalidate", func() IMessage { return &CacheInvalidationMessage{} })
+	RegisterMessageType("action:session:invalidate", func() IMessage { return &SessionInvalidationMessage{} })
+	RegisterMessageType("action:key:rotate", func() IMessage { return &KeyRotationMessage{} })
+	RegisterMessageType("action:store:share", func() IMessage { return &StoreShareMessage{} })
+	RegisterMessageType("action:billing:event", func() IMessage { return &BillingEventMessage{} })
+	RegisterMessageType("action:maintenance", func() IMessage { return &MaintenanceMessage{} })
+	RegisterMessageType("action:result", func() IMessage { return &ResultMessage{} })
+	RegisterMessageType("action:admin:command", func() IMessage { return &AdminCommandMessage{} })
+	RegisterMessageType("action:preferences:update", func() IMessage { return &PreferenceUpdateMessage{} })
+}
+
+// RegisterMessageType Decode Messages of Type (and its Sub Types) with Factory (Replaces Existing)
+//
+// i.e. "action:email:invite" also Covers "action:email:invite:store", unless
+// the Sub Type has a Registration of its Own.
+func RegisterMessageType(t string, f MessageFactory) {
+	typesLock.Lock()
+	defer typesLock.Unlock()
+
+	t = strings.ToLower(strings.TrimSpace(t))
+	if f == nil {
+		delete(types, t)
+		return
+	}
+	types[t] = f
+}
+
+// MessageTypes Registered Message Types
+func MessageTypes() []string {
+	typesLock.RLock()
+	defer typesLock.RUnlock()
+
+	l := make([]string, 0, len(types))
+	for t := range types {
+		l = append(l, t)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// factoryFor Most Specific Factory for Type (nil if None)
+func factoryFor(t string) MessageFactory {
+	typesLock.RLock()
+	defer typesLock.RUnlock()
+
+	for t != "" {
+		if f, ok := types[t]; ok {
+			return f
+		}
+
+		// Try Parent Type (i.e. action:email:invite:store -> action:email:invite)
+		i := strings.LastIndex(t, ":")
+		if i < 0 {
+			break
+		}
+		t = t[:i]
+	}
+
+	return nil
+}
+
+// Decode Decode JSON Encoded Message into the Concrete Type Registered for its Body Type
+//
+// Returns ErrUnknownMessageType if no Registered Type Matches.
+func Decode(b []byte) (IMessage, error) {
+	e, err := decodeEnvelope("Decode", b)
+	if err != nil {
+		return nil, err
+	}
+
+	var body struct {
+		Type string `json:"type"`
+	}
+	if err = json.Unmarshal(e.Body, &body); err != nil {
+		return nil, fmt.Errorf("[Decode] Invalid JSON [%w]", err)
+	}
+
+	// Do we have a Registered Type?
+	t := strings.ToLower(strings.TrimSpace(body.Type))
+	f := factoryFor(t)
+	if f == nil { // NO
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownMessageType, t)
+	}
+
+	m := f()
+	if err = json.Unmarshal(b, m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
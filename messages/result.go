@@ -0,0 +1,145 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// ResultMessage Reply to a Request (Header Correlation ID is the Request ID)
+type ResultMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+// NewResultMessage Reply from 'source' to Request with ID 'request'
+func NewResultMessage(request string, source string) (*ResultMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[ResultMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewResultMessageWithGUID(uid.String(), request, source)
+}
+
+func NewResultMessageWithGUID(guid string, request string, source string) (*ResultMessage, error) {
+	m := &ResultMessage{}
+	err := InitResultMessage(m, guid, request, source)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitResultMessage(m *ResultMessage, guid string, request string, source string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "result")
+	if err != nil {
+		return err
+	}
+
+	// Is Request ID Empty?
+	request = strings.TrimSpace(request)
+	if request == "" { // YES
+		return invalidField("ResultMessage", "correlation_id", "request id required")
+	}
+	m.Header().SetCorrelationID(request)
+
+	return m.SetSource(source)
+}
+
+func (m *ResultMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Request() != "") && (m.Source() != "")
+}
+
+// Request ID of Request this Result Answers
+func (m *ResultMessage) Request() string {
+	return m.Header().CorrelationID()
+}
+
+// Source Responder Identifier (i.e. Processor Name)
+func (m *ResultMessage) Source() string {
+	return m.stringParameter("source")
+}
+
+func (m *ResultMessage) SetSource(source string) error {
+	// Is Source Empty?
+	source = strings.TrimSpace(source)
+	if source == "" {
+		return invalidField("ResultMessage", "source", "required")
+	}
+
+	return m.SetParameter("source", source)
+}
+
+// Succeeded Did Responder Handle Request? (No Error Set)
+func (m *ResultMessage) Succeeded() bool {
+	return m.Error() == ""
+}
+
+// Error Failure Reported by Responder ("" if Succeeded)
+func (m *ResultMessage) Error() string {
+	return m.stringParameter("error")
+}
+
+// SetError Report Failure (nil: Clear)
+func (m *ResultMessage) SetError(err error) error {
+	if err == nil {
+		return m.SetStringParameter("error", "", true)
+	}
+
+	return m.SetStringParameter("error", err.Error(), true)
+}
+
+// Result Value Returned under 'key' (nil if not Set)
+func (m *ResultMessage) Result(key string) interface{} {
+	p := m.Params()
+	if p != nil {
+		v, e := p.Get("result." + key)
+		if e == nil {
+			return v
+		}
+	}
+
+	return nil
+}
+
+// Results All Returned Values (nil if None)
+func (m *ResultMessage) Results() map[string]interface{} {
+	p := m.Params()
+	if p != nil {
+		v, e := p.Get("result")
+		if e == nil {
+			if r, ok := v.(map[string]interface{}); ok {
+				return r
+			}
+		}
+	}
+
+	return nil
+}
+
+// SetResult Return Value under 'key'
+func (m *ResultMessage) SetResult(key string, v interface{}) error {
+	// Is Key Empty?
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return invalidField("ResultMessage", "result", "key required")
+	}
+
+	return m.SetParameter("result."+key, v)
+}
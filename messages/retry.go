@@ -0,0 +1,99 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// Retry Header Properties
+const (
+	PropertyRequeueCount = "requeue_count" // Times Message was Requeued
+	PropertyNextRetryAt  = "next_retry_at" // When Message becomes Eligible Again (RFC 3339)
+)
+
+// RequeueCount Times Message was Requeued (0: Never)
+func (o *QueueMessageHeader) RequeueCount() int {
+	switch v := o.property(PropertyRequeueCount).(type) {
+	case float64:
+		return int(v)
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
+}
+
+// SetRequeueCount Set Requeue Count (0: Clear)
+func (o *QueueMessageHeader) SetRequeueCount(count int) {
+	if count <= 0 {
+		o.setProperty(PropertyRequeueCount, nil)
+		return
+	}
+	o.setProperty(PropertyRequeueCount, count)
+}
+
+// NextRetryAt When Message becomes Eligible for Retry (nil: Now)
+func (o *QueueMessageHeader) NextRetryAt() *time.Time {
+	v := o.property(PropertyNextRetryAt)
+	if v == nil {
+		return nil
+	}
+
+	t, err := shared.ParseTimeValue(v)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// SetNextRetryAt Set Time Message becomes Eligible for Retry (Zero: Clear)
+func (o *QueueMessageHeader) SetNextRetryAt(t time.Time) {
+	if t.IsZero() {
+		o.setProperty(PropertyNextRetryAt, nil)
+		return
+	}
+	o.setProperty(PropertyNextRetryAt, shared.FormatTimeStamp(t))
+}
+
+// ScheduleRetry Record a Failed Attempt and Compute when Message may be Retried
+//
+// Increments the Requeue Count and Stores NextRetryAt ('now' plus the Policy
+// Delay for this Retry). Returns false (NextRetryAt Cleared) when the Policy
+// Allows no more Attempts.
+func (o *QueueMessageHeader) ScheduleRetry(p *shared.RetryPolicy, now time.Time) (time.Time, bool) {
+	// Attempts Made: Original plus Previous Retries
+	attempts := o.RequeueCount() + 1
+
+	// Is another Attempt Allowed?
+	if !p.CanRetry(attempts) { // NO
+		o.SetNextRetryAt(time.Time{})
+		return time.Time{}, false
+	}
+
+	at := now.Add(p.Delay(attempts))
+	o.SetRequeueCount(attempts)
+	o.SetNextRetryAt(at)
+	return at, true
+}
+
+// RetryDue Is Message Eligible for Retry at 'now'?
+func (o *QueueMessageHeader) RetryDue(now time.Time) bool {
+	at := o.NextRetryAt()
+	return at == nil || !now.Before(*at)
+}
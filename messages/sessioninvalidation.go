@@ -0,0 +1,154 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// AllSessions Session List Value Terminating every Session of the User
+const AllSessions = "all"
+
+// Session Invalidation Causes
+const (
+	CausePasswordChange = "password-change"
+	CauseAdminLock      = "admin-lock"
+	CauseLogoutAll      = "logout-all"
+	CauseAccountDeleted = "account-deleted"
+)
+
+// SessionInvalidationMessage Tells every Node to Terminate User Sessions (Broadcast)
+type SessionInvalidationMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+// NewSessionInvalidationMessage Terminate 'sessions' of User (None or AllSessions: every Session)
+func NewSessionInvalidationMessage(user string, cause string, sessions ...string) (*SessionInvalidationMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[SessionInvalidationMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewSessionInvalidationMessageWithGUID(uid.String(), user, cause, sessions...)
+}
+
+func NewSessionInvalidationMessageWithGUID(guid string, user string, cause string, sessions ...string) (*SessionInvalidationMessage, error) {
+	m := &SessionInvalidationMessage{}
+	err := InitSessionInvalidationMessage(m, guid, user, cause, sessions...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitSessionInvalidationMessage(m *SessionInvalidationMessage, guid string, user string, cause string, sessions ...string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "session:invalidate")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetUser(user)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetCause(cause)
+	if err != nil {
+		return err
+	}
+
+	return m.SetSessions(sessions)
+}
+
+func (m *SessionInvalidationMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.User() != "") && (m.Cause() != "") && (len(m.Sessions()) > 0)
+}
+
+// User Identifier of User whose Sessions are Terminated
+func (m *SessionInvalidationMessage) User() string {
+	return m.stringParameter("user")
+}
+
+func (m *SessionInvalidationMessage) SetUser(user string) error {
+	// Is User Empty?
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return invalidField("SessionInvalidationMessage", "user", "required")
+	}
+
+	return m.SetParameter("user", user)
+}
+
+// Sessions Session IDs to Terminate ([AllSessions] for every Session)
+func (m *SessionInvalidationMessage) Sessions() []string {
+	p := m.Params()
+	if p != nil {
+		s, e := p.Get("sessions")
+		if e == nil && s != nil {
+			return toStringList(s)
+		}
+	}
+
+	return nil
+}
+
+// SetSessions Set Session IDs (Empty or Containing AllSessions: every Session)
+func (m *SessionInvalidationMessage) SetSessions(sessions []string) error {
+	list := []string{}
+	for _, s := range sessions {
+		s = strings.TrimSpace(s)
+
+		// Terminate Every Session?
+		if strings.EqualFold(s, AllSessions) { // YES
+			list = []string{AllSessions}
+			break
+		}
+
+		if s != "" {
+			list = append(list, s)
+		}
+	}
+
+	if len(list) == 0 {
+		list = []string{AllSessions}
+	}
+
+	return m.SetParameter("sessions", list)
+}
+
+// AllSessions Should every Session of the User be Terminated?
+func (m *SessionInvalidationMessage) AllSessions() bool {
+	s := m.Sessions()
+	return len(s) == 1 && s[0] == AllSessions
+}
+
+// Cause Why Sessions are Terminated (i.e. CausePasswordChange)
+func (m *SessionInvalidationMessage) Cause() string {
+	return m.stringParameter("cause")
+}
+
+func (m *SessionInvalidationMessage) SetCause(cause string) error {
+	// Is Cause Empty?
+	cause = strings.ToLower(strings.TrimSpace(cause))
+	if cause == "" {
+		return invalidField("SessionInvalidationMessage", "cause", "required")
+	}
+
+	return m.SetParameter("cause", cause)
+}
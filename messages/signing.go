@@ -0,0 +1,104 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore dkim
+import (
+	"strings"
+)
+
+// Signing DKIM (and ARC) Signing Instructions for the Mail Processor
+//
+// Keys are not Carried: the Processor Looks them up by Domain and Selector.
+type Signing struct {
+	Domain   string // DKIM Signing Domain (d=), i.e. Organization Sending Domain
+	Selector string // DKIM Selector (s=)
+	ARC      bool   // Also Add ARC Seal when Forwarding (Same Domain and Selector)
+}
+
+// Signing Signing Instructions (nil: Processor Default)
+func (m *EmailMessage) Signing() *Signing {
+	d := m.stringParameter("signing.domain")
+	if d == "" {
+		return nil
+	}
+
+	s := &Signing{
+		Domain:   d,
+		Selector: m.stringParameter("signing.selector"),
+	}
+
+	p := m.Params()
+	if p != nil {
+		arc, e := p.GetDefault("signing.arc", false)
+		if e == nil {
+			s.ARC, _ = arc.(bool)
+		}
+	}
+	return s
+}
+
+// SetSigning Sign Message with Domain and Selector
+func (m *EmailMessage) SetSigning(s Signing) error {
+	s.Domain = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(s.Domain), "."))
+	s.Selector = strings.ToLower(strings.TrimSpace(s.Selector))
+
+	// Is Domain Valid?
+	if !isDomainName(s.Domain) { // NO
+		return invalidField("EmailMessage", "signing", "invalid domain ["+s.Domain+"]")
+	}
+
+	// Is Selector Valid (Selectors are Domain Labels, Dotted Allowed)?
+	if !isDomainName(s.Selector) { // NO
+		return invalidField("EmailMessage", "signing", "invalid selector ["+s.Selector+"]")
+	}
+
+	err := m.ClearSigning()
+	if err == nil {
+		err = m.SetParameter("signing.domain", s.Domain)
+	}
+	if err == nil {
+		err = m.SetParameter("signing.selector", s.Selector)
+	}
+	if err == nil && s.ARC {
+		err = m.SetParameter("signing.arc", true)
+	}
+	return err
+}
+
+// ClearSigning Use Processor Default Signing
+func (m *EmailMessage) ClearSigning() error {
+	p := m.Params()
+	if p != nil {
+		return p.Clear("signing")
+	}
+
+	return nil
+}
+
+// isDomainName Dotted Labels of Letters, Digits and Hyphens (not at Label Ends)
+func isDomainName(s string) bool {
+	if s == "" || len(s) > 253 {
+		return false
+	}
+
+	for _, label := range strings.Split(s, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z') && !(c >= '0' && c <= '9') && c != '-' && c != '_' {
+				return false
+			}
+		}
+	}
+	return true
+}
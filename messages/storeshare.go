@@ -0,0 +1,162 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+)
+
+// Share Grantee Kinds
+const (
+	GranteeUser = "user"
+	GranteeOrg  = "org"
+)
+
+// StoreShareMessage Grant a User or Organization Access to a Store
+type StoreShareMessage struct {
+	ActionMessage // DERIVED FROM
+}
+
+// NewStoreShareMessage i.e. NewStoreShareMessage("store-id", GranteeUser, "user-id", mask, "admin-id")
+func NewStoreShareMessage(store string, kind string, grantee string, mask uint32, by string) (*StoreShareMessage, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, fmt.Errorf("[StoreShareMessage] Failed to Generate Action Message ID [%v]", err)
+	}
+
+	return NewStoreShareMessageWithGUID(uid.String(), store, kind, grantee, mask, by)
+}
+
+func NewStoreShareMessageWithGUID(guid string, store string, kind string, grantee string, mask uint32, by string) (*StoreShareMessage, error) {
+	m := &StoreShareMessage{}
+	err := InitStoreShareMessage(m, guid, store, kind, grantee, mask, by)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func InitStoreShareMessage(m *StoreShareMessage, guid string, store string, kind string, grantee string, mask uint32, by string) error {
+	// Initialize Action Message
+	err := InitQueueAction(&(m.ActionMessage), guid, "store:share")
+	if err != nil {
+		return err
+	}
+
+	err = m.SetStore(store)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetGrantee(kind, grantee)
+	if err != nil {
+		return err
+	}
+
+	err = m.SetPermissions(mask)
+	if err != nil {
+		return err
+	}
+
+	return m.SetGrantedBy(by)
+}
+
+func (m *StoreShareMessage) IsValid() bool {
+	return m.ActionMessage.IsValid() && (m.Store() != "") && (m.Grantee() != "") &&
+		(m.GranteeKind() == GranteeUser || m.GranteeKind() == GranteeOrg) &&
+		(m.Permissions() != 0) && (m.GrantedBy() != "")
+}
+
+// Store Identifier of Store being Shared
+func (m *StoreShareMessage) Store() string {
+	return m.stringParameter("store")
+}
+
+func (m *StoreShareMessage) SetStore(store string) error {
+	// Is Store Empty?
+	store = strings.TrimSpace(store)
+	if store == "" {
+		return invalidField("StoreShareMessage", "store", "required")
+	}
+
+	return m.SetParameter("store", store)
+}
+
+// GranteeKind GranteeUser or GranteeOrg
+func (m *StoreShareMessage) GranteeKind() string {
+	return m.stringParameter("grantee.kind")
+}
+
+// Grantee Identifier of User or Organization Receiving Access
+func (m *StoreShareMessage) Grantee() string {
+	return m.stringParameter("grantee.id")
+}
+
+func (m *StoreShareMessage) SetGrantee(kind string, id string) error {
+	// Is Grantee Kind Known?
+	kind = strings.ToLower(strings.TrimSpace(kind))
+	if kind != GranteeUser && kind != GranteeOrg { // NO
+		return invalidField("StoreShareMessage", "grantee", "invalid grantee kind ["+kind+"]")
+	}
+
+	// Is Grantee Empty?
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return invalidField("StoreShareMessage", "grantee", "required")
+	}
+
+	err := m.SetParameter("grantee.kind", kind)
+	if err != nil {
+		return err
+	}
+	return m.SetParameter("grantee.id", id)
+}
+
+// Permissions Permission Mask Granted on Store
+func (m *StoreShareMessage) Permissions() uint32 {
+	return uint32(m.intParameter("permissions"))
+}
+
+func (m *StoreShareMessage) SetPermissions(mask uint32) error {
+	// Does Share Grant Anything?
+	if mask == 0 { // NO
+		return invalidField("StoreShareMessage", "permissions", "empty permission mask")
+	}
+
+	return m.SetParameter("permissions", int64(mask))
+}
+
+// HasPermission Does Share Grant every Permission in 'mask'?
+func (m *StoreShareMessage) HasPermission(mask uint32) bool {
+	return mask != 0 && (m.Permissions()&mask) == mask
+}
+
+// GrantedBy Identifier of User Granting Access
+func (m *StoreShareMessage) GrantedBy() string {
+	return m.stringParameter("by-user")
+}
+
+func (m *StoreShareMessage) SetGrantedBy(user string) error {
+	// Is User Empty?
+	user = strings.TrimSpace(user)
+	if user == "" {
+		return invalidField("StoreShareMessage", "by-user", "required")
+	}
+
+	return m.SetParameter("by-user", user)
+}
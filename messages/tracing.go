@@ -0,0 +1,154 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore traceparent tracestate
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// W3C Trace Context Limits (https://www.w3.org/TR/trace-context/)
+const (
+	traceParentLength = 55  // version-traceid-parentid-flags
+	MaxTraceStateSize = 512 // Maximum tracestate Length Propagated
+)
+
+// Tracing W3C Trace Context Carried by the Message (Links Publisher and Consumer Spans)
+type Tracing struct {
+	TraceParent string `json:"traceparent"`          // [REQUIRED] i.e. 00-<trace id>-<span id>-01
+	TraceState  string `json:"tracestate,omitempty"` // [OPTIONAL] Vendor Specific Trace State
+}
+
+// NewTracing Trace Context for Trace and (Parent) Span IDs (Hex Encoded)
+func NewTracing(traceID string, spanID string, sampled bool) (*Tracing, error) {
+	flags := "00"
+	if sampled {
+		flags = "01"
+	}
+
+	return ParseTracing("00-"+strings.ToLower(traceID)+"-"+strings.ToLower(spanID)+"-"+flags, "")
+}
+
+// ParseTracing Validate traceparent and tracestate Values
+func ParseTracing(traceparent string, tracestate string) (*Tracing, error) {
+	t := &Tracing{
+		TraceParent: strings.TrimSpace(traceparent),
+		TraceState:  strings.TrimSpace(tracestate),
+	}
+
+	// Is traceparent Valid?
+	if !validTraceParent(t.TraceParent) { // NO
+		return nil, invalidField("Tracing", "traceparent", "invalid ["+traceparent+"]")
+	}
+
+	// NOTE: Oversized State is Dropped (as Allowed by the Spec) rather than Rejected
+	if len(t.TraceState) > MaxTraceStateSize {
+		t.TraceState = ""
+	}
+	return t, nil
+}
+
+// TraceID Hex Encoded Trace ID
+func (t *Tracing) TraceID() string {
+	if t == nil || len(t.TraceParent) < traceParentLength {
+		return ""
+	}
+	return t.TraceParent[3:35]
+}
+
+// SpanID Hex Encoded ID of the Span that Published the Message
+func (t *Tracing) SpanID() string {
+	if t == nil || len(t.TraceParent) < traceParentLength {
+		return ""
+	}
+	return t.TraceParent[36:52]
+}
+
+// Sampled Was Trace Recorded by the Publisher?
+func (t *Tracing) Sampled() bool {
+	if t == nil || len(t.TraceParent) < traceParentLength {
+		return false
+	}
+
+	flags, err := hex.DecodeString(t.TraceParent[53:55])
+	return err == nil && flags[0]&0x01 != 0
+}
+
+func (t *Tracing) IsValid() bool {
+	return t != nil && validTraceParent(t.TraceParent)
+}
+
+// validTraceParent Version 00 Format (Future Versions are Accepted if Prefix Matches)
+func validTraceParent(s string) bool {
+	// Is Length Valid?
+	if len(s) < traceParentLength || (len(s) > traceParentLength && s[traceParentLength] != '-') { // NO
+		return false
+	}
+
+	parts := strings.Split(s[:traceParentLength], "-")
+	if len(parts) != 4 || len(parts[0]) != 2 || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return false
+	}
+
+	for _, p := range parts {
+		// Lower Case Hex Only
+		if _, err := hex.DecodeString(p); err != nil || strings.ToLower(p) != p {
+			return false
+		}
+	}
+
+	// Version 'ff' is Invalid, Version 00 has no Trailing Data
+	if parts[0] == "ff" || (parts[0] == "00" && len(s) != traceParentLength) {
+		return false
+	}
+
+	// All Zero IDs are Invalid
+	return strings.Trim(parts[1], "0") != "" && strings.Trim(parts[2], "0") != ""
+}
+
+// Tracing Trace Context Message was Published Under (nil if None)
+func (o *QueueMessageHeader) Tracing() *Tracing {
+	if o.tracing == nil {
+		return nil
+	}
+
+	t := *o.tracing
+	return &t
+}
+
+// SetTracing Attach Trace Context (nil: Clear)
+func (o *QueueMessageHeader) SetTracing(t *Tracing) error {
+	// Clear Tracing?
+	if t == nil { // YES
+		o.tracing = nil
+		return nil
+	}
+
+	v, err := ParseTracing(t.TraceParent, t.TraceState)
+	if err != nil {
+		return err
+	}
+
+	o.tracing = v
+	return nil
+}
+
+func (t *Tracing) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"traceparent":`...)
+	dst = appendJSONString(dst, t.TraceParent)
+	if t.TraceState != "" {
+		dst = append(dst, `,"tracestate":`...)
+		dst = appendJSONString(dst, t.TraceState)
+	}
+	return append(dst, '}')
+}
@@ -0,0 +1,770 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/objectvault/common/maps"
+)
+
+// Limits Applied when Decoding Messages from the Wire
+const (
+	MessageVersion = 1       // Highest Supported Message Version
+	MaxMessageSize = 4 << 20 // Maximum Encoded Message Size (bytes)
+	MaxMapDepth    = 16      // Maximum Nesting of Parameter/Property Maps
+	MaxMapEntries  = 4096    // Maximum Number of Values in a Parameter/Property Map
+
+	MaxHistoryEntries = 256 // Maximum Number of Header History Entries
+)
+
+// checkMap Verify Map Nesting Depth and Size are within Limits
+func checkMap(m map[string]interface{}) error {
+	count := 0
+	return checkValue(m, 1, &count)
+}
+
+func checkValue(v interface{}, depth int, count *int) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if depth > MaxMapDepth {
+			return errors.New("Map Nesting Exceeds Limit")
+		}
+
+		for _, e := range t {
+			*count++
+			if *count > MaxMapEntries {
+				return errors.New("Map Size Exceeds Limit")
+			}
+
+			if err := checkValue(e, depth+1, count); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		if depth > MaxMapDepth {
+			return errors.New("Map Nesting Exceeds Limit")
+		}
+
+		for _, e := range t {
+			*count++
+			if *count > MaxMapEntries {
+				return errors.New("Map Size Exceeds Limit")
+			}
+
+			if err := checkValue(e, depth+1, count); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// toMapWrapper Validate Map and Wrap it
+func toMapWrapper(source string, field string, m map[string]interface{}) (maps.MapWrapper, error) {
+	// Do we have a Map?
+	if m == nil { // NO: Empty Wrapper
+		return maps.MapWrapper{}, nil
+	}
+
+	if err := checkMap(m); err != nil {
+		return maps.MapWrapper{}, invalidField(source, field, err.Error())
+	}
+
+	return *maps.NewMapWrapper(m), nil
+}
+
+func (o *QueueMessageStatus) UnmarshalJSON(b []byte) error {
+	j := &statusDocument{}
+	err := json.Unmarshal(b, j)
+	if err != nil {
+		return fmt.Errorf("[QueueMessageStatus] Invalid JSON [%v]", err)
+	}
+
+	return o.fromDocument(j)
+}
+
+// fromDocument Validate Decoded Status and Copy it
+func (o *QueueMessageStatus) fromDocument(j *statusDocument) error {
+	extras, err := toMapWrapper("QueueMessageStatus", "extras", j.Extras)
+	if err != nil {
+		return err
+	}
+
+	o.errorCode = j.ErrorCode
+	o.errorMessage = strings.TrimSpace(j.ErrorMessage)
+	o.errorMessageI18N = strings.TrimSpace(j.ErrorMessageI18N)
+	o.extras = extras
+
+	// Is Attempt Valid?
+	if j.Attempt < 0 { // NO
+		return invalidField("QueueMessageStatus", "attempt", "negative")
+	}
+	o.attempt = j.Attempt
+	o.startedAt = nil
+	o.finishedAt = nil
+	if j.StartedAt != nil {
+		t := j.StartedAt.UTC()
+		o.startedAt = &t
+	}
+	if j.FinishedAt != nil {
+		t := j.FinishedAt.UTC()
+		o.finishedAt = &t
+	}
+	return nil
+}
+
+func (o *QueueMessageHeader) UnmarshalJSON(b []byte) error {
+	j := &headerDocument{}
+	err := json.Unmarshal(b, j)
+	if err != nil {
+		return fmt.Errorf("[QueueMessageHeader] Invalid JSON [%v]", err)
+	}
+
+	return o.fromDocument(j)
+}
+
+// fromDocument Validate Decoded Header and Copy it
+func (o *QueueMessageHeader) fromDocument(j *headerDocument) error {
+	// Is the Version Supported?
+	if j.Version < 1 || j.Version > MessageVersion { // NO
+		return fmt.Errorf("%w [%d]", ErrUnsupportedVersion, j.Version)
+	}
+
+	props, err := toMapWrapper("QueueMessageHeader", "props", j.Props)
+	if err != nil {
+		return err
+	}
+
+	// Is History within Limits?
+	if len(j.History) > MaxHistoryEntries { // NO
+		return invalidField("QueueMessageHeader", "history", "History Size Exceeds Limit")
+	}
+
+	var history []HistoryEntry
+	for _, e := range j.History {
+		e, err = normalizeHistoryEntry(e)
+		if err != nil {
+			return err
+		}
+		history = append(history, e)
+	}
+
+	var status *QueueMessageStatus
+	if j.Status != nil {
+		status = &QueueMessageStatus{}
+		if err = status.fromDocument(j.Status); err != nil {
+			return err
+		}
+	}
+
+	// NOTE: Invalid Trace Context is Dropped (Consumer Starts a New Trace)
+	var tracing *Tracing
+	if j.Tracing != nil {
+		tracing, _ = ParseTracing(j.Tracing.TraceParent, j.Tracing.TraceState)
+	}
+
+	o.version = j.Version
+	o.SetID(j.ID)
+	o.SetParent(j.Parent)
+	o.props = props
+	o.status = status
+	o.created = nil
+	if j.Created != nil {
+		t := j.Created.UTC()
+		o.created = &t
+	}
+	o.history = history
+	o.tracing = tracing
+
+	// Is the Header Valid?
+	if !o.IsValid() { // NO
+		return invalidField("QueueMessageHeader", "id", "missing")
+	}
+
+	return nil
+}
+
+// queueEnvelope Wire Envelope with Undecoded Sections
+type queueEnvelope struct {
+	Header *QueueMessageHeader `json:"header"`
+	Body   json.RawMessage     `json:"body"`
+}
+
+func decodeEnvelope(source string, b []byte) (*queueEnvelope, error) {
+	// Is the Message Within Size Limits?
+	if len(b) > MaxMessageSize { // NO
+		return nil, fmt.Errorf("%w [%s]", ErrMessageTooLarge, source)
+	}
+
+	e := &queueEnvelope{}
+	err := json.Unmarshal(b, e)
+	if err != nil {
+		return nil, fmt.Errorf("[%s] Invalid JSON [%w]", source, err)
+	}
+
+	// Do we have a Header?
+	if e.Header == nil { // NO
+		return nil, invalidField(source, "header", "missing")
+	}
+
+	// Do we have a Body?
+	if len(e.Body) == 0 || string(e.Body) == "null" { // NO
+		return nil, invalidField(source, "body", "missing")
+	}
+
+	return e, nil
+}
+
+func (o *QueueMessage) UnmarshalJSON(b []byte) error {
+	e, err := decodeEnvelope("QueueMessage", b)
+	if err != nil {
+		return err
+	}
+
+	var body interface{}
+	err = json.Unmarshal(e.Body, &body)
+	if err != nil {
+		return fmt.Errorf("[QueueMessage] Invalid Message Body [%v]", err)
+	}
+
+	return o.decoded(e.Header, body)
+}
+
+// UnmarshalDocument Decode Message from Wire Document (see UnmarshalJSON)
+func (o *QueueMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	e := &struct {
+		Header *headerDocument `json:"header"`
+		Body   interface{}     `json:"body"`
+	}{}
+
+	err := checkDocumentSize("QueueMessage", size)
+	if err != nil {
+		return err
+	}
+
+	err = decode(e)
+	if err != nil {
+		return fmt.Errorf("[QueueMessage] Invalid Document [%w]", err)
+	}
+
+	header, err := decodeHeader("QueueMessage", e.Header, e.Body != nil)
+	if err != nil {
+		return err
+	}
+	return o.decoded(header, e.Body)
+}
+
+// decoded Validate Decoded Body and Set Message
+func (o *QueueMessage) decoded(header *QueueMessageHeader, body interface{}) error {
+	count := 0
+	if err := checkValue(body, 1, &count); err != nil {
+		return invalidField("QueueMessage", "body", err.Error())
+	}
+
+	o.header = header
+	o.body = body
+	return runHooks(HookAfterUnmarshal, o)
+}
+
+func (o *ActionMessageContent) UnmarshalJSON(b []byte) error {
+	j := &actionContentDocument{}
+	err := json.Unmarshal(b, j)
+	if err != nil {
+		return fmt.Errorf("[ActionMessageContent] Invalid JSON [%v]", err)
+	}
+
+	return o.fromDocument(j)
+}
+
+// fromDocument Validate Decoded Action Content and Copy it
+func (o *ActionMessageContent) fromDocument(j *actionContentDocument) error {
+	params, err := toMapWrapper("ActionMessageContent", "params", j.Params)
+	if err != nil {
+		return err
+	}
+
+	props, err := toMapWrapper("ActionMessageContent", "props", j.Props)
+	if err != nil {
+		return err
+	}
+
+	o.SetType(j.Type)
+	o.params = params
+	o.props = props
+
+	// Is the Content Valid?
+	if !o.IsValid() { // NO
+		return invalidField("ActionMessageContent", "type", "missing")
+	}
+
+	return nil
+}
+
+func (o *ActionMessage) UnmarshalJSON(b []byte) error {
+	e, err := decodeEnvelope("ActionMessage", b)
+	if err != nil {
+		return err
+	}
+
+	c := &ActionMessageContent{}
+	err = json.Unmarshal(e.Body, c)
+	if err != nil {
+		return err
+	}
+
+	return o.decoded(e.Header, c)
+}
+
+// UnmarshalDocument Decode Action from Wire Document (see UnmarshalJSON)
+func (o *ActionMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	e := &struct {
+		Header *headerDocument        `json:"header"`
+		Body   *actionContentDocument `json:"body"`
+	}{}
+
+	err := checkDocumentSize("ActionMessage", size)
+	if err != nil {
+		return err
+	}
+
+	err = decode(e)
+	if err != nil {
+		return fmt.Errorf("[ActionMessage] Invalid Document [%w]", err)
+	}
+
+	header, err := decodeHeader("ActionMessage", e.Header, e.Body != nil)
+	if err != nil {
+		return err
+	}
+
+	c := &ActionMessageContent{}
+	if err = c.fromDocument(e.Body); err != nil {
+		return err
+	}
+	return o.decoded(header, c)
+}
+
+// decoded Check Decoded Content is an Action and Set Message
+func (o *ActionMessage) decoded(header *QueueMessageHeader, c *ActionMessageContent) error {
+	// Is it an Action?
+	if !strings.HasPrefix(c.Type(), "action:") { // NO
+		return invalidField("ActionMessage", "type", "not an action ["+c.Type()+"]")
+	}
+
+	o.header = header
+	o.body = c
+	return runHooks(HookAfterUnmarshal, &o.QueueMessage)
+}
+
+func (m *EmailMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *EmailMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *EmailMessage) checkType() error {
+	// Is it an Email?
+	if t := m.Type(); t != "action:email" && !strings.HasPrefix(t, "action:email:") { // NO
+		return invalidField("EmailMessage", "type", "not an email ["+t+"]")
+	}
+
+	return nil
+}
+
+func (m *InviteMessage) UnmarshalJSON(b []byte) error {
+	err := m.EmailMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *InviteMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.EmailMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *InviteMessage) checkType() error {
+	// Is it an Invitation?
+	if !strings.HasPrefix(m.Type(), "action:email:invite:") { // NO
+		return invalidField("InviteMessage", "type", "not an invitation ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *EmailBatchMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *EmailBatchMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *EmailBatchMessage) checkType() error {
+	// Is it an Email Batch?
+	if m.Type() != "action:email-batch" { // NO
+		return invalidField("EmailBatchMessage", "type", "not an email batch ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *InviteRevokedMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *InviteRevokedMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *InviteRevokedMessage) checkType() error {
+	// Is it an Invitation Revocation?
+	if m.Type() != "action:invite:revoked" { // NO
+		return invalidField("InviteRevokedMessage", "type", "not an invitation revocation ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *InviteResponseMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *InviteResponseMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *InviteResponseMessage) checkType() error {
+	// Is it an Invitation Response?
+	if m.Type() != "action:invite:response" { // NO
+		return invalidField("InviteResponseMessage", "type", "not an invitation response ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *BulkInviteMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *BulkInviteMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *BulkInviteMessage) checkType() error {
+	// Is it a Bulk Invitation?
+	if !strings.HasPrefix(m.Type(), "action:invite:bulk:") { // NO
+		return invalidField("BulkInviteMessage", "type", "not a bulk invitation ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *CacheInvalidationMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *CacheInvalidationMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *CacheInvalidationMessage) checkType() error {
+	// Is it a Cache Invalidation?
+	if m.Type() != "action:cache:invalidate" { // NO
+		return invalidField("CacheInvalidationMessage", "type", "not a cache invalidation ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *SessionInvalidationMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *SessionInvalidationMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *SessionInvalidationMessage) checkType() error {
+	// Is it a Session Invalidation?
+	if m.Type() != "action:session:invalidate" { // NO
+		return invalidField("SessionInvalidationMessage", "type", "not a session invalidation ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *KeyRotationMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *KeyRotationMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *KeyRotationMessage) checkType() error {
+	// Is it a Key Rotation?
+	if m.Type() != "action:key:rotate" { // NO
+		return invalidField("KeyRotationMessage", "type", "not a key rotation ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *StoreShareMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *StoreShareMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *StoreShareMessage) checkType() error {
+	// Is it a Store Share?
+	if m.Type() != "action:store:share" { // NO
+		return invalidField("StoreShareMessage", "type", "not a store share ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *BillingEventMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *BillingEventMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *BillingEventMessage) checkType() error {
+	// Is it a Billing Event?
+	if m.Type() != "action:billing:event" { // NO
+		return invalidField("BillingEventMessage", "type", "not a billing event ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *MaintenanceMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *MaintenanceMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *MaintenanceMessage) checkType() error {
+	// Is it a Maintenance Announcement?
+	if m.Type() != "action:maintenance" { // NO
+		return invalidField("MaintenanceMessage", "type", "not a maintenance announcement ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *ResultMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *ResultMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *ResultMessage) checkType() error {
+	// Is it a Result?
+	if m.Type() != "action:result" { // NO
+		return invalidField("ResultMessage", "type", "not a result ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *AdminCommandMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *AdminCommandMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *AdminCommandMessage) checkType() error {
+	// Is it an Administrative Command?
+	if m.Type() != "action:admin:command" { // NO
+		return invalidField("AdminCommandMessage", "type", "not an administrative command ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *PreferenceUpdateMessage) UnmarshalJSON(b []byte) error {
+	err := m.ActionMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *PreferenceUpdateMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.ActionMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *PreferenceUpdateMessage) checkType() error {
+	// Is it a Preference Update?
+	if m.Type() != "action:preferences:update" { // NO
+		return invalidField("PreferenceUpdateMessage", "type", "not a preference update ["+m.Type()+"]")
+	}
+
+	return nil
+}
+
+func (m *PasswordResetMessage) UnmarshalJSON(b []byte) error {
+	err := m.EmailMessage.UnmarshalJSON(b)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *PasswordResetMessage) UnmarshalDocument(size int, decode func(v interface{}) error) error {
+	err := m.EmailMessage.UnmarshalDocument(size, decode)
+	if err != nil {
+		return err
+	}
+	return m.checkType()
+}
+
+func (m *PasswordResetMessage) checkType() error {
+	// Is it a Password Reset?
+	if m.Type() != PasswordResetType { // NO
+		return invalidField("PasswordResetMessage", "type", "not a password reset ["+m.Type()+"]")
+	}
+
+	return nil
+}
@@ -0,0 +1,149 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/objectvault/queue-interface/fixtures"
+)
+
+// unmarshaler Message Decoded by Fuzz Targets
+type unmarshaler interface {
+	UnmarshalJSON(b []byte) error
+}
+
+// seedCorpus Every Fixture plus Hand Picked Edge Cases
+func seedCorpus(f *testing.F) {
+	for _, v := range fixtures.Versions() {
+		for _, n := range fixtures.Names(v) {
+			f.Add(fixtures.MustLoad(v, n))
+		}
+	}
+
+	f.Add([]byte(``))
+	f.Add([]byte(`null`))
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"header":null,"body":null}`))
+	f.Add([]byte(`{"header":{"version":1,"id":"x"},"body":{"type":"action:email:invite:store","params":[]}}`))
+	f.Add([]byte(`{"header":{"version":99,"id":"x","history":[{}]},"body":{"type":"action:x","props":{"a":{"b":[1,2,{"c":null}]}}}}`))
+}
+
+// fuzzUnmarshal Decoding must never Panic, and must Reject Messages over the Size Limit
+func fuzzUnmarshal(f *testing.F, create func() unmarshaler) {
+	seedCorpus(f)
+
+	f.Fuzz(func(t *testing.T, b []byte) {
+		m := create()
+		err := m.UnmarshalJSON(b)
+
+		// Is Message over the Size Limit?
+		if len(b) > MaxMessageSize { // YES
+			if !errors.Is(err, ErrMessageTooLarge) {
+				t.Fatalf("oversized message decoded [%v]", err)
+			}
+			return
+		}
+
+		// Decoded Messages must Encode (Errors are Fine, Panics are Not)
+		if err == nil {
+			json.Marshal(m)
+		}
+	})
+}
+
+func FuzzQueueMessageUnmarshal(f *testing.F) {
+	fuzzUnmarshal(f, func() unmarshaler { return &QueueMessage{} })
+}
+
+func FuzzActionMessageUnmarshal(f *testing.F) {
+	fuzzUnmarshal(f, func() unmarshaler { return &ActionMessage{} })
+}
+
+func FuzzEmailMessageUnmarshal(f *testing.F) {
+	fuzzUnmarshal(f, func() unmarshaler { return &EmailMessage{} })
+}
+
+func FuzzInviteMessageUnmarshal(f *testing.F) {
+	fuzzUnmarshal(f, func() unmarshaler { return &InviteMessage{} })
+}
+
+// limitTargets Message Types Checked against Decode Limits
+var limitTargets = map[string]func() unmarshaler{
+	"QueueMessage":  func() unmarshaler { return &QueueMessage{} },
+	"ActionMessage": func() unmarshaler { return &ActionMessage{} },
+	"EmailMessage":  func() unmarshaler { return &EmailMessage{} },
+	"InviteMessage": func() unmarshaler { return &InviteMessage{} },
+}
+
+// inviteWith Invite Message Document with Params
+func inviteWith(params string) []byte {
+	return []byte(`{"header":{"version":1,"id":"7a9c1e3b-5d7f-4a2c-8e4a-6c8e0a2c4e6a"},` +
+		`"body":{"type":"action:email:invite:store","params":` + params + `}}`)
+}
+
+// nested Object Nested 'depth' Levels Deep
+func nested(depth int) string {
+	return strings.Repeat(`{"a":`, depth) + `1` + strings.Repeat(`}`, depth)
+}
+
+func TestUnmarshalSizeLimit(t *testing.T) {
+	pad := `"` + strings.Repeat("x", MaxMessageSize) + `"`
+	b := inviteWith(`{"to":"invitee@example.com","pad":` + pad + `}`)
+
+	for name, create := range limitTargets {
+		if err := create().UnmarshalJSON(b); !errors.Is(err, ErrMessageTooLarge) {
+			t.Errorf("%s: err [%v], want ErrMessageTooLarge", name, err)
+		}
+	}
+}
+
+func TestUnmarshalDepthLimit(t *testing.T) {
+	// NOTE: QueueMessage Counts the Body Object as the First Level
+	ok := inviteWith(nested(MaxMapDepth - 1))
+	deep := inviteWith(nested(MaxMapDepth + 1))
+
+	for name, create := range limitTargets {
+		if err := create().UnmarshalJSON(ok); err != nil {
+			t.Errorf("%s: nesting within limit rejected [%v]", name, err)
+		}
+
+		err := create().UnmarshalJSON(deep)
+		var invalid *ErrInvalidMessage
+		if !errors.As(err, &invalid) || !strings.Contains(invalid.Reason, "Nesting") {
+			t.Errorf("%s: err [%v], want nesting error", name, err)
+		}
+	}
+}
+
+func TestUnmarshalEntriesLimit(t *testing.T) {
+	var b bytes.Buffer
+	b.WriteString(`{"list":[`)
+	for i := 0; i < MaxMapEntries; i++ {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteByte('0')
+	}
+	b.WriteString(`]}`)
+
+	for name, create := range limitTargets {
+		err := create().UnmarshalJSON(inviteWith(b.String()))
+		var invalid *ErrInvalidMessage
+		if !errors.As(err, &invalid) || !strings.Contains(invalid.Reason, "Size") {
+			t.Errorf("%s: err [%v], want size error", name, err)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"net/url"
+	"strings"
+)
+
+// List Unsubscribe Headers (RFC 2369 and RFC 8058)
+const (
+	HeaderListUnsubscribe     = "list-unsubscribe"
+	HeaderListUnsubscribePost = "list-unsubscribe-post"
+	ListUnsubscribeOneClick   = "List-Unsubscribe=One-Click"
+)
+
+// Unsubscribe List Unsubscribe Options of an Email
+type Unsubscribe struct {
+	MailTo   string // [OPTIONAL] Address Receiving Unsubscribe Requests
+	URL      string // [OPTIONAL] HTTPS Unsubscribe Endpoint
+	OneClick bool   // Endpoint Accepts One-Click POST (Requires URL)
+}
+
+// Unsubscribe Options Stored in Headers (nil if not Set)
+func (m *EmailMessage) Unsubscribe() *Unsubscribe {
+	h := m.Header(HeaderListUnsubscribe)
+	if h == "" {
+		return nil
+	}
+
+	u := &Unsubscribe{
+		OneClick: m.Header(HeaderListUnsubscribePost) == ListUnsubscribeOneClick,
+	}
+	for _, part := range strings.Split(h, ",") {
+		part = strings.Trim(strings.TrimSpace(part), "<>")
+		if strings.HasPrefix(strings.ToLower(part), "mailto:") {
+			u.MailTo = part[len("mailto:"):]
+		} else if part != "" {
+			u.URL = part
+		}
+	}
+	return u
+}
+
+// SetUnsubscribe Set List-Unsubscribe (and List-Unsubscribe-Post) Headers
+func (m *EmailMessage) SetUnsubscribe(u Unsubscribe) error {
+	u.MailTo = strings.TrimSpace(u.MailTo)
+	u.URL = strings.TrimSpace(u.URL)
+
+	// Do we have a Way to Unsubscribe?
+	if u.MailTo == "" && u.URL == "" { // NO
+		return invalidField("EmailMessage", HeaderListUnsubscribe, "mailto or url required")
+	}
+
+	parts := []string{}
+	if u.MailTo != "" {
+		// Is Address Plausible?
+		if strings.ContainsAny(u.MailTo, "<>, ") || !strings.Contains(u.MailTo, "@") { // NO
+			return invalidField("EmailMessage", HeaderListUnsubscribe, "invalid mailto ["+u.MailTo+"]")
+		}
+		parts = append(parts, "<mailto:"+u.MailTo+">")
+	}
+
+	if u.URL != "" {
+		// Is URL Valid?
+		p, err := url.Parse(u.URL)
+		if err != nil || (p.Scheme != "https" && p.Scheme != "http") || p.Host == "" { // NO
+			return invalidField("EmailMessage", HeaderListUnsubscribe, "invalid url ["+u.URL+"]")
+		}
+		parts = append(parts, "<"+u.URL+">")
+	}
+
+	// One-Click Requires an HTTPS Endpoint (RFC 8058)
+	if u.OneClick && !strings.HasPrefix(strings.ToLower(u.URL), "https://") {
+		return invalidField("EmailMessage", HeaderListUnsubscribePost, "one-click requires https url")
+	}
+
+	// NOTE: Header Values are Stored as Set (SetHeader Lower Cases Names only)
+	err := m.SetHeader(HeaderListUnsubscribe, strings.Join(parts, ", "))
+	if err != nil {
+		return err
+	}
+
+	if u.OneClick {
+		return m.SetHeader(HeaderListUnsubscribePost, ListUnsubscribeOneClick)
+	}
+	return m.ClearHeader(HeaderListUnsubscribePost)
+}
+
+// ClearUnsubscribe Remove List Unsubscribe Headers
+func (m *EmailMessage) ClearUnsubscribe() error {
+	err := m.ClearHeader(HeaderListUnsubscribe)
+	if err != nil {
+		return err
+	}
+	return m.ClearHeader(HeaderListUnsubscribePost)
+}
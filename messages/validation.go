@@ -0,0 +1,193 @@
+package messages
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Violation Codes
+const (
+	ViolationRequired = "required" // Field Missing
+	ViolationInvalid  = "invalid"  // Field Present but Wrong
+	ViolationType     = "type"     // Field has Wrong Type
+)
+
+// Violation Single Validation Failure
+type Violation struct {
+	Field   string `json:"field,omitempty"` // Offending Field ("" if the Message as a Whole)
+	Code    string `json:"code"`            // i.e. ViolationRequired
+	Message string `json:"message"`         // Description of Problem
+}
+
+func (v Violation) String() string {
+	if v.Field == "" {
+		return v.Code + ": " + v.Message
+	}
+	return v.Field + " " + v.Code + ": " + v.Message
+}
+
+// ValidationError Every Violation Found in a Message (use errors.As)
+//
+// errors.Is(err, &ErrInvalidMessage{Field: "to"}) Matches if any Violation is for Field.
+type ValidationError struct {
+	Message    string      // Message Type (i.e. "EmailMessage")
+	Violations []Violation // Violations Found (in Order Checked)
+}
+
+func (e *ValidationError) Error() string {
+	l := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		l = append(l, v.String())
+	}
+	return fmt.Sprintf("[%s] Is not valid: %s", e.Message, strings.Join(l, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	t, ok := target.(*ErrInvalidMessage)
+	if !ok || (t.Message != "" && t.Message != e.Message) {
+		return false
+	}
+
+	for _, v := range e.Violations {
+		if t.Field == "" || t.Field == v.Field {
+			return true
+		}
+	}
+	return false
+}
+
+// Has Is there a Violation for Field?
+func (e *ValidationError) Has(field string) bool {
+	for _, v := range e.Violations {
+		if v.Field == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Validator Checks a Message, Returning Violations Found (None: Valid)
+type Validator func(msg interface{}) []Violation
+
+// FieldValidator Validator Reporting Violation of Field when 'ok' Returns false
+func FieldValidator[T any](field string, code string, message string, ok func(m T) bool) Validator {
+	return func(msg interface{}) []Violation {
+		m, is := msg.(T)
+		if !is || ok(m) {
+			return nil
+		}
+		return []Violation{{Field: field, Code: code, Message: message}}
+	}
+}
+
+var validators struct {
+	lock   sync.RWMutex
+	byType map[reflect.Type][]Validator
+}
+
+// RegisterValidator Run Validators when Messages of Type T are Validated (i.e. T = *EmailMessage)
+func RegisterValidator[T any](v ...Validator) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+
+	validators.lock.Lock()
+	defer validators.lock.Unlock()
+
+	if validators.byType == nil {
+		validators.byType = make(map[reflect.Type][]Validator)
+	}
+	for _, f := range v {
+		if f != nil {
+			validators.byType[t] = append(validators.byType[t], f)
+		}
+	}
+}
+
+// ValidateMessage Validate Message with Registered Validators and its own Validate() Method
+//
+// Returns nil or *ValidationError Listing every Violation Found.
+func ValidateMessage(msg interface{}) error {
+	name := messageName(msg)
+
+	// Is Message Nil?
+	if msg == nil || (reflect.ValueOf(msg).Kind() == reflect.Ptr && reflect.ValueOf(msg).IsNil()) { // YES
+		return &ValidationError{Message: name, Violations: []Violation{{Code: ViolationRequired, Message: "nil message"}}}
+	}
+
+	validators.lock.RLock()
+	list := validators.byType[reflect.TypeOf(msg)]
+	validators.lock.RUnlock()
+
+	violations := []Violation{}
+	for _, v := range list {
+		violations = append(violations, v(msg)...)
+	}
+
+	// Can Message Validate Itself?
+	if m, ok := msg.(interface{ Validate() error }); ok { // YES
+		violations = appendViolations(violations, m.Validate())
+	} else if m, ok := msg.(interface{ IsValid() bool }); ok && len(violations) == 0 && !m.IsValid() {
+		violations = append(violations, Violation{Code: ViolationInvalid, Message: "failed validation"})
+	}
+
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Message: name, Violations: violations}
+}
+
+// appendViolations Append Violations Described by Error (nil: None)
+func appendViolations(l []Violation, err error) []Violation {
+	if err == nil {
+		return l
+	}
+
+	// Is it a List?
+	var ve *ValidationError
+	if errors.As(err, &ve) { // YES
+		return append(l, ve.Violations...)
+	}
+
+	// Is it a Single Field?
+	var ie *ErrInvalidMessage
+	if errors.As(err, &ie) { // YES
+		code := ViolationInvalid
+		if ie.Reason == ViolationRequired {
+			code = ViolationRequired
+		}
+		return append(l, Violation{Field: ie.Field, Code: code, Message: ie.Reason})
+	}
+
+	return append(l, Violation{Code: ViolationInvalid, Message: err.Error()})
+}
+
+// validationError nil if no Violations
+func validationError(message string, violations []Violation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+	return &ValidationError{Message: message, Violations: violations}
+}
+
+func messageName(msg interface{}) string {
+	t := reflect.TypeOf(msg)
+	if t == nil {
+		return "message"
+	}
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t.Name()
+}
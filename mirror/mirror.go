@@ -0,0 +1,212 @@
+package mirror
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// DefaultBufferSize Copies Waiting to be Published before New Copies are Dropped
+const DefaultBufferSize = 1024
+
+// Channel Used to Publish Copies
+const MirrorChannel = "mirror"
+
+var ErrNoPublisher = errors.New("[Mirror] Missing Publisher")
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+// Publisher Delivers Copies (Preferably a Connection Separate from the Consumer's)
+//
+// Copies Keep the Original's Properties, Headers and Content Encoding (see
+// queue.AMQPServerConnection.QueuePublishDelivery).
+type Publisher interface {
+	QueuePublishDelivery(channel string, queue string, d *amqp.Delivery) error
+}
+
+// Mirror Tees Consumed Messages into a Secondary Queue
+//
+// Copies are Published Asynchronously: a Slow or Failing Secondary Queue
+// never Delays or Fails the Handler, Copies are Dropped Instead.
+type Mirror struct {
+	publisher Publisher           // Copy Delivery
+	queue     string              // Secondary Queue
+	size      int                 // Buffer Size
+	lock      sync.Mutex          // State Lock
+	copies    chan *amqp.Delivery // Copies Waiting to be Published
+	done      chan struct{}       // Publisher Goroutine Finished
+	mirrored  uint64              // Copies Published
+	dropped   uint64              // Copies Dropped (Buffer Full or Mirror Stopped)
+	failed    uint64              // Copies that Failed to Publish
+	logger    queue.Logger        // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+func NewMirror(p Publisher, queue string) *Mirror {
+	return &Mirror{
+		publisher: p,
+		queue:     queue,
+		size:      DefaultBufferSize,
+	}
+}
+
+// SetBufferSize Set Copy Buffer Size (Applied on Next Start)
+func (m *Mirror) SetBufferSize(n int) *Mirror {
+	// Is Size Valid?
+	if n > 0 { // YES
+		m.size = n
+	}
+	return m
+}
+
+// Logger Mirror Logger (Standard Logger if not Set)
+func (m *Mirror) Logger() queue.Logger {
+	// Do we have a Logger?
+	if m.logger == nil { // NO: Use Standard Logger
+		return defaultLogger
+	}
+	return m.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (m *Mirror) SetLogger(l queue.Logger) *Mirror {
+	m.logger = l
+	return m
+}
+
+// Start Publisher Goroutine
+func (m *Mirror) Start() error {
+	// Do we have a Publisher?
+	if m.publisher == nil { // NO
+		return ErrNoPublisher
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Already Running?
+	if m.copies != nil { // YES
+		return nil
+	}
+
+	m.copies = make(chan *amqp.Delivery, m.size)
+	m.done = make(chan struct{})
+	go m.run(m.copies, m.done)
+	return nil
+}
+
+// Stop Publisher Goroutine after Publishing Buffered Copies
+func (m *Mirror) Stop() {
+	m.lock.Lock()
+	copies, done := m.copies, m.done
+	m.copies, m.done = nil, nil
+	m.lock.Unlock()
+
+	// Is Mirror Running?
+	if copies != nil { // YES
+		close(copies)
+		<-done
+	}
+}
+
+// Mirrored Number of Copies Published
+func (m *Mirror) Mirrored() uint64 {
+	return atomic.LoadUint64(&m.mirrored)
+}
+
+// Dropped Number of Copies Dropped
+func (m *Mirror) Dropped() uint64 {
+	return atomic.LoadUint64(&m.dropped)
+}
+
+// Failed Number of Copies that Failed to Publish
+func (m *Mirror) Failed() uint64 {
+	return atomic.LoadUint64(&m.failed)
+}
+
+// Tee Queue a Copy of the Delivery (Never Blocks)
+func (m *Mirror) Tee(d *amqp.Delivery) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Is Mirror Running?
+	if m.copies == nil { // NO
+		atomic.AddUint64(&m.dropped, 1)
+		return
+	}
+
+	c := copyDelivery(d)
+
+	select {
+	case m.copies <- c:
+	default:
+		atomic.AddUint64(&m.dropped, 1)
+	}
+}
+
+// Middleware Tee Every Message before Handing it to the Handler
+func (m *Mirror) Middleware() queue.Middleware {
+	return func(next queue.Handler) queue.Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			m.Tee(d)
+			return next(ctx, d)
+		}
+	}
+}
+
+func (m *Mirror) run(copies chan *amqp.Delivery, done chan struct{}) {
+	defer close(done)
+
+	for c := range copies {
+		m.publish(c)
+	}
+}
+
+func (m *Mirror) publish(c *amqp.Delivery) {
+	// Isolate Publisher Panics from the Consumer
+	defer func() {
+		if r := recover(); r != nil {
+			atomic.AddUint64(&m.failed, 1)
+			m.Logger().Error("[Mirror] Publisher Panic", queue.F("queue", m.queue), queue.F("panic", r))
+		}
+	}()
+
+	err := m.publisher.QueuePublishDelivery(MirrorChannel, m.queue, c)
+	if err != nil {
+		atomic.AddUint64(&m.failed, 1)
+		m.Logger().Error("[Mirror] Failed Publishing Copy", queue.F("queue", m.queue), queue.F("error", err))
+		return
+	}
+	atomic.AddUint64(&m.mirrored, 1)
+}
+
+// copyDelivery Detached Copy of Delivery (Handler may Modify the Original, Copy can't be Acked)
+func copyDelivery(d *amqp.Delivery) *amqp.Delivery {
+	c := *d
+	c.Acknowledger = nil
+	c.Body = append([]byte(nil), d.Body...)
+
+	// Do we have Headers?
+	if d.Headers != nil { // YES
+		c.Headers = amqp.Table{}
+		for k, v := range d.Headers {
+			c.Headers[k] = v
+		}
+	}
+	return &c
+}
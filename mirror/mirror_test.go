@@ -0,0 +1,96 @@
+package mirror
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/memqueue"
+	"github.com/objectvault/queue-interface/queue"
+)
+
+func TestTeeKeepsEncodingAndHeaders(t *testing.T) {
+	cmp, err := queue.CompressorFor(queue.EncodingGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := []byte(`{"header":{"version":1,"id":"1"},"body":{"text":"mirrored"}}`)
+	body, err := cmp.Compress(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b := memqueue.NewBroker()
+	m := NewMirror(b, "copies")
+	if err = m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	d := &amqp.Delivery{
+		Headers:         amqp.Table{"tenant": "acme"},
+		ContentType:     "application/json",
+		ContentEncoding: queue.EncodingGzip,
+		MessageId:       "msg-1",
+		Body:            body,
+	}
+	m.Tee(d)
+
+	// Handler Changes must not Reach the Copy
+	d.Body[0] = 0
+	d.Headers["tenant"] = "changed"
+	m.Stop()
+
+	if m.Mirrored() != 1 {
+		t.Fatalf("mirrored [%d], want 1", m.Mirrored())
+	}
+
+	c, err := b.QueueRetrieve(MirrorChannel, "copies")
+	if err != nil || c == nil {
+		t.Fatalf("copy not published [%v]", err)
+	}
+
+	if c.ContentEncoding != queue.EncodingGzip || c.MessageId != "msg-1" || c.Headers["tenant"] != "acme" {
+		t.Errorf("copy lost properties: %+v", c)
+	}
+
+	got, err := queue.DecodeBody(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, plain) {
+		t.Errorf("copy body [%s], want [%s]", got, plain)
+	}
+}
+
+func TestPublishFailuresUseLogger(t *testing.T) {
+	var out bytes.Buffer
+	b := memqueue.NewBroker().SetFailure(memqueue.FailOperation(memqueue.OpPublish))
+	m := NewMirror(b, "copies").SetLogger(queue.NewStdLogger(log.New(&out, "", 0), queue.LevelInfo))
+	if err := m.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	m.Tee(&amqp.Delivery{ContentType: "text/plain", Body: []byte("copy")})
+	m.Stop()
+
+	if m.Failed() != 1 {
+		t.Fatalf("failed [%d], want 1", m.Failed())
+	}
+	if !strings.Contains(out.String(), "[Mirror] Failed Publishing Copy") {
+		t.Errorf("failure not logged: %q", out.String())
+	}
+}
@@ -0,0 +1,336 @@
+package outbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// Relay Defaults
+const (
+	DefaultRelayInterval = time.Second // Wait between Relay Passes
+	DefaultBatchSize     = 100         // Maximum Records Published per Pass
+	DefaultClaimLease    = time.Minute // Time Claimed Records are Hidden from Other Relays
+	DefaultMaxAttempts   = 10          // Failed Publish Attempts before a Record is Marked Dead
+)
+
+var ErrNoStore = errors.New("[Outbox] Missing Store")
+var ErrNoPublisher = errors.New("[Outbox] Missing Publisher")
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+// Message Persisted before Publishing
+type Record struct {
+	ID          string    // Unique Record ID
+	Channel     string    // Channel Used to Publish
+	Queue       string    // Target Queue (Empty: Connection Default)
+	ContentType string    // Body Content Type
+	Body        []byte    // Encoded Message
+	CreatedAt   time.Time // Time Record was Created
+	Attempts    int       // Number of Failed Publish Attempts
+	LastError   string    // Last Publish Error
+	FailedAt    time.Time // Time Record was Marked Dead (Zero: Pending or Sent)
+}
+
+// Store Persists Outbox Records
+//
+// Claimed Records are Hidden from Other Relays until the Lease Expires, or
+// the Record is Marked Sent, Failed or Released.
+type Store interface {
+	Add(ctx context.Context, r *Record) error                                     // Persist New Record
+	Claim(ctx context.Context, limit int, lease time.Duration) ([]*Record, error) // Reserve Oldest Unsent, Unclaimed Records
+	Release(ctx context.Context, id string) error                                 // Return Claimed Record to Pending
+	MarkSent(ctx context.Context, id string) error                                // Record Published
+	MarkFailed(ctx context.Context, id string, cause error) error                 // Record Publish Attempt Failed (Releases Claim)
+	MarkDead(ctx context.Context, id string, cause error) error                   // Give Up on Record (Never Claimed Again)
+}
+
+// Publisher Delivers Encoded Messages (i.e. queue.AMQPServerConnection)
+type Publisher interface {
+	QueuePublish(channel string, queue string, contentType string, body []byte) error
+}
+
+type Outbox struct {
+	store     Store         // Record Persistence
+	publisher Publisher     // Message Delivery
+	interval  time.Duration // Wait between Relay Passes
+	batch     int           // Records per Relay Pass
+	lease     time.Duration // Time Records are Claimed for
+	attempts  int           // Failed Publish Attempts before a Record is Marked Dead
+	lock      sync.Mutex    // Relay State Lock
+	stop      chan struct{} // Signal Relay to Stop
+	done      chan struct{} // Relay has Stopped
+	logger    queue.Logger  // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+func NewOutbox(s Store, p Publisher) *Outbox {
+	return &Outbox{
+		store:     s,
+		publisher: p,
+		interval:  DefaultRelayInterval,
+		batch:     DefaultBatchSize,
+		lease:     DefaultClaimLease,
+		attempts:  DefaultMaxAttempts,
+	}
+}
+
+func (o *Outbox) Store() Store {
+	return o.store
+}
+
+func (o *Outbox) SetInterval(d time.Duration) *Outbox {
+	// Is Interval Valid?
+	if d > 0 { // YES
+		o.interval = d
+	}
+	return o
+}
+
+func (o *Outbox) SetBatchSize(n int) *Outbox {
+	// Is Batch Size Valid?
+	if n > 0 { // YES
+		o.batch = n
+	}
+	return o
+}
+
+// SetClaimLease Time a Relay Pass may Take before its Records are Claimed by Another Relay
+func (o *Outbox) SetClaimLease(d time.Duration) *Outbox {
+	// Is Lease Valid?
+	if d > 0 { // YES
+		o.lease = d
+	}
+	return o
+}
+
+// SetMaxAttempts Failed Publish Attempts before a Record is Marked Dead and Skipped
+func (o *Outbox) SetMaxAttempts(n int) *Outbox {
+	// Is Limit Valid?
+	if n > 0 { // YES
+		o.attempts = n
+	}
+	return o
+}
+
+// Logger Outbox Logger (Standard Logger if not Set)
+func (o *Outbox) Logger() queue.Logger {
+	// Do we have a Logger?
+	if o.logger == nil { // NO: Use Standard Logger
+		return defaultLogger
+	}
+	return o.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (o *Outbox) SetLogger(l queue.Logger) *Outbox {
+	o.logger = l
+	return o
+}
+
+// NewRecord Create Record for an Already Encoded Message
+func NewRecord(channel string, queue string, contentType string, body []byte) (*Record, error) {
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Record{
+		ID:          uid.String(),
+		Channel:     channel,
+		Queue:       queue,
+		ContentType: contentType,
+		Body:        body,
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// NewJSONRecord Create Record for a Message Encoded as JSON
+func NewJSONRecord(channel string, queue string, msg interface{}) (*Record, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewRecord(channel, queue, "application/json", body)
+}
+
+// AddJSON Persist Message (as JSON) for Later Publishing
+func (o *Outbox) AddJSON(ctx context.Context, channel string, queue string, msg interface{}) (*Record, error) {
+	// Do we have a Store?
+	if o.store == nil { // NO
+		return nil, ErrNoStore
+	}
+
+	r, err := NewJSONRecord(channel, queue, msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, o.store.Add(ctx, r)
+}
+
+// Relay Publish a Batch of Pending Records, Returns Number Published
+//
+// Records are Published in Order: a Failure Stops the Pass, and the Record is
+// Retried First on the Next. A Record that Failed for Reasons other than a
+// Lost Connection (i.e. Rejected by the Broker) 'max attempts' Times is Marked
+// Dead and Skipped, so it does not Block the Records Behind it.
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	// Do we have a Store and Publisher?
+	if o.store == nil { // NO
+		return 0, ErrNoStore
+	}
+	if o.publisher == nil { // NO
+		return 0, ErrNoPublisher
+	}
+
+	// NOTE: Claimed Records are Skipped by Concurrent Relays
+	records, err := o.store.Claim(ctx, o.batch, o.lease)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for i, r := range records {
+		err = o.publisher.QueuePublish(r.Channel, r.Queue, r.ContentType, r.Body)
+		if err != nil {
+			// Has Record Used up its Attempts?
+			if o.isDead(r, err) { // YES: Move it out of the Way
+				o.Logger().Error("[Outbox] Record Marked Dead", queue.F("record", r.ID), queue.F("attempts", r.Attempts+1), queue.F("error", err))
+				if derr := o.store.MarkDead(ctx, r.ID, err); derr != nil {
+					o.Logger().Error("[Outbox] Failed Marking Record as Dead", queue.F("record", r.ID), queue.F("error", derr))
+					return sent, derr
+				}
+				continue
+			}
+
+			// Keep Record for Next Pass (Preserve Order)
+			if ferr := o.store.MarkFailed(ctx, r.ID, err); ferr != nil {
+				o.Logger().Error("[Outbox] Failed Marking Record as Failed", queue.F("record", r.ID), queue.F("error", ferr))
+			}
+
+			// Release Unpublished Records (Expired Claims are Released Anyway)
+			for _, u := range records[i+1:] {
+				if rerr := o.store.Release(ctx, u.ID); rerr != nil {
+					o.Logger().Warn("[Outbox] Failed Releasing Record", queue.F("record", u.ID), queue.F("error", rerr))
+				}
+			}
+			return sent, err
+		}
+
+		// NOTE: Crash here Republishes Record (At Least Once Delivery)
+		err = o.store.MarkSent(ctx, r.ID)
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// isDead Should Record be Given Up on after Failed Publish?
+func (o *Outbox) isDead(r *Record, err error) bool {
+	// Was the Connection Lost?
+	switch queue.StatusCode(err) {
+	case queue.StatusNoServers, queue.StatusConnectFailed, queue.StatusNoConnection, queue.StatusPublishTimeout: // YES: Not the Record's Fault
+		return false
+	}
+
+	return r.Attempts+1 >= o.attempts
+}
+
+// Start Relay Goroutine
+func (o *Outbox) Start() error {
+	// Do we have a Store and Publisher?
+	if o.store == nil { // NO
+		return ErrNoStore
+	}
+	if o.publisher == nil { // NO
+		return ErrNoPublisher
+	}
+
+	o.lock.Lock()
+	defer o.lock.Unlock()
+
+	// Is Relay Already Running?
+	if o.stop != nil { // YES
+		return nil
+	}
+
+	o.stop = make(chan struct{})
+	o.done = make(chan struct{})
+	go o.run(o.stop, o.done)
+	return nil
+}
+
+// Stop Relay Goroutine and Wait for it to Finish
+func (o *Outbox) Stop() {
+	o.lock.Lock()
+	stop, done := o.stop, o.done
+	o.stop, o.done = nil, nil
+	o.lock.Unlock()
+
+	// Is Relay Running?
+	if stop != nil { // YES
+		close(stop)
+		<-done
+	}
+}
+
+func (o *Outbox) run(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	t := time.NewTicker(o.interval)
+	defer t.Stop()
+
+	for {
+		// Drain Full Batches without Waiting
+		for {
+			n, err := o.Relay(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					o.Logger().Error("[Outbox] Relay Failed", queue.F("error", err))
+				}
+				break
+			}
+			if n < o.batch {
+				break
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+	}
+}
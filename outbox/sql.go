@@ -0,0 +1,248 @@
+package outbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/objectvault/queue-interface/sqlstore"
+)
+
+// cSpell:ignore sqlstore varchar
+
+// DefaultTable Outbox Table Name
+const DefaultTable = "queue_outbox"
+
+// SQL Store Implementation (database/sql)
+type SQLStore struct {
+	db      *sql.DB           // Database Connection
+	table   string            // Outbox Table
+	dialect *sqlstore.Dialect // Database Dialect
+}
+
+func NewSQLStore(db *sql.DB, table string, d *sqlstore.Dialect) *SQLStore {
+	// Do we have a Table Name?
+	if table == "" { // NO: Use Default
+		table = DefaultTable
+	}
+
+	// Do we have a Dialect?
+	if d == nil { // NO: Use "?" Placeholders
+		d = sqlstore.DialectMySQL
+	}
+
+	return &SQLStore{
+		db:      db,
+		table:   table,
+		dialect: d,
+	}
+}
+
+func (s *SQLStore) p(n int) string {
+	return s.dialect.Placeholder(n)
+}
+
+// CreateTable Create Outbox Table if it does not Exist
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	q := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	channel VARCHAR(255) NOT NULL,
+	queue VARCHAR(255) NOT NULL,
+	content_type VARCHAR(255) NOT NULL,
+	body %s NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	sent_at TIMESTAMP NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error VARCHAR(1024) NULL,
+	claimed_until TIMESTAMP NULL,
+	failed_at TIMESTAMP NULL
+)`, s.table, s.dialect.BlobType)
+
+	_, err := s.db.ExecContext(ctx, q)
+	return err
+}
+
+// Add Persist Record (Outside of any Transaction)
+func (s *SQLStore) Add(ctx context.Context, r *Record) error {
+	return s.AddWith(ctx, s.db, r)
+}
+
+// AddTx Persist Record in the Same Transaction as the Business Data
+func (s *SQLStore) AddTx(ctx context.Context, tx *sql.Tx, r *Record) error {
+	return s.AddWith(ctx, tx, r)
+}
+
+// AddWith Persist Record using Given Executor
+func (s *SQLStore) AddWith(ctx context.Context, e sqlstore.Execer, r *Record) error {
+	q := fmt.Sprintf("INSERT INTO %s (id, channel, queue, content_type, body, created_at, attempts) VALUES (%s, %s, %s, %s, %s, %s, 0)",
+		s.table, s.p(1), s.p(2), s.p(3), s.p(4), s.p(5), s.p(6))
+
+	_, err := e.ExecContext(ctx, q, r.ID, r.Channel, r.Queue, r.ContentType, r.Body, r.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("[SQLStore] Failed Adding Record [%s]: %w", r.ID, err)
+	}
+	return nil
+}
+
+// Pending Oldest Unsent Records (Claimed or Not, Dead Records Excluded)
+func (s *SQLStore) Pending(ctx context.Context, limit int) ([]*Record, error) {
+	q := fmt.Sprintf("SELECT id, channel, queue, content_type, body, created_at, attempts, last_error, failed_at FROM %s WHERE sent_at IS NULL AND failed_at IS NULL ORDER BY created_at, id LIMIT %d",
+		s.table, limit)
+
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRecords(rows)
+}
+
+// Claim Reserve Oldest Unclaimed Records for 'lease'
+//
+// Candidates are Locked (SKIP LOCKED, if the Dialect Supports it) and each is
+// Claimed with a Conditional Update, so Concurrent Relays never Publish the
+// Same Record. Claims Expire, Records of a Crashed Relay are Published Again.
+func (s *SQLStore) Claim(ctx context.Context, limit int, lease time.Duration) ([]*Record, error) {
+	now := time.Now().UTC()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	q := fmt.Sprintf("SELECT id, channel, queue, content_type, body, created_at, attempts, last_error, failed_at FROM %s WHERE sent_at IS NULL AND failed_at IS NULL AND (claimed_until IS NULL OR claimed_until < %s) ORDER BY created_at, id LIMIT %d%s",
+		s.table, s.p(1), limit, s.dialect.SkipLocked)
+
+	rows, err := tx.QueryContext(ctx, q, now)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := scanRecords(rows)
+	rows.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	q = fmt.Sprintf("UPDATE %s SET claimed_until = %s WHERE id = %s AND sent_at IS NULL AND (claimed_until IS NULL OR claimed_until < %s)",
+		s.table, s.p(1), s.p(2), s.p(3))
+
+	records := []*Record{}
+	for _, r := range candidates {
+		res, err := tx.ExecContext(ctx, q, now.Add(lease), r.ID, now)
+		if err != nil {
+			return nil, err
+		}
+
+		// Did we Claim the Record?
+		n, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if n == 1 { // YES
+			records = append(records, r)
+		}
+	}
+
+	return records, tx.Commit()
+}
+
+// Release Return Claimed Record to Pending (without Counting an Attempt)
+func (s *SQLStore) Release(ctx context.Context, id string) error {
+	q := fmt.Sprintf("UPDATE %s SET claimed_until = NULL WHERE id = %s", s.table, s.p(1))
+	_, err := s.db.ExecContext(ctx, q, id)
+	return err
+}
+
+func scanRecords(rows *sql.Rows) ([]*Record, error) {
+	records := []*Record{}
+	for rows.Next() {
+		r := &Record{}
+		var lastError sql.NullString
+		var failedAt sql.NullTime
+		err := rows.Scan(&r.ID, &r.Channel, &r.Queue, &r.ContentType, &r.Body, &r.CreatedAt, &r.Attempts, &lastError, &failedAt)
+		if err != nil {
+			return nil, err
+		}
+		r.LastError = lastError.String
+		r.FailedAt = failedAt.Time
+		records = append(records, r)
+	}
+
+	return records, rows.Err()
+}
+
+func (s *SQLStore) MarkSent(ctx context.Context, id string) error {
+	q := fmt.Sprintf("UPDATE %s SET sent_at = %s, claimed_until = NULL WHERE id = %s", s.table, s.p(1), s.p(2))
+	_, err := s.db.ExecContext(ctx, q, time.Now().UTC(), id)
+	return err
+}
+
+func (s *SQLStore) MarkFailed(ctx context.Context, id string, cause error) error {
+	q := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = %s, claimed_until = NULL WHERE id = %s", s.table, s.p(1), s.p(2))
+	_, err := s.db.ExecContext(ctx, q, errorText(cause), id)
+	return err
+}
+
+// MarkDead Count Failed Attempt and Stop Relaying Record (see Dead and Revive)
+func (s *SQLStore) MarkDead(ctx context.Context, id string, cause error) error {
+	q := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = %s, claimed_until = NULL, failed_at = %s WHERE id = %s", s.table, s.p(1), s.p(2), s.p(3))
+	_, err := s.db.ExecContext(ctx, q, errorText(cause), time.Now().UTC(), id)
+	return err
+}
+
+// Dead Oldest Records Marked Dead
+func (s *SQLStore) Dead(ctx context.Context, limit int) ([]*Record, error) {
+	q := fmt.Sprintf("SELECT id, channel, queue, content_type, body, created_at, attempts, last_error, failed_at FROM %s WHERE failed_at IS NOT NULL ORDER BY created_at, id LIMIT %d",
+		s.table, limit)
+
+	rows, err := s.db.QueryContext(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanRecords(rows)
+}
+
+// Revive Return Dead Record to Pending (Attempts are Reset)
+func (s *SQLStore) Revive(ctx context.Context, id string) error {
+	q := fmt.Sprintf("UPDATE %s SET attempts = 0, failed_at = NULL WHERE id = %s AND failed_at IS NOT NULL", s.table, s.p(1))
+	_, err := s.db.ExecContext(ctx, q, id)
+	return err
+}
+
+// errorText Publish Error Truncated to Fit Column
+func errorText(cause error) string {
+	msg := ""
+	if cause != nil {
+		msg = cause.Error()
+		if len(msg) > 1024 {
+			msg = msg[:1024]
+		}
+	}
+	return msg
+}
+
+// Purge Remove Records Sent before Given Time
+func (s *SQLStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	q := fmt.Sprintf("DELETE FROM %s WHERE sent_at IS NOT NULL AND sent_at < %s", s.table, s.p(1))
+	res, err := s.db.ExecContext(ctx, q, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
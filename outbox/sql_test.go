@@ -0,0 +1,244 @@
+package outbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore mattn sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/objectvault/queue-interface/queue"
+	"github.com/objectvault/queue-interface/sqlstore"
+)
+
+// testStore SQLite Store with 'n' Pending Records (Oldest First)
+func testStore(t *testing.T, n int) *SQLStore {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db.SetMaxOpenConns(1) // NOTE: Every Connection Opens its Own In Memory Database
+	t.Cleanup(func() { db.Close() })
+
+	s := NewSQLStore(db, "", sqlstore.DialectSQLite)
+	ctx := context.Background()
+	if err = s.CreateTable(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	created := time.Now().UTC().Add(-time.Hour)
+	for i := 0; i < n; i++ {
+		r, err := NewRecord("", "jobs", "text/plain", []byte(fmt.Sprintf("message %d", i)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		r.CreatedAt = created.Add(time.Duration(i) * time.Second)
+		if err = s.Add(ctx, r); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+// bodies Record Bodies in Order
+func bodies(records []*Record) []string {
+	b := []string{}
+	for _, r := range records {
+		b = append(b, string(r.Body))
+	}
+	return b
+}
+
+func TestClaimHidesClaimedRecords(t *testing.T) {
+	s := testStore(t, 3)
+	ctx := context.Background()
+
+	first, err := s.Claim(ctx, 2, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(bodies(first)) != "[message 0 message 1]" {
+		t.Fatalf("first claim %v", bodies(first))
+	}
+
+	// Concurrent Relay only Sees Unclaimed Records
+	second, err := s.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(bodies(second)) != "[message 2]" {
+		t.Fatalf("second claim %v", bodies(second))
+	}
+
+	// Released and Failed Records are Claimable Again, Sent Records are Not
+	if err = s.Release(ctx, first[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.MarkFailed(ctx, first[1].ID, errors.New("failed")); err != nil {
+		t.Fatal(err)
+	}
+	if err = s.MarkSent(ctx, second[0].ID); err != nil {
+		t.Fatal(err)
+	}
+
+	third, err := s.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(bodies(third)) != "[message 0 message 1]" {
+		t.Fatalf("third claim %v", bodies(third))
+	}
+	if third[1].Attempts != 1 || third[1].LastError != "failed" {
+		t.Errorf("failed record %+v", third[1])
+	}
+}
+
+func TestClaimExpiredLease(t *testing.T) {
+	s := testStore(t, 1)
+	ctx := context.Background()
+
+	// Relay Crashed: Lease Already Expired
+	if _, err := s.Claim(ctx, 10, -time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := s.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 {
+		t.Errorf("%d records reclaimed, want 1", len(records))
+	}
+}
+
+// failingPublisher Fails Publishing after 'ok' Messages
+type failingPublisher struct {
+	ok        int
+	published []string
+}
+
+func (p *failingPublisher) QueuePublish(channel string, queue string, contentType string, body []byte) error {
+	if len(p.published) == p.ok {
+		return errors.New("publish failed")
+	}
+	p.published = append(p.published, string(body))
+	return nil
+}
+
+func TestRelayReleasesUnpublishedRecords(t *testing.T) {
+	s := testStore(t, 3)
+	ctx := context.Background()
+
+	p := &failingPublisher{ok: 1}
+	sent, err := NewOutbox(s, p).Relay(ctx)
+	if err == nil || sent != 1 {
+		t.Fatalf("sent [%d] err [%v], want 1 and error", sent, err)
+	}
+
+	// Next Pass Resumes in Order
+	records, err := s.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fmt.Sprint(bodies(records)) != "[message 1 message 2]" {
+		t.Errorf("pending after failure %v", bodies(records))
+	}
+}
+
+// rejectingPublisher Fails Publishing 'reject' Body with 'err'
+type rejectingPublisher struct {
+	reject    string
+	err       error
+	published []string
+}
+
+func (p *rejectingPublisher) QueuePublish(channel string, queue string, contentType string, body []byte) error {
+	if string(body) == p.reject {
+		return p.err
+	}
+	p.published = append(p.published, string(body))
+	return nil
+}
+
+func TestRelaySkipsDeadRecords(t *testing.T) {
+	s := testStore(t, 3)
+	ctx := context.Background()
+
+	p := &rejectingPublisher{reject: "message 0", err: errors.New("rejected")}
+	o := NewOutbox(s, p).SetMaxAttempts(2)
+
+	// First Failure Blocks Pass (Order is Preserved)
+	if sent, err := o.Relay(ctx); err == nil || sent != 0 {
+		t.Fatalf("first pass sent [%d] err [%v], want 0 and error", sent, err)
+	}
+
+	// Last Attempt Marks Record Dead, Records Behind it are Published
+	sent, err := o.Relay(ctx)
+	if err != nil || sent != 2 {
+		t.Fatalf("second pass sent [%d] err [%v], want 2", sent, err)
+	}
+	if fmt.Sprint(p.published) != "[message 1 message 2]" {
+		t.Errorf("published %v", p.published)
+	}
+
+	dead, err := s.Dead(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(dead) != 1 || string(dead[0].Body) != "message 0" || dead[0].Attempts != 2 || dead[0].LastError != "rejected" || dead[0].FailedAt.IsZero() {
+		t.Fatalf("dead records %+v", dead)
+	}
+
+	// Dead Records are not Relayed until Revived
+	if pending, _ := s.Pending(ctx, 10); len(pending) != 0 {
+		t.Fatalf("pending %v, want none", bodies(pending))
+	}
+	if err = s.Revive(ctx, dead[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	pending, err := s.Pending(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Attempts != 0 {
+		t.Errorf("pending after revive %+v", pending)
+	}
+}
+
+func TestRelayKeepsRecordsWhileDisconnected(t *testing.T) {
+	s := testStore(t, 1)
+	ctx := context.Background()
+
+	p := &rejectingPublisher{reject: "message 0", err: queue.ErrNoConnection}
+	o := NewOutbox(s, p).SetMaxAttempts(1)
+	for i := 0; i < 3; i++ {
+		if _, err := o.Relay(ctx); err == nil {
+			t.Fatalf("pass %d: no error", i)
+		}
+	}
+
+	// Lost Connection is not the Record's Fault
+	if dead, _ := s.Dead(ctx, 10); len(dead) != 0 {
+		t.Fatalf("dead records %v, want none", bodies(dead))
+	}
+	if pending, _ := s.Pending(ctx, 10); len(pending) != 1 {
+		t.Fatalf("pending %v, want 1 record", bodies(pending))
+	}
+}
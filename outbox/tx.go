@@ -0,0 +1,146 @@
+package outbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var ErrTxClosed = errors.New("[TxPublisher] Transaction Already Committed or Rolled Back")
+
+type pendingMessage struct {
+	channel     string
+	queue       string
+	contentType string
+	body        []byte
+}
+
+// TxPublisher Buffers Messages until the Database Transaction Commits
+type TxPublisher struct {
+	publisher Publisher        // Message Delivery
+	lock      sync.Mutex       // Buffer Lock
+	pending   []pendingMessage // Messages Waiting for Commit
+	closed    bool             // Committed or Rolled Back
+}
+
+// PublishAfterCommit Create Buffer that Publishes only when Commit is Signalled
+func PublishAfterCommit(p Publisher) *TxPublisher {
+	return &TxPublisher{
+		publisher: p,
+	}
+}
+
+// Publish Buffer Pre-Encoded Message
+func (t *TxPublisher) Publish(channel string, queue string, contentType string, body []byte) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Is Transaction Still Open?
+	if t.closed { // NO
+		return ErrTxClosed
+	}
+
+	t.pending = append(t.pending, pendingMessage{channel, queue, contentType, body})
+	return nil
+}
+
+// PublishJSON Buffer Message Encoded as JSON (Encoding Errors Reported Immediately)
+func (t *TxPublisher) PublishJSON(channel string, queue string, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return t.Publish(channel, queue, "application/json", body)
+}
+
+// Len Number of Buffered Messages
+func (t *TxPublisher) Len() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.pending)
+}
+
+// Commit Publish Buffered Messages (in Order, Stops at First Failure)
+func (t *TxPublisher) Commit() error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Is Transaction Still Open?
+	if t.closed { // NO
+		return ErrTxClosed
+	}
+	t.closed = true
+
+	// Do we have a Publisher?
+	if t.publisher == nil { // NO
+		return ErrNoPublisher
+	}
+
+	for i, m := range t.pending {
+		err := t.publisher.QueuePublish(m.channel, m.queue, m.contentType, m.body)
+		if err != nil {
+			return fmt.Errorf("[TxPublisher] Published %d of %d Messages: %w", i, len(t.pending), err)
+		}
+	}
+
+	t.pending = nil
+	return nil
+}
+
+// Rollback Discard Buffered Messages
+func (t *TxPublisher) Rollback() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.closed = true
+	t.pending = nil
+}
+
+// WithTx Run 'fn' in a Database Transaction, Publishing Buffered Messages only if the Transaction Commits
+//
+// If 'fn' Panics the Transaction is Rolled Back (and Messages Discarded)
+// before the Panic is Propagated.
+func WithTx(ctx context.Context, db *sql.DB, p Publisher, fn func(tx *sql.Tx, pub *TxPublisher) error) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	pub := PublishAfterCommit(p)
+	committed := false
+	defer func() {
+		// Did we Finish without Committing (Error or Panic)?
+		if !committed { // YES: Discard Everything
+			pub.Rollback()
+			tx.Rollback()
+		}
+	}()
+
+	err = fn(tx, pub)
+	if err != nil {
+		return err
+	}
+
+	// Did Database Commit Succeed?
+	err = tx.Commit()
+	if err != nil { // NO: Discard Messages
+		return err
+	}
+	committed = true
+
+	return pub.Commit()
+}
@@ -0,0 +1,61 @@
+package outbox
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+)
+
+func TestWithTxRollsBackOnPanic(t *testing.T) {
+	s := testStore(t, 0)
+	p := &failingPublisher{ok: 10}
+
+	func() {
+		defer func() {
+			if r := recover(); r != "boom" {
+				t.Errorf("recovered [%v], want boom", r)
+			}
+		}()
+
+		WithTx(context.Background(), s.db, p, func(tx *sql.Tx, pub *TxPublisher) error {
+			r, err := NewRecord("", "jobs", "text/plain", []byte("in tx"))
+			if err != nil {
+				return err
+			}
+			if err = s.AddTx(context.Background(), tx, r); err != nil {
+				return err
+			}
+			if err = pub.Publish("", "jobs", "text/plain", []byte("after commit")); err != nil {
+				return err
+			}
+			panic("boom")
+		})
+	}()
+
+	if len(p.published) != 0 {
+		t.Errorf("published %v after panic", p.published)
+	}
+
+	// NOTE: Store has a Single Connection, a Leaked Transaction Blocks it
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	records, err := s.Claim(ctx, 10, time.Minute)
+	if err != nil {
+		t.Fatalf("connection not released [%v]", err)
+	}
+	if len(records) != 0 {
+		t.Errorf("%d records persisted after panic", len(records))
+	}
+}
@@ -0,0 +1,72 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"time"
+)
+
+// AutoAckChannel Channel Used by Auto-Ack Consumers
+const AutoAckChannel = "auto-ack"
+
+// ConsumeAutoAck Fire and Forget Push Consumer, Runs until Context is Cancelled
+//
+// The Broker Considers Messages Delivered as soon as they are Sent: Handler
+// Errors, Crashes and Connection Loss all LOSE Messages. Only Use for Low
+// Value, High Volume Messages (i.e. Metrics, Presence Pings) where the
+// Throughput Gain Outweighs Occasional Loss, Use ConsumeReliable Otherwise.
+//
+// Returns ErrConsumerClosed if the Broker Stops Deliveries (i.e. Connection Lost
+// without a Monitor Running to Redeclare the Consumer).
+func (c *AMQPServerConnection) ConsumeAutoAck(ctx context.Context, queue string, h Handler, mw ...Middleware) error {
+	deliveries, tag, err := c.QueueSubscribeAutoAck(AutoAckChannel, queue, "")
+	if err != nil {
+		return err
+	}
+
+	handler := Chain(h, mw...)
+	for {
+		select {
+		case <-ctx.Done():
+			if err := c.CancelConsumer(tag); err != nil {
+				c.Logger().Warn("[ConsumeAutoAck] Failed Cancelling Consumer", F("tag", tag), F("error", err))
+			}
+			return nil
+
+		case d, ok := <-deliveries:
+			// Did Broker Stop Deliveries?
+			if !ok { // YES
+				return ErrConsumerClosed
+			}
+
+			// NOTE: Message is already Acknowledged, Failures can only be Reported
+			start := time.Now()
+			hctx, settled := WithSettlement(context.Background())
+			err := handler(hctx, &d)
+			if err != nil {
+				c.Logger().Error("[ConsumeAutoAck] Message Lost", F("queue", queue), F("error", err))
+				settled(OutcomeDropped)
+				c.sendReceipt(queue, &d, OutcomeDropped, err, time.Since(start))
+				continue
+			}
+			settled(OutcomeAcked)
+			c.sendReceipt(queue, &d, OutcomeAcked, nil, time.Since(start))
+		}
+	}
+}
+
+// AutoAckConsumer ConsumeAutoAck as a Consumer (Run with RunConsumers)
+func (c *AMQPServerConnection) AutoAckConsumer(queue string, h Handler, mw ...Middleware) Consumer {
+	return func(ctx context.Context) error {
+		return c.ConsumeAutoAck(ctx, queue, h, mw...)
+	}
+}
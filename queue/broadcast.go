@@ -0,0 +1,224 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// BroadcastChannel Channel Used to Declare and Publish to Broadcast Exchanges
+const BroadcastChannel = "broadcast"
+
+// DeclareBroadcast Create Fanout Exchange (Name is Prefixed like Queues)
+func (c *AMQPServerConnection) DeclareBroadcast(exchange string) error {
+	name, err := c.queueName(exchange)
+	if err != nil {
+		return err
+	}
+
+	ch, err := c.OpenChannel(BroadcastChannel)
+	if err != nil {
+		return err
+	}
+
+	err = ch.ExchangeDeclare(
+		name,                // name
+		amqp.ExchangeFanout, // type
+		true,                // durable
+		false,               // auto-delete
+		false,               // internal
+		false,               // no-wait
+		nil,                 // arguments
+	)
+	if err != nil {
+		c.Logger().Error("[DeclareBroadcast] Failed Declaring Exchange", F("exchange", name), F("error", err))
+		c.status.recordError(err)
+		c.dropChannel(BroadcastChannel)
+	}
+	return err
+}
+
+// BroadcastPublish Publish Pre-Encoded Message to every Subscriber of Fanout Exchange
+func (c *AMQPServerConnection) BroadcastPublish(exchange string, contentType string, body []byte, opts ...PublishOption) error {
+	return c.broadcast("BroadcastPublish", exchange, contentType, body, c.publishOptions(opts))
+}
+
+// BroadcastPublishJSON Publish Message Encoded as JSON to every Subscriber of Fanout Exchange
+func (c *AMQPServerConnection) BroadcastPublishJSON(exchange string, msg interface{}, opts ...PublishOption) error {
+	o := c.publishOptions(opts)
+	if err := o.applyMessage(msg); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return c.broadcast("BroadcastPublishJSON", exchange, "application/json", body, o)
+}
+
+func (c *AMQPServerConnection) broadcast(caller string, exchange string, contentType string, body []byte, o *PublishOptions) error {
+	name, err := c.queueName(exchange)
+	if err != nil {
+		return err
+	}
+
+	p := &amqp.Publishing{}
+
+	p.ContentType = contentType
+	p.Body = body
+	o.applyPublishing(p)
+
+	// Is this a Dry Run?
+	if o.dryRun() { // YES: Don't Touch the Broker
+		return c.skipPublish(caller, p)
+	}
+
+	ch, err := c.OpenChannel(BroadcastChannel)
+	if err != nil {
+		return err
+	}
+
+	return c.publishTo(caller, ch, name, "", p)
+}
+
+// BroadcastSubscribe Receive every Message Published to Fanout Exchange from now on
+//
+// Each Subscriber gets its own Exclusive Queue, Deleted when the Subscriber Stops.
+func (c *AMQPServerConnection) BroadcastSubscribe(exchange string) (<-chan amqp.Delivery, string, error) {
+	name, err := c.queueName(exchange)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// NOTE: Dedicated Channel, Exclusive Queue Dies with it
+	tag := c.NewConsumerTag(exchange)
+	return c.register(&subscription{
+		channel: BroadcastChannel + "." + tag,
+		queue:   exchange,
+		tag:     tag,
+		declare: func(s *subscription) (*amqp.Channel, <-chan amqp.Delivery, error) {
+			return c.consumeBroadcast(s, name)
+		},
+	})
+}
+
+// consumeBroadcast Declare Exclusive Queue Bound to Exchange and Consume it
+//
+// A Redeclared Subscriber Gets a New Queue: Broadcasts Sent while it was Down are Lost.
+func (c *AMQPServerConnection) consumeBroadcast(s *subscription, name string) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := c.OpenChannel(s.channel)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	q, err := ch.QueueDeclare(
+		"",    // name (Server Generated)
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err == nil {
+		err = ch.QueueBind(q.Name, "", name, false, nil)
+	}
+	if err != nil {
+		c.Logger().Error("[BroadcastSubscribe] Failed Subscribing to Exchange", F("exchange", name), F("error", err))
+		c.status.recordError(err)
+		c.dropChannel(s.channel)
+		return nil, nil, err
+	}
+
+	deliveries, err := ch.Consume(
+		q.Name, // queue
+		s.tag,  // consumer tag
+		false,  // auto-ack
+		true,   // exclusive
+		false,  // no-local
+		false,  // no-wait
+		nil,    // arguments
+	)
+	if err != nil {
+		c.status.recordError(err)
+		c.dropChannel(s.channel)
+		return nil, nil, err
+	}
+	return ch, deliveries, nil
+}
+
+// BroadcastConsumer Consumer Handling Broadcasts until Cancelled (Run with RunConsumers)
+func (c *AMQPServerConnection) BroadcastConsumer(exchange string, h Handler, mw ...Middleware) Consumer {
+	return func(ctx context.Context) error {
+		deliveries, tag, err := c.BroadcastSubscribe(exchange)
+		if err != nil {
+			return err
+		}
+
+		handler := Chain(h, mw...)
+		for {
+			select {
+			case <-ctx.Done():
+				if err := c.CancelConsumer(tag); err != nil {
+					c.Logger().Warn("[BroadcastConsumer] Failed Cancelling Consumer", F("tag", tag), F("error", err))
+				}
+				// Closing Channel Deletes Exclusive Queue
+				if ch := c.getChannel(BroadcastChannel + "." + tag); ch != nil {
+					ch.Close()
+				}
+				c.dropChannel(BroadcastChannel + "." + tag)
+				return nil
+
+			case d, ok := <-deliveries:
+				// Did Broker Stop Deliveries?
+				if !ok { // YES
+					return ErrConsumerClosed
+				}
+
+				if err := c.handleDelivery(context.Background(), exchange, &d, handler); err != nil {
+					c.Logger().Warn("[BroadcastConsumer] Broadcast Failed", F("exchange", exchange), F("error", err))
+				}
+			}
+		}
+	}
+}
+
+// announce Validate Message and Broadcast it (Exchange is Declared on First Use)
+func (c *AMQPServerConnection) announce(exchange string, msg interface{}, opts ...PublishOption) error {
+	// Is the Message Valid?
+	if err := validate(msg); err != nil { // NO
+		return err
+	}
+
+	err := c.DeclareBroadcast(exchange)
+	if err != nil {
+		return err
+	}
+
+	return c.BroadcastPublishJSON(exchange, msg, opts...)
+}
+
+// typedBroadcastConsumer Broadcast Consumer Decoding Messages before Handling (Undecodable: Quarantined)
+func typedBroadcastConsumer[T any](c *AMQPServerConnection, exchange string, h TypedHandler[T], mw ...Middleware) Consumer {
+	return c.BroadcastConsumer(exchange, func(ctx context.Context, d *amqp.Delivery) error {
+		// Can Message be Decoded?
+		msg, err := decodeDelivery[T](d, c.Codec())
+		if err != nil { // NO: Quarantine
+			return QuarantineError(err)
+		}
+
+		return h(ctx, msg, d)
+	}, mw...)
+}
@@ -0,0 +1,186 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore zstd klauspost
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// Compressor Compresses Bodies for a Content Encoding
+//
+// gzip, deflate and zstd are Built-in, Register Others to Use them.
+type Compressor interface {
+	Encoding() string // AMQP Content Encoding (i.e. "gzip")
+	Compress(data []byte) ([]byte, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+// Compressor Registry
+var (
+	compressorLock sync.RWMutex
+	compressors    = map[string]Compressor{}
+)
+
+// RegisterCompressor Add Compressor to Registry (Replaces Compressor for the Same Encoding)
+func RegisterCompressor(c Compressor) {
+	compressorLock.Lock()
+	defer compressorLock.Unlock()
+	compressors[strings.ToLower(c.Encoding())] = c
+}
+
+// CompressorFor Compressor Registered for Content Encoding
+func CompressorFor(encoding string) (Compressor, error) {
+	compressorLock.RLock()
+	defer compressorLock.RUnlock()
+
+	c, ok := compressors[strings.ToLower(strings.TrimSpace(encoding))]
+	if !ok {
+		return nil, fmt.Errorf("[Compressor] Unsupported Content Encoding [%s]", encoding)
+	}
+	return c, nil
+}
+
+type gzipCompressor struct{}
+
+func (gzipCompressor) Encoding() string {
+	return EncodingGzip
+}
+
+func (gzipCompressor) Compress(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w := gzip.NewWriter(&b)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (gzipCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type deflateCompressor struct{}
+
+func (deflateCompressor) Encoding() string {
+	return EncodingDeflate
+}
+
+func (deflateCompressor) Compress(data []byte) ([]byte, error) {
+	var b bytes.Buffer
+	w, err := flate.NewWriter(&b, flate.DefaultCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = w.Write(data); err != nil {
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (deflateCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return flate.NewReader(r), nil
+}
+
+// zstd Encoder is Safe for Concurrent EncodeAll Calls
+var zstdEncoder, _ = zstd.NewWriter(nil)
+
+type zstdCompressor struct{}
+
+func (zstdCompressor) Encoding() string {
+	return EncodingZstd
+}
+
+func (zstdCompressor) Compress(data []byte) ([]byte, error) {
+	return zstdEncoder.EncodeAll(data, nil), nil
+}
+
+func (zstdCompressor) NewReader(r io.Reader) (io.ReadCloser, error) {
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// compress Compress Body (if Compression is On, the Body is over the Threshold and not Encoded Already)
+func (c *AMQPServerConnection) compress(p *amqp.Publishing) error {
+	d := c.Defaults()
+
+	// Should Body be Compressed?
+	encoding := d.GetCompression()
+	if encoding == "" || p.ContentEncoding != "" || len(p.Body) < d.GetCompressThreshold() { // NO
+		return nil
+	}
+
+	cmp, err := CompressorFor(encoding)
+	if err != nil {
+		return err
+	}
+
+	body, err := cmp.Compress(p.Body)
+	if err != nil {
+		return fmt.Errorf("[Compressor] %s Compression Failed: %w", encoding, err)
+	}
+
+	// Did Compression Help?
+	if len(body) >= len(p.Body) { // NO: Send as Is
+		return nil
+	}
+
+	p.ContentEncoding = cmp.Encoding()
+	p.Body = body
+	return nil
+}
+
+// decompress Read Compressed Body (Bounded by messages.MaxMessageSize)
+func decompress(cmp Compressor, data []byte) ([]byte, error) {
+	r, err := cmp.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("[DecodeBody] Invalid %s Body: %w", cmp.Encoding(), err)
+	}
+	defer r.Close()
+
+	body, err := io.ReadAll(io.LimitReader(r, int64(messages.MaxMessageSize)+1))
+	if err != nil {
+		return nil, fmt.Errorf("[DecodeBody] Invalid %s Body: %w", cmp.Encoding(), err)
+	}
+
+	// Is Body within Size Limit?
+	if len(body) > messages.MaxMessageSize { // NO: Don't Inflate any Further
+		return nil, fmt.Errorf("[DecodeBody] %w [%s]", ErrMessageTooLarge, cmp.Encoding())
+	}
+	return body, nil
+}
+
+func init() {
+	RegisterCompressor(gzipCompressor{})
+	RegisterCompressor(deflateCompressor{})
+	RegisterCompressor(zstdCompressor{})
+}
@@ -0,0 +1,73 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore zstd
+
+import (
+	"bytes"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+func TestCompressorRoundTrip(t *testing.T) {
+	body := bytes.Repeat([]byte(`{"header":{"type":"action"},"body":{"text":"hello"}}`), 100)
+
+	for _, encoding := range []string{EncodingGzip, EncodingDeflate, EncodingZstd} {
+		t.Run(encoding, func(t *testing.T) {
+			cmp, err := CompressorFor(encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			compressed, err := cmp.Compress(body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(compressed) >= len(body) {
+				t.Fatalf("expected smaller body, got %d >= %d bytes", len(compressed), len(body))
+			}
+
+			decoded, err := DecodeBody(&amqp.Delivery{ContentEncoding: encoding, Body: compressed})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(decoded, body) {
+				t.Fatal("decoded body differs from original")
+			}
+		})
+	}
+}
+
+func TestZstdBodyLimit(t *testing.T) {
+	cmp, err := CompressorFor(EncodingZstd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	compressed, err := cmp.Compress(make([]byte, messages.MaxMessageSize+1))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = DecodeBody(&amqp.Delivery{ContentEncoding: EncodingZstd, Body: compressed}); err == nil {
+		t.Fatal("expected oversized body to be rejected")
+	}
+}
+
+func TestZstdInvalidBody(t *testing.T) {
+	if _, err := DecodeBody(&amqp.Delivery{ContentEncoding: EncodingZstd, Body: []byte("not zstd")}); err == nil {
+		t.Fatal("expected invalid body error")
+	}
+}
@@ -0,0 +1,106 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"sync"
+)
+
+// DefaultWorkers Goroutines Handling Deliveries of a Pooled Consumer
+const DefaultWorkers = 4
+
+// ConsumeOptions Pooled Push Consumer Settings
+type ConsumeOptions struct {
+	Tag      string // [OPTIONAL] Consumer Tag ("": Generated)
+	Workers  int    // [OPTIONAL] Concurrent Handlers (0: DefaultWorkers)
+	Prefetch int    // [OPTIONAL] Unacknowledged Messages Held (0: Twice the Workers)
+}
+
+// Consume Push Consumer Handling Deliveries with a Pool of Goroutines, Runs until Context is Cancelled
+//
+// Each Delivery is Acknowledged when the Handler Returns nil, Otherwise it is
+// Settled as per the Error Policy (see QuarantineError and DeadLetterError).
+// Deliveries are Handled Concurrently, so Ordering is NOT Preserved. Use a
+// Dispatcher as Handler to Route Messages by Type.
+//
+// Returns ErrConsumerClosed if the Broker Stops Deliveries (i.e. Connection Lost
+// without a Monitor Running to Redeclare the Consumer).
+func (c *AMQPServerConnection) Consume(ctx context.Context, channel string, queue string, h Handler, opts *ConsumeOptions, mw ...Middleware) error {
+	if opts == nil {
+		opts = &ConsumeOptions{}
+	}
+
+	// Do we have a Worker Count?
+	workers := opts.Workers
+	if workers <= 0 { // NO: Use Default
+		workers = DefaultWorkers
+	}
+
+	// Do we have a Prefetch Limit?
+	prefetch := opts.Prefetch
+	if prefetch <= 0 { // NO: Keep every Worker Busy
+		prefetch = 2 * workers
+	}
+
+	// NOTE: QoS is Reapplied if a Monitor Redeclares the Consumer
+	deliveries, tag, err := c.subscribe(channel, queue, opts.Tag, false, prefetch)
+	if err != nil {
+		return err
+	}
+
+	handler := Chain(h, mw...)
+
+	// Start Workers (Exit once Deliveries Channel is Closed)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for d := range deliveries {
+				d := d
+
+				// NOTE: In-Flight Message Completes even if Shutdown Starts
+				if err := c.handleDelivery(context.Background(), queue, &d, handler); err != nil {
+					c.Logger().Warn("[Consume] Message Failed", F("queue", queue), F("error", err))
+				}
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-ctx.Done():
+		// NOTE: Unacknowledged Prefetched Messages are Redelivered to Other Consumers
+		if err := c.CancelConsumer(tag); err != nil {
+			c.Logger().Warn("[Consume] Failed Cancelling Consumer", F("tag", tag), F("error", err))
+		}
+
+		// Wait for In-Flight Handlers
+		<-done
+		return nil
+
+	case <-done: // Broker Stopped Deliveries
+		return ErrConsumerClosed
+	}
+}
+
+// PoolConsumer Consume as a Consumer (Run with RunConsumers)
+func (c *AMQPServerConnection) PoolConsumer(channel string, queue string, h Handler, opts *ConsumeOptions, mw ...Middleware) Consumer {
+	return func(ctx context.Context) error {
+		return c.Consume(ctx, channel, queue, h, opts, mw...)
+	}
+}
@@ -0,0 +1,179 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// cSpell:ignore dlq dlx
+
+// Queue Types (x-queue-type)
+const (
+	QueueTypeClassic = "classic"
+	QueueTypeQuorum  = "quorum"
+)
+
+// QueueOptions Arguments Applied when Declaring a Queue
+type QueueOptions struct {
+	DeadLetterExchange     string     // [OPTIONAL] Exchange Receiving Rejected/Expired Messages ("": Default Exchange)
+	DeadLetterRoutingKey   string     // [OPTIONAL] Dead Letter Routing Key ("": Dead Letter Queue Name, Ignored if Declared Queue uses Default Exchange)
+	DeclareDeadLetterQueue bool       // Declare (and Bind) the Dead Letter Queue "<queue>-dlq"
+	MaxRetries             int        // [OPTIONAL] Deliveries before Dead Lettering (x-delivery-limit, Forces Quorum Queue; 0: Unlimited)
+	QueueType              string     // [OPTIONAL] QueueTypeClassic or QueueTypeQuorum ("": Broker Default)
+	Arguments              amqp.Table // [OPTIONAL] Extra Declare Arguments
+}
+
+// DeadLetterQueueName Dead Letter Queue (Before Prefix) for Queue
+func DeadLetterQueueName(queue string) string {
+	return queue + "-dlq"
+}
+
+// HasDeadLetter Do Options Route Failed Messages Somewhere?
+func (o *QueueOptions) HasDeadLetter() bool {
+	return o != nil && (o.DeadLetterExchange != "" || o.DeadLetterRoutingKey != "" || o.DeclareDeadLetterQueue)
+}
+
+// OpenQueueChannelWithOptions Open Channel to Queue, Declaring it (and its Dead Letter Queue) with Options
+//
+// NOTE: RabbitMQ Refuses to Redeclare an Existing Queue with Different
+// Arguments (PRECONDITION_FAILED), Existing Queues must be Deleted or have
+// Dead Lettering Applied through a Policy.
+func (c *AMQPServerConnection) OpenQueueChannelWithOptions(name string, queue string, opts *QueueOptions) (*amqp.Channel, error) {
+	// Do we have Options?
+	if opts == nil { // NO: Plain Declare
+		return c.OpenQueueChannel(name, queue, true)
+	}
+
+	args, err := c.queueArguments(queue, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Should we Declare the Dead Letter Queue?
+	if opts.DeclareDeadLetterQueue { // YES: Before Queue so Dead Letters are never Dropped
+		if err = c.declareDeadLetterQueue(name, queue, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	return c.openQueueChannel(name, queue, true, args)
+}
+
+// queueArguments Declare Arguments for Queue from Options
+func (c *AMQPServerConnection) queueArguments(queue string, opts *QueueOptions) (amqp.Table, error) {
+	args := amqp.Table{}
+	for k, v := range opts.Arguments {
+		args[k] = v
+	}
+
+	// Dead Lettering
+	if opts.HasDeadLetter() {
+		exchange, key, err := c.deadLetterRoute(queue, opts)
+		if err != nil {
+			return nil, err
+		}
+
+		args["x-dead-letter-exchange"] = exchange
+		args["x-dead-letter-routing-key"] = key
+	}
+
+	// Delivery Limit (Quorum Queues Only)
+	qtype := opts.QueueType
+	if opts.MaxRetries > 0 {
+		args["x-delivery-limit"] = int64(opts.MaxRetries)
+		if qtype == "" {
+			qtype = QueueTypeQuorum
+		}
+	}
+
+	if qtype != "" {
+		args["x-queue-type"] = qtype
+	}
+
+	return args, nil
+}
+
+// deadLetterRoute Dead Letter Exchange and Routing Key (Prefix Applied)
+func (c *AMQPServerConnection) deadLetterRoute(queue string, opts *QueueOptions) (string, string, error) {
+	exchange := ""
+	if opts.DeadLetterExchange != "" {
+		name, err := c.queueName(opts.DeadLetterExchange)
+		if err != nil {
+			return "", "", err
+		}
+		exchange = name
+	}
+
+	// Do we have a Routing Key?
+	// NOTE: Default Exchange Routes by Queue Name, so a Declared Dead Letter Queue Dictates the Key
+	key := opts.DeadLetterRoutingKey
+	if key == "" || (exchange == "" && opts.DeclareDeadLetterQueue) { // NO: Route to Dead Letter Queue
+		name, err := c.queueName(DeadLetterQueueName(queue))
+		if err != nil {
+			return "", "", err
+		}
+		key = name
+	}
+
+	return exchange, key, nil
+}
+
+// declareDeadLetterQueue Declare "<queue>-dlq" (and Dead Letter Exchange and Binding if not Default Exchange)
+func (c *AMQPServerConnection) declareDeadLetterQueue(name string, queue string, opts *QueueOptions) error {
+	exchange, key, err := c.deadLetterRoute(queue, opts)
+	if err != nil {
+		return err
+	}
+
+	dlq, err := c.queueName(DeadLetterQueueName(queue))
+	if err != nil {
+		return err
+	}
+
+	ch, err := c.OpenChannel(name)
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.QueueDeclare(
+		dlq,   // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+
+	// Routed through an Exchange?
+	if err == nil && exchange != "" { // YES: Declare and Bind it
+		err = ch.ExchangeDeclare(
+			exchange, // name
+			"direct", // type
+			true,     // durable
+			false,    // auto-deleted
+			false,    // internal
+			false,    // no-wait
+			nil,      // arguments
+		)
+		if err == nil {
+			err = ch.QueueBind(dlq, key, exchange, false, nil)
+		}
+	}
+
+	if err != nil {
+		c.Logger().Error("[OpenQueueChannelWithOptions] Failed Declaring Dead Letter Queue", F("queue", dlq), F("error", err))
+		c.status.recordError(err)
+		c.dropChannel(name)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/codec"
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// Content Encodings Understood by DecodeDelivery
+const (
+	EncodingIdentity = ""
+	EncodingGzip     = "gzip"
+	EncodingDeflate  = "deflate"
+	EncodingZstd     = "zstd"
+)
+
+// DecodeDelivery Decode Delivery Body into Validated Message
+//
+// Dispatches on the Content Type to the Registered Codec (Empty: JSON, Unknown:
+// *codec.ErrUnsupportedContentType), Undoes the Content Encoding, Decodes
+// the Body, Fills Header Fields Carried by AMQP Properties and Validates the
+// Result. Pointer Types (i.e. *messages.EmailMessage) are Allocated.
+func DecodeDelivery[T any](d *amqp.Delivery) (T, error) {
+	return decodeDelivery[T](d, nil)
+}
+
+// decodeDelivery DecodeDelivery using 'fallback' Codec (if not nil) for Deliveries without Content Type
+func decodeDelivery[T any](d *amqp.Delivery, fallback codec.Codec) (T, error) {
+	var msg T
+
+	// Pick Codec from Content Type
+	cdc, err := DeliveryCodec(d, fallback)
+	if err != nil {
+		return msg, err
+	}
+
+	body, err := DecodeBody(d)
+	if err != nil {
+		return msg, err
+	}
+
+	// Allocate Pointer Types (i.e. *InviteMessage)
+	var target interface{} = &msg
+	if t := reflect.TypeOf(msg); t == nil {
+		t = reflect.TypeOf((*T)(nil)).Elem()
+		if t.Kind() == reflect.Ptr {
+			msg = reflect.New(t.Elem()).Interface().(T)
+			target = msg
+		}
+	}
+
+	err = cdc.Unmarshal(body, target)
+	if err != nil {
+		return msg, fmt.Errorf("[DecodeDelivery] Decoding %T from [%s]: %w", msg, d.RoutingKey, err)
+	}
+
+	// Fill Header Fields Carried Only by AMQP Properties
+	MapFromDelivery(d, target)
+
+	if err = messages.RunHooks(messages.HookAfterConsume, target); err != nil {
+		return msg, err
+	}
+
+	// Is the Message Valid?
+	if err = validate(msg); err != nil { // NO
+		return msg, err
+	}
+
+	return msg, nil
+}
+
+// DeliveryCodec Codec Registered for Delivery's Content Type (Empty: 'fallback', or JSON if nil)
+//
+// Returns *codec.ErrUnsupportedContentType if no Registered Codec Decodes the Content Type.
+func DeliveryCodec(d *amqp.Delivery, fallback codec.Codec) (codec.Codec, error) {
+	// Do we have a Content Type?
+	if strings.TrimSpace(d.ContentType) == "" { // NO: Assume Fallback or JSON
+		if fallback != nil {
+			return fallback, nil
+		}
+		return codec.JSON, nil
+	}
+
+	return codec.ForContentType(d.ContentType)
+}
+
+// DecodeInto Decode Delivery Body into 'v' (Pointer) with the Codec Matching its Content Type
+func DecodeInto(d *amqp.Delivery, v interface{}) error {
+	cdc, err := DeliveryCodec(d, nil)
+	if err != nil {
+		return err
+	}
+
+	body, err := DecodeBody(d)
+	if err != nil {
+		return err
+	}
+
+	err = cdc.Unmarshal(body, v)
+	if err != nil {
+		return fmt.Errorf("[DecodeInto] Decoding %T from [%s]: %w", v, d.RoutingKey, err)
+	}
+
+	MapFromDelivery(d, v)
+
+	if err = messages.RunHooks(messages.HookAfterConsume, v); err != nil {
+		return err
+	}
+	return validate(v)
+}
+
+// DecodeMessage Decode Delivery Body into the Message Type Registered for it (see messages.Decode)
+//
+// Bodies are Decoded with the Codec for their Content Type. Codecs that can't
+// Produce a Generic Document (i.e. Protobuf) Return *codec.ErrUnsupportedContentType.
+func DecodeMessage(d *amqp.Delivery) (messages.IMessage, error) {
+	cdc, err := DeliveryCodec(d, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Can Body be Read as a Document?
+	if cdc.ContentType() == codec.ContentTypeProtobuf { // NO
+		return nil, &codec.ErrUnsupportedContentType{ContentType: d.ContentType}
+	}
+
+	body, err := DecodeBody(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Registry Types Decode from JSON
+	body, err = codec.ToJSON(cdc, body)
+	if err != nil {
+		return nil, fmt.Errorf("[DecodeMessage] Decoding Message from [%s]: %w", d.RoutingKey, err)
+	}
+
+	msg, err := messages.Decode(body)
+	if err != nil {
+		return nil, fmt.Errorf("[DecodeMessage] Decoding Message from [%s]: %w", d.RoutingKey, err)
+	}
+
+	MapFromDelivery(d, msg)
+
+	if err = messages.RunHooks(messages.HookAfterConsume, msg); err != nil {
+		return nil, err
+	}
+	if err = validate(msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// deliveryBody Delivery Body with Content Encoding Removed (nil if it can't be Removed)
+func deliveryBody(d *amqp.Delivery) []byte {
+	body, err := DecodeBody(d)
+	if err != nil {
+		return nil
+	}
+	return body
+}
+
+// DecodeBody Delivery Body with Content Encoding Removed (see RegisterCompressor)
+func DecodeBody(d *amqp.Delivery) ([]byte, error) {
+	// Is Body Encoded?
+	switch strings.ToLower(strings.TrimSpace(d.ContentEncoding)) {
+	case EncodingIdentity, "identity": // NO
+		return d.Body, nil
+	}
+
+	cmp, err := CompressorFor(d.ContentEncoding)
+	if err != nil {
+		return nil, fmt.Errorf("[DecodeBody] Unsupported Content Encoding [%s]", d.ContentEncoding)
+	}
+	return decompress(cmp, d.Body)
+}
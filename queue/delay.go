@@ -0,0 +1,127 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore requeue, requeues
+import (
+	"encoding/json"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DelayChannel Channel Used for Delayed Requeues
+const DelayChannel = "delay"
+
+// HeaderDelayCount AMQP Header Counting Delayed Requeues
+const HeaderDelayCount = "x-delay-count"
+
+// RequeueAfter Ack Delivery and Return it to its Queue after 'delay'
+//
+// The Copy Waits in a "<queue>-delay-<delay>" Queue whose Expired Messages are
+// Dead Lettered back to the Original Exchange and Routing Key. msg nil Requeues
+// the Original Body, otherwise msg (i.e. with Updated Status) is Sent as JSON.
+func (c *AMQPServerConnection) RequeueAfter(d *amqp.Delivery, msg interface{}, delay time.Duration) error {
+	// Is there a Delay?
+	if delay < time.Millisecond { // NO: Plain Requeue
+		return d.Nack(false, true)
+	}
+
+	ch, err := c.OpenChannel(DelayChannel)
+	if err != nil {
+		return err
+	}
+
+	// Wait Queue Name (Distinguish Exchanges other than Default)
+	name := d.RoutingKey + "-delay-" + delayLabel(delay)
+	if d.Exchange != "" {
+		name = d.Exchange + "." + name
+	}
+
+	_, err = ch.QueueDeclare(
+		name,  // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		amqp.Table{
+			"x-message-ttl":             delay.Milliseconds(),
+			"x-dead-letter-exchange":    d.Exchange,   // Expired Messages go Back...
+			"x-dead-letter-routing-key": d.RoutingKey, // ...to where they Came From
+		},
+	)
+	if err != nil {
+		c.Logger().Error("[RequeueAfter] Failed Declaring Delay Queue", F("queue", name), F("error", err))
+		c.status.recordError(err)
+		c.dropChannel(DelayChannel)
+		return err
+	}
+
+	p := &amqp.Publishing{}
+
+	copyDelivery(p, d)
+	p.Headers[HeaderDelayCount] = int32(delayCount(d) + 1)
+
+	// Replace Body?
+	if msg != nil { // YES
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		p.ContentType = "application/json"
+		p.ContentEncoding = ""
+		p.Body = body
+	}
+
+	// Remove Original only once Copy is Waiting
+	err = c.publishTo("RequeueAfter", ch, "", name, p)
+	if err != nil {
+		return err
+	}
+	return d.Ack(false)
+}
+
+// PublishingFromDelivery Publishing Carrying Delivery's Properties, Headers and (Encoded) Body
+func PublishingFromDelivery(d *amqp.Delivery) *amqp.Publishing {
+	p := &amqp.Publishing{}
+	copyDelivery(p, d)
+	return p
+}
+
+// copyDelivery Initialize Publishing from Delivery (Properties, Headers and Body)
+func copyDelivery(p *amqp.Publishing, d *amqp.Delivery) {
+	p.Headers = amqp.Table{}
+	for k, v := range d.Headers {
+		p.Headers[k] = v
+	}
+	p.ContentType = d.ContentType
+	p.ContentEncoding = d.ContentEncoding
+	p.DeliveryMode = d.DeliveryMode
+	p.Priority = d.Priority
+	p.CorrelationId = d.CorrelationId
+	p.MessageId = d.MessageId
+	p.Timestamp = d.Timestamp
+	p.Type = d.Type
+	p.AppId = d.AppId
+	p.Body = d.Body
+}
+
+func delayCount(d *amqp.Delivery) int {
+	switch v := d.Headers[HeaderDelayCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
@@ -0,0 +1,156 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/codec"
+)
+
+// Dispatcher Handler Routing Deliveries to Handlers Registered for the Message Type
+//
+// Types are Matched Most Specific First, i.e. "action:email:invite" is Handled
+// by the "action:email:invite" Handler, or else "action:email", or else
+// "action". Messages without a Matching Handler go to the Fallback, or are
+// Quarantined (ErrUnknownMessageType) if there is None.
+type Dispatcher struct {
+	lock     sync.RWMutex       // Handlers Lock
+	handlers map[string]Handler // Message Type -> Handler
+	fallback Handler            // [OPTIONAL] Handler for Unregistered Types
+	codec    codec.Codec        // [OPTIONAL] Codec for Deliveries without Content Type (nil: JSON)
+}
+
+func NewDispatcher() *Dispatcher {
+	return &Dispatcher{
+		handlers: map[string]Handler{},
+	}
+}
+
+// SetCodec Codec for Deliveries without Content Type (i.e. Connection Codec)
+func (r *Dispatcher) SetCodec(cdc codec.Codec) *Dispatcher {
+	r.codec = cdc
+	return r
+}
+
+// SetFallback Handler for Messages of Unregistered Types (nil: Quarantine)
+func (r *Dispatcher) SetFallback(h Handler) *Dispatcher {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.fallback = h
+	return r
+}
+
+// Register Raw Handler for Message Type (Replaces Handler for Same Type)
+func (r *Dispatcher) Register(messageType string, h Handler) *Dispatcher {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.handlers[strings.ToLower(strings.TrimSpace(messageType))] = h
+	return r
+}
+
+// Types Message Types with Registered Handlers
+func (r *Dispatcher) Types() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	l := make([]string, 0, len(r.handlers))
+	for t := range r.handlers {
+		l = append(l, t)
+	}
+	return l
+}
+
+// On Register Handler Receiving Messages Decoded as T, i.e.
+//
+//	queue.On(d, "action:email:invite", func(ctx context.Context, m *messages.InviteMessage, d *amqp.Delivery) error { ... })
+//
+// Messages that can't be Decoded as T are Quarantined as Retrying won't Help.
+func On[T any](r *Dispatcher, messageType string, h TypedHandler[T]) *Dispatcher {
+	return r.Register(messageType, func(ctx context.Context, d *amqp.Delivery) error {
+		msg, err := decodeDelivery[T](d, r.codec)
+		if err != nil {
+			return QuarantineError(err)
+		}
+
+		return h(ctx, msg, d)
+	})
+}
+
+// Handle Route Delivery to the Handler for its Message Type (Dispatcher is a Handler)
+func (r *Dispatcher) Handle(ctx context.Context, d *amqp.Delivery) error {
+	t, err := MessageType(d, r.codec)
+	if err != nil {
+		return QuarantineError(err)
+	}
+
+	// Do we have a Handler?
+	h := r.handler(t)
+	if h == nil { // NO
+		return QuarantineError(fmt.Errorf("%w [%s]", ErrUnknownMessageType, t))
+	}
+
+	return h(ctx, d)
+}
+
+// handler Most Specific Handler for Type (Fallback or nil if None)
+func (r *Dispatcher) handler(t string) Handler {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for t != "" {
+		if h, ok := r.handlers[t]; ok {
+			return h
+		}
+
+		// Try Parent Type (i.e. action:email:invite -> action:email)
+		i := strings.LastIndex(t, ":")
+		if i < 0 {
+			break
+		}
+		t = t[:i]
+	}
+
+	return r.fallback
+}
+
+// MessageType Type of Message Carried by Delivery (Body "type" of the Queue Message Envelope)
+//
+// Deliveries without Content Type are Decoded with 'fallback' (nil: JSON).
+func MessageType(d *amqp.Delivery, fallback codec.Codec) (string, error) {
+	cdc, err := DeliveryCodec(d, fallback)
+	if err != nil {
+		return "", err
+	}
+
+	body, err := DecodeBody(d)
+	if err != nil {
+		return "", err
+	}
+
+	var envelope struct {
+		Body struct {
+			Type string `json:"type"`
+		} `json:"body"`
+	}
+	if err = cdc.Unmarshal(body, &envelope); err != nil {
+		return "", fmt.Errorf("[MessageType] Decoding Message from [%s]: %w", d.RoutingKey, err)
+	}
+
+	return strings.ToLower(strings.TrimSpace(envelope.Body.Type)), nil
+}
@@ -0,0 +1,206 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// cSpell:ignore dlq
+
+// ReplayChannel Channel Used for Dead Letter Replay
+const ReplayChannel = "dlq-replay"
+
+// RequeueCountProperty Header Property Counting Message Requeues
+const RequeueCountProperty = messages.PropertyRequeueCount
+
+// ReplayFilter Select Dead Letters to Replay (nil: Replay All)
+type ReplayFilter func(d *amqp.Delivery) bool
+
+// ReplayOptions Changes Applied to Replayed Messages
+type ReplayOptions struct {
+	ClearStatus   bool // Remove Error Status from Message Header
+	ResetRequeues bool // Remove Requeue Count (and NextRetryAt) from Message Header Properties
+}
+
+// ReplayReport Result of a Replay
+type ReplayReport struct {
+	Scanned  int // Messages Read from Dead Letter Queue
+	Replayed int // Messages Republished
+	Skipped  int // Messages Rejected by Filter (Left in Dead Letter Queue)
+}
+
+// OriginalQueue Queue a Dead Letter was Rejected From (x-death Header)
+func OriginalQueue(d *amqp.Delivery) string {
+	// Did RabbitMQ Record the First Death?
+	if q, ok := d.Headers["x-first-death-queue"].(string); ok && q != "" { // YES
+		return q
+	}
+
+	// Use Most Recent Death
+	if deaths, ok := d.Headers["x-death"].([]interface{}); ok && len(deaths) > 0 {
+		if death, ok := deaths[0].(amqp.Table); ok {
+			if q, ok := death["queue"].(string); ok {
+				return q
+			}
+		}
+	}
+
+	return ""
+}
+
+// ReplayDeadLetters Move Messages from Dead Letter Queue Back to 'target' ("": Original Queue), Stops after 'limit' (0: No Limit) Replays
+func (c *AMQPServerConnection) ReplayDeadLetters(dlq string, target string, filter ReplayFilter, limit int, opts *ReplayOptions) (*ReplayReport, error) {
+	r := &ReplayReport{}
+
+	// Hold Skipped Messages Unacknowledged so they aren't Retrieved Again
+	skipped := []*amqp.Delivery{}
+	defer func() {
+		for _, d := range skipped {
+			d.Nack(false, true)
+		}
+	}()
+
+	for limit <= 0 || r.Replayed < limit {
+		d, err := c.QueueRetrieve(ReplayChannel, dlq)
+		if err != nil {
+			return r, err
+		}
+
+		// Is Dead Letter Queue Empty?
+		if d == nil { // YES: Done
+			break
+		}
+		r.Scanned++
+
+		// Should Message be Replayed?
+		if filter != nil && !filter(d) { // NO
+			skipped = append(skipped, d)
+			r.Skipped++
+			continue
+		}
+
+		// Do we have a Target?
+		to := target
+		if to == "" { // NO: Use Original Queue
+			to = c.unprefixed(OriginalQueue(d))
+		}
+		if to == "" {
+			d.Nack(false, true)
+			return r, fmt.Errorf("[ReplayDeadLetters] Message [%d] has no Original Queue: %w", d.DeliveryTag, ErrQueueNameMissing)
+		}
+
+		p, err := replayPublishing(d, opts)
+		if err != nil {
+			d.Nack(false, true)
+			return r, err
+		}
+
+		// Is Package Wide Dry Run On?
+		if DryRun() { // YES: Count it but Keep Dead Letter
+			if err = c.skipPublish("ReplayDeadLetters", p); err != nil {
+				d.Nack(false, true)
+				return r, err
+			}
+			skipped = append(skipped, d)
+			r.Replayed++
+			continue
+		}
+
+		// Remove from Dead Letter Queue only once Republished
+		err = c.republish("ReplayDeadLetters", ReplayChannel, to, p)
+		if err != nil {
+			d.Nack(false, true)
+			return r, err
+		}
+
+		err = d.Ack(false)
+		if err != nil {
+			return r, err
+		}
+		r.Replayed++
+	}
+
+	return r, nil
+}
+
+func (c *AMQPServerConnection) unprefixed(name string) string {
+	// Do we have a Prefix?
+	if c.prefix == "" { // NO
+		return name
+	}
+	return strings.TrimPrefix(name, c.prefix+"-")
+}
+
+// replayPublishing Publishing Replaying Dead Letter (Properties, Headers and Content Encoding Kept)
+func replayPublishing(d *amqp.Delivery, opts *ReplayOptions) (*amqp.Publishing, error) {
+	p := PublishingFromDelivery(d)
+
+	// Do we need to Modify the Message?
+	if opts == nil || (!opts.ClearStatus && !opts.ResetRequeues) { // NO
+		return p, nil
+	}
+
+	body, err := DecodeBody(d)
+	if err != nil {
+		return nil, fmt.Errorf("[ReplayDeadLetters] %w", err)
+	}
+
+	// Was the Message Modified?
+	if b, ok := replayBody(body, opts); ok { // YES: Body is now Plain (publishTo Compresses it, if Enabled)
+		p.Body = b
+		p.ContentEncoding = ""
+	}
+	return p, nil
+}
+
+// replayBody Apply Replay Options to JSON Queue Message (false: Not a Queue Message)
+func replayBody(body []byte, opts *ReplayOptions) ([]byte, bool) {
+	// Is Message a JSON Queue Message?
+	var m map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if dec.Decode(&m) != nil { // NO: Replay Unchanged
+		return nil, false
+	}
+
+	header, ok := m["header"].(map[string]interface{})
+	if !ok {
+		return nil, false
+	}
+
+	if opts.ClearStatus {
+		delete(header, "status")
+	}
+
+	if opts.ResetRequeues {
+		if props, ok := header["props"].(map[string]interface{}); ok {
+			delete(props, RequeueCountProperty)
+			delete(props, messages.PropertyNextRetryAt)
+			if len(props) == 0 {
+				delete(header, "props")
+			}
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return nil, false
+	}
+	return b, true
+}
@@ -0,0 +1,134 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore dlq
+
+import (
+	"bytes"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/fixtures"
+)
+
+// gzipDeadLetter Dead Letter Carrying Fixture Compressed with gzip
+func gzipDeadLetter(t *testing.T, name string) (*amqp.Delivery, []byte) {
+	t.Helper()
+
+	plain := fixtures.MustLoad("v1", name)
+	cmp, err := CompressorFor(EncodingGzip)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := cmp.Compress(plain)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &amqp.Delivery{
+		Headers:         amqp.Table{"x-first-death-queue": "jobs", "custom": "kept"},
+		ContentType:     "application/json",
+		ContentEncoding: EncodingGzip,
+		MessageId:       "msg-1",
+		CorrelationId:   "corr-1",
+		Type:            "action:email:welcome",
+		Body:            body,
+	}, plain
+}
+
+// replayed Delivery as the Target Queue would Receive the Publishing
+func replayed(p *amqp.Publishing) *amqp.Delivery {
+	return &amqp.Delivery{
+		Headers:         p.Headers,
+		ContentType:     p.ContentType,
+		ContentEncoding: p.ContentEncoding,
+		Body:            p.Body,
+	}
+}
+
+func TestReplayPublishingKeepsEncoding(t *testing.T) {
+	d, plain := gzipDeadLetter(t, "queue-message-status")
+
+	p, err := replayPublishing(d, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if p.ContentEncoding != EncodingGzip || p.MessageId != d.MessageId || p.CorrelationId != d.CorrelationId || p.Type != d.Type {
+		t.Errorf("properties not copied: %+v", p)
+	}
+	if p.Headers["custom"] != "kept" {
+		t.Errorf("headers not copied: %v", p.Headers)
+	}
+
+	// NOTE: Encoded Bodies must not be Compressed Again on Publish
+	c := &AMQPServerConnection{}
+	if err = c.compress(p); err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := DecodeBody(replayed(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, plain) {
+		t.Errorf("replayed body differs\n got: %s\nwant: %s", body, plain)
+	}
+}
+
+func TestReplayPublishingModifiesCompressedBody(t *testing.T) {
+	d, _ := gzipDeadLetter(t, "queue-message-status")
+
+	p, err := replayPublishing(d, &ReplayOptions{ClearStatus: true, ResetRequeues: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Modified Bodies are Plain (Compressed Again by publishTo, if Enabled)
+	if p.ContentEncoding != "" {
+		t.Fatalf("content encoding [%s], want plain", p.ContentEncoding)
+	}
+
+	body, err := DecodeBody(replayed(p))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []byte(`{
+		"header": {
+			"version": 1,
+			"id": "0b6c2f4e-1d7a-4f3b-8e2c-6a9d5b1c3e70",
+			"parent": "5d3f1e0a-8c7b-4c1e-9a55-0f7b2c9e4a10",
+			"props": {
+				"requeue": 2
+			},
+			"created": "2022-06-01T12:00:00Z"
+		},
+		"body": {
+			"text": "plain queue message"
+		}
+	}`)
+	if ok, err := fixtures.EqualJSON(body, want); err != nil || !ok {
+		t.Errorf("replayed body [%s] (%v)", body, err)
+	}
+}
+
+func TestReplayPublishingRejectsUnknownEncoding(t *testing.T) {
+	d, _ := gzipDeadLetter(t, "queue-message")
+	d.ContentEncoding = "br"
+
+	if _, err := replayPublishing(d, &ReplayOptions{ClearStatus: true}); err == nil {
+		t.Error("expected error for unsupported content encoding")
+	}
+}
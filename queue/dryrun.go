@@ -0,0 +1,107 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// dryRunAll Package Wide Dry Run Toggle (1: Never Write to Broker)
+var dryRunAll int32
+
+// SetDryRun Turn Dry Run On/Off for every Publish (i.e. Staging, Pre-Flight Checks)
+func SetDryRun(on bool) {
+	var v int32
+	if on {
+		v = 1
+	}
+	atomic.StoreInt32(&dryRunAll, v)
+}
+
+// DryRun Is Package Wide Dry Run On?
+func DryRun() bool {
+	return atomic.LoadInt32(&dryRunAll) == 1
+}
+
+// WithDryRun Validate, Encode and Check Message but Skip the Broker Write
+func WithDryRun() PublishOption {
+	return func(o *PublishOptions) {
+		o.DryRun = true
+	}
+}
+
+// dryRun Should Publish Skip the Broker Write? (nil Options: Package Toggle Only)
+func (o *PublishOptions) dryRun() bool {
+	return DryRun() || (o != nil && o.DryRun)
+}
+
+// checkPublishing Verify Message would be Accepted and could be Decoded by Consumers
+//
+// Checks the Size Limit and that the Body Decodes with the Codec Registered for
+// its Content Type (Plain Text is not Decoded).
+func checkPublishing(caller string, p *amqp.Publishing) error {
+	// Is Message within Size Limit?
+	if len(p.Body) > messages.MaxMessageSize { // NO
+		return fmt.Errorf("[%s] %w [%d bytes]", caller, ErrMessageTooLarge, len(p.Body))
+	}
+
+	// Is Body Plain Text?
+	if strings.HasPrefix(strings.ToLower(strings.TrimSpace(p.ContentType)), "text/") { // YES: Nothing to Decode
+		return nil
+	}
+
+	d := &amqp.Delivery{
+		ContentType:     p.ContentType,
+		ContentEncoding: p.ContentEncoding,
+		Body:            p.Body,
+	}
+
+	cdc, err := DeliveryCodec(d, nil)
+	if err != nil {
+		return fmt.Errorf("[%s] %w", caller, err)
+	}
+
+	body, err := DecodeBody(d)
+	if err != nil {
+		return fmt.Errorf("[%s] %w", caller, err)
+	}
+
+	var v interface{}
+	if err = cdc.Unmarshal(body, &v); err != nil {
+		return fmt.Errorf("[%s] Body does not Decode as [%s]: %w", caller, cdc.ContentType(), err)
+	}
+	return nil
+}
+
+// skipPublish Check Dry Run Message and Count it (Broker is not Contacted)
+func (c *AMQPServerConnection) skipPublish(caller string, p *amqp.Publishing) error {
+	// Stamp Identity (as publishTo would)
+	if p.AppId == "" {
+		p.AppId = c.appID
+	}
+	if p.UserId == "" {
+		p.UserId = c.userID
+	}
+
+	if err := checkPublishing(caller, p); err != nil {
+		c.metrics.add(MetricDryRunFailures, 1)
+		return err
+	}
+
+	c.metrics.add(MetricDryRuns, 1)
+	return nil
+}
@@ -0,0 +1,43 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Failure Classes (use errors.Is)
+var (
+	ErrNoServers          = errors.New("[AMQPServerConnection] No Connection Settings")
+	ErrConnectFailed      = errors.New("[AMQPServerConnection] Unable to Connect to any Servers")
+	ErrNoConnection       = errors.New("[AMQPServerConnection] No Connection Established")
+	ErrQueueNameMissing   = errors.New("[AMQPServerConnection] Missing Queue Name")
+	ErrPublishTimeout     = errors.New("[AMQPServerConnection] Publish Timed Out")
+	ErrShutdownTimeout    = errors.New("[RunConsumers] Consumers did not Finish in Grace Period")
+	ErrPoisonMessage      = errors.New("[PoisonDetector] Message Exceeded Delivery Threshold")
+	ErrConsumerClosed     = errors.New("[AMQPServerConnection] Broker Stopped Deliveries to Consumer")
+	ErrQuorumNotReached   = errors.New("[ScatterGather] Quorum not Reached before Timeout")
+	ErrMessageTooLarge    = errors.New("[AMQPServerConnection] Message Exceeds Size Limit")
+	ErrUnknownMessageType = errors.New("[Dispatcher] No Handler for Message Type")
+	ErrUnknownStrategy    = errors.New("[ServerStrategy] Unknown Server Strategy")
+)
+
+// publishError Classify Error Returned by Publish
+func publishError(err error) error {
+	// Did the Publish Time Out?
+	if errors.Is(err, context.DeadlineExceeded) { // YES
+		return fmt.Errorf("%w [%v]", ErrPublishTimeout, err)
+	}
+
+	return err
+}
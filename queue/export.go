@@ -0,0 +1,211 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore ndjson
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ExportChannel Channel Used by Export and Import
+const ExportChannel = "export"
+
+// ExportRecord Message as Written by Export (One JSON Object per Line)
+type ExportRecord struct {
+	MessageID       string                 `json:"message_id,omitempty"`
+	CorrelationID   string                 `json:"correlation_id,omitempty"`
+	Type            string                 `json:"type,omitempty"`
+	AppID           string                 `json:"app_id,omitempty"`
+	ContentType     string                 `json:"content_type,omitempty"`
+	ContentEncoding string                 `json:"content_encoding,omitempty"`
+	Priority        uint8                  `json:"priority,omitempty"`
+	Persistent      bool                   `json:"persistent,omitempty"`
+	Timestamp       *time.Time             `json:"timestamp,omitempty"`
+	Headers         map[string]interface{} `json:"headers,omitempty"`
+	Body            json.RawMessage        `json:"body,omitempty"` // JSON Body (as is)
+	Raw             []byte                 `json:"raw,omitempty"`  // Non JSON Body (Base64)
+}
+
+// NewExportRecord Create Record for Delivery
+func NewExportRecord(d *amqp.Delivery) *ExportRecord {
+	r := &ExportRecord{
+		MessageID:       d.MessageId,
+		CorrelationID:   d.CorrelationId,
+		Type:            d.Type,
+		AppID:           d.AppId,
+		ContentType:     d.ContentType,
+		ContentEncoding: d.ContentEncoding,
+		Priority:        d.Priority,
+		Persistent:      d.DeliveryMode == amqp.Persistent,
+	}
+
+	if !d.Timestamp.IsZero() {
+		t := d.Timestamp.UTC()
+		r.Timestamp = &t
+	}
+
+	// Do we have Headers?
+	if len(d.Headers) > 0 { // YES
+		r.Headers = make(map[string]interface{}, len(d.Headers))
+		for k, v := range d.Headers {
+			r.Headers[k] = v
+		}
+	}
+
+	// Can Body be Stored as JSON?
+	if d.ContentEncoding == "" && isJSONContent(d.ContentType) && json.Valid(d.Body) { // YES
+		r.Body = json.RawMessage(d.Body)
+	} else {
+		r.Raw = d.Body
+	}
+	return r
+}
+
+// toPublishing Initialize Publishing from Record
+func (r *ExportRecord) toPublishing(p *amqp.Publishing) {
+	p.MessageId = r.MessageID
+	p.CorrelationId = r.CorrelationID
+	p.Type = r.Type
+	p.AppId = r.AppID
+	p.ContentType = r.ContentType
+	p.ContentEncoding = r.ContentEncoding
+	p.Priority = r.Priority
+	if r.Persistent {
+		p.DeliveryMode = amqp.Persistent
+	}
+	if r.Timestamp != nil {
+		p.Timestamp = *r.Timestamp
+	}
+	if len(r.Headers) > 0 {
+		p.Headers = importValue(r.Headers).(amqp.Table)
+	}
+
+	// Was Body Stored as JSON?
+	if r.Body != nil { // YES
+		p.Body = r.Body
+	} else {
+		p.Body = r.Raw
+	}
+}
+
+// Export Drain Messages from Queue to Writer as Newline Delimited JSON, Stops
+// after 'limit' (0: No Limit) Messages, Returns Number Exported
+//
+// Messages are Removed from the Queue only once Written.
+func (c *AMQPServerConnection) Export(queue string, w io.Writer, limit int) (int, error) {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	count := 0
+	for limit <= 0 || count < limit {
+		d, err := c.QueueRetrieve(ExportChannel, queue)
+		if err != nil {
+			return count, err
+		}
+
+		// Is Queue Empty?
+		if d == nil { // YES: Done
+			break
+		}
+
+		// Written (to Destination, not just Buffered)?
+		err = enc.Encode(NewExportRecord(d))
+		if err == nil {
+			err = bw.Flush()
+		}
+		if err != nil { // NO: Leave Message in Queue
+			d.Nack(false, true)
+			return count, fmt.Errorf("[Export] Failed Writing Message [%d]: %w", d.DeliveryTag, err)
+		}
+
+		err = d.Ack(false)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	return count, nil
+}
+
+// Import Publish Messages Read from Newline Delimited JSON (as Written by Export)
+// to Queue, Returns Number Imported
+func (c *AMQPServerConnection) Import(r io.Reader, queue string) (int, error) {
+	ch, err := c.OpenQueueChannel(ExportChannel, queue, false)
+	if err != nil {
+		return 0, err
+	}
+
+	name, err := c.queueName(queue)
+	if err != nil {
+		return 0, err
+	}
+
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+
+	count := 0
+	for {
+		rec := &ExportRecord{}
+		err = dec.Decode(rec)
+		if err == io.EOF {
+			return count, nil
+		}
+		if err != nil {
+			return count, fmt.Errorf("[Import] Invalid Record %d: %w", count+1, err)
+		}
+
+		p := &amqp.Publishing{}
+		rec.toPublishing(p)
+		err = c.publishTo("Import", ch, "", name, p)
+		if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// importValue Convert Decoded JSON Header Values to Types AMQP Tables Accept
+func importValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if i, err := t.Int64(); err == nil {
+			return i
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		m := make(amqp.Table, len(t))
+		for k, e := range t {
+			m[k] = importValue(e)
+		}
+		return m
+	case []interface{}:
+		a := make([]interface{}, len(t))
+		for i, e := range t {
+			a[i] = importValue(e)
+		}
+		return a
+	}
+	return v
+}
+
+func isJSONContent(contentType string) bool {
+	ct := strings.ToLower(strings.TrimSpace(strings.SplitN(contentType, ";", 2)[0]))
+	return ct == "application/json" || strings.HasSuffix(ct, "+json")
+}
@@ -0,0 +1,120 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Handler Processes a Consumed Message (Error: Message is Settled by the Error Policy, DEFAULT Requeued)
+type Handler func(ctx context.Context, d *amqp.Delivery) error
+
+// Middleware Wraps a Handler with Additional Behaviour
+type Middleware func(next Handler) Handler
+
+// Chain Wrap Handler with Middleware (First Middleware is Outermost)
+func Chain(h Handler, mw ...Middleware) Handler {
+	for i := len(mw) - 1; i >= 0; i-- {
+		h = mw[i](h)
+	}
+	return h
+}
+
+// MessageID Delivery Identifier (AMQP Message ID or Queue Message Header ID)
+func MessageID(d *amqp.Delivery) string {
+	// Do we have a Delivery?
+	if d == nil { // NO
+		return ""
+	}
+
+	// Was Message ID Set by Publisher?
+	if d.MessageId != "" { // YES
+		return d.MessageId
+	}
+
+	// Try Queue Message Header
+	var envelope struct {
+		Header struct {
+			ID string `json:"id"`
+		} `json:"header"`
+	}
+	if json.Unmarshal(deliveryBody(d), &envelope) != nil {
+		return ""
+	}
+	return envelope.Header.ID
+}
+
+// QueueProcess Retrieve a Single Message and Pass it to Handler (Returns false if Queue was Empty)
+func (c *AMQPServerConnection) QueueProcess(ctx context.Context, channel string, queue string, h Handler, mw ...Middleware) (bool, error) {
+	d, err := c.QueueRetrieve(channel, queue)
+	if err != nil || d == nil {
+		return false, err
+	}
+
+	return true, c.handleDelivery(ctx, queue, d, Chain(h, mw...))
+}
+
+// handleDelivery Pass Delivery (from 'queue') to Handler and Settle it
+func (c *AMQPServerConnection) handleDelivery(ctx context.Context, queue string, d *amqp.Delivery, h Handler) error {
+	start := time.Now()
+	ctx, settled := WithSettlement(ctx)
+
+	// Did Handler Fail?
+	err := h(ctx, d)
+	if err != nil { // YES
+		outcome := c.settleFailure(queue, d, err)
+		settled(outcome)
+		c.sendReceipt(queue, d, outcome, err, time.Since(start))
+		return err
+	}
+
+	err = d.Ack(false)
+	if err != nil { // NOTE: Unacked Messages are Redelivered
+		settled(OutcomeRequeued)
+		return err
+	}
+
+	settled(OutcomeAcked)
+	c.sendReceipt(queue, d, OutcomeAcked, nil, time.Since(start))
+	return nil
+}
+
+// settleFailure Quarantine or Dead Letter Poison Messages, Apply Error Policy to Others (Returns Outcome)
+func (c *AMQPServerConnection) settleFailure(queue string, d *amqp.Delivery, err error) string {
+	// Should Message be Quarantined?
+	if IsQuarantineError(err) { // YES
+		qerr := c.Quarantine(queue, d, err)
+		if qerr == nil {
+			return OutcomeQuarantined
+		}
+
+		// Can't Quarantine: Dead Letter (Never Requeue Poison)
+		c.status.recordError(qerr)
+		if nerr := d.Nack(false, false); nerr != nil {
+			c.status.recordError(nerr)
+		}
+		return OutcomeDeadLettered
+	}
+
+	// Did Handler ask for Dead Lettering?
+	if IsDeadLetterError(err) { // YES
+		if nerr := d.Nack(false, false); nerr != nil {
+			c.status.recordError(nerr)
+		}
+		return OutcomeDeadLettered
+	}
+
+	return c.applyErrorPolicy(d, err)
+}
@@ -0,0 +1,153 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// Health Status Values
+const (
+	HealthOK       = "ok"       // Connected
+	HealthDegraded = "degraded" // Connected, but some Servers Unreachable
+	HealthDown     = "down"     // Not Connected
+)
+
+// Time Allowed to Probe the Servers (Probes Run in Parallel)
+const DefaultHealthDialTimeout = 2 * time.Second
+
+// Server Reachability
+type ServerHealth struct {
+	Address   string `json:"address"`
+	Reachable bool   `json:"reachable"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Connection Health Report
+type Health struct {
+	Status      string         `json:"status"`
+	Connected   bool           `json:"connected"`
+	Channels    int            `json:"channels"`
+	LastError   string         `json:"last_error,omitempty"`
+	LastErrorAt *time.Time     `json:"last_error_at,omitempty"`
+	Servers     []ServerHealth `json:"servers"`
+}
+
+// Health Build Health Report (each Server is Probed with a TCP Dial)
+//
+// Servers are Probed in Parallel, and all Probes Share a Single Deadline
+// ('timeout', or the Context Deadline if Earlier).
+func (c *AMQPServerConnection) Health(ctx context.Context, timeout time.Duration) *Health {
+	h := &Health{
+		Connected: c.IsConnected(),
+		Servers:   []ServerHealth{},
+	}
+
+	var lastError error
+	var at time.Time
+	h.Channels, lastError, at = c.status.snapshot()
+	if lastError != nil {
+		h.LastError = lastError.Error()
+		h.LastErrorAt = &at
+	}
+
+	for _, s := range c.servers {
+		// Do we have a Server Definition?
+		if s.Server == nil || s.Server.Host == "" { // NO: Skip
+			continue
+		}
+
+		port := s.Server.Port
+		if port == 0 {
+			port = shared.DefaultPort
+			if s.UseTLS() {
+				port = shared.DefaultTLSPort
+			}
+		}
+
+		h.Servers = append(h.Servers, ServerHealth{
+			Address: net.JoinHostPort(s.Server.Host, strconv.Itoa(port)),
+		})
+	}
+
+	// Probe Servers
+	reachable := probeServers(ctx, timeout, h.Servers)
+
+	switch {
+	case !h.Connected:
+		h.Status = HealthDown
+	case reachable < len(h.Servers):
+		h.Status = HealthDegraded
+	default:
+		h.Status = HealthOK
+	}
+
+	return h
+}
+
+// probeServers Dial Every Server Concurrently (Returns Number Reachable)
+func probeServers(ctx context.Context, timeout time.Duration, servers []ServerHealth) int {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var d net.Dialer
+	for i := range servers {
+		wg.Add(1)
+		go func(sh *ServerHealth) {
+			defer wg.Done()
+
+			con, err := d.DialContext(ctx, "tcp", sh.Address)
+			if err != nil {
+				sh.Error = err.Error()
+				return
+			}
+
+			con.Close()
+			sh.Reachable = true
+		}(&servers[i])
+	}
+	wg.Wait()
+
+	reachable := 0
+	for _, sh := range servers {
+		if sh.Reachable {
+			reachable++
+		}
+	}
+	return reachable
+}
+
+// HealthHandler HTTP Handler Reporting Connection Health as JSON
+// (i.e. mount under /healthz/queue). Responds 503 when not Connected.
+func HealthHandler(c *AMQPServerConnection) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := c.Health(r.Context(), DefaultHealthDialTimeout)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if h.Status == HealthDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		} else {
+			w.WriteHeader(http.StatusOK)
+		}
+
+		json.NewEncoder(w).Encode(h)
+	})
+}
@@ -0,0 +1,85 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// localServer Server Definition for Listener Address
+func localServer(t *testing.T, addr net.Addr) shared.AMQPConnection {
+	t.Helper()
+
+	host, port, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, _ := strconv.Atoi(port)
+	return shared.AMQPConnection{Server: &shared.Server{Host: host, Port: p}}
+}
+
+func TestHealthProbesServers(t *testing.T) {
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback listener [%v]", err)
+	}
+	defer up.Close()
+
+	// Closed Listener: Nothing Accepts on its Port
+	down, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	down.Close()
+
+	c := &AMQPServerConnection{}
+	c.SetConnection([]shared.AMQPConnection{localServer(t, up.Addr()), localServer(t, down.Addr())})
+
+	h := c.Health(context.Background(), time.Second)
+	if h.Connected || h.Status != HealthDown {
+		t.Errorf("status [%s] connected [%v], want down", h.Status, h.Connected)
+	}
+
+	if len(h.Servers) != 2 {
+		t.Fatalf("%d servers, want 2", len(h.Servers))
+	}
+	if !h.Servers[0].Reachable || h.Servers[0].Address != up.Addr().String() {
+		t.Errorf("server 0 %+v, want reachable %s", h.Servers[0], up.Addr())
+	}
+	if h.Servers[1].Reachable || h.Servers[1].Error == "" {
+		t.Errorf("server 1 %+v, want unreachable", h.Servers[1])
+	}
+}
+
+func TestHealthUsesContextDeadline(t *testing.T) {
+	up, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("no loopback listener [%v]", err)
+	}
+	defer up.Close()
+
+	c := &AMQPServerConnection{}
+	c.SetConnection([]shared.AMQPConnection{localServer(t, up.Addr())})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	h := c.Health(ctx, time.Minute)
+	if h.Servers[0].Reachable {
+		t.Errorf("server probed after context was cancelled: %+v", h.Servers[0])
+	}
+}
@@ -0,0 +1,140 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+)
+
+// Field Key/Value Pair Attached to a Log Entry
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F Shorthand for Field
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Logger Structured Logger (Adapt zap, zerolog, slog, ... to Plug them in)
+//
+// NOTE: Message Bodies are Never Logged, at any Level.
+type Logger interface {
+	Debug(msg string, fields ...Field)
+	Info(msg string, fields ...Field)
+	Warn(msg string, fields ...Field)
+	Error(msg string, fields ...Field)
+}
+
+// LogLevel Minimum Level Written by StdLogger
+type LogLevel int
+
+const (
+	LevelDebug LogLevel = iota
+	LevelInfo
+	LevelWarn
+	LevelError
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case LevelDebug:
+		return "DEBUG"
+	case LevelInfo:
+		return "INFO"
+	case LevelWarn:
+		return "WARN"
+	case LevelError:
+		return "ERROR"
+	}
+	return "LEVEL(" + strconv.Itoa(int(l)) + ")"
+}
+
+// StdLogger Logger Writing "LEVEL msg key=value ..." Lines to a log.Logger
+type StdLogger struct {
+	out   *log.Logger
+	level LogLevel
+}
+
+// NewStdLogger Logger Writing Entries at or above 'level' to 'out' (nil: Standard Logger)
+func NewStdLogger(out *log.Logger, level LogLevel) *StdLogger {
+	if out == nil {
+		out = log.Default()
+	}
+	return &StdLogger{out: out, level: level}
+}
+
+func (l *StdLogger) Debug(msg string, fields ...Field) {
+	l.write(LevelDebug, msg, fields)
+}
+
+func (l *StdLogger) Info(msg string, fields ...Field) {
+	l.write(LevelInfo, msg, fields)
+}
+
+func (l *StdLogger) Warn(msg string, fields ...Field) {
+	l.write(LevelWarn, msg, fields)
+}
+
+func (l *StdLogger) Error(msg string, fields ...Field) {
+	l.write(LevelError, msg, fields)
+}
+
+func (l *StdLogger) write(level LogLevel, msg string, fields []Field) {
+	// Is Level Enabled?
+	if level < l.level { // NO
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(level.String())
+	b.WriteByte(' ')
+	b.WriteString(msg)
+	for _, f := range fields {
+		v := fmt.Sprint(f.Value)
+		if v == "" || strings.ContainsAny(v, " \t\n\"=") {
+			v = strconv.Quote(v)
+		}
+		fmt.Fprintf(&b, " %s=%s", f.Key, v)
+	}
+	l.out.Print(b.String())
+}
+
+// NopLogger Logger Discarding Everything
+type NopLogger struct{}
+
+func (NopLogger) Debug(string, ...Field) {}
+func (NopLogger) Info(string, ...Field)  {}
+func (NopLogger) Warn(string, ...Field)  {}
+func (NopLogger) Error(string, ...Field) {}
+
+// defaultLogger Used when no Logger is Set (and by Helpers without a Connection)
+var defaultLogger Logger = NewStdLogger(nil, LevelInfo)
+
+// Logger Logger Used by Connection (DEFAULT: Standard Logger, Info and Above)
+func (c *AMQPServerConnection) Logger() Logger {
+	// Do we have a Logger Set?
+	if c == nil || c.logger == nil { // NO: Use Default
+		return defaultLogger
+	}
+
+	return c.logger
+}
+
+// SetLogger Replace Connection Logger (nil: Restore Default)
+func (c *AMQPServerConnection) SetLogger(l Logger) error {
+	c.logger = l
+	return nil
+}
@@ -0,0 +1,121 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// MaintenanceExchange Fanout Exchange Carrying Maintenance Announcements to every Node
+const MaintenanceExchange = "maintenance"
+
+// AnnounceMaintenance Broadcast Maintenance Window to every Node
+func (c *AMQPServerConnection) AnnounceMaintenance(msg *messages.MaintenanceMessage, opts ...PublishOption) error {
+	return c.announce(MaintenanceExchange, msg, opts...)
+}
+
+// MaintenanceConsumer Consumer Handling Maintenance Announcements (i.e. with MaintenanceTracker.Handle)
+func (c *AMQPServerConnection) MaintenanceConsumer(h TypedHandler[*messages.MaintenanceMessage], mw ...Middleware) Consumer {
+	return typedBroadcastConsumer(c, MaintenanceExchange, h, mw...)
+}
+
+// MaintenanceTracker Node Local View of Announced Maintenance Windows
+//
+// Feed it from MaintenanceConsumer and Query it to Show Banners or Reject Writes.
+type MaintenanceTracker struct {
+	mu      sync.RWMutex
+	windows map[string]*messages.MaintenanceMessage // Announcements by Message ID
+}
+
+func NewMaintenanceTracker() *MaintenanceTracker {
+	return &MaintenanceTracker{
+		windows: make(map[string]*messages.MaintenanceMessage),
+	}
+}
+
+// Handle Record Announcement (Use as MaintenanceConsumer Handler)
+func (t *MaintenanceTracker) Handle(ctx context.Context, msg *messages.MaintenanceMessage, d *amqp.Delivery) error {
+	t.Add(msg)
+	return nil
+}
+
+// Add Record Announcement (Re-Announcing Same Message ID Replaces Window)
+func (t *MaintenanceTracker) Add(msg *messages.MaintenanceMessage) {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// Is Window Already Over?
+	if end := msg.End(); end != nil && !now.Before(*end) { // YES: Ignore
+		return
+	}
+
+	t.windows[msg.Header().ID()] = msg
+	t.prune(now)
+}
+
+// Active Windows in Effect at 'now' (Ordered by Start)
+func (t *MaintenanceTracker) Active(now time.Time) []*messages.MaintenanceMessage {
+	return t.list(func(m *messages.MaintenanceMessage) bool {
+		return m.Active(now)
+	})
+}
+
+// Upcoming Windows not yet Started at 'now' (Ordered by Start, i.e. for Advance Banners)
+func (t *MaintenanceTracker) Upcoming(now time.Time) []*messages.MaintenanceMessage {
+	return t.list(func(m *messages.MaintenanceMessage) bool {
+		s := m.Start()
+		return s != nil && now.Before(*s)
+	})
+}
+
+// ReadOnly Should 'component' Reject Writes at 'now'?
+func (t *MaintenanceTracker) ReadOnly(now time.Time, component string) bool {
+	for _, m := range t.Active(now) {
+		if m.ReadOnly() && m.Affects(component) {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *MaintenanceTracker) list(match func(m *messages.MaintenanceMessage) bool) []*messages.MaintenanceMessage {
+	t.mu.RLock()
+	l := []*messages.MaintenanceMessage{}
+	for _, m := range t.windows {
+		if match(m) {
+			l = append(l, m)
+		}
+	}
+	t.mu.RUnlock()
+
+	sort.Slice(l, func(i, j int) bool {
+		return l[i].Start().Before(*l[j].Start())
+	})
+	return l
+}
+
+// prune Drop Windows Ended before 'now' (Lock Held)
+func (t *MaintenanceTracker) prune(now time.Time) {
+	for id, m := range t.windows {
+		if end := m.End(); end == nil || !now.Before(*end) {
+			delete(t.windows, id)
+		}
+	}
+}
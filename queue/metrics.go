@@ -0,0 +1,129 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore expvar
+import (
+	"expvar"
+	"sync"
+)
+
+// Counter Names Published under the Metrics Namespace
+const (
+	MetricPublishes        = "publishes"
+	MetricPublishFailures  = "publish_failures"
+	MetricReconnects       = "reconnects"
+	MetricRetrieved        = "retrieved"
+	MetricRetrieveFailures = "retrieve_failures"
+	MetricBacklog          = "backlog"
+	MetricDryRuns          = "dry_runs"
+	MetricDryRunFailures   = "dry_run_failures"
+)
+
+// Connection Counters Published via expvar (/debug/vars)
+type Metrics struct {
+	vars    *expvar.Map // Namespace Map
+	backlog *expvar.Map // Queue Name -> Last Known Message Count
+}
+
+var (
+	metricsLock     sync.Mutex
+	metricsRegistry = map[string]*Metrics{}
+)
+
+// NewMetrics Publish Counters under Namespace (Connections Sharing a
+// Namespace Share the Counters)
+func NewMetrics(namespace string) *Metrics {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+
+	// Is the Namespace Already Published?
+	if m, ok := metricsRegistry[namespace]; ok { // YES: Reuse
+		return m
+	}
+
+	m := &Metrics{
+		vars:    expvar.NewMap(namespace),
+		backlog: new(expvar.Map).Init(),
+	}
+
+	// Initialize Counters so they are Visible before First Use
+	for _, n := range []string{MetricPublishes, MetricPublishFailures, MetricReconnects, MetricRetrieved, MetricRetrieveFailures} {
+		m.vars.Add(n, 0)
+	}
+	m.vars.Set(MetricBacklog, m.backlog)
+
+	metricsRegistry[namespace] = m
+	return m
+}
+
+func (m *Metrics) add(name string, delta int64) {
+	if m != nil {
+		m.vars.Add(name, delta)
+	}
+}
+
+func (m *Metrics) setBacklog(queue string, count int) {
+	if m != nil {
+		v := new(expvar.Int)
+		v.Set(int64(count))
+		m.backlog.Set(queue, v)
+	}
+}
+
+// Counter Current Value of Counter (0 if Unknown)
+func (m *Metrics) Counter(name string) int64 {
+	if m != nil {
+		if v, ok := m.vars.Get(name).(*expvar.Int); ok {
+			return v.Value()
+		}
+	}
+
+	return 0
+}
+
+func (c *AMQPServerConnection) Metrics() *Metrics {
+	return c.metrics
+}
+
+func (c *AMQPServerConnection) SetMetrics(m *Metrics) error {
+	c.metrics = m
+	return nil
+}
+
+// UpdateBacklog Inspect Queue Depth and Record it in the Backlog Metrics
+func (c *AMQPServerConnection) UpdateBacklog(channel string, queue string) (int, error) {
+	ch, err := c.OpenQueueChannel(channel, queue, false)
+	if err != nil {
+		return 0, err
+	}
+
+	qName, _ := c.queueName(queue)
+	q, err := ch.QueueDeclarePassive(
+		qName, // name
+		true,  // durable
+		false, // delete when unused
+		false, // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+
+	// Does the Queue Exist?
+	if err != nil { // NO: Failed Passive Declare Closes the Channel
+		c.Logger().Error("[UpdateBacklog] Failed to Inspect Queue", F("queue", qName), F("error", err))
+		c.status.recordError(err)
+		c.dropChannel(channel + "." + qName)
+		return 0, err
+	}
+
+	c.metrics.setBacklog(qName, q.Messages)
+	return q.Messages, nil
+}
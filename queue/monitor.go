@@ -0,0 +1,193 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// DefaultMonitorInterval Half the Default AMQP Heartbeat (10s)
+const DefaultMonitorInterval = 5 * time.Second
+
+// Monitor Event Types
+const (
+	EventConnectionLost     = "connection.lost"
+	EventConnectionRestored = "connection.restored"
+	EventReconnectFailed    = "connection.reconnect_failed"
+	EventChannelLost        = "channel.lost"
+	EventChannelRestored    = "channel.restored"
+	EventChannelFailed      = "channel.reopen_failed"
+	EventConsumerRestored   = "consumer.restored"
+	EventConsumerFailed     = "consumer.restore_failed"
+)
+
+// MonitorEvent State Change Detected by Monitor
+type MonitorEvent struct {
+	Type    string    // Event Type
+	Channel string    // Channel Name (Channel Events) or Consumer Tag (Consumer Events)
+	Err     error     // [OPTIONAL] Cause
+	Time    time.Time // Time of Event
+}
+
+// Monitor Periodically Validates Connection and Cached Channels, Reopening Dead Ones
+//
+// Push Consumers (see QueueSubscribe) are Redeclared, with their QoS, on the
+// New Channels. While a Monitor Runs, Consumers Wait for it instead of Closing.
+type Monitor struct {
+	conn     *AMQPServerConnection // Monitored Connection
+	interval time.Duration         // Time between Checks
+	events   func(MonitorEvent)    // [OPTIONAL] Event Receiver
+	lock     sync.Mutex            // State Lock
+	stop     chan struct{}         // Signal Monitor to Stop
+	done     chan struct{}         // Monitor has Stopped
+}
+
+// NewMonitor Monitor for Connection (interval <= 0: DefaultMonitorInterval)
+func NewMonitor(c *AMQPServerConnection, interval time.Duration, events func(MonitorEvent)) *Monitor {
+	if interval <= 0 {
+		interval = DefaultMonitorInterval
+	}
+
+	return &Monitor{
+		conn:     c,
+		interval: interval,
+		events:   events,
+	}
+}
+
+// Start Monitor Goroutine
+func (m *Monitor) Start() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+
+	// Already Running?
+	if m.stop != nil { // YES
+		return
+	}
+
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	atomic.AddInt32(&m.conn.monitors, 1)
+	go m.run(m.stop, m.done)
+}
+
+// Stop Monitor Goroutine and Wait for it to Finish
+func (m *Monitor) Stop() {
+	m.lock.Lock()
+	stop, done := m.stop, m.done
+	m.stop, m.done = nil, nil
+	m.lock.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+		atomic.AddInt32(&m.conn.monitors, -1)
+	}
+}
+
+func (m *Monitor) emit(t string, channel string, err error) {
+	if m.events != nil {
+		m.events(MonitorEvent{Type: t, Channel: channel, Err: err, Time: time.Now().UTC()})
+	}
+}
+
+func (m *Monitor) run(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+
+	var watched *amqp.Connection
+	var closed chan *amqp.Error
+	for {
+		// Watch Current Connection for Close Notifications (Immediate Detection)
+		if con := m.conn.conn(); con != nil && con != watched {
+			watched = con
+			closed = con.NotifyClose(make(chan *amqp.Error, 1))
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		case <-closed:
+			closed = nil
+		}
+
+		m.Check()
+	}
+}
+
+// Check Validate Connection and Channels Once
+func (m *Monitor) Check() {
+	c := m.conn
+
+	// Was Connection Established and Lost?
+	if con := c.conn(); con != nil && con.IsClosed() { // YES: Reconnect
+		m.emit(EventConnectionLost, "", nil)
+
+		// Remember Channels to Reopen
+		names := c.channelNames()
+		_, err := c.ResetConnection()
+		if err != nil {
+			c.status.recordError(err)
+			m.emit(EventReconnectFailed, "", err)
+			return
+		}
+		m.emit(EventConnectionRestored, "", nil)
+
+		for _, name := range names {
+			_, err = c.OpenChannel(name)
+			if err != nil {
+				m.emit(EventChannelFailed, name, err)
+				continue
+			}
+			m.emit(EventChannelRestored, name, nil)
+		}
+
+		m.restoreConsumers()
+		return
+	}
+
+	// Are Cached Channels Alive?
+	for _, name := range c.channelNames() {
+		ch := c.getChannel(name)
+		if ch == nil || !ch.IsClosed() {
+			continue
+		}
+
+		m.emit(EventChannelLost, name, nil)
+		c.dropChannel(name)
+
+		_, err := c.OpenChannel(name)
+		if err != nil {
+			m.emit(EventChannelFailed, name, err)
+			continue
+		}
+		m.emit(EventChannelRestored, name, nil)
+	}
+	m.restoreConsumers()
+}
+
+// restoreConsumers Redeclare Push Consumers whose Channel was Lost
+func (m *Monitor) restoreConsumers() {
+	for tag, err := range m.conn.restoreConsumers() {
+		if err != nil {
+			m.emit(EventConsumerFailed, tag, err)
+			continue
+		}
+		m.emit(EventConsumerRestored, tag, nil)
+	}
+}
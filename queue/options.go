@@ -0,0 +1,220 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/codec"
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// HeaderRequestID AMQP Header Carrying the Request/Trace ID
+const HeaderRequestID = "x-request-id"
+
+// PublishOptions Per Message Publish Settings
+type PublishOptions struct {
+	RequestID string            // [OPTIONAL] Request/Trace ID (Message Header and AMQP Header)
+	Headers   amqp.Table        // [OPTIONAL] Extra AMQP Headers
+	Sequencer *Sequencer        // [OPTIONAL] Stamp Publisher Sequence Number (Message Header and AMQP Headers)
+	DryRun    bool              // [OPTIONAL] Validate and Encode Message but Skip the Broker Write (see SetDryRun)
+	Tracing   *messages.Tracing // [OPTIONAL] W3C Trace Context (Message Header and AMQP Headers)
+	Codec     codec.Codec       // [OPTIONAL] Wire Encoding for QueuePublishEncoded (DEFAULT: Connection Codec)
+
+	message  interface{} // Message being Published (Envelope Mapped to Properties)
+	sequence uint64      // Sequence Number Issued to Message (0: None Yet)
+}
+
+// PublishOption Modifies Publish Settings
+type PublishOption func(o *PublishOptions)
+
+// WithRequestID Tag Message with Request ID
+func WithRequestID(id string) PublishOption {
+	return func(o *PublishOptions) {
+		o.RequestID = id
+	}
+}
+
+// WithContext Tag Message with Request ID and Trace Context Carried by Context (if Any)
+func WithContext(ctx context.Context) PublishOption {
+	return func(o *PublishOptions) {
+		if id := RequestIDFromContext(ctx); id != "" {
+			o.RequestID = id
+		}
+		if t := TracingFromContext(ctx); t != nil {
+			o.Tracing = t
+		}
+	}
+}
+
+// WithCodec Encode Message with Codec (QueuePublishEncoded Only)
+func WithCodec(c codec.Codec) PublishOption {
+	return func(o *PublishOptions) {
+		o.Codec = c
+	}
+}
+
+// WithHeader Add AMQP Header
+func WithHeader(name string, v interface{}) PublishOption {
+	return func(o *PublishOptions) {
+		if o.Headers == nil {
+			o.Headers = amqp.Table{}
+		}
+		o.Headers[name] = v
+	}
+}
+
+func newPublishOptions(opts []PublishOption) *PublishOptions {
+	o := &PublishOptions{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// PreparePublishing Apply Options and Publish Hooks to Message (nil: Raw Body),
+// then Map Message Envelope and Options to AMQP Properties
+//
+// Lets Transports other than AMQPServerConnection (i.e. memqueue) Stamp
+// Messages the way QueuePublishJSON does.
+func PreparePublishing(msg interface{}, p *amqp.Publishing, opts ...PublishOption) error {
+	o := newPublishOptions(opts)
+
+	// Do we have a Message?
+	if msg != nil { // YES
+		if err := o.applyMessage(msg); err != nil {
+			return err
+		}
+	}
+
+	o.applyPublishing(p)
+	return nil
+}
+
+// applyMessage Apply Options and Publish Hooks to Message Envelope (before Encoding)
+func (o *PublishOptions) applyMessage(msg interface{}) error {
+	o.message = msg
+
+	if err := o.applyHeader(msg); err != nil {
+		return err
+	}
+
+	// Dry Run: Fail on Anything Consumers would Reject
+	if o.dryRun() {
+		return validate(msg)
+	}
+	return nil
+}
+
+// applyHeader Apply Options and Publish Hooks to Message Header (if Any)
+func (o *PublishOptions) applyHeader(msg interface{}) error {
+	// Does Message have a Queue Header?
+	h := messageHeader(msg)
+	if h == nil { // NO
+		return nil
+	}
+
+	if o.RequestID != "" {
+		h.SetRequestID(o.RequestID)
+	}
+
+	if o.nextSequence() > 0 {
+		h.SetSequence(o.Sequencer.ID(), o.sequence)
+	}
+
+	if o.Tracing != nil {
+		if err := h.SetTracing(o.Tracing); err != nil {
+			return err
+		}
+	}
+
+	return messages.RunHooks(messages.HookBeforePublish, msg)
+}
+
+// nextSequence Sequence Number for Message (Issued Once per Publish, 0: No Sequencer)
+func (o *PublishOptions) nextSequence() uint64 {
+	if o.sequence == 0 && o.Sequencer != nil {
+		o.sequence = o.Sequencer.Next()
+	}
+	return o.sequence
+}
+
+// applyPublishing Apply Options (and Message Envelope) to AMQP Properties
+func (o *PublishOptions) applyPublishing(p *amqp.Publishing) {
+	// Do we have the Message?
+	if o.message != nil { // YES: Map Envelope Header
+		MapToPublishing(o.message, p)
+	}
+
+	if len(o.Headers) == 0 && o.RequestID == "" && o.nextSequence() == 0 && !o.Tracing.IsValid() {
+		return
+	}
+
+	if p.Headers == nil {
+		p.Headers = amqp.Table{}
+	}
+	for k, v := range o.Headers {
+		p.Headers[k] = v
+	}
+	if o.RequestID != "" {
+		p.Headers[HeaderRequestID] = o.RequestID
+	}
+	if o.sequence > 0 {
+		p.Headers[HeaderPublisherID] = o.Sequencer.ID()
+		p.Headers[HeaderSequence] = int64(o.sequence)
+	}
+	if o.Tracing.IsValid() {
+		p.Headers[HeaderTraceParent] = o.Tracing.TraceParent
+		if o.Tracing.TraceState != "" {
+			p.Headers[HeaderTraceState] = o.Tracing.TraceState
+		}
+	}
+}
+
+type requestIDKey struct{}
+
+// ContextWithRequestID Attach Request ID to Context
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestIDFromContext Request ID Attached to Context ("" if None)
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// RequestID Request ID of Delivery (AMQP Header or Queue Message Header)
+func RequestID(d *amqp.Delivery) string {
+	if id, ok := d.Headers[HeaderRequestID].(string); ok && id != "" {
+		return id
+	}
+
+	m := &messages.QueueMessage{}
+	if m.UnmarshalJSON(deliveryBody(d)) != nil {
+		return ""
+	}
+	return m.Header().RequestID()
+}
+
+// RequestIDMiddleware Place Delivery Request ID into Handler Context
+func RequestIDMiddleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			if id := RequestID(d); id != "" {
+				ctx = ContextWithRequestID(ctx, id)
+			}
+			return next(ctx, d)
+		}
+	}
+}
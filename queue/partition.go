@@ -0,0 +1,163 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// ExchangeConsistentHash RabbitMQ Consistent Hash Exchange Plugin Type
+const ExchangeConsistentHash = "x-consistent-hash"
+
+// PartitionChannel Channel Used to Declare and Publish to Partitions
+const PartitionChannel = "partition"
+
+// PartitionedQueue N Queues Bound to a Consistent Hash Exchange
+//
+// Messages with the Same Partition Key (i.e. Organization ID) always Land in
+// the Same Partition, Processing each Partition Sequentially Preserves Order.
+type PartitionedQueue struct {
+	conn       *AMQPServerConnection // Server Connection
+	name       string                // Base Name (Exchange and Partition Prefix)
+	partitions int                   // Number of Partitions
+}
+
+// NewPartitionedQueue Partitioned Queue 'name' with 'n' Partitions
+func NewPartitionedQueue(conn *AMQPServerConnection, name string, n int) (*PartitionedQueue, error) {
+	// Is Configuration Valid?
+	if name == "" { // NO
+		return nil, ErrQueueNameMissing
+	}
+	if n < 1 { // NO
+		return nil, fmt.Errorf("[PartitionedQueue] Invalid Number of Partitions [%d]", n)
+	}
+
+	return &PartitionedQueue{
+		conn:       conn,
+		name:       name,
+		partitions: n,
+	}, nil
+}
+
+func (q *PartitionedQueue) Partitions() int {
+	return q.partitions
+}
+
+// Partition Queue Name (Before Prefix) of Partition 'i'
+func (q *PartitionedQueue) Partition(i int) string {
+	return q.name + "-" + strconv.Itoa(i)
+}
+
+// Exchange Name of Consistent Hash Exchange on the Server
+func (q *PartitionedQueue) Exchange() (string, error) {
+	return q.conn.queueName(q.name)
+}
+
+// Declare Exchange and Partition Queues (Idempotent)
+func (q *PartitionedQueue) Declare() error {
+	exchange, err := q.Exchange()
+	if err != nil {
+		return err
+	}
+
+	ch, err := q.conn.OpenChannel(PartitionChannel)
+	if err != nil {
+		return err
+	}
+
+	err = ch.ExchangeDeclare(
+		exchange,               // name
+		ExchangeConsistentHash, // type
+		true,                   // durable
+		false,                  // auto-delete
+		false,                  // internal
+		false,                  // no-wait
+		nil,                    // arguments
+	)
+	if err != nil {
+		q.conn.Logger().Error("[PartitionedQueue] Failed Declaring Exchange (is rabbitmq_consistent_hash_exchange enabled?)", F("exchange", exchange), F("error", err))
+		q.conn.status.recordError(err)
+		return err
+	}
+
+	for i := 0; i < q.partitions; i++ {
+		name, _ := q.conn.queueName(q.Partition(i))
+		_, err = ch.QueueDeclare(
+			name,  // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			nil,   // arguments
+		)
+		if err != nil {
+			q.conn.status.recordError(err)
+			return err
+		}
+
+		// Binding Key is the Partition Weight (Equal Weights)
+		err = ch.QueueBind(name, "1", exchange, false, nil)
+		if err != nil {
+			q.conn.status.recordError(err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Publish Message with Partition Key (Messages with Equal Keys Stay in Order)
+func (q *PartitionedQueue) Publish(key string, contentType string, body []byte) error {
+	exchange, err := q.Exchange()
+	if err != nil {
+		return err
+	}
+
+	return q.conn.ExchangePublish(PartitionChannel, exchange, key, contentType, body)
+}
+
+// PublishJSON Message Encoded as JSON with Partition Key
+func (q *PartitionedQueue) PublishJSON(key string, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return q.Publish(key, "application/json", body)
+}
+
+// Owned Partitions Owned by 'member' of a Group of 'members' Processes
+func (q *PartitionedQueue) Owned(member int, members int) []int {
+	// Is Group Valid?
+	if members < 1 || member < 0 || member >= members { // NO
+		return nil
+	}
+
+	owned := []int{}
+	for i := member; i < q.partitions; i += members {
+		owned = append(owned, i)
+	}
+	return owned
+}
+
+// ConsumerGroup One Sequential Consumer per Partition Owned by 'member' of 'members'
+//
+// Every Partition must be Owned by Exactly One Member to Preserve Per Key
+// Ordering (Run with RunConsumers).
+func (q *PartitionedQueue) ConsumerGroup(member int, members int, h Handler, mw ...Middleware) []Consumer {
+	consumers := []Consumer{}
+	for _, i := range q.Owned(member, members) {
+		consumers = append(consumers, q.conn.QueueConsumer(PartitionChannel+"-"+strconv.Itoa(i), q.Partition(i), h, mw...))
+	}
+	return consumers
+}
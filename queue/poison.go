@@ -0,0 +1,167 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore requeue, requeues
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// HeaderDeliveryCount Delivery Attempts Header Maintained by Quorum Queues
+const HeaderDeliveryCount = "x-delivery-count"
+
+// errDeadLetter Handler Error Requesting Message be Dead Lettered
+type errDeadLetter struct {
+	err error
+}
+
+func (e *errDeadLetter) Error() string {
+	return "[DeadLetter] " + e.err.Error()
+}
+
+func (e *errDeadLetter) Unwrap() error {
+	return e.err
+}
+
+// DeadLetterError Wrap Handler Error so the Message is Rejected (Dead Lettered) instead of Requeued
+func DeadLetterError(err error) error {
+	// Already Wrapped?
+	var dl *errDeadLetter
+	if err == nil || errors.As(err, &dl) { // YES
+		return err
+	}
+	return &errDeadLetter{err}
+}
+
+// IsDeadLetterError Does Error Request Dead Lettering?
+func IsDeadLetterError(err error) bool {
+	var dl *errDeadLetter
+	return errors.As(err, &dl)
+}
+
+// PoisonOptions Poison Detection Settings
+type PoisonOptions struct {
+	Threshold  int  // Deliveries Allowed before Message is Considered Poison
+	DeadLetter bool // Dead Letter Poison Messages instead of Quarantining them
+}
+
+// DeliveryCount Best Estimate of Times Delivery has been Attempted Before
+//
+// Uses the Largest of the Quorum Queue Delivery Count, the Retry Tier and
+// Delayed Requeue Counts and the Envelope Requeue Count.
+func DeliveryCount(d *amqp.Delivery) int {
+	count := maxInt(RetryCount(d), delayCount(d))
+
+	switch v := d.Headers[HeaderDeliveryCount].(type) {
+	case int32:
+		count = maxInt(count, int(v))
+	case int64:
+		count = maxInt(count, int(v))
+	case int:
+		count = maxInt(count, v)
+	}
+
+	return maxInt(count, EnvelopeRequeueCount(d))
+}
+
+// EnvelopeRequeueCount Requeue Count Recorded in Queue Message Header Properties
+func EnvelopeRequeueCount(d *amqp.Delivery) int {
+	var envelope struct {
+		Header struct {
+			Props struct {
+				Count json.Number `json:"requeue_count"`
+			} `json:"props"`
+		} `json:"header"`
+	}
+
+	// Is Body a Queue Message with a Requeue Count?
+	if json.Unmarshal(deliveryBody(d), &envelope) != nil { // NO
+		return 0
+	}
+
+	n, err := envelope.Header.Props.Count.Int64()
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
+
+// EnvelopeNextRetryAt NextRetryAt Recorded in Queue Message Header Properties (nil if not Set)
+func EnvelopeNextRetryAt(d *amqp.Delivery) *time.Time {
+	m := &messages.QueueMessage{}
+	if m.UnmarshalJSON(deliveryBody(d)) != nil {
+		return nil
+	}
+	return m.Header().NextRetryAt()
+}
+
+// PoisonDetector Middleware Short-Circuiting Messages Redelivered too Often
+//
+// Redeliveries without a Count (Classic Queues) are Tracked in Memory by Message ID.
+// Poison Messages never Reach the Handler and are Quarantined (or Dead Lettered).
+func PoisonDetector(opts PoisonOptions) Middleware {
+	var lock sync.Mutex
+	seen := map[string]int{}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			count := DeliveryCount(d)
+
+			// Was Message Delivered Before?
+			id := MessageID(d)
+			if d.Redelivered && id != "" { // YES: Track Locally as Well
+				lock.Lock()
+				seen[id]++
+				count = maxInt(count, seen[id])
+				lock.Unlock()
+			}
+
+			// Is Message Poison?
+			if opts.Threshold > 0 && count >= opts.Threshold { // YES
+				lock.Lock()
+				delete(seen, id)
+				lock.Unlock()
+
+				err := fmt.Errorf("%w [%d deliveries]", ErrPoisonMessage, count+1)
+				if opts.DeadLetter {
+					return DeadLetterError(err)
+				}
+				return QuarantineError(err)
+			}
+
+			err := next(ctx, d)
+
+			// Did Handler Succeed?
+			if err == nil && id != "" { // YES: Stop Tracking
+				lock.Lock()
+				delete(seen, id)
+				lock.Unlock()
+			}
+			return err
+		}
+	}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,133 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore requeue
+import (
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// ErrorAction What to do with a Message whose Handler Failed
+type ErrorAction int
+
+const (
+	ActionRetryNow   ErrorAction = iota // Requeue Immediately
+	ActionRetryLater                    // Requeue after Delay (see RequeueAfter)
+	ActionDeadLetter                    // Reject without Requeue
+	ActionDrop                          // Acknowledge and Discard
+)
+
+// ErrorDecision Action (and Delay for ActionRetryLater) Chosen by an ErrorPolicy
+type ErrorDecision struct {
+	Action ErrorAction
+	Delay  time.Duration
+}
+
+// ErrorPolicy Decides what Happens to a Message when its Handler Fails
+//
+// NOTE: QuarantineError and DeadLetterError Returned by Handlers take
+// Precedence over the Policy.
+type ErrorPolicy interface {
+	Decide(d *amqp.Delivery, err error) ErrorDecision
+}
+
+// ErrorPolicyFunc Function as ErrorPolicy
+type ErrorPolicyFunc func(d *amqp.Delivery, err error) ErrorDecision
+
+func (f ErrorPolicyFunc) Decide(d *amqp.Delivery, err error) ErrorDecision {
+	return f(d, err)
+}
+
+// AlwaysRequeue Policy Requeuing Failed Messages Immediately (DEFAULT)
+func AlwaysRequeue() ErrorPolicy {
+	return ErrorPolicyFunc(func(*amqp.Delivery, error) ErrorDecision {
+		return ErrorDecision{Action: ActionRetryNow}
+	})
+}
+
+// DropAndLog Policy Discarding Failed Messages (Error is Logged)
+func DropAndLog() ErrorPolicy {
+	return ErrorPolicyFunc(func(d *amqp.Delivery, err error) ErrorDecision {
+		defaultLogger.Warn("[DropAndLog] Dropping Message", F("message_id", MessageID(d)), F("routing_key", d.RoutingKey), F("error", err))
+		return ErrorDecision{Action: ActionDrop}
+	})
+}
+
+// MaxAttemptsThenDLQ Policy Retrying Failed Messages until they have been
+// Attempted 'attempts' Times, then Dead Lettering them
+//
+// Retries are Delayed as per Retry Policy 'p' (nil: Retry Immediately).
+func MaxAttemptsThenDLQ(attempts int, p *shared.RetryPolicy) ErrorPolicy {
+	return ErrorPolicyFunc(func(d *amqp.Delivery, _ error) ErrorDecision {
+		// Deliveries so Far (Including this One)
+		n := DeliveryCount(d) + 1
+
+		// Has Message Used all its Attempts?
+		if n >= attempts { // YES
+			return ErrorDecision{Action: ActionDeadLetter}
+		}
+
+		// Should Retry be Delayed?
+		if p != nil { // YES
+			return ErrorDecision{Action: ActionRetryLater, Delay: p.Delay(n)}
+		}
+		return ErrorDecision{Action: ActionRetryNow}
+	})
+}
+
+// ErrorPolicy Policy Applied to Handler Failures (nil: Always Requeue)
+func (c *AMQPServerConnection) ErrorPolicy() ErrorPolicy {
+	return c.errorPolicy
+}
+
+func (c *AMQPServerConnection) SetErrorPolicy(p ErrorPolicy) error {
+	c.errorPolicy = p
+	return nil
+}
+
+// applyErrorPolicy Settle Failed Delivery as Decided by Error Policy (Returns Outcome)
+func (c *AMQPServerConnection) applyErrorPolicy(d *amqp.Delivery, err error) string {
+	decision := ErrorDecision{Action: ActionRetryNow}
+	if c.errorPolicy != nil {
+		decision = c.errorPolicy.Decide(d, err)
+	}
+
+	var serr error
+	outcome := OutcomeRequeued
+	switch decision.Action {
+	case ActionRetryLater:
+		serr = c.RequeueAfter(d, nil, decision.Delay)
+		if serr == nil {
+			return OutcomeDelayed
+		}
+
+		// Can't Delay: Requeue Immediately
+		c.status.recordError(serr)
+		serr = d.Nack(false, true)
+	case ActionDeadLetter:
+		serr = d.Nack(false, false)
+		outcome = OutcomeDeadLettered
+	case ActionDrop:
+		serr = d.Ack(false)
+		outcome = OutcomeDropped
+	default:
+		serr = d.Nack(false, true)
+	}
+
+	if serr != nil {
+		c.status.recordError(serr)
+	}
+	return outcome
+}
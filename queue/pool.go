@@ -0,0 +1,41 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+)
+
+// Buffers Larger than this are not Returned to the Pool (avoid pinning memory)
+const maxPooledBuffer = 1 << 20
+
+// Encode Buffers Reused Across Publishes
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 4096)
+		return &b
+	},
+}
+
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// putBuffer Return Buffer to Pool (Callers Store the, possibly grown, Encoded Message in *bp)
+func putBuffer(bp *[]byte) {
+	// Is the Buffer too Large to Keep?
+	if cap(*bp) > maxPooledBuffer { // YES: Let GC Reclaim it
+		return
+	}
+
+	*bp = (*bp)[:0]
+	bufferPool.Put(bp)
+}
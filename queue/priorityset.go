@@ -0,0 +1,147 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// PriorityChannel Channel Used by Priority Sets
+const PriorityChannel = "priority"
+
+// PriorityLevel Sibling Queue of a Priority Set
+type PriorityLevel int
+
+// Priority Levels (Highest First)
+const (
+	PriorityHigh PriorityLevel = iota
+	PriorityNormal
+	PriorityLow
+)
+
+var priorityLevels = []PriorityLevel{PriorityHigh, PriorityNormal, PriorityLow}
+
+func (l PriorityLevel) String() string {
+	switch l {
+	case PriorityHigh:
+		return "high"
+	case PriorityLow:
+		return "low"
+	}
+	return "normal"
+}
+
+// LevelForPriority Map AMQP Priority (0-9) to Level (7+: High, 0-2: Low)
+func LevelForPriority(p uint8) PriorityLevel {
+	switch {
+	case p >= 7:
+		return PriorityHigh
+	case p <= 2:
+		return PriorityLow
+	}
+	return PriorityNormal
+}
+
+// PrioritySet High, Normal and Low Sibling Queues Emulating Message Priority
+//
+// For Queues without x-max-priority: Consumers always Drain Higher Priority
+// Queues First, so Lower Priority Messages can Starve under Sustained Load.
+type PrioritySet struct {
+	conn *AMQPServerConnection // Server Connection
+	name string                // Base Queue Name
+}
+
+// NewPrioritySet Priority Set with Sibling Queues "<name>-high", "<name>-normal" and "<name>-low"
+func NewPrioritySet(conn *AMQPServerConnection, name string) (*PrioritySet, error) {
+	// Do we have a Name?
+	if name == "" { // NO
+		return nil, ErrQueueNameMissing
+	}
+
+	return &PrioritySet{
+		conn: conn,
+		name: name,
+	}, nil
+}
+
+// Queue Name (Before Prefix) of Level's Queue
+func (s *PrioritySet) Queue(l PriorityLevel) string {
+	return s.name + "-" + l.String()
+}
+
+// Declare Sibling Queues (Idempotent)
+func (s *PrioritySet) Declare() error {
+	for _, l := range priorityLevels {
+		_, err := s.conn.OpenQueueChannel(PriorityChannel, s.Queue(l), true)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Publish Message to Level's Queue
+func (s *PrioritySet) Publish(l PriorityLevel, contentType string, body []byte) error {
+	// Is Level Valid?
+	if l < PriorityHigh || l > PriorityLow { // NO
+		return fmt.Errorf("[PrioritySet] Invalid Priority Level [%d]", l)
+	}
+
+	return s.conn.QueuePublish(PriorityChannel, s.Queue(l), contentType, body)
+}
+
+// PublishJSON Message Encoded as JSON to Level's Queue
+func (s *PrioritySet) PublishJSON(l PriorityLevel, msg interface{}) error {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+
+	return s.Publish(l, "application/json", body)
+}
+
+// Retrieve Next Message, Highest Priority First (nil if all Queues are Empty)
+func (s *PrioritySet) Retrieve() (*amqp.Delivery, PriorityLevel, error) {
+	for _, l := range priorityLevels {
+		d, err := s.conn.QueueRetrieve(PriorityChannel, s.Queue(l))
+		if err != nil {
+			return nil, l, err
+		}
+
+		// Found a Message?
+		if d != nil { // YES
+			return d, l, nil
+		}
+	}
+
+	return nil, PriorityLow, nil
+}
+
+// Process Retrieve Next Message (Highest Priority First) and Pass it to Handler (Returns false if Set was Empty)
+func (s *PrioritySet) Process(ctx context.Context, h Handler, mw ...Middleware) (bool, error) {
+	d, l, err := s.Retrieve()
+	if err != nil || d == nil {
+		return false, err
+	}
+
+	return true, s.conn.handleDelivery(ctx, s.Queue(l), d, Chain(h, mw...))
+}
+
+// Consumer Process Set Messages with Handler until Cancelled
+func (s *PrioritySet) Consumer(h Handler, mw ...Middleware) Consumer {
+	return pollConsumer(s.conn.Logger(), s.name, func(ctx context.Context) (bool, error) {
+		return s.Process(ctx, h, mw...)
+	})
+}
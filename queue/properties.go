@@ -0,0 +1,109 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// Envelope <-> AMQP Properties Mapping
+//
+//	header id             <-> MessageId
+//	header created        <-> Timestamp
+//	body type             <-> Type
+//	header priority       <-> Priority
+//	header correlation_id <-> CorrelationId (DEFAULT: header parent)
+
+type headerCarrier interface {
+	Header() *messages.QueueMessageHeader
+}
+
+type typeCarrier interface {
+	Type() string
+}
+
+// messageHeader Queue Header of Message (nil if not a Queue Message)
+//
+// NOTE: Types that Shadow Header() (i.e. EmailMessage) are Reached through their Envelope.
+func messageHeader(msg interface{}) *messages.QueueMessageHeader {
+	if m, ok := msg.(headerCarrier); ok {
+		return m.Header()
+	}
+
+	if e := messages.EnvelopeOf(msg); e != nil {
+		return e.Header()
+	}
+	return nil
+}
+
+// MapToPublishing Copy Envelope Header Fields to AMQP Properties (Properties already Set are Kept)
+func MapToPublishing(msg interface{}, p *amqp.Publishing) {
+	// Does Message have a Type?
+	if t, ok := msg.(typeCarrier); ok && p.Type == "" { // YES
+		p.Type = t.Type()
+	}
+
+	// Does Message have a Queue Header?
+	h := messageHeader(msg)
+	if h == nil { // NO
+		return
+	}
+
+	if p.MessageId == "" {
+		p.MessageId = h.ID()
+	}
+
+	if p.Timestamp.IsZero() {
+		p.Timestamp = h.Created()
+	}
+
+	if p.Priority == 0 {
+		p.Priority = h.Priority()
+	}
+
+	if p.CorrelationId == "" {
+		p.CorrelationId = h.CorrelationID()
+		if p.CorrelationId == "" {
+			p.CorrelationId = h.Parent()
+		}
+	}
+}
+
+// MapFromDelivery Fill Envelope Header Fields Missing from the Body with AMQP Properties
+func MapFromDelivery(d *amqp.Delivery, msg interface{}) {
+	// Does Message have a Queue Header?
+	h := messageHeader(msg)
+	if h == nil { // NO
+		return
+	}
+
+	if h.ID() == "" && d.MessageId != "" {
+		h.SetID(d.MessageId)
+	}
+
+	if !h.HasCreated() && !d.Timestamp.IsZero() {
+		h.SetCreated(d.Timestamp)
+	}
+
+	if h.Priority() == 0 && d.Priority > 0 {
+		h.SetPriority(d.Priority)
+	}
+
+	if h.CorrelationID() == "" && d.CorrelationId != "" && d.CorrelationId != h.Parent() {
+		h.SetCorrelationID(d.CorrelationId)
+	}
+
+	if h.Tracing() == nil {
+		h.SetTracing(headerTracing(d))
+	}
+}
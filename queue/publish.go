@@ -0,0 +1,67 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// PublishMessage Validate and Publish Queue Message
+//
+// Missing Header ID and Creation Time are Stamped before Validation. The
+// Message is Encoded by its own MarshalJSON, and its Envelope Sets the AMQP
+// MessageId, Timestamp and Type (so Brokers can Deduplicate and Route on them).
+func (c *AMQPServerConnection) PublishMessage(channel string, queue string, m messages.IMessage, opts ...PublishOption) error {
+	// Do we have a Message?
+	if m == nil || (reflect.ValueOf(m).Kind() == reflect.Ptr && reflect.ValueOf(m).IsNil()) { // NO
+		return &messages.ErrInvalidMessage{Message: fmt.Sprintf("%T", m), Reason: "Nil Message"}
+	}
+
+	// Stamp Missing Header Fields
+	if err := stampHeader(m); err != nil {
+		return err
+	}
+
+	// Is the Message Valid?
+	if err := validate(m); err != nil { // NO
+		return err
+	}
+
+	return c.QueuePublishJSON(channel, queue, m, opts...)
+}
+
+// stampHeader Set Missing Header ID (GUID) and Creation Time
+func stampHeader(msg interface{}) error {
+	// Does Message have a Queue Header?
+	h := messageHeader(msg)
+	if h == nil { // NO
+		return nil
+	}
+
+	if h.ID() == "" {
+		uid, err := uuid.NewV4()
+		if err != nil {
+			return fmt.Errorf("[PublishMessage] Failed to Generate Message ID [%v]", err)
+		}
+		h.SetID(uid.String())
+	}
+
+	if !h.HasCreated() {
+		h.SetCreated(time.Now())
+	}
+	return nil
+}
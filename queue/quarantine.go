@@ -0,0 +1,162 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// QuarantineSuffix Appended to Queue Name to Form its Quarantine Queue
+const QuarantineSuffix = "-quarantine"
+
+// QuarantineChannel Channel Used to Publish to Quarantine Queues
+const QuarantineChannel = "quarantine"
+
+// Quarantine Metadata Headers
+const (
+	HeaderQuarantineError = "x-quarantine-error" // Why Message was Quarantined
+	HeaderQuarantineQueue = "x-quarantine-queue" // Queue Message was Consumed From
+	HeaderQuarantineTime  = "x-quarantine-time"  // When Message was Quarantined
+	HeaderRedelivered     = "x-quarantine-redelivered"
+)
+
+// errQuarantine Handler Error Requesting Quarantine
+type errQuarantine struct {
+	err error
+}
+
+func (e *errQuarantine) Error() string {
+	return "[Quarantine] " + e.err.Error()
+}
+
+func (e *errQuarantine) Unwrap() error {
+	return e.err
+}
+
+// QuarantineError Wrap Handler Error so the Message is Quarantined instead of Requeued
+func QuarantineError(err error) error {
+	// Already Wrapped?
+	var q *errQuarantine
+	if err == nil || errors.As(err, &q) { // YES
+		return err
+	}
+	return &errQuarantine{err}
+}
+
+// IsQuarantineError Does Error Request Quarantine?
+func IsQuarantineError(err error) bool {
+	var q *errQuarantine
+	return errors.As(err, &q)
+}
+
+// QuarantineQueue Name (Before Prefix) of Quarantine Queue for 'queue'
+func QuarantineQueue(queue string) string {
+	return queue + QuarantineSuffix
+}
+
+// DeclareQuarantine Create Quarantine Queue for 'queue'
+func (c *AMQPServerConnection) DeclareQuarantine(queue string) error {
+	// Resolve Default Queue
+	if queue == "" {
+		queue = c.queue
+	}
+
+	_, err := c.OpenQueueChannel(QuarantineChannel, QuarantineQueue(queue), true)
+	return err
+}
+
+// Quarantine Publish Raw Delivery plus Error Metadata to Quarantine Queue, then Ack Original
+func (c *AMQPServerConnection) Quarantine(queue string, d *amqp.Delivery, cause error) error {
+	// Resolve Default Queue
+	if queue == "" {
+		queue = c.queue
+	}
+
+	// Make Sure Quarantine Queue Exists (Declared Once per Connection)
+	q := QuarantineQueue(queue)
+	ch, err := c.OpenQueueChannel(QuarantineChannel, q, true)
+	if err != nil {
+		return err
+	}
+
+	p := &amqp.Publishing{}
+
+	p.Headers = amqp.Table{}
+	for k, v := range d.Headers {
+		p.Headers[k] = v
+	}
+
+	reason := "unknown"
+	if cause != nil {
+		reason = cause.Error()
+	}
+	p.Headers[HeaderQuarantineError] = reason
+	p.Headers[HeaderQuarantineQueue] = queue
+	p.Headers[HeaderQuarantineTime] = time.Now().UTC().Format(time.RFC3339)
+	p.Headers[HeaderRedelivered] = d.Redelivered
+
+	p.ContentType = d.ContentType
+	p.ContentEncoding = d.ContentEncoding
+	p.DeliveryMode = amqp.Persistent
+	p.MessageId = d.MessageId
+	p.CorrelationId = d.CorrelationId
+	p.Timestamp = d.Timestamp
+	p.Type = d.Type
+	p.AppId = d.AppId
+	p.Body = d.Body
+
+	qName, _ := c.queueName(q)
+	err = c.publishTo("Quarantine", ch, "", qName, p)
+	if err != nil {
+		return err
+	}
+	return d.Ack(false)
+}
+
+// QuarantineAfter Middleware Quarantining Messages whose Handler Failed 'failures' Times
+//
+// Failures are Counted in Memory by Message ID (Messages without ID are not Counted).
+func QuarantineAfter(failures int) Middleware {
+	var lock sync.Mutex
+	counts := map[string]int{}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			err := next(ctx, d)
+
+			id := MessageID(d)
+			if id == "" {
+				return err
+			}
+
+			lock.Lock()
+			defer lock.Unlock()
+
+			// Did Handler Succeed?
+			if err == nil { // YES: Forget Failures
+				delete(counts, id)
+				return nil
+			}
+
+			counts[id]++
+			if counts[id] >= failures {
+				delete(counts, id)
+				return QuarantineError(err)
+			}
+			return err
+		}
+	}
+}
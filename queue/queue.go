@@ -12,23 +12,47 @@ package queue
  */
 
 import (
-	"encoding/json"
-	"errors"
+	"context"
+	"crypto/tls"
 	"fmt"
-	"log"
-	"strings"
+	"sync"
+	"time"
 
 	amqp "github.com/rabbitmq/amqp091-go"
 
+	"github.com/objectvault/queue-interface/codec"
 	"github.com/objectvault/queue-interface/shared"
 )
 
+// Connection Settings (as Used by amqp.Dial)
+const (
+	DefaultHeartbeat = 10 * time.Second
+	DefaultLocale    = "en_US"
+)
+
 type AMQPServerConnection struct {
-	connection *amqp.Connection          // Server Connection
-	channels   *map[string]*amqp.Channel // Channels to Server
-	servers    []shared.AMQPConnection   // Connection Settings for Multiple Servers
-	prefix     string                    // Queue Name Prefix
-	queue      string                    // Default Queue Name
+	connection  *amqp.Connection          // Server Connection (Guarded by connLock)
+	connLock    sync.RWMutex              // Connection Lock (Monitor Swaps the Connection)
+	dialLock    sync.Mutex                // Serializes Connection Attempts
+	monitors    int32                     // Running Monitors (Consumers Wait for Reconnects)
+	channels    *map[string]*amqp.Channel // Channels to Server
+	lock        sync.RWMutex              // Channels Lock (Consumers Share Connection)
+	servers     []shared.AMQPConnection   // Connection Settings for Multiple Servers
+	prefix      string                    // Queue Name Prefix
+	queue       string                    // Default Queue Name
+	defaults    *shared.Defaults          // Operational Defaults (Timeouts, Retries)
+	codec       codec.Codec               // Message Encoding (DEFAULT: JSON)
+	status      connectionStatus          // State Reported to Monitoring
+	metrics     *Metrics                  // [OPTIONAL] Published Counters
+	appID       string                    // [OPTIONAL] AppId Stamped on Published Messages
+	userID      string                    // [OPTIONAL] UserId Stamped on Published Messages
+	consumers   consumerRegistry          // Active Push Consumers
+	receipts    string                    // [OPTIONAL] Queue Receiving Delivery Receipts
+	errorPolicy ErrorPolicy               // [OPTIONAL] Handler Failure Policy (DEFAULT: Requeue)
+	sequencer   *Sequencer                // [OPTIONAL] Stamps Published Messages with Sequence Numbers
+	strategy    ServerStrategy            // [OPTIONAL] Order Servers are Tried (DEFAULT: from Defaults)
+	logger      Logger                    // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+	tracer      Tracer                    // [OPTIONAL] Creates Spans for Traced Publish
 }
 
 func (c *AMQPServerConnection) queueName(name string) (string, error) {
@@ -37,7 +61,7 @@ func (c *AMQPServerConnection) queueName(name string) (string, error) {
 	}
 
 	if name == "" {
-		return "", errors.New("[queueName] Missing Queue Name")
+		return "", ErrQueueNameMissing
 	}
 
 	if c.prefix == "" {
@@ -46,8 +70,16 @@ func (c *AMQPServerConnection) queueName(name string) (string, error) {
 	return c.prefix + "-" + name, nil
 }
 
+// QueueName Name of Queue on the Server (Prefix Applied, "" for Default Queue)
+func (c *AMQPServerConnection) QueueName(name string) (string, error) {
+	return c.queueName(name)
+}
+
 func (c *AMQPServerConnection) getChannel(name string) *amqp.Channel {
 	// Do we have any Open Channels?
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
 	if c.channels != nil { // YES: Is the Required Channel Opened?
 		ch, ok := (*c.channels)[name]
 		if ok { // YES: Return Reference to Channel
@@ -58,117 +90,151 @@ func (c *AMQPServerConnection) getChannel(name string) *amqp.Channel {
 	return nil
 }
 
-func (c *AMQPServerConnection) queueURI(con *shared.AMQPConnection) (string, error) {
-	// Do we have a User Defined?
-	user := con.User
-	if user == "" { // NO
-		log.Println("[queueURI] Server Configuration Missing User [DEFAULT=guest]")
-		user = "guest"
-	}
+func (c *AMQPServerConnection) cacheChannel(name string, ch *amqp.Channel, count bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
 
-	// Do we have a Password Defined?
-	password := con.Password
-	if password == "" { // NO
-		log.Println("[queueURI] Server Configuration Missing Password [DEFAULT=guest]")
-		password = "guest"
-	}
-
-	// String Builder
-	var builder strings.Builder
-
-	// URI BUILT ACCORDING TO https://www.rabbitmq.com/uri-spec.html
-	// AUTHENTICATION COMPONENT of URI //
-
-	// Is a User Set?
-	var auth string
-	if user != "" { // YES
-		// Is Password Set?
-		if password != "" { // YES
-			fmt.Fprintf(&builder, "%s:%s", user, password)
-			auth = builder.String()
-			builder.Reset()
-		} else { // NO: Just User
-			auth = user
-		}
+	// Do we have a Channels Cache?
+	if c.channels == nil { // NO: Create it
+		c.channels = &map[string]*amqp.Channel{}
 	}
 
-	// CONNECTION COMPONENT of URI //
-
-	// Do we have a Servers Definition?
-	server := con.Server
-	if server == nil { // NO
-		return "", errors.New("[queueURI] Server Configuration Missing Server Definition")
+	(*c.channels)[name] = ch
+	if count {
+		c.status.setChannels(len(*c.channels))
 	}
+}
 
-	// Get Server Host (IP or ADDRESS)
-	host := server.Host
-	if host == "" {
-		return "", errors.New("[queueURI] Server Configuration Contains Invalid Server Definitions")
+func (c *AMQPServerConnection) dropChannel(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	// Do we have a Channels Cache?
+	if c.channels != nil { // YES
+		delete(*c.channels, name)
+		c.status.setChannels(len(*c.channels))
 	}
+}
 
-	// [OPTIONAL] Get Server Port
-	port := server.Port
+func (c *AMQPServerConnection) channelNames() []string {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
 
-	// Does Server Have Specific Port?
-	var connection string
-	if port != 0 { // YES: Build Server Address
-		fmt.Fprintf(&builder, "%s:%d", host, port)
-		connection = builder.String()
-		builder.Reset()
-	} else { // NO: Just Add the Server
-		connection = host
+	names := []string{}
+	if c.channels != nil {
+		for name := range *c.channels {
+			names = append(names, name)
+		}
 	}
+	return names
+}
 
-	// [OPTIONAL] Virtual Host
-	vhost := con.VHost
+func (c *AMQPServerConnection) queueURI(con *shared.AMQPConnection) (string, error) {
+	// Do we have a User Defined?
+	if con.User == "" { // NO
+		c.Logger().Warn("[queueURI] Configuration Missing User [DEFAULT=guest]", F("server", con.SafeString()))
+	}
 
-	// BUILD URI //
-	if auth != "" {
-		if vhost != "" {
-			fmt.Fprintf(&builder, "amqp://%s@%s/%s", auth, connection, vhost)
-		} else {
-			fmt.Fprintf(&builder, "amqp://%s@%s", auth, connection)
-		}
-	} else {
-		if vhost != "" {
-			fmt.Fprintf(&builder, "amqp://%s/%s", connection, vhost)
-		} else {
-			fmt.Fprintf(&builder, "amqp://%s", connection)
-		}
+	// Do we have a Password Defined?
+	if con.Password == "" { // NO
+		c.Logger().Warn("[queueURI] Configuration Missing Password [DEFAULT=guest]", F("server", con.SafeString()))
 	}
 
-	// TODO Handle Server Options (Convert to URI Query Options)
-	return builder.String(), nil
+	return con.URI()
 }
 
 func (c *AMQPServerConnection) openConnection() (*amqp.Connection, error) {
 	limit := len(c.servers)
 	// Do we have a Connection Set?
 	if limit == 0 { // NO: Abort
-		return nil, errors.New("[AMQPServerConnection] No Connection Settings")
+		return nil, ErrNoServers
 	}
 
-	for i := 0; i < limit; i++ {
-		server := &c.servers[0]
+	var last error
+	for _, i := range c.ServerStrategy().Order(limit) {
+		// Is Index Valid?
+		if i < 0 || i >= limit { // NO: Broken Strategy
+			continue
+		}
+
+		server := &c.servers[i]
 		// Can we Create a URI from the Information?
 		uri, err := c.queueURI(server)
 		if err != nil { // NO
+			last = err
+			c.Logger().Error("[openConnection] Invalid Server", F("server", server.SafeString()), F("error", err))
 			continue
 		}
 
 		// Can we Create a Connection from the URI?
-		newConnection, err := amqp.Dial(uri)
-		if err == nil { // NO
+		newConnection, err := c.dial(server, uri)
+		if err == nil { // YES
 			return newConnection, nil
 		}
+
+		// NOTE: Dial Errors can Quote the URI (Password Included)
+		last = server.RedactError(err)
+		c.Logger().Warn("[openConnection] Failed Connecting", F("server", server.SafeString()), F("error", last))
+	}
+
+	err := ErrConnectFailed
+	if last != nil {
+		err = fmt.Errorf("%w [%v]", ErrConnectFailed, last)
+	}
+
+	c.status.recordError(err)
+	return nil, err
+}
+
+// dial Connect to Server (AMQPS if TLS is Enabled) within the Connect Timeout
+func (c *AMQPServerConnection) dial(server *shared.AMQPConnection, uri string) (*amqp.Connection, error) {
+	// Is TLS Enabled?
+	cfg, err := server.TLSConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	// Warn about Unverified Connections
+	if cfg != nil && cfg.InsecureSkipVerify {
+		c.Logger().Warn("[dial] Certificate is NOT Verified [insecure_skip_verify]", F("server", server.SafeString()))
+	}
+
+	// Are Server Options Valid?
+	options, err := server.ConnectionOptions()
+	if err != nil { // NO
+		return nil, err
+	}
+
+	return amqp.DialConfig(uri, dialConfig(options, cfg, c.Defaults().GetConnectTimeout()))
+}
+
+// dialConfig Same Settings as amqp.Dial, with Connect Timeout and Server Options Applied
+func dialConfig(o *shared.ConnectionOptions, cfg *tls.Config, timeout time.Duration) amqp.Config {
+	config := amqp.Config{
+		Heartbeat:       DefaultHeartbeat,
+		Locale:          DefaultLocale,
+		TLSClientConfig: cfg,
+		ChannelMax:      o.ChannelMax,
+		FrameSize:       o.FrameMax,
 	}
 
-	return nil, errors.New("[openConnection] Unable to Connect to any Servers")
+	if o.Heartbeat > 0 {
+		config.Heartbeat = o.Heartbeat
+	}
+	if o.Locale != "" {
+		config.Locale = o.Locale
+	}
+	if o.ConnectionTimeout > 0 {
+		timeout = o.ConnectionTimeout
+	}
+
+	config.Dial = amqp.DefaultDial(timeout)
+	return config
 }
 
 func (c *AMQPServerConnection) SetConnection(s []shared.AMQPConnection) error {
 	// Do we already have a connection open?
-	if c.connection != nil { // YES: Close it
+	if c.conn() != nil { // YES: Close it
 		c.CloseConnection()
 	}
 
@@ -176,13 +242,54 @@ func (c *AMQPServerConnection) SetConnection(s []shared.AMQPConnection) error {
 	return nil
 }
 
+// Configure Apply Queue Configuration (Servers, Prefix, Defaults and Identity)
+func (c *AMQPServerConnection) Configure(q *shared.Queue) error {
+	// Do we have a Configuration?
+	if q == nil { // NO
+		return shared.ErrNoConfiguration
+	}
+
+	err := c.SetConnection(q.Servers)
+	if err != nil {
+		return err
+	}
+
+	c.SetPrefix(q.QueuePrefix)
+	c.SetDefaults(q.Defaults)
+	c.SetAppID(q.AppID)
+	c.SetUserID(q.UserID)
+	c.SetReceiptsQueue(q.Receipts)
+	return nil
+}
+
+func (c *AMQPServerConnection) AppID() string {
+	return c.appID
+}
+
+// SetAppID Application Identifier Stamped on Published Messages
+func (c *AMQPServerConnection) SetAppID(id string) error {
+	c.appID = id
+	return nil
+}
+
+func (c *AMQPServerConnection) UserID() string {
+	return c.userID
+}
+
+// SetUserID User Stamped on Published Messages (RabbitMQ Rejects Messages whose
+// UserId does not Match the Connection User)
+func (c *AMQPServerConnection) SetUserID(id string) error {
+	c.userID = id
+	return nil
+}
+
 func (c *AMQPServerConnection) Prefix() string {
 	return c.prefix
 }
 
 func (c *AMQPServerConnection) SetPrefix(p string) error {
 	// Do we already have a connection open?
-	if c.connection != nil { // YES: Close it
+	if c.conn() != nil { // YES: Close it
 		c.CloseConnection()
 	}
 
@@ -199,14 +306,74 @@ func (c *AMQPServerConnection) SetDefaultQueue(name string) error {
 	return nil
 }
 
+func (c *AMQPServerConnection) Defaults() *shared.Defaults {
+	// Do we have Defaults Set?
+	if c.defaults == nil { // NO: Use Package Defaults
+		c.defaults = shared.NewDefaults()
+	}
+
+	return c.defaults
+}
+
+func (c *AMQPServerConnection) SetDefaults(d *shared.Defaults) error {
+	c.defaults = d
+	return nil
+}
+
+func (c *AMQPServerConnection) Codec() codec.Codec {
+	// Do we have a Codec Set?
+	if c.codec == nil { // NO: Use JSON
+		return codec.JSON
+	}
+
+	return c.codec
+}
+
+func (c *AMQPServerConnection) SetCodec(cdc codec.Codec) error {
+	c.codec = cdc
+	return nil
+}
+
+// SetCodecByName Select Registered Codec (i.e. "json-fast")
+func (c *AMQPServerConnection) SetCodecByName(name string) error {
+	cdc, err := codec.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	c.codec = cdc
+	return nil
+}
+
+// RequeueLimitReached Has the Message been Requeued the Maximum Number of Times?
+func (c *AMQPServerConnection) RequeueLimitReached(count int) bool {
+	return count >= c.Defaults().GetMaxRequeues()
+}
+
+// conn Current Server Connection (nil if not Open)
+func (c *AMQPServerConnection) conn() *amqp.Connection {
+	c.connLock.RLock()
+	defer c.connLock.RUnlock()
+	return c.connection
+}
+
 func (c *AMQPServerConnection) HasConnection() bool {
-	return c.connection != nil
+	return c.conn() != nil
+}
+
+// IsConnected Do we have a Connection the Server hasn't Closed?
+func (c *AMQPServerConnection) IsConnected() bool {
+	con := c.conn()
+	return con != nil && !con.IsClosed()
 }
 
 func (c *AMQPServerConnection) OpenConnection() (*amqp.Connection, error) {
+	c.dialLock.Lock()
+	defer c.dialLock.Unlock()
+
 	// Do we already have a connection open?
-	if c.connection != nil { // YES: Return it
-		return c.connection, nil
+	if con := c.conn(); con != nil { // YES: Return it
+		return con, nil
 	}
 
 	// Open a New Connection
@@ -215,42 +382,54 @@ func (c *AMQPServerConnection) OpenConnection() (*amqp.Connection, error) {
 		return nil, err
 	}
 
+	c.connLock.Lock()
 	c.connection = newConnection
-	return c.connection, nil
+	c.connLock.Unlock()
+	return newConnection, nil
 }
 
 func (c *AMQPServerConnection) ResetConnection() (*amqp.Connection, error) {
-	// Do we already have a connection open?
-	if c.connection != nil { // YES: Close it
-		c.CloseConnection()
-	}
-
-	return c.OpenConnection()
+	return c.ResetConnectionWithPolicy(nil)
 }
 
 func (c *AMQPServerConnection) CloseConnection() error {
+	return c.closeConnection(true)
+}
+
+// closeConnection Close Connection and its Channels ('forget': Stop Push Consumers,
+// otherwise they Wait for the Monitor to Redeclare them)
+func (c *AMQPServerConnection) closeConnection(forget bool) error {
+	c.connLock.Lock()
+	con := c.connection
+	c.connection = nil
+	c.connLock.Unlock()
+
 	// Do we have an open connection?
-	if c.connection != nil { // YES: Close it
+	if con != nil { // YES: Close it
 		// Do we have Open Channels
+		c.lock.Lock()
 		if c.channels != nil { // YES: Close any Open Channels
 			var err error
 			for _, ch := range *c.channels {
 				err = ch.Close()
 				if err != nil {
-					log.Println("[CloseConnection] Error Closing Channel")
+					c.Logger().Warn("[CloseConnection] Error Closing Channel", F("error", err))
 				}
 			}
 		}
 		// Clear Channels
 		c.channels = nil
+		c.lock.Unlock()
+		c.status.setChannels(0)
+		if forget {
+			c.forgetConsumers()
+		}
 
 		// Close the Connection
-		err := c.connection.Close()
+		err := con.Close()
 		if err != nil {
-			log.Println("[CloseConnection] Error Closing Connections")
+			c.Logger().Warn("[CloseConnection] Error Closing Connections", F("error", err))
 		}
-		// Clear Connections
-		c.connection = nil
 		return err
 	}
 
@@ -268,8 +447,9 @@ func (c *AMQPServerConnection) IsChannelOpen(name string) bool {
 
 func (c *AMQPServerConnection) OpenChannel(name string) (*amqp.Channel, error) {
 	// Do we have a Server Connection?
-	if c.connection == nil { // NO: Abort
-		return nil, errors.New("[OpenChannel] NO Connection Established")
+	con := c.conn()
+	if con == nil { // NO: Abort
+		return nil, ErrNoConnection
 	}
 
 	// Do we have any Open Channels?
@@ -278,24 +458,25 @@ func (c *AMQPServerConnection) OpenChannel(name string) (*amqp.Channel, error) {
 		return ch, nil
 	}
 
-	// Do we have a Channels Cache?
-	if c.channels == nil { // NO: Create it
-		c.channels = &map[string]*amqp.Channel{}
-	}
-
 	// Open a Channel to the Server
-	ch, err := c.connection.Channel()
+	ch, err := con.Channel()
 	if err != nil {
-		log.Println("[OpenChannel] Failed to Open Channel [" + name + "]")
+		c.Logger().Error("[OpenChannel] Failed to Open Channel", F("channel", name), F("error", err))
+		c.status.recordError(err)
 		return nil, err
 	}
 
 	// Cache Channel
-	(*c.channels)[name] = ch
+	c.cacheChannel(name, ch, true)
 	return ch, nil
 }
 
 func (c *AMQPServerConnection) OpenQueueChannel(name string, queue string, create bool) (*amqp.Channel, error) {
+	return c.openQueueChannel(name, queue, create, nil)
+}
+
+// openQueueChannel Open (Cached) Channel to Queue, Declaring Queue with Arguments if 'create'
+func (c *AMQPServerConnection) openQueueChannel(name string, queue string, create bool, args amqp.Table) (*amqp.Channel, error) {
 	// Get Queue Name
 	queue, err := c.queueName(queue)
 	if err != nil {
@@ -315,7 +496,7 @@ func (c *AMQPServerConnection) OpenQueueChannel(name string, queue string, creat
 	// Can we open the Channel?
 	ch, err = c.OpenChannel(chq)
 	if err != nil { // NO
-		log.Println("[OpenQueueChannel] Unable to Open Channel")
+		c.Logger().Error("[OpenQueueChannel] Unable to Open Channel", F("channel", chq), F("error", err))
 		return nil, err
 	}
 
@@ -328,81 +509,209 @@ func (c *AMQPServerConnection) OpenQueueChannel(name string, queue string, creat
 			false, // delete when unused
 			false, // exclusive
 			false, // no-wait
-			nil,   // arguments
+			args,  // arguments
 		)
 
 		// Was Queue Created?
-		if err != nil { // NO: Abort
-			log.Println("[OpenQueueChannel] Failed to Open a Channel to Queue [" + queue + "]")
+		if err != nil { // NO: Abort (Failed Declare Closes the Channel)
+			c.Logger().Error("[OpenQueueChannel] Failed to Open a Channel to Queue", F("queue", queue), F("error", err))
+			c.status.recordError(err)
+			c.dropChannel(chq)
 			return nil, err
 		}
 	}
 
 	// Cache Queue Channel (ALIAS)
-	(*c.channels)[chq] = ch
+	c.cacheChannel(chq, ch, false)
 	return ch, nil
 }
 
-func (c *AMQPServerConnection) QueuePublishString(channel string, queue string, msg string) error {
-	ch, err := c.OpenQueueChannel(channel, queue, false)
+func (c *AMQPServerConnection) QueuePublishString(channel string, queue string, msg string, opts ...PublishOption) error {
+	return c.publish("QueuePublishString", channel, queue, "text/plain", []byte(msg), c.publishOptions(opts))
+}
+
+// QueuePublish Publish Pre-Encoded Message Body with the Given Content Type
+func (c *AMQPServerConnection) QueuePublish(channel string, queue string, contentType string, body []byte) error {
+	return c.publish("QueuePublish", channel, queue, contentType, body, nil)
+}
+
+// QueuePublishDelivery Republish Delivery Keeping its Properties, Headers and
+// Content Encoding (Encoded Bodies are not Compressed Again)
+func (c *AMQPServerConnection) QueuePublishDelivery(channel string, queue string, d *amqp.Delivery) error {
+	return c.republish("QueuePublishDelivery", channel, queue, PublishingFromDelivery(d))
+}
+
+// republish Publish Prepared Message to Queue (Properties are Sent as Is)
+func (c *AMQPServerConnection) republish(caller string, channel string, queue string, p *amqp.Publishing) error {
+	qName, err := c.queueName(queue)
 	if err != nil {
 		return err
 	}
 
-	qName, _ := c.queueName(queue)
-	err = ch.Publish(
-		"",    // exchange : Queue Default Exchange
-		qName, // routing key : Queue Name
-		false, // mandatory
-		false, // immediate
-		amqp.Publishing{
-			ContentType: "text/plain",
-			Body:        []byte(msg),
-		})
+	// Is Package Wide Dry Run On?
+	if DryRun() { // YES
+		return c.skipPublish(caller, p)
+	}
 
+	ch, err := c.OpenQueueChannel(channel, queue, false)
 	if err != nil {
-		log.Println("[QueuePublishString] Failed Publishing Message to Queue [" + queue + "]")
+		return err
 	}
 
-	return err
+	return c.publishTo(caller, ch, "", qName, p)
 }
 
-func (c *AMQPServerConnection) DefaultQueuePublishJSON(channel string, msg interface{}) error {
-	return c.QueuePublishJSON(channel, "", msg)
-}
+func (c *AMQPServerConnection) publish(caller string, channel string, queue string, contentType string, body []byte, o *PublishOptions) error {
+	qName, err := c.queueName(queue)
+	if err != nil {
+		return err
+	}
+
+	// Message Properties
+	p := &amqp.Publishing{}
+
+	p.ContentType = contentType
+	p.Body = body
+	if o != nil {
+		o.applyPublishing(p)
+	}
+
+	// Is this a Dry Run?
+	if o.dryRun() { // YES: Don't Touch the Broker
+		return c.skipPublish(caller, p)
+	}
 
-func (c *AMQPServerConnection) QueuePublishJSON(channel string, queue string, msg interface{}) error {
 	ch, err := c.OpenQueueChannel(channel, queue, false)
 	if err != nil {
 		return err
 	}
 
-	// Marshall Message to JSON Object
-	body, err := json.Marshal(msg)
+	return c.publishTo(caller, ch, "", qName, p)
+}
+
+// ExchangePublish Publish Pre-Encoded Message Body to an Exchange
+func (c *AMQPServerConnection) ExchangePublish(channel string, exchange string, routingKey string, contentType string, body []byte) error {
+	// Message Properties
+	p := &amqp.Publishing{}
+
+	p.ContentType = contentType
+	p.Body = body
+
+	// Is Package Wide Dry Run On?
+	if DryRun() { // YES
+		return c.skipPublish("ExchangePublish", p)
+	}
+
+	ch, err := c.OpenChannel(channel)
 	if err != nil {
 		return err
 	}
 
-	log.Printf("publishing %dB body (%s)", len(body), body)
+	return c.publishTo("ExchangePublish", ch, exchange, routingKey, p)
+}
 
-	qName, _ := c.queueName(queue)
-	err = ch.Publish(
-		"",    // exchange : Queue Default Exchange
-		qName, // routing key : Queue Name
-		false, // mandatory
-		false, // immediate
-		amqp.Publishing{
-			ContentType: "application/json",
-			Body:        []byte(body),
-		})
+// publishTo Publish Message to Exchange ("": Queue Default Exchange) with Routing Key
+func (c *AMQPServerConnection) publishTo(caller string, ch *amqp.Channel, exchange string, routingKey string, p *amqp.Publishing) error {
+	// Compress Large Bodies (if Enabled)
+	if err := c.compress(p); err != nil {
+		return err
+	}
+
+	// Stamp Identity (unless Set by Caller)
+	if p.AppId == "" {
+		p.AppId = c.appID
+	}
+	if p.UserId == "" {
+		p.UserId = c.userID
+	}
+
+	// Limit Time Spent Publishing
+	ctx, cancel := context.WithTimeout(context.Background(), c.Defaults().GetPublishTimeout())
+	defer cancel()
+
+	err := ch.PublishWithContext(
+		ctx,
+		exchange,   // exchange
+		routingKey, // routing key
+		false,      // mandatory
+		false,      // immediate
+		*p)
 
 	if err != nil {
-		log.Println("[QueuePublishJSON] Failed Publishing Message to Queue [" + queue + "]")
+		err = publishError(err)
+		c.Logger().Error("["+caller+"] Failed Publishing Message", F("exchange", exchange), F("routing_key", routingKey), F("error", err))
+		c.status.recordError(err)
+		c.metrics.add(MetricPublishFailures, 1)
+	} else {
+		c.metrics.add(MetricPublishes, 1)
 	}
 
 	return err
 }
 
+func (c *AMQPServerConnection) DefaultQueuePublishJSON(channel string, msg interface{}, opts ...PublishOption) error {
+	return c.QueuePublishJSON(channel, "", msg, opts...)
+}
+
+func (c *AMQPServerConnection) QueuePublishJSON(channel string, queue string, msg interface{}, opts ...PublishOption) error {
+	return c.publishJSON(channel, queue, msg, c.publishOptions(opts))
+}
+
+func (c *AMQPServerConnection) publishJSON(channel string, queue string, msg interface{}, o *PublishOptions) error {
+	if err := o.applyMessage(msg); err != nil {
+		return err
+	}
+
+	// Use Connection Codec if it Produces JSON (i.e. "json-fast")
+	cdc := c.Codec()
+	if cdc.ContentType() != codec.ContentTypeJSON {
+		cdc = codec.JSON
+	}
+
+	// Marshall Message to JSON Object (Buffer Released once Published)
+	bp := getBuffer()
+	defer putBuffer(bp)
+
+	body, err := codec.AppendMarshal(cdc, *bp, msg)
+	if err != nil {
+		return err
+	}
+	*bp = body
+
+	// NOTE: Bodies can Carry Personal Data (Size Only)
+	c.Logger().Debug("[QueuePublishJSON] Publishing", F("queue", queue), F("bytes", len(body)))
+
+	return c.publish("QueuePublishJSON", channel, queue, "application/json", body, o)
+}
+
+// QueuePublishEncoded Publish Message Encoded with the Publish Codec (see
+// WithCodec), or the Connection Codec, Setting the Matching Content Type
+func (c *AMQPServerConnection) QueuePublishEncoded(channel string, queue string, msg interface{}, opts ...PublishOption) error {
+	o := c.publishOptions(opts)
+	if err := o.applyMessage(msg); err != nil {
+		return err
+	}
+
+	cdc := o.Codec
+	if cdc == nil {
+		cdc = c.Codec()
+	}
+
+	// Encode Message (Buffer Released once Published)
+	bp := getBuffer()
+	defer putBuffer(bp)
+
+	body, err := codec.AppendMarshal(cdc, *bp, msg)
+	if err != nil {
+		return err
+	}
+	*bp = body
+
+	c.Logger().Debug("[QueuePublishEncoded] Publishing", F("queue", queue), F("codec", cdc.Name()), F("bytes", len(body)))
+
+	return c.publish("QueuePublishEncoded", channel, queue, cdc.ContentType(), body, o)
+}
+
 func (c *AMQPServerConnection) DefaultQueueRetrieve(channel string) (*amqp.Delivery, error) {
 	return c.QueueRetrieve(channel, "")
 }
@@ -419,6 +728,8 @@ func (c *AMQPServerConnection) QueueRetrieve(channel string, queue string) (*amq
 
 	// Did we receive an error?
 	if err != nil { // YES: Abort
+		c.status.recordError(err)
+		c.metrics.add(MetricRetrieveFailures, 1)
 		return nil, err
 	}
 
@@ -427,6 +738,32 @@ func (c *AMQPServerConnection) QueueRetrieve(channel string, queue string) (*amq
 		return nil, nil
 	}
 
+	c.metrics.add(MetricRetrieved, 1)
+
 	// Return Message
 	return &delivery, nil
 }
+
+func (c *AMQPServerConnection) DefaultQueueRetrieveWait(channel string) (*amqp.Delivery, error) {
+	return c.QueueRetrieveWait(channel, "")
+}
+
+// QueueRetrieveWait Poll Queue until a Message Arrives or the Consume Timeout Expires
+func (c *AMQPServerConnection) QueueRetrieveWait(channel string, queue string) (*amqp.Delivery, error) {
+	deadline := time.Now().Add(c.Defaults().GetConsumeTimeout())
+	for {
+		delivery, err := c.QueueRetrieve(channel, queue)
+
+		// Did we Receive a Message (or an Error)?
+		if err != nil || delivery != nil { // YES: Return it
+			return delivery, err
+		}
+
+		// Have we Waited Long Enough?
+		if time.Now().After(deadline) { // YES: No Message
+			return nil, nil
+		}
+
+		time.Sleep(100 * time.Millisecond)
+	}
+}
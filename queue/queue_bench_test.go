@@ -0,0 +1,90 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+
+	"github.com/objectvault/queue-interface/codec"
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// benchAction Action Message Similar in Size to Production Email Actions
+func benchAction(b *testing.B) *messages.ActionMessage {
+	b.Helper()
+
+	m, err := messages.NewQueueActionWithGUID("9f1b7a3c-2e4d-4b6f-8a1c-3d5e7f9a1b2c", "email:welcome")
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	err = m.SetParameters(map[string]interface{}{
+		"template": "welcome",
+		"to":       "user@example.com",
+		"from":     "vault@example.com",
+		"headers":  map[string]interface{}{"reply-to": "support@example.com"},
+	})
+	if err == nil {
+		err = m.SetProperties(map[string]interface{}{"user": "admin", "store": "1234"})
+	}
+	if err != nil {
+		b.Fatal(err)
+	}
+	return m
+}
+
+// benchConnection Connection in Dry Run Mode (Publishes Encode and Check but never Reach a Broker)
+func benchConnection(b *testing.B, cdc codec.Codec) *AMQPServerConnection {
+	b.Helper()
+
+	c := &AMQPServerConnection{}
+	if err := c.SetDefaultQueue("bench"); err != nil {
+		b.Fatal(err)
+	}
+	if err := c.SetCodec(cdc); err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
+
+func benchmarkPublishJSON(b *testing.B, cdc codec.Codec) {
+	c := benchConnection(b, cdc)
+	m := benchAction(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.QueuePublishJSON("bench", "", m, WithDryRun()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkQueuePublishJSON(b *testing.B) {
+	benchmarkPublishJSON(b, codec.JSON)
+}
+
+func BenchmarkQueuePublishJSONFast(b *testing.B) {
+	benchmarkPublishJSON(b, codec.FastJSON)
+}
+
+func BenchmarkQueuePublishEncoded(b *testing.B) {
+	c := benchConnection(b, codec.FastJSON)
+	m := benchAction(b)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := c.QueuePublishEncoded("bench", "", m, WithDryRun()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
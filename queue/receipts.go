@@ -0,0 +1,81 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ReceiptsChannel Channel Used to Publish Delivery Receipts
+const ReceiptsChannel = "receipts"
+
+// Delivery Outcomes
+const (
+	OutcomeAcked        = "acked"         // Handler Succeeded
+	OutcomeRequeued     = "requeued"      // Handler Failed, Message Returned to Queue
+	OutcomeDeadLettered = "dead-lettered" // Message Rejected without Requeue
+	OutcomeQuarantined  = "quarantined"   // Message Moved to Quarantine Queue
+	OutcomeDropped      = "dropped"       // Handler Failed, Message Discarded
+	OutcomeDelayed      = "delayed"       // Handler Failed, Message Requeued after a Delay
+)
+
+// Receipt Published after each Handled Message (Receipts Mode)
+type Receipt struct {
+	MessageID  string    `json:"message_id"`      // Message ID (if Known)
+	Queue      string    `json:"queue"`           // Queue Message was Consumed From
+	Outcome    string    `json:"outcome"`         // What Happened to the Message
+	Error      string    `json:"error,omitempty"` // Handler Error
+	DurationMS int64     `json:"duration_ms"`     // Time Spent Handling Message
+	Time       time.Time `json:"time"`            // Time Message was Settled
+}
+
+// ReceiptsQueue Queue Receiving Delivery Receipts ("": Receipts Disabled)
+func (c *AMQPServerConnection) ReceiptsQueue() string {
+	return c.receipts
+}
+
+// SetReceiptsQueue Enable Receipts for Messages Handled through this Connection ("": Disable)
+func (c *AMQPServerConnection) SetReceiptsQueue(queue string) error {
+	c.receipts = queue
+	return nil
+}
+
+// sendReceipt Publish Receipt (if Enabled)
+//
+// NOTE: Receipt Failures never Affect Message Processing
+func (c *AMQPServerConnection) sendReceipt(queue string, d *amqp.Delivery, outcome string, err error, elapsed time.Duration) {
+	// Are Receipts Enabled?
+	if c.receipts == "" { // NO
+		return
+	}
+
+	// Resolve Default Queue
+	if queue == "" {
+		queue = c.queue
+	}
+
+	r := &Receipt{
+		MessageID:  MessageID(d),
+		Queue:      queue,
+		Outcome:    outcome,
+		DurationMS: elapsed.Milliseconds(),
+		Time:       time.Now().UTC(),
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+
+	if perr := c.QueuePublishJSON(ReceiptsChannel, c.receipts, r); perr != nil {
+		c.Logger().Warn("[Receipts] Failed Publishing Receipt", F("message_id", r.MessageID), F("error", perr))
+	}
+}
@@ -0,0 +1,98 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore dedup
+import (
+	"context"
+)
+
+// ReliableChannel Channel Used by Reliable Consumers
+const ReliableChannel = "reliable"
+
+// DefaultPrefetch Unacknowledged Messages a Reliable Consumer Holds at Once
+const DefaultPrefetch = 10
+
+// ReliableOptions At-Least-Once Consumer Settings
+type ReliableOptions struct {
+	Tag      string     // [OPTIONAL] Consumer Tag ("": Generated)
+	Prefetch int        // [OPTIONAL] Unacknowledged Messages Held (0: DefaultPrefetch)
+	Dedup    Middleware // [OPTIONAL] Idempotency Guard (i.e. inbox.Dedup) Applied before Other Middleware
+}
+
+// ConsumeReliable At-Least-Once Push Consumer, Runs until Context is Cancelled
+//
+// Semantics:
+//   - A Message is Acknowledged only After the Handler Returns nil.
+//   - Handler Errors Requeue the Message (or Quarantine / Dead Letter it, see
+//     QuarantineError and DeadLetterError).
+//   - If the Process Crashes or the Connection Drops, the Broker Redelivers
+//     every Unacknowledged Message (with the Redelivered Flag Set).
+//
+// Idempotency Contract: a Message can therefore be Delivered More than Once,
+// Handlers MUST Tolerate Duplicates, either by being Naturally Idempotent or
+// by Setting opts.Dedup (i.e. inbox.Dedup) so Handled Message IDs are Skipped.
+// A Crash Between Handler Success and Marking the ID Still Yields a Duplicate.
+//
+// Returns ErrConsumerClosed if the Broker Stops Deliveries (i.e. Connection Lost
+// without a Monitor Running to Redeclare the Consumer).
+func (c *AMQPServerConnection) ConsumeReliable(ctx context.Context, queue string, h Handler, opts *ReliableOptions, mw ...Middleware) error {
+	if opts == nil {
+		opts = &ReliableOptions{}
+	}
+
+	// Do we have a Prefetch Limit?
+	prefetch := opts.Prefetch
+	if prefetch <= 0 { // NO: Use Default
+		prefetch = DefaultPrefetch
+	}
+
+	// Limit Unacknowledged Messages (Bounds Redeliveries after a Crash)
+	deliveries, tag, err := c.subscribe(ReliableChannel, queue, opts.Tag, false, prefetch)
+	if err != nil {
+		return err
+	}
+
+	// Dedup Guard Wraps Everything (Duplicates are Acked without Running Handler)
+	if opts.Dedup != nil {
+		mw = append([]Middleware{opts.Dedup}, mw...)
+	}
+	handler := Chain(h, mw...)
+
+	for {
+		select {
+		case <-ctx.Done():
+			// NOTE: Unacknowledged Prefetched Messages are Redelivered to Other Consumers
+			if err := c.CancelConsumer(tag); err != nil {
+				c.Logger().Warn("[ConsumeReliable] Failed Cancelling Consumer", F("tag", tag), F("error", err))
+			}
+			return nil
+
+		case d, ok := <-deliveries:
+			// Did Broker Stop Deliveries?
+			if !ok { // YES
+				return ErrConsumerClosed
+			}
+
+			// NOTE: In-Flight Message Completes even if Shutdown Starts
+			if err := c.handleDelivery(context.Background(), queue, &d, handler); err != nil {
+				c.Logger().Warn("[ConsumeReliable] Message Failed", F("queue", queue), F("error", err))
+			}
+		}
+	}
+}
+
+// ReliableConsumer ConsumeReliable as a Consumer (Run with RunConsumers)
+func (c *AMQPServerConnection) ReliableConsumer(queue string, h Handler, opts *ReliableOptions, mw ...Middleware) Consumer {
+	return func(ctx context.Context) error {
+		return c.ConsumeReliable(ctx, queue, h, opts, mw...)
+	}
+}
@@ -0,0 +1,143 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore requeue, requeues, dlq
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// HeaderDelay Delay (ms) Applied by x-delayed-message Exchanges
+const HeaderDelay = "x-delay"
+
+// RequeueOptions Where Retried and Exhausted Messages are Sent
+type RequeueOptions struct {
+	DelayExchange   string // [OPTIONAL] x-delayed-message Exchange (DEFAULT: Delay Queues, see RequeueAfter)
+	DeadLetterQueue string // [OPTIONAL] Queue Receiving Exhausted Messages (DEFAULT: Nack to Queue's Dead Letter Exchange)
+}
+
+// RequeueWithPolicy Retry Failed Delivery as Scheduled by Policy
+//
+// Records 'cause' on the Message Status and Schedules the Retry (Header
+// Requeue Count and Next Retry Time, see QueueMessageHeader.ScheduleRetry).
+// The Updated Message is Returned to its Queue after the Policy Delay. Once
+// the Policy Allows no more Attempts the Message is Dead Lettered and
+// ErrRetriesExhausted is Returned.
+func (c *AMQPServerConnection) RequeueWithPolicy(d *amqp.Delivery, msg interface{}, p *shared.RetryPolicy, cause error, opts *RequeueOptions) error {
+	// Is Message a Queue Message?
+	env := messages.EnvelopeOf(msg)
+	if env == nil { // NO: Can't Record Retries
+		return &messages.ErrInvalidMessage{Message: fmt.Sprintf("%T", msg), Reason: "Not a Queue Message"}
+	}
+
+	if opts == nil {
+		opts = &RequeueOptions{}
+	}
+
+	h := env.Header()
+	if cause != nil {
+		SetMessageError(h, cause)
+	}
+
+	// Is another Attempt Allowed?
+	now := time.Now()
+	at, ok := h.ScheduleRetry(p, now)
+	if !ok { // NO
+		return c.deadLetter(d, msg, opts.DeadLetterQueue)
+	}
+
+	// Does Broker Delay Messages?
+	if opts.DelayExchange == "" { // NO: Use Delay Queues
+		return c.RequeueAfter(d, msg, at.Sub(now))
+	}
+	return c.requeueDelayed(d, msg, opts.DelayExchange, at.Sub(now))
+}
+
+// requeueDelayed Ack Delivery and Publish Message to x-delayed-message Exchange
+func (c *AMQPServerConnection) requeueDelayed(d *amqp.Delivery, msg interface{}, exchange string, delay time.Duration) error {
+	p, err := replacePublishing(d, msg)
+	if err != nil {
+		return err
+	}
+
+	p.Headers[HeaderDelay] = delay.Milliseconds()
+	p.Headers[HeaderDelayCount] = int32(delayCount(d) + 1)
+
+	ch, err := c.OpenChannel(DelayChannel)
+	if err != nil {
+		return err
+	}
+
+	// Remove Original only once Copy is Waiting
+	err = c.publishTo("RequeueWithPolicy", ch, exchange, d.RoutingKey, p)
+	if err != nil {
+		return err
+	}
+	return d.Ack(false)
+}
+
+// deadLetter Move Message to 'dlq' ("": Nack to Queue's Dead Letter Exchange)
+func (c *AMQPServerConnection) deadLetter(d *amqp.Delivery, msg interface{}, dlq string) error {
+	// Do we have a Dead Letter Queue?
+	if dlq == "" { // NO: Let the Broker Route it
+		if err := d.Nack(false, false); err != nil {
+			return err
+		}
+		return ErrRetriesExhausted
+	}
+
+	p, err := replacePublishing(d, msg)
+	if err != nil {
+		return err
+	}
+
+	name, err := c.queueName(dlq)
+	if err != nil {
+		return err
+	}
+
+	ch, err := c.OpenQueueChannel(RetryChannel, dlq, true)
+	if err != nil {
+		return err
+	}
+
+	// Remove Original only once Copy is Dead Lettered
+	err = c.publishTo("RequeueWithPolicy", ch, "", name, p)
+	if err != nil {
+		return err
+	}
+	if err = d.Ack(false); err != nil {
+		return err
+	}
+	return ErrRetriesExhausted
+}
+
+// replacePublishing Publishing Copying Delivery, with Body Replaced by 'msg' (JSON)
+func replacePublishing(d *amqp.Delivery, msg interface{}) (*amqp.Publishing, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &amqp.Publishing{}
+	copyDelivery(p, d)
+	p.ContentType = "application/json"
+	p.ContentEncoding = ""
+	p.Body = body
+	return p, nil
+}
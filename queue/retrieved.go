@@ -0,0 +1,142 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// RetrievedMessage Retrieved Message with its Delivery Metadata
+//
+// Lets Handlers Inspect and Settle Messages without Depending on amqp Types.
+type RetrievedMessage struct {
+	delivery *amqp.Delivery // Broker Delivery
+}
+
+// NewRetrievedMessage Wrap Delivery (nil if d is nil)
+func NewRetrievedMessage(d *amqp.Delivery) *RetrievedMessage {
+	if d == nil {
+		return nil
+	}
+	return &RetrievedMessage{delivery: d}
+}
+
+// Delivery Underlying Broker Delivery
+func (m *RetrievedMessage) Delivery() *amqp.Delivery {
+	return m.delivery
+}
+
+// Body Raw Message Body
+func (m *RetrievedMessage) Body() []byte {
+	return m.delivery.Body
+}
+
+func (m *RetrievedMessage) ContentType() string {
+	return m.delivery.ContentType
+}
+
+func (m *RetrievedMessage) ContentEncoding() string {
+	return m.delivery.ContentEncoding
+}
+
+// MessageID Message Identifier (AMQP Message ID or Queue Message Header ID)
+func (m *RetrievedMessage) MessageID() string {
+	return MessageID(m.delivery)
+}
+
+// Redelivered Was Message Delivered Before (and not Acknowledged)?
+func (m *RetrievedMessage) Redelivered() bool {
+	return m.delivery.Redelivered
+}
+
+// DeliveryTag Channel Specific Delivery Sequence Number
+func (m *RetrievedMessage) DeliveryTag() uint64 {
+	return m.delivery.DeliveryTag
+}
+
+// Exchange Message was Published To ("": Default Exchange)
+func (m *RetrievedMessage) Exchange() string {
+	return m.delivery.Exchange
+}
+
+// RoutingKey Message was Published With (Queue Name for Default Exchange)
+func (m *RetrievedMessage) RoutingKey() string {
+	return m.delivery.RoutingKey
+}
+
+// Timestamp Time Set by Publisher (Zero if not Set)
+func (m *RetrievedMessage) Timestamp() time.Time {
+	return m.delivery.Timestamp
+}
+
+// MessageCount Messages Remaining in Queue when Message was Retrieved
+func (m *RetrievedMessage) MessageCount() uint32 {
+	return m.delivery.MessageCount
+}
+
+// Headers Copy of AMQP Header Table
+func (m *RetrievedMessage) Headers() map[string]interface{} {
+	h := make(map[string]interface{}, len(m.delivery.Headers))
+	for k, v := range m.delivery.Headers {
+		h[k] = v
+	}
+	return h
+}
+
+// Header Single AMQP Header (nil if not Set)
+func (m *RetrievedMessage) Header(name string) interface{} {
+	return m.delivery.Headers[name]
+}
+
+// DeliveryCount Best Estimate of Previous Delivery Attempts (see DeliveryCount)
+func (m *RetrievedMessage) DeliveryCount() int {
+	return DeliveryCount(m.delivery)
+}
+
+// Decode Body into 'v' (Pointer) with the Codec Matching the Content Type
+func (m *RetrievedMessage) Decode(v interface{}) error {
+	return DecodeInto(m.delivery, v)
+}
+
+// Ack Acknowledge Message
+func (m *RetrievedMessage) Ack() error {
+	return m.delivery.Ack(false)
+}
+
+// Requeue Return Message to Queue
+func (m *RetrievedMessage) Requeue() error {
+	return m.delivery.Nack(false, true)
+}
+
+// Reject Reject Message without Requeue (Dead Lettered if Queue has a DLX)
+func (m *RetrievedMessage) Reject() error {
+	return m.delivery.Nack(false, false)
+}
+
+// QueueRetrieveMessage Retrieve Next Message with Delivery Metadata (nil if Queue is Empty)
+func (c *AMQPServerConnection) QueueRetrieveMessage(channel string, queue string) (*RetrievedMessage, error) {
+	d, err := c.QueueRetrieve(channel, queue)
+	if err != nil {
+		return nil, err
+	}
+	return NewRetrievedMessage(d), nil
+}
+
+// QueueRetrieveMessageWait Wait for Next Message with Delivery Metadata (nil if Consume Timeout Expires)
+func (c *AMQPServerConnection) QueueRetrieveMessageWait(channel string, queue string) (*RetrievedMessage, error) {
+	d, err := c.QueueRetrieveWait(channel, queue)
+	if err != nil {
+		return nil, err
+	}
+	return NewRetrievedMessage(d), nil
+}
@@ -0,0 +1,100 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"time"
+
+	"github.com/objectvault/queue-interface/shared"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// ResetConnectionWithPolicy Close and Reopen Connection, Retrying as per Policy (nil: Connection Defaults)
+func (c *AMQPServerConnection) ResetConnectionWithPolicy(p *shared.RetryPolicy) (*amqp.Connection, error) {
+	p = c.Defaults().GetReconnectPolicy().Override(p)
+
+	// Do we already have a connection open?
+	wait := c.conn() != nil
+	if wait { // YES: Close it (Push Consumers Wait for the Monitor to Redeclare them)
+		c.closeConnection(false)
+	}
+
+	var err error
+	var con *amqp.Connection
+	for attempt := 1; ; attempt++ {
+		// Give the Server Time to Recover
+		if wait {
+			time.Sleep(p.Delay(attempt))
+			c.metrics.add(MetricReconnects, 1)
+		}
+
+		con, err = c.OpenConnection()
+		if err == nil || !p.CanRetry(attempt) {
+			return con, err
+		}
+		wait = true
+	}
+}
+
+// QueuePublishStringWithRetry Publish Text Message, Reconnecting and Retrying as per Policy (nil: Connection Defaults)
+func (c *AMQPServerConnection) QueuePublishStringWithRetry(channel string, queue string, msg string, p *shared.RetryPolicy, opts ...PublishOption) error {
+	// NOTE: Options Built Once, so Retries Reuse the Sequence Number
+	o := c.publishOptions(opts)
+	return c.withPublishRetry(p, func() error {
+		return c.publish("QueuePublishString", channel, queue, "text/plain", []byte(msg), o)
+	})
+}
+
+// QueuePublishJSONWithRetry Publish JSON Message, Reconnecting and Retrying as per Policy (nil: Connection Defaults)
+func (c *AMQPServerConnection) QueuePublishJSONWithRetry(channel string, queue string, msg interface{}, p *shared.RetryPolicy, opts ...PublishOption) error {
+	// NOTE: Options Built Once, so Retries Reuse the Sequence Number
+	o := c.publishOptions(opts)
+	return c.withPublishRetry(p, func() error {
+		return c.publishJSON(channel, queue, msg, o)
+	})
+}
+
+// RequeueDelay Wait before Requeuing a Message already Requeued 'count' Times (nil: Connection Defaults)
+func (c *AMQPServerConnection) RequeueDelay(count int, p *shared.RetryPolicy) time.Duration {
+	return c.Defaults().GetRequeuePolicy().Override(p).Delay(count + 1)
+}
+
+func (c *AMQPServerConnection) withPublishRetry(p *shared.RetryPolicy, publish func() error) error {
+	p = c.Defaults().GetPublishPolicy().Override(p)
+
+	for attempt := 1; ; attempt++ {
+		err := publish()
+		if err == nil || !p.CanRetry(attempt) {
+			return err
+		}
+
+		// Was the Connection (or Channel) Lost?
+		if !isConnectionError(err) { // NO: Retrying won't Help
+			return err
+		}
+
+		// Wait and Retry on a Fresh Connection
+		time.Sleep(p.Delay(attempt))
+		if _, rerr := c.ResetConnection(); rerr != nil {
+			c.Logger().Error("[PublishWithRetry] Failed to Reconnect", F("error", rerr))
+			return rerr
+		}
+	}
+}
+
+// isConnectionError Is Error Caused by a Lost Connection, Closed Channel or Publish Timeout?
+func isConnectionError(err error) bool {
+	switch StatusCode(err) {
+	case StatusNoConnection, StatusPublishTimeout:
+		return true
+	}
+	return false
+}
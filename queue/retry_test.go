@@ -0,0 +1,100 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/shared"
+)
+
+func TestIsConnectionError(t *testing.T) {
+	cases := []struct {
+		err  error
+		want bool
+	}{
+		{amqp.ErrClosed, true},
+		{fmt.Errorf("publish: %w", ErrNoConnection), true},
+		{ErrPublishTimeout, true},
+		{&amqp.Error{Code: amqp.ChannelError, Reason: "channel closed"}, true},
+		{&amqp.Error{Code: amqp.ConnectionForced, Reason: "shutdown"}, true},
+		{&amqp.Error{Code: amqp.NotFound, Reason: "no queue"}, false},
+		{ErrQueueNameMissing, false},
+		{errors.New("marshal failed"), false},
+	}
+
+	for _, c := range cases {
+		if got := isConnectionError(c.err); got != c.want {
+			t.Errorf("isConnectionError(%v) = %v, want %v", c.err, got, c.want)
+		}
+	}
+}
+
+func TestPublishRetrySkipsOtherErrors(t *testing.T) {
+	c := &AMQPServerConnection{}
+
+	calls := 0
+	cause := errors.New("marshal failed")
+	err := c.withPublishRetry(shared.ConstantRetry(0, 5), func() error {
+		calls++
+		return cause
+	})
+
+	if !errors.Is(err, cause) || calls != 1 {
+		t.Errorf("err [%v] after %d calls, want [%v] after 1", err, calls, cause)
+	}
+}
+
+func TestPublishRetryStopsWhenReconnectFails(t *testing.T) {
+	// No Servers: Reconnect Fails without Waiting
+	c := &AMQPServerConnection{}
+	if err := c.SetDefaults(&shared.Defaults{Reconnect: shared.ConstantRetry(0, 1)}); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := 0
+	err := c.withPublishRetry(shared.ConstantRetry(0, 5), func() error {
+		calls++
+		return amqp.ErrClosed
+	})
+
+	if err == nil || errors.Is(err, amqp.ErrClosed) || calls != 1 {
+		t.Errorf("err [%v] after %d calls, want reconnect error after 1", err, calls)
+	}
+}
+
+func TestPublishRetryReusesSequence(t *testing.T) {
+	s, err := NewSequencer("publisher")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &AMQPServerConnection{}
+	if err = c.SetSequencer(s); err != nil {
+		t.Fatal(err)
+	}
+
+	// Attempts Share the Options Built by QueuePublishJSONWithRetry
+	o := c.publishOptions([]PublishOption{WithDryRun()})
+	for attempt := 0; attempt < 3; attempt++ {
+		if err = c.publishJSON("retry", "jobs", map[string]string{"a": "b"}, o); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if s.Last() != 1 {
+		t.Errorf("sequencer issued [%d] numbers, want 1", s.Last())
+	}
+}
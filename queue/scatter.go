@@ -0,0 +1,293 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// ScatterChannel Channel Used to Publish Scattered Requests and Replies
+const ScatterChannel = "scatter"
+
+// DefaultGatherTimeout Time to Wait for Replies when no Timeout is Given
+const DefaultGatherTimeout = 5 * time.Second
+
+// ScatterTarget Destination of Scattered Request
+type ScatterTarget struct {
+	Exchange   string // [OPTIONAL] Exchange (Prefixed like Queues, "": Queue Default Exchange)
+	RoutingKey string // Routing Key (Default Exchange: Queue Name, Prefixed)
+}
+
+// ScatterToQueues Targets for Queues Published through the Default Exchange
+func ScatterToQueues(queues ...string) []ScatterTarget {
+	t := make([]ScatterTarget, 0, len(queues))
+	for _, q := range queues {
+		t = append(t, ScatterTarget{RoutingKey: q})
+	}
+	return t
+}
+
+// ScatterOptions Gather Settings
+type ScatterOptions struct {
+	Quorum  int           // Replies Needed (0: One per Target)
+	Timeout time.Duration // Maximum Wait for Quorum (0: DefaultGatherTimeout)
+}
+
+// GatherResult Replies Collected for a Scattered Request
+type GatherResult struct {
+	Request  string                    // Correlation ID of Request
+	Expected int                       // Replies Needed for Quorum
+	Replies  []*messages.ResultMessage // Replies in Order of Arrival
+}
+
+// Complete Was Quorum Reached?
+func (r *GatherResult) Complete() bool {
+	return len(r.Replies) >= r.Expected
+}
+
+// Failed Replies Reporting an Error
+func (r *GatherResult) Failed() []*messages.ResultMessage {
+	l := []*messages.ResultMessage{}
+	for _, m := range r.Replies {
+		if !m.Succeeded() {
+			l = append(l, m)
+		}
+	}
+	return l
+}
+
+// ScatterGather Publish Request to every Target and Gather Correlated Results
+//
+// Replies go to an Exclusive Reply Queue (AMQP ReplyTo) and must carry the
+// Request ID as Correlation ID (see Reply). Returns when Quorum is Reached,
+// the Timeout Expires (ErrQuorumNotReached) or the Context is Cancelled,
+// with whatever Replies were Gathered.
+func (c *AMQPServerConnection) ScatterGather(ctx context.Context, request interface{}, targets []ScatterTarget, opts *ScatterOptions, popts ...PublishOption) (*GatherResult, error) {
+	// Do we have Targets?
+	if len(targets) == 0 { // NO
+		return nil, fmt.Errorf("[ScatterGather] No Targets")
+	}
+
+	if opts == nil {
+		opts = &ScatterOptions{}
+	}
+
+	r := &GatherResult{
+		Request:  requestID(request),
+		Expected: opts.Quorum,
+	}
+	if r.Expected <= 0 || r.Expected > len(targets) {
+		r.Expected = len(targets)
+	}
+	if r.Request == "" {
+		uid, err := uuid.NewV4()
+		if err != nil {
+			return nil, fmt.Errorf("[ScatterGather] Failed to Generate Request ID [%v]", err)
+		}
+		r.Request = uid.String()
+	}
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultGatherTimeout
+	}
+
+	// Encode Request
+	o := c.publishOptions(append([]PublishOption{WithContext(ctx)}, popts...))
+	if err := o.applyMessage(request); err != nil {
+		return nil, err
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	// Reply Queue (NOTE: Dedicated Channel, Exclusive Queue Dies with it)
+	tag := c.NewConsumerTag(ScatterChannel)
+	replies, replyTo, err := c.openReplyQueue(tag)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeReplyQueue(tag)
+
+	// Scatter
+	ch, err := c.OpenChannel(ScatterChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &amqp.Publishing{}
+
+	p.ContentType = "application/json"
+	p.Body = body
+	p.CorrelationId = r.Request
+	p.ReplyTo = replyTo
+	o.applyPublishing(p)
+
+	for _, t := range targets {
+		exchange, key, err := c.scatterRoute(t)
+		if err == nil {
+			err = c.publishTo("ScatterGather", ch, exchange, key, p)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Gather
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for !r.Complete() {
+		select {
+		case <-ctx.Done():
+			return r, ctx.Err()
+
+		case <-timer.C:
+			return r, fmt.Errorf("%w [%d of %d]", ErrQuorumNotReached, len(r.Replies), r.Expected)
+
+		case d, ok := <-replies:
+			// Did Broker Stop Deliveries?
+			if !ok { // YES
+				return r, ErrConsumerClosed
+			}
+
+			// Is Reply for this Request?
+			if d.CorrelationId != "" && d.CorrelationId != r.Request { // NO: Stale Reply
+				continue
+			}
+
+			m := &messages.ResultMessage{}
+			if err := DecodeInto(&d, m); err != nil {
+				c.Logger().Warn("[ScatterGather] Ignoring Invalid Reply", F("request", r.Request), F("error", err))
+				continue
+			}
+
+			if m.Request() == r.Request {
+				r.Replies = append(r.Replies, m)
+			}
+		}
+	}
+
+	return r, nil
+}
+
+// Reply Send Result to the Requester of a Scattered Request (Delivery ReplyTo)
+func (c *AMQPServerConnection) Reply(d *amqp.Delivery, result *messages.ResultMessage) error {
+	// Does Request Expect a Reply?
+	if d.ReplyTo == "" { // NO
+		return fmt.Errorf("[Reply] Message [%s] has no Reply Queue", MessageID(d))
+	}
+
+	// Correlate with Request
+	request := d.CorrelationId
+	if request == "" {
+		request = MessageID(d)
+	}
+	result.Header().SetCorrelationID(request)
+
+	body, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	ch, err := c.OpenChannel(ScatterChannel)
+	if err != nil {
+		return err
+	}
+
+	p := &amqp.Publishing{}
+
+	p.ContentType = "application/json"
+	p.Body = body
+	p.CorrelationId = request
+	MapToPublishing(result, p)
+
+	// NOTE: Reply Queue is Server Named, so no Prefix
+	return c.publishTo("Reply", ch, "", d.ReplyTo, p)
+}
+
+// scatterRoute Exchange and Routing Key for Target (Names Prefixed)
+func (c *AMQPServerConnection) scatterRoute(t ScatterTarget) (string, string, error) {
+	// Is Target a Queue?
+	if t.Exchange == "" { // YES
+		name, err := c.queueName(t.RoutingKey)
+		return "", name, err
+	}
+
+	name, err := c.queueName(t.Exchange)
+	return name, t.RoutingKey, err
+}
+
+func (c *AMQPServerConnection) openReplyQueue(tag string) (<-chan amqp.Delivery, string, error) {
+	ch, err := c.OpenChannel(ScatterChannel + "." + tag)
+	if err != nil {
+		return nil, "", err
+	}
+
+	q, err := ch.QueueDeclare(
+		"",    // name (Server Generated)
+		false, // durable
+		true,  // delete when unused
+		true,  // exclusive
+		false, // no-wait
+		nil,   // arguments
+	)
+	if err != nil {
+		c.status.recordError(err)
+		c.dropChannel(ScatterChannel + "." + tag)
+		return nil, "", err
+	}
+
+	deliveries, err := ch.Consume(
+		q.Name, // queue
+		tag,    // consumer tag
+		true,   // auto-ack
+		true,   // exclusive
+		false,  // no-local
+		false,  // no-wait
+		nil,    // arguments
+	)
+	if err != nil {
+		c.status.recordError(err)
+		c.dropChannel(ScatterChannel + "." + tag)
+		return nil, "", err
+	}
+
+	return deliveries, q.Name, nil
+}
+
+func (c *AMQPServerConnection) closeReplyQueue(tag string) {
+	// Closing Channel Deletes Exclusive Queue
+	if ch := c.getChannel(ScatterChannel + "." + tag); ch != nil {
+		ch.Close()
+	}
+	c.dropChannel(ScatterChannel + "." + tag)
+}
+
+// requestID Header ID of Request Message ("" if not an Envelope)
+func requestID(msg interface{}) string {
+	h := messageHeader(msg)
+	if h == nil {
+		return ""
+	}
+	return h.ID()
+}
@@ -0,0 +1,234 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore gofrs
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gofrs/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// Sequence AMQP Headers (Mirror Message Header, Readable without Decoding Body)
+const (
+	HeaderPublisherID = "x-publisher-id"
+	HeaderSequence    = "x-sequence"
+)
+
+// Sequencer Per Publisher Instance Monotonic Counter (Safe for Concurrent Use)
+type Sequencer struct {
+	id   string // Publisher Instance Identifier
+	last uint64 // Last Number Issued
+}
+
+// NewSequencer Sequencer for Publisher Instance 'id' ("": Generated)
+func NewSequencer(id string) (*Sequencer, error) {
+	// Do we have an ID?
+	if id == "" { // NO: Generate one (Instance Restarts Start a New Sequence)
+		uid, err := uuid.NewV4()
+		if err != nil {
+			return nil, fmt.Errorf("[Sequencer] Failed to Generate Publisher ID [%v]", err)
+		}
+		id = uid.String()
+	}
+
+	return &Sequencer{id: id}, nil
+}
+
+func (s *Sequencer) ID() string {
+	return s.id
+}
+
+// Next Issue Next Sequence Number (First is 1)
+func (s *Sequencer) Next() uint64 {
+	return atomic.AddUint64(&s.last, 1)
+}
+
+// Last Last Sequence Number Issued (0: None)
+func (s *Sequencer) Last() uint64 {
+	return atomic.LoadUint64(&s.last)
+}
+
+// WithSequencer Stamp Message with Next Number from Sequencer (Overrides Connection Sequencer)
+func WithSequencer(s *Sequencer) PublishOption {
+	return func(o *PublishOptions) {
+		o.Sequencer = s
+	}
+}
+
+func (c *AMQPServerConnection) Sequencer() *Sequencer {
+	return c.sequencer
+}
+
+// SetSequencer Stamp every Message Published through Connection (nil: Stop Stamping)
+func (c *AMQPServerConnection) SetSequencer(s *Sequencer) error {
+	c.sequencer = s
+	return nil
+}
+
+// publishOptions Publish Options with Connection Wide Settings Applied
+func (c *AMQPServerConnection) publishOptions(opts []PublishOption) *PublishOptions {
+	// Do we have a Connection Sequencer?
+	if c.sequencer != nil { // YES: Options may Override it
+		opts = append([]PublishOption{WithSequencer(c.sequencer)}, opts...)
+	}
+	return newPublishOptions(opts)
+}
+
+// SequenceOf Publisher and Sequence Number of Delivery (AMQP Headers or Message Header)
+func SequenceOf(d *amqp.Delivery) (string, uint64, bool) {
+	// Is Sequence in AMQP Headers?
+	if p, ok := d.Headers[HeaderPublisherID].(string); ok && p != "" { // YES
+		if n := tableUint(d.Headers[HeaderSequence]); n > 0 {
+			return p, n, true
+		}
+	}
+
+	m := &messages.QueueMessage{}
+	if m.UnmarshalJSON(deliveryBody(d)) != nil {
+		return "", 0, false
+	}
+
+	h := m.Header()
+	if h.Publisher() == "" || h.Sequence() == 0 {
+		return "", 0, false
+	}
+	return h.Publisher(), h.Sequence(), true
+}
+
+// Sequence Check Results
+const (
+	SequenceInOrder   = "in-order"  // Next Expected Number
+	SequenceGap       = "gap"       // Numbers Skipped (Lost or still In Flight)
+	SequenceReordered = "reordered" // Older than Last Seen (Arrived Late)
+	SequenceDuplicate = "duplicate" // Same as Last Seen (Redelivered)
+)
+
+// SequenceEvent Result of Checking a Delivery against its Publisher Sequence
+type SequenceEvent struct {
+	Publisher string // Publisher Instance
+	Sequence  uint64 // Sequence Number of Delivery
+	Expected  uint64 // Sequence Number Expected (0: First Seen from Publisher)
+	Result    string // i.e. SequenceGap
+	Missing   uint64 // Numbers Skipped (SequenceGap Only)
+}
+
+// SequenceTracker Track Sequence Numbers per Publisher to Detect Gaps and Reordering
+type SequenceTracker struct {
+	lock      sync.Mutex
+	last      map[string]uint64 // Highest Sequence Seen by Publisher
+	gaps      uint64
+	reordered uint64
+	duplicate uint64
+}
+
+func NewSequenceTracker() *SequenceTracker {
+	return &SequenceTracker{
+		last: make(map[string]uint64),
+	}
+}
+
+// Observe Check Sequence Number from Publisher (First Number Seen is always In Order)
+func (t *SequenceTracker) Observe(publisher string, seq uint64) SequenceEvent {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	e := SequenceEvent{Publisher: publisher, Sequence: seq, Result: SequenceInOrder}
+
+	last, seen := t.last[publisher]
+	if seen {
+		e.Expected = last + 1
+	}
+
+	switch {
+	case !seen || seq == e.Expected:
+		t.last[publisher] = seq
+	case seq > e.Expected:
+		e.Result = SequenceGap
+		e.Missing = seq - e.Expected
+		t.gaps += e.Missing
+		t.last[publisher] = seq
+	case seq == last:
+		e.Result = SequenceDuplicate
+		t.duplicate++
+	default:
+		e.Result = SequenceReordered
+		t.reordered++
+	}
+
+	return e
+}
+
+// Counts Numbers Skipped, Late Arrivals and Duplicates Observed so Far
+func (t *SequenceTracker) Counts() (gaps uint64, reordered uint64, duplicates uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.gaps, t.reordered, t.duplicate
+}
+
+// Forget Drop State for Publisher (i.e. Publisher Instance Stopped)
+func (t *SequenceTracker) Forget(publisher string) {
+	t.lock.Lock()
+	delete(t.last, publisher)
+	t.lock.Unlock()
+}
+
+// SequenceCheck Observe Delivery Sequence Numbers and Report Anomalies (nil report: Logged)
+//
+// Deliveries without Sequence Numbers are Passed Through Unchecked.
+func SequenceCheck(t *SequenceTracker, report func(e SequenceEvent)) Middleware {
+	if report == nil {
+		report = func(e SequenceEvent) {
+			defaultLogger.Warn("[SequenceCheck] "+e.Result, F("publisher", e.Publisher), F("sequence", e.Sequence), F("expected", e.Expected))
+		}
+	}
+
+	return func(next Handler) Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			if p, n, ok := SequenceOf(d); ok {
+				if e := t.Observe(p, n); e.Result != SequenceInOrder {
+					report(e)
+				}
+			}
+			return next(ctx, d)
+		}
+	}
+}
+
+func tableUint(v interface{}) uint64 {
+	switch n := v.(type) {
+	case int64:
+		if n > 0 {
+			return uint64(n)
+		}
+	case int32:
+		if n > 0 {
+			return uint64(n)
+		}
+	case int:
+		if n > 0 {
+			return uint64(n)
+		}
+	case uint64:
+		return n
+	case string:
+		u, _ := strconv.ParseUint(n, 10, 64)
+		return u
+	}
+	return 0
+}
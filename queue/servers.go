@@ -0,0 +1,125 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Server Strategy Names (shared.Defaults.ServerStrategy)
+const (
+	StrategyFailover   = "failover"
+	StrategyRoundRobin = "round-robin"
+	StrategyRandom     = "random"
+)
+
+// ServerStrategy Decides the Order Configured Servers are Tried when Connecting
+type ServerStrategy interface {
+	// Order Indexes of 'n' Servers in the Order they should be Tried
+	Order(n int) []int
+}
+
+// failover Always Start with the First Server (Others are Backups)
+type failover struct{}
+
+func (failover) Order(n int) []int {
+	l := make([]int, n)
+	for i := range l {
+		l[i] = i
+	}
+	return l
+}
+
+// roundRobin Start each Connect with the Server after the Previous Start
+type roundRobin struct {
+	next uint32
+}
+
+func (s *roundRobin) Order(n int) []int {
+	if n <= 0 {
+		return nil
+	}
+
+	start := int((atomic.AddUint32(&s.next, 1) - 1) % uint32(n))
+	l := make([]int, n)
+	for i := range l {
+		l[i] = (start + i) % n
+	}
+	return l
+}
+
+// random Try Servers in Random Order
+type random struct {
+	lock sync.Mutex
+	rnd  *rand.Rand
+}
+
+func (s *random) Order(n int) []int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.rnd.Perm(n)
+}
+
+// FailoverStrategy Try Servers in Configured Order (DEFAULT)
+func FailoverStrategy() ServerStrategy {
+	return failover{}
+}
+
+// RoundRobinStrategy Spread Connections by Rotating the First Server Tried
+func RoundRobinStrategy() ServerStrategy {
+	return &roundRobin{}
+}
+
+// RandomStrategy Spread Connections by Shuffling Servers on every Connect
+func RandomStrategy() ServerStrategy {
+	return &random{rnd: rand.New(rand.NewSource(time.Now().UnixNano()))}
+}
+
+// ServerStrategyByName Strategy for Name ("" is Failover)
+func ServerStrategyByName(name string) (ServerStrategy, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", StrategyFailover:
+		return FailoverStrategy(), nil
+	case StrategyRoundRobin:
+		return RoundRobinStrategy(), nil
+	case StrategyRandom:
+		return RandomStrategy(), nil
+	}
+
+	return nil, fmt.Errorf("%w [%s]", ErrUnknownStrategy, name)
+}
+
+// ServerStrategy Strategy Used to Order Servers (DEFAULT: Named in Defaults, or Failover)
+func (c *AMQPServerConnection) ServerStrategy() ServerStrategy {
+	// Do we have a Strategy Set?
+	if c.strategy == nil { // NO: Use the one Named in Defaults
+		s, err := ServerStrategyByName(c.Defaults().GetServerStrategy())
+		if err != nil {
+			c.Logger().Warn("[ServerStrategy] Using Failover", F("error", err))
+			s = FailoverStrategy()
+		}
+
+		// NOTE: Keep Strategy so Round Robin State Survives Reconnects
+		c.strategy = s
+	}
+
+	return c.strategy
+}
+
+func (c *AMQPServerConnection) SetServerStrategy(s ServerStrategy) error {
+	c.strategy = s
+	return nil
+}
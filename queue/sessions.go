@@ -0,0 +1,30 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// SessionsExchange Fanout Exchange Carrying Session Invalidations to every API Node
+const SessionsExchange = "sessions"
+
+// InvalidateSessions Broadcast Session Invalidation to every API Node
+//
+// Exchange is Declared on First Use, so Publishing never Fails for Lack of Subscribers.
+func (c *AMQPServerConnection) InvalidateSessions(msg *messages.SessionInvalidationMessage, opts ...PublishOption) error {
+	return c.announce(SessionsExchange, msg, opts...)
+}
+
+// SessionsConsumer Consumer Handling Session Invalidations (Run with RunConsumers on every API Node)
+func (c *AMQPServerConnection) SessionsConsumer(h TypedHandler[*messages.SessionInvalidationMessage], mw ...Middleware) Consumer {
+	return typedBroadcastConsumer(c, SessionsExchange, h, mw...)
+}
@@ -0,0 +1,77 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"sync"
+)
+
+// OutcomeFunc Receives the Outcome of a Handled Delivery (i.e. OutcomeQuarantined)
+type OutcomeFunc func(outcome string)
+
+type settlementKey struct{}
+
+// settlement Callbacks Waiting for the Delivery to be Settled
+type settlement struct {
+	lock      sync.Mutex
+	callbacks []OutcomeFunc
+	settled   bool
+}
+
+// WithSettlement Context Collecting OnSettled Callbacks for a Single Delivery,
+// and the Function the Transport Calls, Once, with the Outcome after Settling it
+func WithSettlement(ctx context.Context) (context.Context, OutcomeFunc) {
+	s := &settlement{}
+	return context.WithValue(ctx, settlementKey{}, s), s.settle
+}
+
+// OnSettled Call f with the Outcome once the Delivery being Handled is Settled
+//
+// Lets Middleware (i.e. Journals) Record what Actually Happened to the
+// Message (Quarantined, Dead Lettered, Delayed, ...) rather than Guess it
+// from the Handler Error. Returns false if the Transport doesn't Report
+// Outcomes (f is never Called).
+func OnSettled(ctx context.Context, f OutcomeFunc) bool {
+	s, ok := ctx.Value(settlementKey{}).(*settlement)
+	if !ok || f == nil {
+		return false
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Already Settled?
+	if s.settled { // YES: Too Late
+		return false
+	}
+
+	s.callbacks = append(s.callbacks, f)
+	return true
+}
+
+func (s *settlement) settle(outcome string) {
+	s.lock.Lock()
+	callbacks := s.callbacks
+	done := s.settled
+	s.callbacks, s.settled = nil, true
+	s.lock.Unlock()
+
+	// Settled Before?
+	if done { // YES: Outcome Reported Once
+		return
+	}
+
+	// NOTE: Innermost Middleware Registered Last, Called First
+	for i := len(callbacks) - 1; i >= 0; i-- {
+		callbacks[i](outcome)
+	}
+}
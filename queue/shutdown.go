@@ -0,0 +1,121 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultShutdownGrace Time Allowed for In-Flight Handlers to Finish
+const DefaultShutdownGrace = 30 * time.Second
+
+// Consumer Long Running Consumer Loop (Returns when Context is Cancelled)
+type Consumer func(ctx context.Context) error
+
+// QueueConsumer Consumer Processing Queue Messages with Handler until Cancelled
+func (c *AMQPServerConnection) QueueConsumer(channel string, queue string, h Handler, mw ...Middleware) Consumer {
+	return pollConsumer(c.Logger(), queue, func(ctx context.Context) (bool, error) {
+		return c.QueueProcess(ctx, channel, queue, h, mw...)
+	})
+}
+
+// pollConsumer Consumer Calling 'process' until Cancelled, Waiting while Nothing was Processed
+func pollConsumer(logger Logger, name string, process func(ctx context.Context) (bool, error)) Consumer {
+	return func(ctx context.Context) error {
+		for {
+			// Has Shutdown Started?
+			if err := ctx.Err(); err != nil { // YES
+				return nil
+			}
+
+			// NOTE: Handler Receives a Context that Survives Shutdown so In-Flight Messages Complete
+			ok, err := process(context.Background())
+			if err != nil {
+				// Did Retrieve Fail?
+				if !ok { // YES: Abort
+					return err
+				}
+				logger.Warn("[QueueConsumer] Message Failed", F("queue", name), F("error", err))
+			}
+
+			// Was Queue Empty?
+			if !ok { // YES: Wait
+				select {
+				case <-ctx.Done():
+					return nil
+				case <-time.After(100 * time.Millisecond):
+				}
+			}
+		}
+	}
+}
+
+// RunConsumers Run Consumers until SIGTERM/SIGINT (or Context Cancelled), then
+// Wait up to 'grace' for them to Finish and Close the Connection
+func RunConsumers(ctx context.Context, c *AMQPServerConnection, grace time.Duration, consumers ...Consumer) error {
+	// Do we have a Grace Period?
+	if grace <= 0 { // NO: Use Default
+		grace = DefaultShutdownGrace
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(consumers))
+	for _, consumer := range consumers {
+		wg.Add(1)
+		go func(consumer Consumer) {
+			defer wg.Done()
+			if err := consumer(ctx); err != nil && !errors.Is(err, context.Canceled) {
+				errs <- err
+				stop() // One Consumer Failed: Stop the Others
+			}
+		}(consumer)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// Wait for Shutdown (or a Consumer Failure)
+	var err error
+	select {
+	case <-done:
+	case <-ctx.Done():
+		c.Logger().Info("[RunConsumers] Shutting Down")
+		select {
+		case <-done:
+		case <-time.After(grace):
+			err = ErrShutdownTimeout
+		}
+	}
+
+	if c != nil {
+		c.CloseConnection()
+	}
+
+	// Report First Consumer Error
+	select {
+	case cerr := <-errs:
+		return cerr
+	default:
+		return err
+	}
+}
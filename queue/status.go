@@ -0,0 +1,65 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync"
+	"time"
+)
+
+// Connection State Shared with Monitoring (safe for concurrent reads)
+type connectionStatus struct {
+	lock        sync.Mutex
+	channels    int       // Number of Open Channels
+	lastError   error     // Last Operation Error
+	lastErrorAt time.Time // Time of Last Error
+}
+
+func (s *connectionStatus) recordError(err error) {
+	if err == nil {
+		return
+	}
+
+	s.lock.Lock()
+	s.lastError = err
+	s.lastErrorAt = time.Now().UTC()
+	s.lock.Unlock()
+}
+
+func (s *connectionStatus) setChannels(n int) {
+	s.lock.Lock()
+	s.channels = n
+	s.lock.Unlock()
+}
+
+func (s *connectionStatus) snapshot() (int, error, time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.channels, s.lastError, s.lastErrorAt
+}
+
+// LastError Last Error Encountered by the Connection
+func (c *AMQPServerConnection) LastError() error {
+	_, err, _ := c.status.snapshot()
+	return err
+}
+
+// LastErrorTime Time of Last Error (Zero if None)
+func (c *AMQPServerConnection) LastErrorTime() time.Time {
+	_, _, at := c.status.snapshot()
+	return at
+}
+
+// ChannelCount Number of Open Channels
+func (c *AMQPServerConnection) ChannelCount() int {
+	n, _, _ := c.status.snapshot()
+	return n
+}
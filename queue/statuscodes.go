@@ -0,0 +1,146 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// Standard Message Status Codes for Queue Failures
+const (
+	StatusOK                 = 0
+	StatusUnknownError       = 1000
+	StatusNoServers          = 1001
+	StatusConnectFailed      = 1002
+	StatusNoConnection       = 1003
+	StatusQueueNameMissing   = 1004
+	StatusQueueNotFound      = 1005
+	StatusAccessRefused      = 1006
+	StatusPublishTimeout     = 1010
+	StatusPublishFailed      = 1011
+	StatusConsumeFailed      = 1020
+	StatusBrokerError        = 1030
+	StatusInvalidMessage     = 1100
+	StatusUnsupportedVersion = 1101
+	StatusMessageTooLarge    = 1102
+	StatusNotInitialized     = 1103
+)
+
+// Status Text (EN) and I18N Key for a Code
+type statusText struct {
+	en   string
+	i18n string
+}
+
+var statusTexts = map[int]statusText{
+	StatusUnknownError:       {"Unknown Queue Error", "queue.error.unknown"},
+	StatusNoServers:          {"No Queue Servers Configured", "queue.error.no_servers"},
+	StatusConnectFailed:      {"Unable to Connect to Queue Servers", "queue.error.connect_failed"},
+	StatusNoConnection:       {"Queue Connection not Established", "queue.error.no_connection"},
+	StatusQueueNameMissing:   {"Missing Queue Name", "queue.error.queue_name_missing"},
+	StatusQueueNotFound:      {"Queue does not Exist", "queue.error.queue_not_found"},
+	StatusAccessRefused:      {"Queue Access Refused", "queue.error.access_refused"},
+	StatusPublishTimeout:     {"Publish Timed Out", "queue.error.publish_timeout"},
+	StatusPublishFailed:      {"Publish Failed", "queue.error.publish_failed"},
+	StatusConsumeFailed:      {"Consume Failed", "queue.error.consume_failed"},
+	StatusBrokerError:        {"Queue Broker Error", "queue.error.broker"},
+	StatusInvalidMessage:     {"Invalid Message", "message.error.invalid"},
+	StatusUnsupportedVersion: {"Unsupported Message Version", "message.error.unsupported_version"},
+	StatusMessageTooLarge:    {"Message too Large", "message.error.too_large"},
+	StatusNotInitialized:     {"Message not Initialized", "message.error.not_initialized"},
+}
+
+// StatusCode Classify Error into a Standard Status Code
+func StatusCode(err error) int {
+	return statusCode(err, StatusUnknownError)
+}
+
+// statusCode Classify Error (fallback is used for Unrecognized Errors)
+func statusCode(err error, fallback int) int {
+	if err == nil {
+		return StatusOK
+	}
+
+	// Package Errors
+	switch {
+	case errors.Is(err, ErrNoServers):
+		return StatusNoServers
+	case errors.Is(err, ErrConnectFailed):
+		return StatusConnectFailed
+	case errors.Is(err, ErrNoConnection), errors.Is(err, amqp.ErrClosed):
+		return StatusNoConnection
+	case errors.Is(err, ErrQueueNameMissing):
+		return StatusQueueNameMissing
+	case errors.Is(err, ErrPublishTimeout):
+		return StatusPublishTimeout
+	case errors.Is(err, messages.ErrUnsupportedVersion):
+		return StatusUnsupportedVersion
+	case errors.Is(err, messages.ErrMessageTooLarge):
+		return StatusMessageTooLarge
+	case errors.Is(err, messages.ErrNotInitialized):
+		return StatusNotInitialized
+	case errors.Is(err, &messages.ErrInvalidMessage{}):
+		return StatusInvalidMessage
+	}
+
+	// Broker Errors
+	var ae *amqp.Error
+	if errors.As(err, &ae) {
+		switch ae.Code {
+		case amqp.NotFound:
+			return StatusQueueNotFound
+		case amqp.AccessRefused:
+			return StatusAccessRefused
+		case amqp.ChannelError, amqp.ConnectionForced:
+			return StatusNoConnection
+		}
+		return StatusBrokerError
+	}
+
+	return fallback
+}
+
+// StatusForError Message Status Describing Error (nil if err is nil)
+func StatusForError(err error) *messages.QueueMessageStatus {
+	return newStatus(err, StatusUnknownError)
+}
+
+// StatusForPublishError Message Status Describing Error Returned by a Publish
+func StatusForPublishError(err error) *messages.QueueMessageStatus {
+	return newStatus(err, StatusPublishFailed)
+}
+
+// StatusForConsumeError Message Status Describing Error Returned by a Retrieve/Consume
+func StatusForConsumeError(err error) *messages.QueueMessageStatus {
+	return newStatus(err, StatusConsumeFailed)
+}
+
+func newStatus(err error, fallback int) *messages.QueueMessageStatus {
+	if err == nil {
+		return nil
+	}
+
+	code := statusCode(err, fallback)
+	t := statusTexts[code]
+
+	s := messages.NewQueueMessageStatus()
+	s.SetError(code, t.en+": "+err.Error(), t.i18n)
+	return s
+}
+
+// SetMessageError Attach Status Describing Error to Message Header
+func SetMessageError(h *messages.QueueMessageHeader, err error) {
+	h.SetStatus(StatusForError(err))
+}
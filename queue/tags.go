@@ -0,0 +1,308 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+var ErrUnknownConsumer = errors.New("[AMQPServerConnection] Unknown Consumer Tag")
+
+// Consumers Registered on this Connection (by Tag)
+type consumerRegistry struct {
+	lock sync.Mutex
+	tags map[string]*subscription
+}
+
+// subscription Push Consumer, Redeclared by a Monitor after the Connection or its Channel is Lost
+type subscription struct {
+	channel  string                      // Channel Name
+	queue    string                      // Queue (without Prefix)
+	tag      string                      // Consumer Tag
+	autoAck  bool                        // Deliveries Acknowledged on Send
+	prefetch int                         // QoS Prefetch (0: Channel Default)
+	ch       *amqp.Channel               // Current Broker Channel (Guarded by Registry Lock)
+	out      chan amqp.Delivery          // Deliveries Returned to the Caller
+	restored chan (<-chan amqp.Delivery) // Deliveries of Redeclared Consumer
+	done     chan struct{}               // Closed when Consumer is Cancelled or Forgotten
+
+	// Consumer Declaration (nil: Queue Consumer, see consumeQueue)
+	declare func(s *subscription) (*amqp.Channel, <-chan amqp.Delivery, error)
+}
+
+var consumerSequence uint64
+
+// ConsumerTag Structured Tag <service>.<queue>.<host>.<pid>.<sequence>
+func ConsumerTag(service string, queue string) string {
+	host, err := os.Hostname()
+	if err != nil || host == "" {
+		host = "unknown"
+	}
+
+	n := atomic.AddUint64(&consumerSequence, 1)
+	return fmt.Sprintf("%s.%s.%s.%d.%d", tagPart(service), tagPart(queue), tagPart(host), os.Getpid(), n)
+}
+
+func tagPart(s string) string {
+	s = strings.Map(func(r rune) rune {
+		if r == '.' || r == ' ' {
+			return '_'
+		}
+		return r
+	}, strings.TrimSpace(s))
+
+	if s == "" {
+		return "_"
+	}
+	return s
+}
+
+// NewConsumerTag Tag for Consumer of Queue (Service is AppId, or the Program Name)
+func (c *AMQPServerConnection) NewConsumerTag(queue string) string {
+	service := c.appID
+	if service == "" {
+		service = filepath.Base(os.Args[0])
+	}
+
+	name, err := c.queueName(queue)
+	if err != nil {
+		name = queue
+	}
+	return ConsumerTag(service, name)
+}
+
+// QueueSubscribe Start Broker Push Consumer (tag "": Generated), Returns Deliveries and Tag
+//
+// While a Monitor is Running, the Consumer Survives Connection and Channel
+// Loss: the Monitor Redeclares it and the Same Deliveries Channel Resumes.
+func (c *AMQPServerConnection) QueueSubscribe(channel string, queue string, tag string) (<-chan amqp.Delivery, string, error) {
+	return c.subscribe(channel, queue, tag, false, 0)
+}
+
+// QueueSubscribeAutoAck Start Broker Push Consumer whose Deliveries are Acknowledged on Send
+//
+// Messages are Lost if the Consumer Fails to Handle them (see ConsumeAutoAck).
+func (c *AMQPServerConnection) QueueSubscribeAutoAck(channel string, queue string, tag string) (<-chan amqp.Delivery, string, error) {
+	return c.subscribe(channel, queue, tag, true, 0)
+}
+
+// subscribe Start Push Consumer with QoS 'prefetch' (0: Channel Default)
+func (c *AMQPServerConnection) subscribe(channel string, queue string, tag string, autoAck bool, prefetch int) (<-chan amqp.Delivery, string, error) {
+	// Do we have a Tag?
+	if tag == "" { // NO: Generate One
+		tag = c.NewConsumerTag(queue)
+	}
+
+	return c.register(&subscription{
+		channel:  channel,
+		queue:    queue,
+		tag:      tag,
+		autoAck:  autoAck,
+		prefetch: prefetch,
+	})
+}
+
+// register Declare Consumer and Start Forwarding its Deliveries
+func (c *AMQPServerConnection) register(s *subscription) (<-chan amqp.Delivery, string, error) {
+	s.out = make(chan amqp.Delivery)
+	s.restored = make(chan (<-chan amqp.Delivery), 1)
+	s.done = make(chan struct{})
+
+	deliveries, err := c.consume(s)
+	if err != nil {
+		return nil, "", err
+	}
+
+	c.consumers.lock.Lock()
+	if c.consumers.tags == nil {
+		c.consumers.tags = map[string]*subscription{}
+	}
+	c.consumers.tags[s.tag] = s
+	c.consumers.lock.Unlock()
+
+	go c.forward(s, deliveries)
+	return s.out, s.tag, nil
+}
+
+// consume Declare Consumer on Broker, Recording its Channel
+func (c *AMQPServerConnection) consume(s *subscription) (<-chan amqp.Delivery, error) {
+	declare := s.declare
+	if declare == nil {
+		declare = c.consumeQueue
+	}
+
+	ch, deliveries, err := declare(s)
+	if err != nil {
+		return nil, err
+	}
+
+	c.consumers.lock.Lock()
+	s.ch = ch
+	c.consumers.lock.Unlock()
+	return deliveries, nil
+}
+
+// consumeQueue Declare Queue Consumer (Applying QoS)
+func (c *AMQPServerConnection) consumeQueue(s *subscription) (*amqp.Channel, <-chan amqp.Delivery, error) {
+	ch, err := c.OpenQueueChannel(s.channel, s.queue, false)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Do we have a Prefetch Limit?
+	if s.prefetch > 0 { // YES
+		if err = ch.Qos(s.prefetch, 0, false); err != nil {
+			c.status.recordError(err)
+			return nil, nil, err
+		}
+	}
+
+	qName, _ := c.queueName(s.queue)
+	deliveries, err := ch.Consume(
+		qName,     // queue
+		s.tag,     // consumer tag
+		s.autoAck, // auto-ack
+		false,     // exclusive
+		false,     // no-local
+		false,     // no-wait
+		nil,       // arguments
+	)
+	if err != nil {
+		c.status.recordError(err)
+		return nil, nil, err
+	}
+	return ch, deliveries, nil
+}
+
+// forward Pass Broker Deliveries to Caller, Switching to Redeclared Consumers
+//
+// The Caller's Channel is Closed when the Consumer is Cancelled, or when the
+// Broker Stops Deliveries and no Monitor is Running to Redeclare the Consumer.
+func (c *AMQPServerConnection) forward(s *subscription, deliveries <-chan amqp.Delivery) {
+	defer close(s.out)
+
+	for {
+		for d := range deliveries {
+			select {
+			case s.out <- d:
+			case <-s.done:
+				return
+			}
+		}
+
+		// Broker Stopped Deliveries: Wait for Monitor to Redeclare Consumer
+		for deliveries = nil; deliveries == nil; {
+			// Is a Monitor Running?
+			if atomic.LoadInt32(&c.monitors) == 0 { // NO: Consumer is Closed
+				c.dropConsumer(s)
+				return
+			}
+
+			select {
+			case deliveries = <-s.restored:
+			case <-s.done:
+				return
+			case <-time.After(time.Second): // Check Monitor is Still Running
+			}
+		}
+	}
+}
+
+// ConsumerTags Tags of Active Consumers
+func (c *AMQPServerConnection) ConsumerTags() []string {
+	c.consumers.lock.Lock()
+	defer c.consumers.lock.Unlock()
+
+	tags := make([]string, 0, len(c.consumers.tags))
+	for t := range c.consumers.tags {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// CancelConsumer Stop Broker Deliveries to Consumer (Delivery Channel is Closed)
+func (c *AMQPServerConnection) CancelConsumer(tag string) error {
+	c.consumers.lock.Lock()
+	s, ok := c.consumers.tags[tag]
+	delete(c.consumers.tags, tag)
+	c.consumers.lock.Unlock()
+
+	// Is Consumer Known?
+	if !ok { // NO
+		return fmt.Errorf("%w [%s]", ErrUnknownConsumer, tag)
+	}
+
+	close(s.done)
+	return s.ch.Cancel(tag, false)
+}
+
+// forgetConsumers Clear Registry, Closing every Consumer (Connection Closed)
+func (c *AMQPServerConnection) forgetConsumers() {
+	c.consumers.lock.Lock()
+	tags := c.consumers.tags
+	c.consumers.tags = nil
+	c.consumers.lock.Unlock()
+
+	for _, s := range tags {
+		close(s.done)
+	}
+}
+
+// dropConsumer Remove Closed Consumer from Registry
+func (c *AMQPServerConnection) dropConsumer(s *subscription) {
+	c.consumers.lock.Lock()
+	defer c.consumers.lock.Unlock()
+
+	// Is Consumer Still Registered?
+	if c.consumers.tags[s.tag] == s { // YES
+		delete(c.consumers.tags, s.tag)
+	}
+}
+
+// restoreConsumers Redeclare Consumers whose Channel was Lost (Result by Tag, nil if Restored)
+func (c *AMQPServerConnection) restoreConsumers() map[string]error {
+	c.consumers.lock.Lock()
+	lost := []*subscription{}
+	for _, s := range c.consumers.tags {
+		if s.ch == nil || s.ch.IsClosed() {
+			lost = append(lost, s)
+		}
+	}
+	c.consumers.lock.Unlock()
+
+	results := map[string]error{}
+	for _, s := range lost {
+		deliveries, err := c.consume(s)
+		results[s.tag] = err
+		if err != nil {
+			continue
+		}
+
+		// NOTE: Forwarder Picks up the New Deliveries once the Old Ones are Drained
+		select {
+		case <-s.restored: // Replace Unused (Already Lost) Deliveries
+		default:
+		}
+		s.restored <- deliveries
+	}
+	return results
+}
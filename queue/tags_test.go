@@ -0,0 +1,89 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// testSubscription Registered Subscription without a Broker
+func testSubscription(c *AMQPServerConnection, tag string) *subscription {
+	s := &subscription{
+		tag:      tag,
+		out:      make(chan amqp.Delivery),
+		restored: make(chan (<-chan amqp.Delivery), 1),
+		done:     make(chan struct{}),
+	}
+	c.consumers.tags = map[string]*subscription{tag: s}
+	return s
+}
+
+func receive(t *testing.T, out <-chan amqp.Delivery) (amqp.Delivery, bool) {
+	t.Helper()
+
+	select {
+	case d, ok := <-out:
+		return d, ok
+	case <-time.After(5 * time.Second):
+		t.Fatal("no delivery")
+	}
+	return amqp.Delivery{}, false
+}
+
+func TestForwardResumesRestoredConsumer(t *testing.T) {
+	c := &AMQPServerConnection{}
+	atomic.AddInt32(&c.monitors, 1)
+	s := testSubscription(c, "t1")
+
+	lost := make(chan amqp.Delivery, 1)
+	lost <- amqp.Delivery{MessageId: "before"}
+	close(lost)
+	go c.forward(s, lost)
+
+	if d, _ := receive(t, s.out); d.MessageId != "before" {
+		t.Fatalf("expected 'before', got %q", d.MessageId)
+	}
+
+	restored := make(chan amqp.Delivery, 1)
+	restored <- amqp.Delivery{MessageId: "after"}
+	s.restored <- restored
+
+	if d, _ := receive(t, s.out); d.MessageId != "after" {
+		t.Fatalf("expected 'after', got %q", d.MessageId)
+	}
+
+	// Cancelled Consumer Closes the Caller's Channel
+	close(s.done)
+	close(restored)
+	if _, ok := receive(t, s.out); ok {
+		t.Fatal("expected closed deliveries")
+	}
+}
+
+func TestForwardClosesWithoutMonitor(t *testing.T) {
+	c := &AMQPServerConnection{}
+	s := testSubscription(c, "t1")
+
+	lost := make(chan amqp.Delivery)
+	close(lost)
+	go c.forward(s, lost)
+
+	if _, ok := receive(t, s.out); ok {
+		t.Fatal("expected closed deliveries")
+	}
+	if tags := c.ConsumerTags(); len(tags) != 0 {
+		t.Fatalf("expected consumer dropped, got %v", tags)
+	}
+}
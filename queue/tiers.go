@@ -0,0 +1,172 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// cSpell:ignore dlx
+
+// HeaderRetryCount AMQP Header Counting Delayed Retries
+const HeaderRetryCount = "x-retry-count"
+
+// RetryChannel Channel Used for Retry Tiers
+const RetryChannel = "retry"
+
+// DefaultRetryDelays Standard Retry Tiers
+var DefaultRetryDelays = []time.Duration{30 * time.Second, 5 * time.Minute, time.Hour}
+
+var ErrRetriesExhausted = errors.New("[RetryTiers] Retries Exhausted, Message Dead Lettered")
+
+// RetryTiers Wait Queues (TTL + Dead Letter back to Queue) Implementing Delayed Retries
+type RetryTiers struct {
+	conn   *AMQPServerConnection // Server Connection
+	queue  string                // Work Queue (Before Prefix)
+	delays []time.Duration       // Delay for Each Tier
+}
+
+// SetupRetryTiers Declare Wait Queues for 'queue' (nil delays: DefaultRetryDelays)
+func (c *AMQPServerConnection) SetupRetryTiers(queue string, delays []time.Duration) (*RetryTiers, error) {
+	// Do we have Delays?
+	if len(delays) == 0 { // NO: Use Default
+		delays = DefaultRetryDelays
+	}
+
+	target, err := c.queueName(queue)
+	if err != nil {
+		return nil, err
+	}
+
+	ch, err := c.OpenChannel(RetryChannel)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &RetryTiers{
+		conn:   c,
+		queue:  queue,
+		delays: append([]time.Duration(nil), delays...),
+	}
+
+	for i, d := range t.delays {
+		// Is Delay Valid?
+		if d < time.Millisecond { // NO
+			return nil, fmt.Errorf("[SetupRetryTiers] Invalid Delay [%v] for Tier %d", d, i)
+		}
+
+		name, _ := c.queueName(t.Tier(i))
+		_, err = ch.QueueDeclare(
+			name,  // name
+			true,  // durable
+			false, // delete when unused
+			false, // exclusive
+			false, // no-wait
+			amqp.Table{
+				"x-message-ttl":             d.Milliseconds(),
+				"x-dead-letter-exchange":    "",     // Default Exchange...
+				"x-dead-letter-routing-key": target, // ...Routes Expired Messages Back to Queue
+			},
+		)
+		if err != nil {
+			c.Logger().Error("[SetupRetryTiers] Failed Declaring Tier", F("queue", name), F("error", err))
+			c.status.recordError(err)
+			return nil, err
+		}
+	}
+
+	return t, nil
+}
+
+// Tier Queue Name (Before Prefix) of Tier 'i'
+func (t *RetryTiers) Tier(i int) string {
+	return t.queue + "-retry-" + delayLabel(t.delays[i])
+}
+
+func (t *RetryTiers) Delays() []time.Duration {
+	return append([]time.Duration(nil), t.delays...)
+}
+
+// RetryCount Number of Delayed Retries Delivery has Gone Through
+func RetryCount(d *amqp.Delivery) int {
+	switch v := d.Headers[HeaderRetryCount].(type) {
+	case int32:
+		return int(v)
+	case int64:
+		return int(v)
+	case int:
+		return v
+	}
+	return 0
+}
+
+// RetryLater Route Message to the Tier Matching its Retry Count (Dead Letter when Exhausted)
+//
+// msg nil Retries the Original Body, otherwise msg (i.e. with Updated Status) is Sent as JSON.
+func (t *RetryTiers) RetryLater(msg interface{}, d *amqp.Delivery) error {
+	count := RetryCount(d)
+
+	// Have all Tiers been Tried?
+	if count >= len(t.delays) { // YES: Dead Letter
+		if err := d.Nack(false, false); err != nil {
+			return err
+		}
+		return ErrRetriesExhausted
+	}
+
+	// Copy Original Properties
+	p := &amqp.Publishing{}
+
+	copyDelivery(p, d)
+	p.Headers[HeaderRetryCount] = int32(count + 1)
+
+	// Replace Body?
+	if msg != nil { // YES
+		body, err := json.Marshal(msg)
+		if err != nil {
+			return err
+		}
+		p.ContentType = "application/json"
+		p.ContentEncoding = ""
+		p.Body = body
+	}
+
+	ch, err := t.conn.OpenChannel(RetryChannel)
+	if err != nil {
+		return err
+	}
+
+	// Remove Original only once Copy is in the Tier
+	tier, _ := t.conn.queueName(t.Tier(count))
+	err = t.conn.publishTo("RetryLater", ch, "", tier, p)
+	if err != nil {
+		return err
+	}
+	return d.Ack(false)
+}
+
+func delayLabel(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return strconv.FormatInt(int64(d/time.Hour), 10) + "h"
+	case d%time.Minute == 0:
+		return strconv.FormatInt(int64(d/time.Minute), 10) + "m"
+	case d%time.Second == 0:
+		return strconv.FormatInt(int64(d/time.Second), 10) + "s"
+	}
+	return strconv.FormatInt(d.Milliseconds(), 10) + "ms"
+}
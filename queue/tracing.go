@@ -0,0 +1,150 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore traceparent tracestate
+
+import (
+	"context"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// AMQP Headers Carrying W3C Trace Context (as Used by OpenTelemetry AMQP Propagators)
+const (
+	HeaderTraceParent = "traceparent"
+	HeaderTraceState  = "tracestate"
+)
+
+// Span Kinds
+const (
+	SpanKindProducer = "producer"
+	SpanKindConsumer = "consumer"
+)
+
+// Span Unit of Work Started by a Tracer
+type Span interface {
+	// Tracing Trace Context with this Span as Parent (Propagated with Messages)
+	Tracing() *messages.Tracing
+	// End Finish Span, Recording 'err' (if not nil)
+	End(err error)
+}
+
+// Tracer Starts Spans (Adapt an OpenTelemetry Tracer to Plug it in)
+//
+// 'parent' is the Remote Trace Context (nil: Start a New Trace, or Use 'ctx').
+type Tracer interface {
+	Start(ctx context.Context, name string, kind string, parent *messages.Tracing) (context.Context, Span)
+}
+
+// WithTracing Propagate Trace Context with Message
+func WithTracing(t *messages.Tracing) PublishOption {
+	return func(o *PublishOptions) {
+		o.Tracing = t
+	}
+}
+
+type tracingKey struct{}
+
+// ContextWithTracing Attach Trace Context to Context
+func ContextWithTracing(ctx context.Context, t *messages.Tracing) context.Context {
+	return context.WithValue(ctx, tracingKey{}, t)
+}
+
+// TracingFromContext Trace Context Attached to Context (nil if None)
+func TracingFromContext(ctx context.Context) *messages.Tracing {
+	t, _ := ctx.Value(tracingKey{}).(*messages.Tracing)
+	return t
+}
+
+// TracingFromDelivery Trace Context of Delivery (AMQP Headers or Queue Message Header, nil if None)
+func TracingFromDelivery(d *amqp.Delivery) *messages.Tracing {
+	// Did Publisher Set AMQP Headers?
+	if t := headerTracing(d); t != nil { // YES
+		return t
+	}
+
+	m := &messages.QueueMessage{}
+	if m.UnmarshalJSON(deliveryBody(d)) != nil {
+		return nil
+	}
+	return m.Header().Tracing()
+}
+
+// headerTracing Trace Context in Delivery AMQP Headers (nil if None or Invalid)
+func headerTracing(d *amqp.Delivery) *messages.Tracing {
+	tp, ok := d.Headers[HeaderTraceParent].(string)
+	if !ok {
+		return nil
+	}
+
+	ts, _ := d.Headers[HeaderTraceState].(string)
+	t, err := messages.ParseTracing(tp, ts)
+	if err != nil {
+		return nil
+	}
+	return t
+}
+
+func (c *AMQPServerConnection) Tracer() Tracer {
+	return c.tracer
+}
+
+// SetTracer Tracer Used by TracedQueuePublishJSON (nil: Propagate Context Only)
+func (c *AMQPServerConnection) SetTracer(t Tracer) error {
+	c.tracer = t
+	return nil
+}
+
+// TracedQueuePublishJSON Publish Message in a Producer Span, Propagating the Trace Context in 'ctx'
+func (c *AMQPServerConnection) TracedQueuePublishJSON(ctx context.Context, channel string, queue string, msg interface{}, opts ...PublishOption) (err error) {
+	opts = append([]PublishOption{WithContext(ctx)}, opts...)
+
+	// Do we have a Tracer?
+	if c.tracer != nil { // YES: Message Carries the Producer Span
+		var span Span
+		ctx, span = c.tracer.Start(ctx, "publish "+queue, SpanKindProducer, TracingFromContext(ctx))
+		defer func() { span.End(err) }()
+
+		opts = append(opts, WithTracing(span.Tracing()))
+	}
+
+	return c.QueuePublishJSON(channel, queue, msg, opts...)
+}
+
+// TracingMiddleware Place Delivery Trace Context into Handler Context, and
+// Wrap Handler in a Consumer Span (if 't' is not nil)
+func TracingMiddleware(t Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, d *amqp.Delivery) error {
+			parent := TracingFromDelivery(d)
+
+			// Do we have a Tracer?
+			if t == nil { // NO: Propagate Only
+				if parent != nil {
+					ctx = ContextWithTracing(ctx, parent)
+				}
+				return next(ctx, d)
+			}
+
+			ctx, span := t.Start(ctx, "process "+d.RoutingKey, SpanKindConsumer, parent)
+			if st := span.Tracing(); st != nil {
+				ctx = ContextWithTracing(ctx, st)
+			}
+
+			err := next(ctx, d)
+			span.End(err)
+			return err
+		}
+	}
+}
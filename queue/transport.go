@@ -0,0 +1,134 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Headers Carrying AMQP Properties on Transports without them (i.e. Kafka, NATS)
+const (
+	PropertyContentType     = "content-type"
+	PropertyContentEncoding = "content-encoding"
+	PropertyMessageID       = "message-id"
+	PropertyCorrelationID   = "correlation-id"
+	PropertyReplyTo         = "reply-to"
+	PropertyType            = "type"
+	PropertyAppID           = "app-id"
+	PropertyUserID          = "user-id"
+	PropertyPriority        = "priority"
+	PropertyExpiration      = "expiration"
+	PropertyTimestamp       = "timestamp"    // Unix Milliseconds
+	PropertyHeaders         = "amqp-headers" // AMQP Headers (JSON Object)
+)
+
+// PublishingHeaders AMQP Properties and Headers of Publishing as String Headers (Empty Properties Skipped)
+func PublishingHeaders(p *amqp.Publishing) map[string]string {
+	h := map[string]string{}
+	set := func(k string, v string) {
+		if v != "" {
+			h[k] = v
+		}
+	}
+
+	set(PropertyContentType, p.ContentType)
+	set(PropertyContentEncoding, p.ContentEncoding)
+	set(PropertyMessageID, p.MessageId)
+	set(PropertyCorrelationID, p.CorrelationId)
+	set(PropertyReplyTo, p.ReplyTo)
+	set(PropertyType, p.Type)
+	set(PropertyAppID, p.AppId)
+	set(PropertyUserID, p.UserId)
+	set(PropertyExpiration, p.Expiration)
+	if p.Priority > 0 {
+		h[PropertyPriority] = strconv.Itoa(int(p.Priority))
+	}
+	if !p.Timestamp.IsZero() {
+		h[PropertyTimestamp] = strconv.FormatInt(p.Timestamp.UnixMilli(), 10)
+	}
+
+	// NOTE: Headers that can't be Encoded (i.e. Decimals) are Dropped
+	if len(p.Headers) > 0 {
+		if b, err := json.Marshal(p.Headers); err == nil {
+			h[PropertyHeaders] = string(b)
+		}
+	}
+	return h
+}
+
+// DeliveryFromHeaders Delivery with AMQP Properties and Headers Restored from String Headers
+//
+// Integral Header Values are Restored as int64, other Numbers as float64.
+func DeliveryFromHeaders(h map[string]string, body []byte) amqp.Delivery {
+	d := amqp.Delivery{
+		ContentType:     h[PropertyContentType],
+		ContentEncoding: h[PropertyContentEncoding],
+		MessageId:       h[PropertyMessageID],
+		CorrelationId:   h[PropertyCorrelationID],
+		ReplyTo:         h[PropertyReplyTo],
+		Type:            h[PropertyType],
+		AppId:           h[PropertyAppID],
+		UserId:          h[PropertyUserID],
+		Expiration:      h[PropertyExpiration],
+		Body:            body,
+	}
+
+	if n, err := strconv.Atoi(h[PropertyPriority]); err == nil && n > 0 && n < 256 {
+		d.Priority = uint8(n)
+	}
+	if ms, err := strconv.ParseInt(h[PropertyTimestamp], 10, 64); err == nil {
+		d.Timestamp = time.UnixMilli(ms)
+	}
+
+	// Do we have AMQP Headers?
+	if s := h[PropertyHeaders]; s != "" { // YES
+		dec := json.NewDecoder(bytes.NewReader([]byte(s)))
+		dec.UseNumber()
+
+		var t map[string]interface{}
+		if dec.Decode(&t) == nil {
+			d.Headers = amqp.Table{}
+			for k, v := range t {
+				d.Headers[k] = tableValue(v)
+			}
+		}
+	}
+	return d
+}
+
+// tableValue Convert Decoded JSON Value to AMQP Table Value
+func tableValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case json.Number:
+		if n, err := t.Int64(); err == nil {
+			return n
+		}
+		f, _ := t.Float64()
+		return f
+	case map[string]interface{}:
+		m := amqp.Table{}
+		for k, e := range t {
+			m[k] = tableValue(e)
+		}
+		return m
+	case []interface{}:
+		for i, e := range t {
+			t[i] = tableValue(e)
+		}
+		return t
+	}
+	return v
+}
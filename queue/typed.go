@@ -0,0 +1,154 @@
+package queue
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/messages"
+)
+
+// TypedChannel Channel Used by Typed Queues
+const TypedChannel = "typed"
+
+// TypedHandler Processes a Decoded Message (Error: Message is Requeued)
+type TypedHandler[T any] func(ctx context.Context, msg T, d *amqp.Delivery) error
+
+// TypedQueue Queue Client Carrying Messages of a Single Type
+type TypedQueue[T any] struct {
+	conn  *AMQPServerConnection // Server Connection
+	queue string                // Queue Name (Prefix Applied by Connection)
+	poll  time.Duration         // Wait between Retrieves when Queue is Empty
+}
+
+// NewTypedQueue i.e. NewTypedQueue[*messages.InviteMessage](conn, "invites")
+func NewTypedQueue[T any](conn *AMQPServerConnection, queue string) *TypedQueue[T] {
+	return &TypedQueue[T]{
+		conn:  conn,
+		queue: queue,
+		poll:  100 * time.Millisecond,
+	}
+}
+
+func (q *TypedQueue[T]) Name() string {
+	return q.queue
+}
+
+// SetPollInterval Wait between Retrieves when Queue is Empty
+func (q *TypedQueue[T]) SetPollInterval(d time.Duration) *TypedQueue[T] {
+	// Is Interval Valid?
+	if d > 0 { // YES
+		q.poll = d
+	}
+	return q
+}
+
+// Publish Validate and Publish Message (Request ID Taken from Context)
+func (q *TypedQueue[T]) Publish(ctx context.Context, msg T, opts ...PublishOption) error {
+	// Has Caller Given Up?
+	if err := ctx.Err(); err != nil { // YES
+		return err
+	}
+
+	// Is the Message Valid?
+	if err := validate(msg); err != nil { // NO
+		return err
+	}
+
+	return q.conn.QueuePublishJSON(TypedChannel, q.queue, msg, append([]PublishOption{WithContext(ctx)}, opts...)...)
+}
+
+// Decode Delivery Body into Message (Validated, see DecodeDelivery)
+//
+// Deliveries without Content Type are Decoded with the Connection Codec.
+func (q *TypedQueue[T]) Decode(d *amqp.Delivery) (T, error) {
+	msg, err := decodeDelivery[T](d, q.conn.Codec())
+	if err != nil {
+		return msg, fmt.Errorf("[TypedQueue] Queue [%s]: %w", q.queue, err)
+	}
+	return msg, nil
+}
+
+// Process Retrieve and Handle a Single Message (Returns false if Queue was Empty)
+//
+// Messages that can't be Decoded are Quarantined as Retrying won't Help.
+func (q *TypedQueue[T]) Process(ctx context.Context, h TypedHandler[T]) (bool, error) {
+	d, err := q.conn.QueueRetrieve(TypedChannel, q.queue)
+	if err != nil || d == nil {
+		return false, err
+	}
+
+	return true, q.conn.handleDelivery(ctx, q.queue, d, func(ctx context.Context, d *amqp.Delivery) error {
+		// Can Message be Decoded?
+		msg, err := q.Decode(d)
+		if err != nil { // NO: Quarantine
+			return QuarantineError(err)
+		}
+
+		return h(ctx, msg, d)
+	})
+}
+
+// Consume Handle Messages until Context is Cancelled
+func (q *TypedQueue[T]) Consume(ctx context.Context, h TypedHandler[T]) error {
+	for {
+		// Has Caller Stopped Consuming?
+		if err := ctx.Err(); err != nil { // YES
+			return err
+		}
+
+		ok, err := q.Process(ctx, h)
+		if err != nil {
+			// Did Retrieve Fail (Connection Failure)?
+			if !ok { // YES: Abort
+				return err
+			}
+			q.conn.Logger().Warn("[TypedQueue] Message Failed", F("queue", q.queue), F("error", err))
+		}
+
+		// Was Queue Empty?
+		if !ok { // YES: Wait
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(q.poll):
+			}
+		}
+	}
+}
+
+func validate(msg interface{}) error {
+	// Can Message Validate Itself?
+	v, ok := msg.(interface{ IsValid() bool })
+	if !ok { // NO
+		return nil
+	}
+
+	// Is Pointer Nil?
+	if rv := reflect.ValueOf(msg); rv.Kind() == reflect.Ptr && rv.IsNil() { // YES
+		return &messages.ErrInvalidMessage{Message: fmt.Sprintf("%T", msg), Reason: "Nil Message"}
+	}
+
+	// Explain what is Wrong (Registered Validators, Template Context, ...)
+	if err := messages.ValidateMessage(msg); err != nil {
+		return err
+	}
+
+	if !v.IsValid() {
+		return &messages.ErrInvalidMessage{Message: fmt.Sprintf("%T", msg), Reason: "Failed Validation"}
+	}
+	return nil
+}
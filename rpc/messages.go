@@ -0,0 +1,256 @@
+package rpc
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"errors"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Wire Types for queue.proto (Hand Written, Protobuf Binary Format)
+
+// AckAction What to do with a Pulled Message
+type AckAction int32
+
+const (
+	AckActionAck     AckAction = 0
+	AckActionRequeue AckAction = 1
+	AckActionReject  AckAction = 2
+)
+
+var errInvalidWire = errors.New("[rpc] Invalid Protobuf Message")
+
+// wireMessage Message that Encodes Itself
+type wireMessage interface {
+	appendWire(b []byte) []byte
+	readWire(b []byte) error
+}
+
+type PublishRequest struct {
+	Queue       string
+	ContentType string
+	Body        []byte
+}
+
+type PublishResponse struct {
+	MessageID string
+}
+
+type PullRequest struct {
+	Queue       string
+	MaxMessages int32
+	WaitMS      int32
+}
+
+type Message struct {
+	Receipt     string
+	MessageID   string
+	ContentType string
+	Body        []byte
+	Redelivered bool
+}
+
+type PullResponse struct {
+	Messages []*Message
+}
+
+type AckRequest struct {
+	Receipt string
+	Action  AckAction
+}
+
+type AckResponse struct{}
+
+func (m *PublishRequest) appendWire(b []byte) []byte {
+	b = appendString(b, 1, m.Queue)
+	b = appendString(b, 2, m.ContentType)
+	return appendBytes(b, 3, m.Body)
+}
+
+func (m *PublishRequest) readWire(b []byte) error {
+	return readFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			m.Queue = string(v)
+		case num == 2 && typ == protowire.BytesType:
+			m.ContentType = string(v)
+		case num == 3 && typ == protowire.BytesType:
+			m.Body = append([]byte(nil), v...)
+		}
+	})
+}
+
+func (m *PublishResponse) appendWire(b []byte) []byte {
+	return appendString(b, 1, m.MessageID)
+}
+
+func (m *PublishResponse) readWire(b []byte) error {
+	return readFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) {
+		if num == 1 && typ == protowire.BytesType {
+			m.MessageID = string(v)
+		}
+	})
+}
+
+func (m *PullRequest) appendWire(b []byte) []byte {
+	b = appendString(b, 1, m.Queue)
+	b = appendVarint(b, 2, uint64(int64(m.MaxMessages)))
+	return appendVarint(b, 3, uint64(int64(m.WaitMS)))
+}
+
+func (m *PullRequest) readWire(b []byte) error {
+	return readFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			m.Queue = string(v)
+		case num == 2 && typ == protowire.VarintType:
+			m.MaxMessages = int32(n)
+		case num == 3 && typ == protowire.VarintType:
+			m.WaitMS = int32(n)
+		}
+	})
+}
+
+func (m *Message) appendWire(b []byte) []byte {
+	b = appendString(b, 1, m.Receipt)
+	b = appendString(b, 2, m.MessageID)
+	b = appendString(b, 3, m.ContentType)
+	b = appendBytes(b, 4, m.Body)
+	if m.Redelivered {
+		b = appendVarint(b, 5, 1)
+	}
+	return b
+}
+
+func (m *Message) readWire(b []byte) error {
+	return readFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			m.Receipt = string(v)
+		case num == 2 && typ == protowire.BytesType:
+			m.MessageID = string(v)
+		case num == 3 && typ == protowire.BytesType:
+			m.ContentType = string(v)
+		case num == 4 && typ == protowire.BytesType:
+			m.Body = append([]byte(nil), v...)
+		case num == 5 && typ == protowire.VarintType:
+			m.Redelivered = n != 0
+		}
+	})
+}
+
+func (m *PullResponse) appendWire(b []byte) []byte {
+	for _, msg := range m.Messages {
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, msg.appendWire(nil))
+	}
+	return b
+}
+
+func (m *PullResponse) readWire(b []byte) error {
+	var err error
+	rerr := readFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) {
+		if num == 1 && typ == protowire.BytesType && err == nil {
+			msg := &Message{}
+			err = msg.readWire(v)
+			m.Messages = append(m.Messages, msg)
+		}
+	})
+	if rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+func (m *AckRequest) appendWire(b []byte) []byte {
+	b = appendString(b, 1, m.Receipt)
+	return appendVarint(b, 2, uint64(int64(m.Action)))
+}
+
+func (m *AckRequest) readWire(b []byte) error {
+	return readFields(b, func(num protowire.Number, typ protowire.Type, v []byte, n uint64) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			m.Receipt = string(v)
+		case num == 2 && typ == protowire.VarintType:
+			m.Action = AckAction(int32(n))
+		}
+	})
+}
+
+func (m *AckResponse) appendWire(b []byte) []byte {
+	return b
+}
+
+func (m *AckResponse) readWire(b []byte) error {
+	return readFields(b, func(protowire.Number, protowire.Type, []byte, uint64) {})
+}
+
+// proto3: Default (Zero) Values are not Encoded
+func appendString(b []byte, num protowire.Number, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, s)
+}
+
+func appendBytes(b []byte, num protowire.Number, v []byte) []byte {
+	if len(v) == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, v)
+}
+
+func appendVarint(b []byte, num protowire.Number, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, v)
+}
+
+// readFields Call 'field' for Every Field (Unknown Fields are Skipped)
+func readFields(b []byte, field func(num protowire.Number, typ protowire.Type, v []byte, n uint64)) error {
+	for len(b) > 0 {
+		num, typ, l := protowire.ConsumeTag(b)
+		if l < 0 {
+			return errInvalidWire
+		}
+		b = b[l:]
+
+		switch typ {
+		case protowire.VarintType:
+			n, l := protowire.ConsumeVarint(b)
+			if l < 0 {
+				return errInvalidWire
+			}
+			field(num, typ, nil, n)
+			b = b[l:]
+		case protowire.BytesType:
+			v, l := protowire.ConsumeBytes(b)
+			if l < 0 {
+				return errInvalidWire
+			}
+			field(num, typ, v, 0)
+			b = b[l:]
+		default:
+			l := protowire.ConsumeFieldValue(num, typ, b)
+			if l < 0 {
+				return errInvalidWire
+			}
+			b = b[l:]
+		}
+	}
+	return nil
+}
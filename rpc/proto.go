@@ -0,0 +1,61 @@
+package rpc
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+)
+
+// Wire Types are (Legacy) Protobuf Messages: gRPC's Standard "proto" Codec
+// Encodes them through Marshal/Unmarshal, so no Custom Codec is Installed on
+// Servers or Calls, and Clients Generated from queue.proto Interoperate.
+
+func (m *PublishRequest) Reset()                   { *m = PublishRequest{} }
+func (m *PublishRequest) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*PublishRequest) ProtoMessage()              {}
+func (m *PublishRequest) Marshal() ([]byte, error) { return m.appendWire(nil), nil }
+func (m *PublishRequest) Unmarshal(b []byte) error { return m.readWire(b) }
+
+func (m *PublishResponse) Reset()                   { *m = PublishResponse{} }
+func (m *PublishResponse) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*PublishResponse) ProtoMessage()              {}
+func (m *PublishResponse) Marshal() ([]byte, error) { return m.appendWire(nil), nil }
+func (m *PublishResponse) Unmarshal(b []byte) error { return m.readWire(b) }
+
+func (m *PullRequest) Reset()                   { *m = PullRequest{} }
+func (m *PullRequest) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*PullRequest) ProtoMessage()              {}
+func (m *PullRequest) Marshal() ([]byte, error) { return m.appendWire(nil), nil }
+func (m *PullRequest) Unmarshal(b []byte) error { return m.readWire(b) }
+
+func (m *Message) Reset()                   { *m = Message{} }
+func (m *Message) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*Message) ProtoMessage()              {}
+func (m *Message) Marshal() ([]byte, error) { return m.appendWire(nil), nil }
+func (m *Message) Unmarshal(b []byte) error { return m.readWire(b) }
+
+func (m *PullResponse) Reset()                   { *m = PullResponse{} }
+func (m *PullResponse) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*PullResponse) ProtoMessage()              {}
+func (m *PullResponse) Marshal() ([]byte, error) { return m.appendWire(nil), nil }
+func (m *PullResponse) Unmarshal(b []byte) error { return m.readWire(b) }
+
+func (m *AckRequest) Reset()                   { *m = AckRequest{} }
+func (m *AckRequest) String() string           { return fmt.Sprintf("%+v", *m) }
+func (*AckRequest) ProtoMessage()              {}
+func (m *AckRequest) Marshal() ([]byte, error) { return m.appendWire(nil), nil }
+func (m *AckRequest) Unmarshal(b []byte) error { return m.readWire(b) }
+
+func (m *AckResponse) Reset()                   { *m = AckResponse{} }
+func (m *AckResponse) String() string           { return "{}" }
+func (*AckResponse) ProtoMessage()              {}
+func (m *AckResponse) Marshal() ([]byte, error) { return m.appendWire(nil), nil }
+func (m *AckResponse) Unmarshal(b []byte) error { return m.readWire(b) }
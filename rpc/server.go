@@ -0,0 +1,298 @@
+package rpc
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	amqp "github.com/rabbitmq/amqp091-go"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/objectvault/queue-interface/messages"
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// Server Defaults
+const (
+	DefaultVisibilityTimeout = 5 * time.Minute // Pulled Messages not Acknowledged in Time are Requeued
+	MaxPullMessages          = 100             // Limit on Messages per Pull
+	MaxPullWait              = 30 * time.Second
+)
+
+// RPCChannel Channel Used by the Server
+const RPCChannel = "rpc"
+
+// expiryInterval Wait between Visibility Timeout Checks
+var expiryInterval = time.Second
+
+// Broker Publishes and Retrieves Messages (i.e. queue.AMQPServerConnection)
+type Broker interface {
+	QueuePublish(channel string, queue string, contentType string, body []byte) error
+	QueueRetrieve(channel string, queue string) (*amqp.Delivery, error)
+}
+
+type pulled struct {
+	delivery *amqp.Delivery
+	expires  time.Time
+}
+
+// Server QueueService Implementation over an AMQP Connection
+//
+// Expired Receipts are Requeued in the Background until the Server is Closed.
+type Server struct {
+	conn       Broker             // Server Connection
+	lock       sync.Mutex         // Connection and Receipts Lock
+	pending    map[string]*pulled // Receipt to Unacknowledged Delivery
+	visibility time.Duration      // Time Allowed to Acknowledge
+	stop       chan struct{}      // Closed to Stop Expiry Goroutine
+	done       chan struct{}      // Closed when Expiry Goroutine Finishes
+}
+
+func NewServer(conn Broker) *Server {
+	s := &Server{
+		conn:       conn,
+		pending:    map[string]*pulled{},
+		visibility: DefaultVisibilityTimeout,
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+
+	go s.run(s.stop, s.done)
+	return s
+}
+
+// SetVisibilityTimeout Time Allowed between Pull and Ack
+func (s *Server) SetVisibilityTimeout(d time.Duration) *Server {
+	if d > 0 {
+		s.visibility = d
+	}
+	return s
+}
+
+func (s *Server) Publish(ctx context.Context, in *PublishRequest) (*PublishResponse, error) {
+	// Is Request Valid?
+	if in.Queue == "" || len(in.Body) == 0 { // NO
+		return nil, status.Error(codes.InvalidArgument, "queue and body are required")
+	}
+
+	ct := in.ContentType
+	if ct == "" {
+		ct = "application/json"
+	}
+
+	// Validate Standard Envelope
+	id := ""
+	if ct == "application/json" {
+		m := &messages.QueueMessage{}
+		if err := json.Unmarshal(in.Body, m); err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		id = m.Header().ID()
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	err := s.conn.QueuePublish(RPCChannel, in.Queue, ct, in.Body)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &PublishResponse{MessageID: id}, nil
+}
+
+func (s *Server) Pull(ctx context.Context, in *PullRequest) (*PullResponse, error) {
+	// Is Request Valid?
+	if in.Queue == "" { // NO
+		return nil, status.Error(codes.InvalidArgument, "queue is required")
+	}
+
+	max := int(in.MaxMessages)
+	if max < 1 {
+		max = 1
+	} else if max > MaxPullMessages {
+		max = MaxPullMessages
+	}
+
+	wait := time.Duration(in.WaitMS) * time.Millisecond
+	if wait > MaxPullWait {
+		wait = MaxPullWait
+	}
+	deadline := time.Now().Add(wait)
+
+	out := &PullResponse{}
+	for {
+		err := s.pull(in.Queue, max, out)
+		if err != nil {
+			return nil, toStatus(err)
+		}
+
+		// Do we have Messages or has the Wait Expired?
+		if len(out.Messages) > 0 || !time.Now().Before(deadline) { // YES
+			return out, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return out, nil
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+func (s *Server) pull(name string, max int, out *PullResponse) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.expire(time.Now())
+	for len(out.Messages) < max {
+		d, err := s.conn.QueueRetrieve(RPCChannel, name)
+		if err != nil {
+			return err
+		}
+
+		// Is Queue Empty?
+		if d == nil { // YES
+			return nil
+		}
+
+		// NOTE: Clients Receive Plain Bodies (Content Encoding isn't Part of the API)
+		body, err := queue.DecodeBody(d)
+		if err != nil {
+			d.Nack(false, true)
+			return err
+		}
+
+		uid, err := uuid.NewV4()
+		if err != nil {
+			d.Nack(false, true)
+			return err
+		}
+
+		receipt := uid.String()
+		s.pending[receipt] = &pulled{d, time.Now().Add(s.visibility)}
+		out.Messages = append(out.Messages, &Message{
+			Receipt:     receipt,
+			MessageID:   queue.MessageID(d),
+			ContentType: d.ContentType,
+			Body:        body,
+			Redelivered: d.Redelivered,
+		})
+	}
+	return nil
+}
+
+func (s *Server) Ack(ctx context.Context, in *AckRequest) (*AckResponse, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.expire(time.Now())
+
+	// Is Receipt Known?
+	p, ok := s.pending[in.Receipt]
+	if !ok { // NO: Expired or Already Acknowledged
+		return nil, status.Error(codes.NotFound, "unknown or expired receipt")
+	}
+	delete(s.pending, in.Receipt)
+
+	var err error
+	switch in.Action {
+	case AckActionAck:
+		err = p.delivery.Ack(false)
+	case AckActionRequeue:
+		err = p.delivery.Nack(false, true)
+	case AckActionReject:
+		err = p.delivery.Nack(false, false)
+	default:
+		p.delivery.Nack(false, true)
+		return nil, status.Error(codes.InvalidArgument, "unknown action")
+	}
+
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &AckResponse{}, nil
+}
+
+// Close Stop Expiry Goroutine and Requeue all Unacknowledged Messages
+func (s *Server) Close() {
+	s.lock.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.lock.Unlock()
+
+	// Is Expiry Goroutine Running?
+	if stop != nil { // YES
+		close(stop)
+		<-done
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	for r, p := range s.pending {
+		p.delivery.Nack(false, true)
+		delete(s.pending, r)
+	}
+}
+
+// run Requeue Expired Messages Periodically (Receipts are Otherwise Only Checked on Pull or Ack)
+func (s *Server) run(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	t := time.NewTicker(expiryInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-t.C:
+			s.lock.Lock()
+			s.expire(now)
+			s.lock.Unlock()
+		}
+	}
+}
+
+// expire Requeue Messages Past their Visibility Timeout (Lock Held)
+func (s *Server) expire(now time.Time) {
+	for r, p := range s.pending {
+		if now.After(p.expires) {
+			p.delivery.Nack(false, true)
+			delete(s.pending, r)
+		}
+	}
+}
+
+func toStatus(err error) error {
+	var invalid *messages.ErrInvalidMessage
+	var violations *messages.ValidationError
+	switch {
+	case errors.Is(err, queue.ErrQueueNameMissing), errors.As(err, &invalid), errors.As(err, &violations):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, queue.ErrPublishTimeout):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	case errors.Is(err, queue.ErrNoServers), errors.Is(err, queue.ErrConnectFailed), errors.Is(err, queue.ErrNoConnection):
+		return status.Error(codes.Unavailable, err.Error())
+	}
+
+	code := codes.Internal
+	if s, ok := status.FromError(err); ok && s.Code() != codes.Unknown {
+		code = s.Code()
+	}
+	return status.Error(code, err.Error())
+}
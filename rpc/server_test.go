@@ -0,0 +1,153 @@
+package rpc
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/objectvault/queue-interface/memqueue"
+)
+
+// testClient Client Connected over an In Memory Listener to a Server on 'b'
+func testClient(t *testing.T, b *memqueue.Broker) (*QueueServiceClient, *Server) {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(b)
+	gs := grpc.NewServer()
+	RegisterQueueServiceServer(gs, srv)
+	go gs.Serve(lis)
+
+	cc, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return lis.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() {
+		cc.Close()
+		gs.Stop()
+		srv.Close()
+	})
+	return NewQueueServiceClient(cc), srv
+}
+
+func TestPublishPullAck(t *testing.T) {
+	b := memqueue.NewBroker()
+	client, _ := testClient(t, b)
+	ctx := context.Background()
+
+	_, err := client.Publish(ctx, &PublishRequest{Queue: "actions", ContentType: "text/plain", Body: []byte{0, 1, 2, 0xff}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := client.Pull(ctx, &PullRequest{Queue: "actions", MaxMessages: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %d", len(out.Messages))
+	}
+	m := out.Messages[0]
+	if m.ContentType != "text/plain" || string(m.Body) != string([]byte{0, 1, 2, 0xff}) || m.Receipt == "" {
+		t.Fatalf("unexpected message %v", m)
+	}
+	if b.Unacked() != 1 {
+		t.Fatalf("expected 1 unacked delivery, got %d", b.Unacked())
+	}
+
+	if _, err = client.Ack(ctx, &AckRequest{Receipt: m.Receipt}); err != nil {
+		t.Fatal(err)
+	}
+	if b.Unacked() != 0 || b.Len("actions") != 0 {
+		t.Fatalf("expected message acknowledged, got %d unacked, %d queued", b.Unacked(), b.Len("actions"))
+	}
+
+	// Receipts are Single Use
+	_, err = client.Ack(ctx, &AckRequest{Receipt: m.Receipt})
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+}
+
+func TestAckRequeue(t *testing.T) {
+	b := memqueue.NewBroker()
+	client, _ := testClient(t, b)
+	ctx := context.Background()
+
+	if _, err := client.Publish(ctx, &PublishRequest{Queue: "actions", ContentType: "text/plain", Body: []byte("retry")}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := client.Pull(ctx, &PullRequest{Queue: "actions"})
+	if err != nil || len(out.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %v [%v]", out, err)
+	}
+	if _, err = client.Ack(ctx, &AckRequest{Receipt: out.Messages[0].Receipt, Action: AckActionRequeue}); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err = client.Pull(ctx, &PullRequest{Queue: "actions"})
+	if err != nil || len(out.Messages) != 1 {
+		t.Fatalf("expected requeued message, got %v [%v]", out, err)
+	}
+	if !out.Messages[0].Redelivered {
+		t.Fatal("expected redelivered message")
+	}
+}
+
+func TestPublishRejectsInvalidEnvelope(t *testing.T) {
+	client, _ := testClient(t, memqueue.NewBroker())
+
+	_, err := client.Publish(context.Background(), &PublishRequest{Queue: "actions", Body: []byte("not json")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
+
+func TestExpiredReceiptsRequeued(t *testing.T) {
+	interval := expiryInterval
+	expiryInterval = 10 * time.Millisecond
+	defer func() { expiryInterval = interval }()
+
+	b := memqueue.NewBroker()
+	client, srv := testClient(t, b)
+	srv.SetVisibilityTimeout(20 * time.Millisecond)
+	ctx := context.Background()
+
+	if _, err := client.Publish(ctx, &PublishRequest{Queue: "actions", ContentType: "text/plain", Body: []byte("slow")}); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := client.Pull(ctx, &PullRequest{Queue: "actions"}); err != nil || len(out.Messages) != 1 {
+		t.Fatalf("expected 1 message, got %v [%v]", out, err)
+	}
+
+	// NOTE: No Further Pull or Ack, Expiry Runs in the Background
+	deadline := time.Now().Add(5 * time.Second)
+	for b.Len("actions") != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("expired message not requeued")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,99 @@
+package rpc
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// ServiceName Fully Qualified gRPC Service Name (see queue.proto)
+const ServiceName = "objectvault.queue.v1.QueueService"
+
+// QueueServiceServer Server API for QueueService
+type QueueServiceServer interface {
+	Publish(ctx context.Context, in *PublishRequest) (*PublishResponse, error)
+	Pull(ctx context.Context, in *PullRequest) (*PullResponse, error)
+	Ack(ctx context.Context, in *AckRequest) (*AckResponse, error)
+}
+
+// QueueServiceDesc gRPC Service Description
+var QueueServiceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*QueueServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Publish", Handler: publishHandler},
+		{MethodName: "Pull", Handler: pullHandler},
+		{MethodName: "Ack", Handler: ackHandler},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "queue.proto",
+}
+
+// RegisterQueueServiceServer Register Implementation (Any gRPC Server, Standard Codec)
+func RegisterQueueServiceServer(s *grpc.Server, srv QueueServiceServer) {
+	s.RegisterService(&QueueServiceDesc, srv)
+}
+
+func unary[Req any, Res any](method string, call func(QueueServiceServer, context.Context, *Req) (*Res, error)) func(interface{}, context.Context, func(interface{}) error, grpc.UnaryServerInterceptor) (interface{}, error) {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := new(Req)
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+
+		// Do we have an Interceptor?
+		if interceptor == nil { // NO
+			return call(srv.(QueueServiceServer), ctx, in)
+		}
+
+		info := &grpc.UnaryServerInfo{
+			Server:     srv,
+			FullMethod: "/" + ServiceName + "/" + method,
+		}
+		return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return call(srv.(QueueServiceServer), ctx, req.(*Req))
+		})
+	}
+}
+
+var publishHandler = unary("Publish", QueueServiceServer.Publish)
+var pullHandler = unary("Pull", QueueServiceServer.Pull)
+var ackHandler = unary("Ack", QueueServiceServer.Ack)
+
+// QueueServiceClient Go Client for QueueService
+type QueueServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewQueueServiceClient(cc grpc.ClientConnInterface) *QueueServiceClient {
+	return &QueueServiceClient{cc}
+}
+
+func (c *QueueServiceClient) Publish(ctx context.Context, in *PublishRequest, opts ...grpc.CallOption) (*PublishResponse, error) {
+	out := new(PublishResponse)
+	return out, c.invoke(ctx, "Publish", in, out, opts)
+}
+
+func (c *QueueServiceClient) Pull(ctx context.Context, in *PullRequest, opts ...grpc.CallOption) (*PullResponse, error) {
+	out := new(PullResponse)
+	return out, c.invoke(ctx, "Pull", in, out, opts)
+}
+
+func (c *QueueServiceClient) Ack(ctx context.Context, in *AckRequest, opts ...grpc.CallOption) (*AckResponse, error) {
+	out := new(AckResponse)
+	return out, c.invoke(ctx, "Ack", in, out, opts)
+}
+
+func (c *QueueServiceClient) invoke(ctx context.Context, method string, in interface{}, out interface{}, opts []grpc.CallOption) error {
+	return c.cc.Invoke(ctx, "/"+ServiceName+"/"+method, in, out, opts...)
+}
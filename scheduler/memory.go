@@ -0,0 +1,106 @@
+package scheduler
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// In Memory Store (Single Process Only, Entries are Lost on Restart)
+type MemoryStore struct {
+	lock    sync.Mutex        // Map Lock
+	entries map[string]*Entry // Unsent Entries by ID
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries: map[string]*Entry{},
+	}
+}
+
+func (s *MemoryStore) Add(_ context.Context, e *Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	c := *e
+	s.entries[e.ID] = &c
+	return nil
+}
+
+func (s *MemoryStore) Due(_ context.Context, now time.Time, limit int) ([]*Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	l := []*Entry{}
+	for _, e := range s.entries {
+		if !e.DueAt.After(now) {
+			c := *e
+			l = append(l, &c)
+		}
+	}
+
+	sort.Slice(l, func(i, j int) bool {
+		if l[i].DueAt.Equal(l[j].DueAt) {
+			return l[i].ID < l[j].ID
+		}
+		return l[i].DueAt.Before(l[j].DueAt)
+	})
+
+	if limit > 0 && len(l) > limit {
+		l = l[:limit]
+	}
+	return l, nil
+}
+
+func (s *MemoryStore) MarkSent(_ context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	delete(s.entries, id)
+	return nil
+}
+
+func (s *MemoryStore) MarkFailed(_ context.Context, id string, cause error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.entries[id]
+	if ok {
+		e.Attempts++
+		if cause != nil {
+			e.LastError = cause.Error()
+		}
+	}
+	return nil
+}
+
+func (s *MemoryStore) Cancel(_ context.Context, id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Is Entry Pending?
+	if _, ok := s.entries[id]; !ok { // NO
+		return ErrEntryNotFound
+	}
+
+	delete(s.entries, id)
+	return nil
+}
+
+// Len Number of Unsent Entries
+func (s *MemoryStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.entries)
+}
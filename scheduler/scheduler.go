@@ -0,0 +1,298 @@
+package scheduler
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// Runner Defaults
+const (
+	DefaultInterval  = time.Second // Wait between Dispatch Passes
+	DefaultBatchSize = 100         // Maximum Entries Published per Pass
+)
+
+var ErrNoStore = errors.New("[Scheduler] Missing Store")
+var ErrNoPublisher = errors.New("[Scheduler] Missing Publisher")
+var ErrNoDueTime = errors.New("[Scheduler] Missing Due Time")
+var ErrEntryNotFound = errors.New("[Scheduler] Entry not Found")
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+// Message Persisted until it is Due
+type Entry struct {
+	ID          string    // Unique Entry ID
+	Channel     string    // Channel Used to Publish
+	Queue       string    // Target Queue (Empty: Connection Default)
+	ContentType string    // Body Content Type
+	Body        []byte    // Encoded Message
+	DueAt       time.Time // Time Message should be Published
+	CreatedAt   time.Time // Time Entry was Created
+	Attempts    int       // Number of Failed Publish Attempts
+	LastError   string    // Last Publish Error
+}
+
+// Store Persists Scheduled Entries (Must Survive Restarts, see SQLStore)
+type Store interface {
+	Add(ctx context.Context, e *Entry) error                             // Persist New Entry
+	Due(ctx context.Context, now time.Time, limit int) ([]*Entry, error) // Unsent Entries Due at 'now' (Earliest First)
+	MarkSent(ctx context.Context, id string) error                       // Entry Published
+	MarkFailed(ctx context.Context, id string, cause error) error        // Entry Publish Attempt Failed
+	Cancel(ctx context.Context, id string) error                         // Remove Unsent Entry (ErrEntryNotFound if Unknown or Sent)
+}
+
+// Publisher Delivers Encoded Messages (i.e. queue.AMQPServerConnection)
+type Publisher interface {
+	QueuePublish(channel string, queue string, contentType string, body []byte) error
+}
+
+type Scheduler struct {
+	store     Store         // Entry Persistence
+	publisher Publisher     // Message Delivery
+	interval  time.Duration // Wait between Dispatch Passes
+	batch     int           // Entries per Dispatch Pass
+	lock      sync.Mutex    // Runner State Lock
+	stop      chan struct{} // Signal Runner to Stop
+	done      chan struct{} // Runner has Stopped
+	logger    queue.Logger  // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+func NewScheduler(s Store, p Publisher) *Scheduler {
+	return &Scheduler{
+		store:     s,
+		publisher: p,
+		interval:  DefaultInterval,
+		batch:     DefaultBatchSize,
+	}
+}
+
+func (s *Scheduler) Store() Store {
+	return s.store
+}
+
+// SetInterval Wait between Dispatch Passes (Granularity of Publish Time)
+func (s *Scheduler) SetInterval(d time.Duration) *Scheduler {
+	// Is Interval Valid?
+	if d > 0 { // YES
+		s.interval = d
+	}
+	return s
+}
+
+func (s *Scheduler) SetBatchSize(n int) *Scheduler {
+	// Is Batch Size Valid?
+	if n > 0 { // YES
+		s.batch = n
+	}
+	return s
+}
+
+// Logger Scheduler Logger (Standard Logger if not Set)
+func (s *Scheduler) Logger() queue.Logger {
+	// Do we have a Logger?
+	if s.logger == nil { // NO: Use Standard Logger
+		return defaultLogger
+	}
+	return s.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (s *Scheduler) SetLogger(l queue.Logger) *Scheduler {
+	s.logger = l
+	return s
+}
+
+// NewEntry Create Entry for an Already Encoded Message
+func NewEntry(channel string, queue string, contentType string, body []byte, at time.Time) (*Entry, error) {
+	// Do we have a Due Time?
+	if at.IsZero() { // NO
+		return nil, ErrNoDueTime
+	}
+
+	// Create GUID (V4 see https://www.sohamkamani.com/uuid-versions-explained/)
+	uid, err := uuid.NewV4()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Entry{
+		ID:          uid.String(),
+		Channel:     channel,
+		Queue:       queue,
+		ContentType: contentType,
+		Body:        body,
+		DueAt:       at.UTC(),
+		CreatedAt:   time.Now().UTC(),
+	}, nil
+}
+
+// NewJSONEntry Create Entry for a Message Encoded as JSON
+func NewJSONEntry(channel string, queue string, msg interface{}, at time.Time) (*Entry, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewEntry(channel, queue, "application/json", body, at)
+}
+
+// Schedule Persist Entry for Publishing once Due
+func (s *Scheduler) Schedule(ctx context.Context, e *Entry) error {
+	// Do we have a Store?
+	if s.store == nil { // NO
+		return ErrNoStore
+	}
+
+	return s.store.Add(ctx, e)
+}
+
+// ScheduleMessage Persist Message (as JSON) for Publishing at 'at' (Past: Next Dispatch Pass)
+func (s *Scheduler) ScheduleMessage(ctx context.Context, channel string, queue string, msg interface{}, at time.Time) (*Entry, error) {
+	e, err := NewJSONEntry(channel, queue, msg, at)
+	if err != nil {
+		return nil, err
+	}
+
+	return e, s.Schedule(ctx, e)
+}
+
+// Cancel Remove Scheduled Entry before it is Published
+func (s *Scheduler) Cancel(ctx context.Context, id string) error {
+	// Do we have a Store?
+	if s.store == nil { // NO
+		return ErrNoStore
+	}
+
+	return s.store.Cancel(ctx, id)
+}
+
+// Dispatch Publish a Batch of Due Entries, Returns Number Published
+func (s *Scheduler) Dispatch(ctx context.Context) (int, error) {
+	// Do we have a Store and Publisher?
+	if s.store == nil { // NO
+		return 0, ErrNoStore
+	}
+	if s.publisher == nil { // NO
+		return 0, ErrNoPublisher
+	}
+
+	entries, err := s.store.Due(ctx, time.Now().UTC(), s.batch)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, e := range entries {
+		err = s.publisher.QueuePublish(e.Channel, e.Queue, e.ContentType, e.Body)
+		if err != nil {
+			// Keep Entry for Next Pass
+			if ferr := s.store.MarkFailed(ctx, e.ID, err); ferr != nil {
+				s.Logger().Error("[Scheduler] Failed Marking Entry as Failed", queue.F("entry", e.ID), queue.F("error", ferr))
+			}
+			return sent, err
+		}
+
+		// NOTE: Crash here Republishes Entry (At Least Once Delivery)
+		err = s.store.MarkSent(ctx, e.ID)
+		if err != nil {
+			return sent, err
+		}
+		sent++
+	}
+
+	return sent, nil
+}
+
+// Start Runner Goroutine (Entries that Fell Due while Stopped are Published Immediately)
+func (s *Scheduler) Start() error {
+	// Do we have a Store and Publisher?
+	if s.store == nil { // NO
+		return ErrNoStore
+	}
+	if s.publisher == nil { // NO
+		return ErrNoPublisher
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	// Is Runner Already Running?
+	if s.stop != nil { // YES
+		return nil
+	}
+
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.run(s.stop, s.done)
+	return nil
+}
+
+// Stop Runner Goroutine and Wait for it to Finish
+func (s *Scheduler) Stop() {
+	s.lock.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.lock.Unlock()
+
+	// Is Runner Running?
+	if stop != nil { // YES
+		close(stop)
+		<-done
+	}
+}
+
+func (s *Scheduler) run(stop chan struct{}, done chan struct{}) {
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	t := time.NewTicker(s.interval)
+	defer t.Stop()
+
+	for {
+		// Drain Full Batches without Waiting
+		for {
+			n, err := s.Dispatch(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					s.Logger().Error("[Scheduler] Dispatch Failed", queue.F("error", err))
+				}
+				break
+			}
+			if n < s.batch {
+				break
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package scheduler
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/objectvault/queue-interface/sqlstore"
+)
+
+// cSpell:ignore sqlstore varchar
+
+// DefaultTable Scheduler Table Name
+const DefaultTable = "queue_scheduled"
+
+// SQL Store Implementation (database/sql)
+type SQLStore struct {
+	db      *sql.DB           // Database Connection
+	table   string            // Scheduled Messages Table
+	dialect *sqlstore.Dialect // Database Dialect
+}
+
+func NewSQLStore(db *sql.DB, table string, d *sqlstore.Dialect) *SQLStore {
+	// Do we have a Table Name?
+	if table == "" { // NO: Use Default
+		table = DefaultTable
+	}
+
+	// Do we have a Dialect?
+	if d == nil { // NO: Use "?" Placeholders
+		d = sqlstore.DialectMySQL
+	}
+
+	return &SQLStore{
+		db:      db,
+		table:   table,
+		dialect: d,
+	}
+}
+
+func (s *SQLStore) p(n int) string {
+	return s.dialect.Placeholder(n)
+}
+
+// CreateTable Create Scheduler Table if it does not Exist
+func (s *SQLStore) CreateTable(ctx context.Context) error {
+	q := fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+	id VARCHAR(36) NOT NULL PRIMARY KEY,
+	channel VARCHAR(255) NOT NULL,
+	queue VARCHAR(255) NOT NULL,
+	content_type VARCHAR(255) NOT NULL,
+	body %s NOT NULL,
+	due_at TIMESTAMP NOT NULL,
+	created_at TIMESTAMP NOT NULL,
+	sent_at TIMESTAMP NULL,
+	attempts INTEGER NOT NULL DEFAULT 0,
+	last_error VARCHAR(1024) NULL
+)`, s.table, s.dialect.BlobType)
+
+	_, err := s.db.ExecContext(ctx, q)
+	return err
+}
+
+// Add Persist Entry (Outside of any Transaction)
+func (s *SQLStore) Add(ctx context.Context, e *Entry) error {
+	return s.AddWith(ctx, s.db, e)
+}
+
+// AddTx Persist Entry in the Same Transaction as the Business Data
+func (s *SQLStore) AddTx(ctx context.Context, tx *sql.Tx, e *Entry) error {
+	return s.AddWith(ctx, tx, e)
+}
+
+// AddWith Persist Entry using Given Executor
+func (s *SQLStore) AddWith(ctx context.Context, x sqlstore.Execer, e *Entry) error {
+	q := fmt.Sprintf("INSERT INTO %s (id, channel, queue, content_type, body, due_at, created_at, attempts) VALUES (%s, %s, %s, %s, %s, %s, %s, 0)",
+		s.table, s.p(1), s.p(2), s.p(3), s.p(4), s.p(5), s.p(6), s.p(7))
+
+	_, err := x.ExecContext(ctx, q, e.ID, e.Channel, e.Queue, e.ContentType, e.Body, e.DueAt.UTC(), e.CreatedAt.UTC())
+	if err != nil {
+		return fmt.Errorf("[SQLStore] Failed Adding Entry [%s]: %w", e.ID, err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Due(ctx context.Context, now time.Time, limit int) ([]*Entry, error) {
+	q := fmt.Sprintf("SELECT id, channel, queue, content_type, body, due_at, created_at, attempts, last_error FROM %s WHERE sent_at IS NULL AND due_at <= %s ORDER BY due_at, id LIMIT %d",
+		s.table, s.p(1), limit)
+
+	rows, err := s.db.QueryContext(ctx, q, now.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []*Entry{}
+	for rows.Next() {
+		e := &Entry{}
+		var lastError sql.NullString
+		err = rows.Scan(&e.ID, &e.Channel, &e.Queue, &e.ContentType, &e.Body, &e.DueAt, &e.CreatedAt, &e.Attempts, &lastError)
+		if err != nil {
+			return nil, err
+		}
+		e.LastError = lastError.String
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func (s *SQLStore) MarkSent(ctx context.Context, id string) error {
+	q := fmt.Sprintf("UPDATE %s SET sent_at = %s WHERE id = %s", s.table, s.p(1), s.p(2))
+	_, err := s.db.ExecContext(ctx, q, time.Now().UTC(), id)
+	return err
+}
+
+func (s *SQLStore) MarkFailed(ctx context.Context, id string, cause error) error {
+	msg := ""
+	if cause != nil {
+		msg = cause.Error()
+		if len(msg) > 1024 {
+			msg = msg[:1024]
+		}
+	}
+
+	q := fmt.Sprintf("UPDATE %s SET attempts = attempts + 1, last_error = %s WHERE id = %s", s.table, s.p(1), s.p(2))
+	_, err := s.db.ExecContext(ctx, q, msg, id)
+	return err
+}
+
+func (s *SQLStore) Cancel(ctx context.Context, id string) error {
+	q := fmt.Sprintf("DELETE FROM %s WHERE id = %s AND sent_at IS NULL", s.table, s.p(1))
+	res, err := s.db.ExecContext(ctx, q, id)
+	if err != nil {
+		return err
+	}
+
+	// Was an Entry Removed?
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 { // NO
+		return ErrEntryNotFound
+	}
+	return err
+}
+
+// Purge Remove Entries Sent before Given Time
+func (s *SQLStore) Purge(ctx context.Context, before time.Time) (int64, error) {
+	q := fmt.Sprintf("DELETE FROM %s WHERE sent_at IS NOT NULL AND sent_at < %s", s.table, s.p(1))
+	res, err := s.db.ExecContext(ctx, q, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,95 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore vhost
+import "fmt"
+
+// Connections Cluster Servers with Cluster Level Settings Applied
+func (c *Cluster) Connections() []AMQPConnection {
+	l := make([]AMQPConnection, 0, len(c.Servers))
+	for _, s := range c.Servers {
+		// Server Settings Take Precedence over Cluster Settings
+		if s.User == "" {
+			s.User = c.User
+		}
+
+		if s.Password == "" {
+			s.Password = c.Password
+		}
+
+		if s.VHost == "" {
+			s.VHost = c.VHost
+		}
+
+		if s.TLS == nil {
+			s.TLS = c.TLS
+		}
+
+		l = append(l, s)
+	}
+
+	return l
+}
+
+// Cluster Find Named Cluster
+func (o *Queues) Cluster(name string) (*Cluster, error) {
+	// Do we have the Cluster?
+	c, ok := o.Clusters[name]
+	if !ok || c == nil { // NO
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownCluster, name)
+	}
+
+	return c, nil
+}
+
+// ResolveQueue Copy of Queue with Cluster Servers Expanded
+// (Cluster Servers are Listed before any Queue Specific Servers)
+func (o *Queues) ResolveQueue(q *Queue) (*Queue, error) {
+	// Do we have a Queue?
+	if q == nil { // NO: Nothing to Resolve
+		return nil, nil
+	}
+
+	r := *q
+
+	// Does the Queue Reference a Cluster?
+	if q.Cluster != "" { // YES
+		c, err := o.Cluster(q.Cluster)
+		if err != nil {
+			return nil, err
+		}
+
+		r.Servers = append(c.Connections(), q.Servers...)
+	}
+
+	return &r, nil
+}
+
+// Resolve Copy of Configuration with all Cluster References Expanded
+func (o *Queues) Resolve() (*Queues, error) {
+	r := &Queues{
+		Clusters: o.Clusters,
+	}
+
+	var err error
+	r.Activation, err = o.ResolveQueue(o.Activation)
+	if err != nil {
+		return nil, err
+	}
+
+	r.Mail, err = o.ResolveQueue(o.Mail)
+	if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
@@ -11,82 +11,135 @@ package shared
  */
 
 import (
+	"bytes"
 	"encoding/json"
 	"errors"
-	"time"
+	"fmt"
+	"strings"
 )
 
 // TYPE DEFINTION FOR CONFIG FILE //
 type Server struct {
-	Host string `json:"host,omitempty"`
-	Port int    `json:"port,omitempty"`
+	Host string `json:"host,omitempty" yaml:"host,omitempty" toml:"host,omitempty"`
+	Port int    `json:"port,omitempty" yaml:"port,omitempty" toml:"port,omitempty"`
 }
 
 type AMQPConnection struct {
-	User     string                 `json:"user,omitempty"`
-	Password string                 `json:"password,omitempty"`
-	Server   *Server                `json:"server,omitempty"`
-	VHost    string                 `json:"vhost,omitempty"`
-	Options  map[string]interface{} `json:"options,omitempty"`
+	User     string                 `json:"user,omitempty" yaml:"user,omitempty" toml:"user,omitempty"`
+	Password string                 `json:"password,omitempty" yaml:"password,omitempty" toml:"password,omitempty"`
+	Server   *Server                `json:"server,omitempty" yaml:"server,omitempty" toml:"server,omitempty"`
+	VHost    string                 `json:"vhost,omitempty" yaml:"vhost,omitempty" toml:"vhost,omitempty"`
+	Options  map[string]interface{} `json:"options,omitempty" yaml:"options,omitempty" toml:"options,omitempty"`
+	TLS      *TLS                   `json:"tls,omitempty" yaml:"tls,omitempty" toml:"tls,omitempty"`
 }
 
-type Queue struct {
-	Servers     []AMQPConnection `json:"servers,omitempty"` // List of AMQP Servers
-	QueuePrefix string           `json:"prefix,omitempty"`  // [REQUIRED] Prefix to Queue Name
+// Transport Security Settings
+type TLS struct {
+	Enabled            bool   `json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty"`                                        // Use AMQPS
+	CACert             string `json:"ca_cert,omitempty" yaml:"ca_cert,omitempty" toml:"ca_cert,omitempty"`                                        // [OPTIONAL] Path to CA Certificate (PEM)
+	ClientCert         string `json:"client_cert,omitempty" yaml:"client_cert,omitempty" toml:"client_cert,omitempty"`                            // [OPTIONAL] Path to Client Certificate (PEM)
+	ClientKey          string `json:"client_key,omitempty" yaml:"client_key,omitempty" toml:"client_key,omitempty"`                               // [OPTIONAL] Path to Client Key (PEM)
+	ServerName         string `json:"server_name,omitempty" yaml:"server_name,omitempty" toml:"server_name,omitempty"`                            // [OPTIONAL] Server Name for Verification
+	InsecureSkipVerify bool   `json:"insecure_skip_verify,omitempty" yaml:"insecure_skip_verify,omitempty" toml:"insecure_skip_verify,omitempty"` // [DEV ONLY] Skip Certificate Verification
 }
 
-type Queues struct {
-	Activation *Queue `json:"activation,omitempty"` // Message Queue Configuration: Activation
-	Mail       *Queue `json:"mail,omitempty"`       // Message Queue Configuration: Email
+// Named Set of Servers Shared by Multiple Queues
+type Cluster struct {
+	Servers  []AMQPConnection `json:"servers,omitempty" yaml:"servers,omitempty" toml:"servers,omitempty"`    // List of AMQP Servers
+	User     string           `json:"user,omitempty" yaml:"user,omitempty" toml:"user,omitempty"`             // [OPTIONAL] Credentials for Servers without User
+	Password string           `json:"password,omitempty" yaml:"password,omitempty" toml:"password,omitempty"` // [OPTIONAL] Credentials for Servers without Password
+	VHost    string           `json:"vhost,omitempty" yaml:"vhost,omitempty" toml:"vhost,omitempty"`          // [OPTIONAL] Virtual Host for Servers without One
+	TLS      *TLS             `json:"tls,omitempty" yaml:"tls,omitempty" toml:"tls,omitempty"`                // [OPTIONAL] TLS for Servers without Own Settings
 }
 
-func ToJSONTimeStamp(t *time.Time) string {
-	if t == nil {
-		return ""
-	}
+type Queue struct {
+	Cluster     string           `json:"cluster,omitempty" yaml:"cluster,omitempty" toml:"cluster,omitempty"`    // [OPTIONAL] Named Cluster Providing Servers
+	Servers     []AMQPConnection `json:"servers,omitempty" yaml:"servers,omitempty" toml:"servers,omitempty"`    // List of AMQP Servers
+	QueuePrefix string           `json:"prefix,omitempty" yaml:"prefix,omitempty" toml:"prefix,omitempty"`       // [REQUIRED] Prefix to Queue Name
+	Defaults    *Defaults        `json:"defaults,omitempty" yaml:"defaults,omitempty" toml:"defaults,omitempty"` // [OPTIONAL] Operational Defaults
+	AppID       string           `json:"app_id,omitempty" yaml:"app_id,omitempty" toml:"app_id,omitempty"`       // [OPTIONAL] Application Identifier Stamped on Published Messages
+	UserID      string           `json:"user_id,omitempty" yaml:"user_id,omitempty" toml:"user_id,omitempty"`    // [OPTIONAL] Authenticated User Stamped on Published Messages (Broker Verified)
+	Receipts    string           `json:"receipts,omitempty" yaml:"receipts,omitempty" toml:"receipts,omitempty"` // [OPTIONAL] Queue Receiving Delivery Receipts for Handled Messages
+}
 
-	return t.Format(time.RFC3339)
+type Queues struct {
+	Clusters   map[string]*Cluster `json:"clusters,omitempty" yaml:"clusters,omitempty" toml:"clusters,omitempty"`       // Named Cluster Definitions
+	Activation *Queue              `json:"activation,omitempty" yaml:"activation,omitempty" toml:"activation,omitempty"` // Message Queue Configuration: Activation
+	Mail       *Queue              `json:"mail,omitempty" yaml:"mail,omitempty" toml:"mail,omitempty"`                   // Message Queue Configuration: Email
 }
 
-func FromJSONTimeStamp(t string) *time.Time {
-	if t == "" {
-		return nil
-	}
+// Helpers
 
-	timestamp, err := time.Parse(time.RFC3339, t)
-	if err == nil {
-		return nil
+// ToQueue Convert Generic Configuration to Queue (Unknown Fields are Ignored,
+// use ToStructStrict[Queue] to Reject them)
+func ToQueue(source interface{}) (*Queue, error) {
+	// Do we have Queue Configuration?
+	if source == nil { // NO
+		return nil, ErrNoConfiguration
 	}
 
-	return &timestamp
+	return ToStruct[Queue](source)
 }
 
-// UTCTimeStamp Return UTC Time Stamp String in RFC 3339
-func UTCTimeStamp() string {
-	return time.Now().UTC().Format(time.RFC3339)
+// ToStruct Convert Generic Configuration (i.e. map[string]interface{}) to Typed Structure
+//
+// Unknown Fields are Ignored (see ToStructStrict)
+func ToStruct[T any](source interface{}) (*T, error) {
+	return toStruct[T](source, false)
 }
 
-// Helpers
-func ToQueue(source interface{}) (*Queue, error) {
-	// Do we have Queue Configuration?
+// ToStructStrict Same as ToStruct, but Rejects Unknown Fields (i.e. Misspelled Keys)
+func ToStructStrict[T any](source interface{}) (*T, error) {
+	return toStruct[T](source, true)
+}
+
+func toStruct[T any](source interface{}, strict bool) (*T, error) {
+	// Do we have a Source?
 	if source == nil { // NO
-		return nil, errors.New("No Queue Configuration")
+		return nil, ErrNoConfiguration
 	}
 
 	// Create
-	q := &Queue{}
+	o := new(T)
 
 	// Convert Source to JSON
 	bo, err := json.Marshal(source)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("[ToStruct] Source is not Convertible [%v]", err)
+	}
+
+	// JSON Back to Structure
+	d := json.NewDecoder(bytes.NewReader(bo))
+
+	// Reject Unknown Fields?
+	if strict { // YES
+		d.DisallowUnknownFields()
 	}
 
-	// JSON Back to Queue
-	err = json.Unmarshal(bo, q)
+	err = d.Decode(o)
 	if err != nil {
-		return nil, err
+		return nil, toStructError(o, err)
+	}
+
+	return o, nil
+}
+
+func toStructError(target interface{}, err error) error {
+	// Is it a Type Mismatch?
+	var te *json.UnmarshalTypeError
+	if errors.As(err, &te) { // YES: Identify Field
+		field := te.Field
+		if field == "" {
+			field = "(root)"
+		}
+		return fmt.Errorf("[ToStruct] Field [%s] expected [%s] found [%s] in %T", field, te.Type.String(), te.Value, target)
+	}
+
+	// Is it an Unknown Field?
+	msg := err.Error()
+	if strings.HasPrefix(msg, "json: unknown field ") { // YES
+		return fmt.Errorf("[ToStruct] Unknown Field %s in %T", strings.TrimPrefix(msg, "json: unknown field "), target)
 	}
 
-	return q, nil
+	return fmt.Errorf("[ToStruct] Invalid Source for %T [%v]", target, err)
 }
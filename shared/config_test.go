@@ -0,0 +1,36 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestToQueueIgnoresUnknownFields(t *testing.T) {
+	source := map[string]interface{}{
+		"prefix":  "mail",
+		"comment": "not a queue setting",
+	}
+
+	q, err := ToQueue(source)
+	if err != nil {
+		t.Fatalf("lenient conversion failed [%v]", err)
+	}
+	if q.QueuePrefix != "mail" {
+		t.Errorf("prefix [%s], want mail", q.QueuePrefix)
+	}
+
+	_, err = ToStructStrict[Queue](source)
+	if err == nil || !strings.Contains(err.Error(), "Unknown Field") {
+		t.Errorf("strict conversion err [%v], want unknown field", err)
+	}
+}
@@ -0,0 +1,159 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore requeue, requeues
+import (
+	"strings"
+	"time"
+)
+
+// Values used when the Configuration does not Override them
+const (
+	DefaultPublishTimeout   = 5 * time.Second
+	DefaultConsumeTimeout   = 30 * time.Second
+	DefaultMaxRequeues      = 5
+	DefaultReconnectBackoff = 2 * time.Second
+	DefaultConnectTimeout   = 30 * time.Second
+
+	DefaultCompressThreshold = 16 * 1024 // Bytes
+)
+
+// Operational Policy for Queue Connections (all durations in milliseconds)
+type Defaults struct {
+	PublishTimeout   int `json:"publish_timeout,omitempty" yaml:"publish_timeout,omitempty" toml:"publish_timeout,omitempty"`       // [OPTIONAL] Maximum Time to Wait on a Publish
+	ConsumeTimeout   int `json:"consume_timeout,omitempty" yaml:"consume_timeout,omitempty" toml:"consume_timeout,omitempty"`       // [OPTIONAL] Maximum Time to Wait for a Message
+	MaxRequeues      int `json:"max_requeues,omitempty" yaml:"max_requeues,omitempty" toml:"max_requeues,omitempty"`                // [OPTIONAL] Number of Requeues before Message is Abandoned
+	ReconnectBackoff int `json:"reconnect_backoff,omitempty" yaml:"reconnect_backoff,omitempty" toml:"reconnect_backoff,omitempty"` // [OPTIONAL] Wait before Trying to Reconnect
+	ConnectTimeout   int `json:"connect_timeout,omitempty" yaml:"connect_timeout,omitempty" toml:"connect_timeout,omitempty"`       // [OPTIONAL] Maximum Time to Wait on each Server when Connecting
+
+	ServerStrategy string `json:"server_strategy,omitempty" yaml:"server_strategy,omitempty" toml:"server_strategy,omitempty"` // [OPTIONAL] Order Servers are Tried: failover (DEFAULT), round-robin or random
+
+	Compression       string `json:"compression,omitempty" yaml:"compression,omitempty" toml:"compression,omitempty"`                      // [OPTIONAL] Content Encoding for Large Bodies, gzip, deflate or zstd (DEFAULT: None)
+	CompressThreshold int    `json:"compress_threshold,omitempty" yaml:"compress_threshold,omitempty" toml:"compress_threshold,omitempty"` // [OPTIONAL] Smallest Body (in Bytes) that is Compressed
+
+	Reconnect *RetryPolicy `json:"reconnect,omitempty" yaml:"reconnect,omitempty" toml:"reconnect,omitempty"` // [OPTIONAL] Reconnect Policy (Overrides ReconnectBackoff)
+	Publish   *RetryPolicy `json:"publish,omitempty" yaml:"publish,omitempty" toml:"publish,omitempty"`       // [OPTIONAL] Publish Retry Policy
+	Requeue   *RetryPolicy `json:"requeue,omitempty" yaml:"requeue,omitempty" toml:"requeue,omitempty"`       // [OPTIONAL] Requeue Delay Policy
+}
+
+// NewDefaults Create Defaults Initialized with Package Values
+func NewDefaults() *Defaults {
+	return &Defaults{
+		PublishTimeout:   int(DefaultPublishTimeout / time.Millisecond),
+		ConsumeTimeout:   int(DefaultConsumeTimeout / time.Millisecond),
+		MaxRequeues:      DefaultMaxRequeues,
+		ReconnectBackoff: int(DefaultReconnectBackoff / time.Millisecond),
+		ConnectTimeout:   int(DefaultConnectTimeout / time.Millisecond),
+	}
+}
+
+// GetPublishTimeout Publish Timeout (Package Default if not Set)
+func (d *Defaults) GetPublishTimeout() time.Duration {
+	if d == nil || d.PublishTimeout <= 0 {
+		return DefaultPublishTimeout
+	}
+
+	return time.Duration(d.PublishTimeout) * time.Millisecond
+}
+
+// GetConsumeTimeout Consume Timeout (Package Default if not Set)
+func (d *Defaults) GetConsumeTimeout() time.Duration {
+	if d == nil || d.ConsumeTimeout <= 0 {
+		return DefaultConsumeTimeout
+	}
+
+	return time.Duration(d.ConsumeTimeout) * time.Millisecond
+}
+
+// GetMaxRequeues Maximum Requeues (Package Default if not Set)
+func (d *Defaults) GetMaxRequeues() int {
+	if d == nil || d.MaxRequeues <= 0 {
+		return DefaultMaxRequeues
+	}
+
+	return d.MaxRequeues
+}
+
+// GetReconnectBackoff Reconnect Backoff (Package Default if not Set)
+func (d *Defaults) GetReconnectBackoff() time.Duration {
+	if d == nil || d.ReconnectBackoff <= 0 {
+		return DefaultReconnectBackoff
+	}
+
+	return time.Duration(d.ReconnectBackoff) * time.Millisecond
+}
+
+// GetConnectTimeout Per Server Connect Timeout (Package Default if not Set)
+func (d *Defaults) GetConnectTimeout() time.Duration {
+	if d == nil || d.ConnectTimeout <= 0 {
+		return DefaultConnectTimeout
+	}
+
+	return time.Duration(d.ConnectTimeout) * time.Millisecond
+}
+
+// GetServerStrategy Name of Order Servers are Tried ("failover" if not Set)
+func (d *Defaults) GetServerStrategy() string {
+	if d == nil || strings.TrimSpace(d.ServerStrategy) == "" {
+		return "failover"
+	}
+
+	return strings.ToLower(strings.TrimSpace(d.ServerStrategy))
+}
+
+// GetCompression Content Encoding for Large Bodies ("" if Compression is Off)
+func (d *Defaults) GetCompression() string {
+	if d == nil {
+		return ""
+	}
+
+	return strings.ToLower(strings.TrimSpace(d.Compression))
+}
+
+// GetCompressThreshold Smallest Body Compressed (Package Default if not Set)
+func (d *Defaults) GetCompressThreshold() int {
+	if d == nil || d.CompressThreshold <= 0 {
+		return DefaultCompressThreshold
+	}
+
+	return d.CompressThreshold
+}
+
+// GetReconnectPolicy Reconnect Policy (DEFAULT: Single Attempt after ReconnectBackoff)
+func (d *Defaults) GetReconnectPolicy() *RetryPolicy {
+	p := ConstantRetry(d.GetReconnectBackoff(), 1)
+	if d == nil {
+		return p
+	}
+
+	return p.Override(d.Reconnect)
+}
+
+// GetPublishPolicy Publish Retry Policy (DEFAULT: No Retries)
+func (d *Defaults) GetPublishPolicy() *RetryPolicy {
+	p := ConstantRetry(0, 1)
+	if d == nil {
+		return p
+	}
+
+	return p.Override(d.Publish)
+}
+
+// GetRequeuePolicy Requeue Delay Policy (DEFAULT: Exponential from 1s, Capped at 1h, MaxRequeues Attempts)
+func (d *Defaults) GetRequeuePolicy() *RetryPolicy {
+	p := ExponentialRetry(time.Second, time.Hour, d.GetMaxRequeues())
+	if d == nil {
+		return p
+	}
+
+	return p.Override(d.Requeue)
+}
@@ -0,0 +1,24 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import "errors"
+
+// Failure Classes (use errors.Is)
+var (
+	ErrNoConfiguration   = errors.New("[shared] No Configuration")
+	ErrUnsupportedFormat = errors.New("[shared] Unsupported Configuration Format")
+	ErrUnknownCluster    = errors.New("[shared] Unknown Cluster")
+	ErrInvalidServer     = errors.New("[shared] Invalid Server Definition")
+	ErrInvalidTimeStamp  = errors.New("[shared] Invalid Time Stamp")
+	ErrInvalidTLS        = errors.New("[shared] Invalid TLS Settings")
+	ErrInvalidOption     = errors.New("[shared] Invalid Server Option")
+)
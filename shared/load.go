@@ -0,0 +1,100 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Supported Configuration Formats
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+)
+
+// FormatFromPath Configuration Format based on File Extension
+func FormatFromPath(path string) (string, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	}
+
+	return "", fmt.Errorf("%w [%s]", ErrUnsupportedFormat, path)
+}
+
+// Unmarshal Decode Configuration in the Given Format
+func Unmarshal(data []byte, format string, v interface{}) error {
+	var err error
+	switch strings.ToLower(format) {
+	case FormatJSON:
+		err = json.Unmarshal(data, v)
+	case FormatYAML, "yml":
+		err = yaml.Unmarshal(data, v)
+	case FormatTOML:
+		err = toml.Unmarshal(data, v)
+	default:
+		return fmt.Errorf("%w [%s]", ErrUnsupportedFormat, format)
+	}
+
+	if err != nil {
+		return fmt.Errorf("[Unmarshal] Invalid %s Configuration [%v]", strings.ToUpper(format), err)
+	}
+
+	return nil
+}
+
+// Load Decode Configuration into a New Structure
+func Load[T any](data []byte, format string) (*T, error) {
+	o := new(T)
+	err := Unmarshal(data, format, o)
+	if err != nil {
+		return nil, err
+	}
+
+	return o, nil
+}
+
+// LoadFile Read Configuration File (Format Selected by Extension)
+func LoadFile[T any](path string) (*T, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("[LoadFile] Failed to Read Configuration [%v]", err)
+	}
+
+	return Load[T](data, format)
+}
+
+// LoadQueues Decode Queues Configuration (JSON, YAML or TOML)
+func LoadQueues(data []byte, format string) (*Queues, error) {
+	return Load[Queues](data, format)
+}
+
+// LoadQueuesFile Read Queues Configuration File (JSON, YAML or TOML)
+func LoadQueuesFile(path string) (*Queues, error) {
+	return LoadFile[Queues](path)
+}
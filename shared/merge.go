@@ -0,0 +1,131 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// Merge Layer Configurations (base, environment, instance, ...)
+// Later non-zero values override earlier ones. Server lists are replaced
+// and clusters as a whole, never combined: a layer selecting a cluster drops
+// the servers of earlier layers. Source configurations are not modified.
+func Merge(cfgs ...*Queues) *Queues {
+	r := &Queues{}
+	for _, c := range cfgs {
+		// Do we have a Configuration Layer?
+		if c == nil { // NO: Skip
+			continue
+		}
+
+		// Later Cluster Definitions Replace Earlier Ones
+		for n, cl := range c.Clusters {
+			if r.Clusters == nil {
+				r.Clusters = map[string]*Cluster{}
+			}
+			r.Clusters[n] = cl
+		}
+
+		r.Activation = MergeQueue(r.Activation, c.Activation)
+		r.Mail = MergeQueue(r.Mail, c.Mail)
+	}
+
+	return r
+}
+
+// MergeQueue Layer Queue Configuration 'o' over 'base'
+func MergeQueue(base *Queue, o *Queue) *Queue {
+	// Do we have an Override?
+	if o == nil { // NO: Use Base
+		return base
+	}
+
+	r := &Queue{}
+	if base != nil {
+		*r = *base
+	}
+
+	// Does the Override Select a Cluster?
+	if o.Cluster != "" { // YES: Base Servers would be Appended to the Cluster's (see ResolveQueue)
+		r.Cluster = o.Cluster
+		r.Servers = nil
+	}
+
+	if len(o.Servers) > 0 {
+		r.Servers = append([]AMQPConnection{}, o.Servers...)
+	}
+
+	if o.QueuePrefix != "" {
+		r.QueuePrefix = o.QueuePrefix
+	}
+
+	if o.AppID != "" {
+		r.AppID = o.AppID
+	}
+
+	if o.UserID != "" {
+		r.UserID = o.UserID
+	}
+
+	if o.Receipts != "" {
+		r.Receipts = o.Receipts
+	}
+
+	r.Defaults = MergeDefaults(r.Defaults, o.Defaults)
+	return r
+}
+
+// MergeDefaults Layer Defaults 'o' over 'base'
+func MergeDefaults(base *Defaults, o *Defaults) *Defaults {
+	// Do we have an Override?
+	if o == nil { // NO: Use Base
+		return base
+	}
+
+	r := &Defaults{}
+	if base != nil {
+		*r = *base
+	}
+
+	if o.PublishTimeout != 0 {
+		r.PublishTimeout = o.PublishTimeout
+	}
+
+	if o.ConsumeTimeout != 0 {
+		r.ConsumeTimeout = o.ConsumeTimeout
+	}
+
+	if o.MaxRequeues != 0 {
+		r.MaxRequeues = o.MaxRequeues
+	}
+
+	if o.ReconnectBackoff != 0 {
+		r.ReconnectBackoff = o.ReconnectBackoff
+	}
+
+	if o.ConnectTimeout != 0 {
+		r.ConnectTimeout = o.ConnectTimeout
+	}
+
+	if o.ServerStrategy != "" {
+		r.ServerStrategy = o.ServerStrategy
+	}
+
+	if o.Compression != "" {
+		r.Compression = o.Compression
+	}
+
+	if o.CompressThreshold != 0 {
+		r.CompressThreshold = o.CompressThreshold
+	}
+
+	r.Reconnect = r.Reconnect.Override(o.Reconnect)
+	r.Publish = r.Publish.Override(o.Publish)
+	r.Requeue = r.Requeue.Override(o.Requeue)
+
+	return r
+}
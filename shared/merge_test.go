@@ -0,0 +1,68 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"testing"
+)
+
+// hosts Server Hosts in Order
+func hosts(l []AMQPConnection) []string {
+	h := []string{}
+	for _, c := range l {
+		h = append(h, c.Server.Host)
+	}
+	return h
+}
+
+func TestMergeClusterReplacesServers(t *testing.T) {
+	base := &Queues{
+		Mail: &Queue{
+			Servers:     []AMQPConnection{{Server: &Server{Host: "localhost", Port: 5672}}},
+			QueuePrefix: "mail",
+		},
+	}
+	prod := &Queues{
+		Clusters: map[string]*Cluster{
+			"prod": {
+				User:    "mailer",
+				Servers: []AMQPConnection{{Server: &Server{Host: "rmq-1"}}, {Server: &Server{Host: "rmq-2"}}},
+			},
+		},
+		Mail: &Queue{Cluster: "prod"},
+	}
+
+	merged := Merge(base, prod)
+	if len(merged.Mail.Servers) != 0 || merged.Mail.Cluster != "prod" || merged.Mail.QueuePrefix != "mail" {
+		t.Fatalf("merged queue %+v", merged.Mail)
+	}
+	if len(base.Mail.Servers) != 1 {
+		t.Errorf("base configuration modified %+v", base.Mail)
+	}
+
+	// Only the Cluster's Servers are Used
+	resolved, err := merged.Resolve()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hosts(resolved.Mail.Servers); len(got) != 2 || got[0] != "rmq-1" || got[1] != "rmq-2" {
+		t.Errorf("resolved servers %v, want [rmq-1 rmq-2]", got)
+	}
+
+	// Servers of the Layer Selecting the Cluster are Kept
+	extra := &Queues{
+		Mail: &Queue{Cluster: "prod", Servers: []AMQPConnection{{Server: &Server{Host: "rmq-dr"}}}},
+	}
+	merged = Merge(base, prod, extra)
+	if got := hosts(merged.Mail.Servers); len(got) != 1 || got[0] != "rmq-dr" {
+		t.Errorf("merged servers %v, want [rmq-dr]", got)
+	}
+}
@@ -0,0 +1,170 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Server Options (AMQPConnection.Options)
+const (
+	OptionHeartbeat         = "heartbeat"          // Seconds (or Duration String i.e. "10s")
+	OptionConnectionTimeout = "connection_timeout" // Milliseconds (or Duration String i.e. "5s")
+	OptionChannelMax        = "channel_max"        // Maximum Channels (0: Server Limit)
+	OptionFrameMax          = "frame_max"          // Maximum Frame Size in Bytes (0: Server Limit)
+	OptionLocale            = "locale"             // Connection Locale (i.e. en_US)
+)
+
+// AMQP Limits
+const (
+	maxChannels  = math.MaxUint16
+	minFrameSize = 4096 // AMQP 0-9-1 frame-min-size
+)
+
+// ConnectionOptions Validated Server Options (Zero Values are Not Set)
+type ConnectionOptions struct {
+	Heartbeat         time.Duration
+	ConnectionTimeout time.Duration
+	ChannelMax        int
+	FrameMax          int
+	Locale            string
+}
+
+// ConnectionOptions Parse and Validate Server Options
+//
+// Unknown Options and Invalid Values are Reported as ErrInvalidOption.
+func (c *AMQPConnection) ConnectionOptions() (*ConnectionOptions, error) {
+	o := &ConnectionOptions{}
+
+	// Do we have Options?
+	if c == nil || len(c.Options) == 0 { // NO
+		return o, nil
+	}
+
+	// NOTE: Sorted so the Same Error is Reported for the Same Configuration
+	keys := make([]string, 0, len(c.Options))
+	for k := range c.Options {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var err error
+	for _, k := range keys {
+		v := c.Options[k]
+		switch strings.ToLower(strings.TrimSpace(k)) {
+		case OptionHeartbeat:
+			o.Heartbeat, err = durationOption(k, v, time.Second)
+			if err == nil && o.Heartbeat < time.Second {
+				err = invalidOption(k, v, "must be at least 1s")
+			}
+		case OptionConnectionTimeout:
+			o.ConnectionTimeout, err = durationOption(k, v, time.Millisecond)
+			if err == nil && o.ConnectionTimeout <= 0 {
+				err = invalidOption(k, v, "must be positive")
+			}
+		case OptionChannelMax:
+			o.ChannelMax, err = intOption(k, v)
+			if err == nil && (o.ChannelMax < 0 || o.ChannelMax > maxChannels) {
+				err = invalidOption(k, v, fmt.Sprintf("must be between 0 and %d", maxChannels))
+			}
+		case OptionFrameMax:
+			o.FrameMax, err = intOption(k, v)
+			if err == nil && o.FrameMax != 0 && (o.FrameMax < minFrameSize || o.FrameMax > math.MaxInt32) {
+				err = invalidOption(k, v, fmt.Sprintf("must be 0 or at least %d", minFrameSize))
+			}
+		case OptionLocale:
+			s, ok := v.(string)
+			if !ok || strings.TrimSpace(s) == "" {
+				err = invalidOption(k, v, "must be a non empty string")
+			}
+			o.Locale = strings.TrimSpace(s)
+		default:
+			err = fmt.Errorf("%w: unknown option [%s]", ErrInvalidOption, k)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return o, nil
+}
+
+// Query URI Query Parameters for Options Defined by the RabbitMQ URI Spec
+//
+// See https://www.rabbitmq.com/uri-query-parameters.html
+func (o *ConnectionOptions) Query() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+
+	if o.Heartbeat > 0 {
+		q.Set(OptionHeartbeat, strconv.FormatInt(int64(o.Heartbeat/time.Second), 10))
+	}
+	if o.ConnectionTimeout > 0 {
+		q.Set(OptionConnectionTimeout, strconv.FormatInt(int64(o.ConnectionTimeout/time.Millisecond), 10))
+	}
+	if o.ChannelMax > 0 {
+		q.Set(OptionChannelMax, strconv.Itoa(o.ChannelMax))
+	}
+	return q
+}
+
+func invalidOption(k string, v interface{}, reason string) error {
+	return fmt.Errorf("%w: option [%s] value [%v] %s", ErrInvalidOption, k, v, reason)
+}
+
+// intOption Whole Number from Option Value (Numbers or Numeric Strings)
+func intOption(k string, v interface{}) (int, error) {
+	switch n := v.(type) {
+	case int:
+		return n, nil
+	case int64:
+		return int(n), nil
+	case float64:
+		// Is Number Whole?
+		if n != math.Trunc(n) || math.Abs(n) > math.MaxInt32 { // NO
+			return 0, invalidOption(k, v, "must be a whole number")
+		}
+		return int(n), nil
+	case string:
+		i, err := strconv.Atoi(strings.TrimSpace(n))
+		if err != nil {
+			return 0, invalidOption(k, v, "must be a whole number")
+		}
+		return i, nil
+	}
+
+	return 0, invalidOption(k, v, "must be a whole number")
+}
+
+// durationOption Duration from Option Value (Numbers in 'unit' or Duration Strings)
+func durationOption(k string, v interface{}, unit time.Duration) (time.Duration, error) {
+	// Is Value a Duration String?
+	if s, ok := v.(string); ok {
+		if d, err := time.ParseDuration(strings.TrimSpace(s)); err == nil { // YES
+			return d, nil
+		}
+	}
+
+	n, err := intOption(k, v)
+	if err != nil {
+		return 0, invalidOption(k, v, "must be a duration")
+	}
+	return time.Duration(n) * unit, nil
+}
@@ -0,0 +1,163 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// Retry Strategies
+const (
+	RetryConstant    = "constant"    // Same Delay for Every Attempt
+	RetryExponential = "exponential" // Delay Multiplied on Every Attempt
+	RetryFibonacci   = "fibonacci"   // Delay Follows Fibonacci Sequence
+)
+
+// Retry/Backoff Policy (all durations in milliseconds)
+type RetryPolicy struct {
+	Strategy    string  `json:"strategy,omitempty" yaml:"strategy,omitempty" toml:"strategy,omitempty"`             // [OPTIONAL] constant, exponential or fibonacci (DEFAULT: constant)
+	Initial     int     `json:"initial,omitempty" yaml:"initial,omitempty" toml:"initial,omitempty"`                // Delay before First Retry
+	Max         int     `json:"max,omitempty" yaml:"max,omitempty" toml:"max,omitempty"`                            // [OPTIONAL] Cap on Delay
+	Multiplier  float64 `json:"multiplier,omitempty" yaml:"multiplier,omitempty" toml:"multiplier,omitempty"`       // [OPTIONAL] Exponential Growth Factor (DEFAULT: 2)
+	Jitter      float64 `json:"jitter,omitempty" yaml:"jitter,omitempty" toml:"jitter,omitempty"`                   // [OPTIONAL] Random Fraction (0-1) Added/Removed from Delay
+	MaxAttempts int     `json:"max_attempts,omitempty" yaml:"max_attempts,omitempty" toml:"max_attempts,omitempty"` // [OPTIONAL] Total Attempts (DEFAULT: 1, no retries)
+}
+
+// ConstantRetry Policy with Fixed Delay
+func ConstantRetry(delay time.Duration, attempts int) *RetryPolicy {
+	return &RetryPolicy{
+		Strategy:    RetryConstant,
+		Initial:     int(delay / time.Millisecond),
+		MaxAttempts: attempts,
+	}
+}
+
+// ExponentialRetry Policy with Doubling Delay Capped at max
+func ExponentialRetry(initial time.Duration, max time.Duration, attempts int) *RetryPolicy {
+	return &RetryPolicy{
+		Strategy:    RetryExponential,
+		Initial:     int(initial / time.Millisecond),
+		Max:         int(max / time.Millisecond),
+		Multiplier:  2,
+		MaxAttempts: attempts,
+	}
+}
+
+// FibonacciRetry Policy with Fibonacci Delays Capped at max
+func FibonacciRetry(initial time.Duration, max time.Duration, attempts int) *RetryPolicy {
+	return &RetryPolicy{
+		Strategy:    RetryFibonacci,
+		Initial:     int(initial / time.Millisecond),
+		Max:         int(max / time.Millisecond),
+		MaxAttempts: attempts,
+	}
+}
+
+// Attempts Total Number of Attempts Allowed (at least 1)
+func (p *RetryPolicy) Attempts() int {
+	if p == nil || p.MaxAttempts < 1 {
+		return 1
+	}
+
+	return p.MaxAttempts
+}
+
+// CanRetry Is another Attempt Allowed after 'attempt' Attempts?
+func (p *RetryPolicy) CanRetry(attempt int) bool {
+	return attempt < p.Attempts()
+}
+
+// Delay Wait before Retry Number 'retry' (1 = First Retry)
+func (p *RetryPolicy) Delay(retry int) time.Duration {
+	if p == nil || p.Initial <= 0 {
+		return 0
+	}
+
+	if retry < 1 {
+		retry = 1
+	}
+
+	d := float64(p.Initial)
+	switch strings.ToLower(p.Strategy) {
+	case RetryExponential:
+		m := p.Multiplier
+		if m <= 1 {
+			m = 2
+		}
+		d *= math.Pow(m, float64(retry-1))
+	case RetryFibonacci:
+		a, b := 1.0, 1.0
+		for i := 1; i < retry; i++ {
+			a, b = b, a+b
+		}
+		d *= a
+	}
+
+	// Apply Cap
+	if p.Max > 0 && d > float64(p.Max) {
+		d = float64(p.Max)
+	}
+
+	// Apply Jitter (+/- Fraction of Delay)
+	if p.Jitter > 0 {
+		j := math.Min(p.Jitter, 1)
+		d += d * j * (2*rand.Float64() - 1)
+	}
+
+	// Guard against Overflow
+	if d > float64(math.MaxInt64/int64(time.Millisecond)) {
+		d = float64(math.MaxInt64 / int64(time.Millisecond))
+	}
+
+	return time.Duration(d) * time.Millisecond
+}
+
+// Override Copy of Policy with Non-Zero Fields of 'o' Applied
+func (p *RetryPolicy) Override(o *RetryPolicy) *RetryPolicy {
+	// Do we have an Override?
+	if o == nil { // NO: Use Base
+		return p
+	}
+
+	r := &RetryPolicy{}
+	if p != nil {
+		*r = *p
+	}
+
+	if o.Strategy != "" {
+		r.Strategy = o.Strategy
+	}
+
+	if o.Initial != 0 {
+		r.Initial = o.Initial
+	}
+
+	if o.Max != 0 {
+		r.Max = o.Max
+	}
+
+	if o.Multiplier != 0 {
+		r.Multiplier = o.Multiplier
+	}
+
+	if o.Jitter != 0 {
+		r.Jitter = o.Jitter
+	}
+
+	if o.MaxAttempts != 0 {
+		r.MaxAttempts = o.MaxAttempts
+	}
+
+	return r
+}
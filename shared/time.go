@@ -0,0 +1,100 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Time Stamps are Written as RFC 3339 in UTC (Second Precision) and Read
+// Leniently: RFC 3339, RFC 3339 with Fractional Seconds or Epoch Milliseconds.
+
+// FormatTimeStamp Format Time as RFC 3339 in UTC
+func FormatTimeStamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// ParseTimeStamp Parse RFC 3339 (Fractional Seconds Allowed) or Epoch Milliseconds
+func ParseTimeStamp(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}, fmt.Errorf("%w: empty", ErrInvalidTimeStamp)
+	}
+
+	// Is it Epoch Milliseconds?
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil { // YES
+		return time.UnixMilli(ms).UTC(), nil
+	}
+
+	// NOTE: RFC3339Nano Layout also Accepts Time Stamps without Fractional Seconds
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w [%s]", ErrInvalidTimeStamp, s)
+	}
+	return t, nil
+}
+
+// ParseTimeValue Parse Decoded Value (String, Epoch Milliseconds Number or Time)
+func ParseTimeValue(v interface{}) (time.Time, error) {
+	switch tv := v.(type) {
+	case time.Time:
+		return tv, nil
+	case *time.Time:
+		if tv != nil {
+			return *tv, nil
+		}
+	case string:
+		return ParseTimeStamp(tv)
+	case json.Number:
+		return ParseTimeStamp(tv.String())
+	case float64:
+		return time.UnixMilli(int64(tv)).UTC(), nil
+	case int64:
+		return time.UnixMilli(tv).UTC(), nil
+	case int:
+		return time.UnixMilli(int64(tv)).UTC(), nil
+	}
+
+	return time.Time{}, fmt.Errorf("%w: unsupported value [%T]", ErrInvalidTimeStamp, v)
+}
+
+// EpochMillis Time as Milliseconds since Unix Epoch
+func EpochMillis(t time.Time) int64 {
+	return t.UnixMilli()
+}
+
+// ToJSONTimeStamp Format Time as RFC 3339 in UTC ("" if nil)
+func ToJSONTimeStamp(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return FormatTimeStamp(*t)
+}
+
+// FromJSONTimeStamp Parse Time Stamp (nil if Empty or Invalid, use ParseTimeStamp for the Error)
+func FromJSONTimeStamp(t string) *time.Time {
+	timestamp, err := ParseTimeStamp(t)
+	if err != nil {
+		return nil
+	}
+
+	return &timestamp
+}
+
+// UTCTimeStamp Return UTC Time Stamp String in RFC 3339
+func UTCTimeStamp() string {
+	return FormatTimeStamp(time.Now())
+}
@@ -0,0 +1,95 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore amqps
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+)
+
+// Default Broker Ports
+const (
+	DefaultPort    = 5672 // AMQP
+	DefaultTLSPort = 5671 // AMQPS
+)
+
+// UseTLS Does Connection use AMQPS?
+func (c *AMQPConnection) UseTLS() bool {
+	return c.TLS != nil && c.TLS.Enabled
+}
+
+// Scheme URI Scheme for Connection (amqp or amqps)
+func (c *AMQPConnection) Scheme() string {
+	if c.UseTLS() {
+		return "amqps"
+	}
+	return "amqp"
+}
+
+// TLSConfig TLS Client Configuration for Connection (nil if TLS is not Enabled)
+func (c *AMQPConnection) TLSConfig() (*tls.Config, error) {
+	// Is TLS Enabled?
+	if !c.UseTLS() { // NO
+		return nil, nil
+	}
+
+	cfg, err := c.TLS.Config()
+	if err != nil {
+		return nil, err
+	}
+
+	// Verify Certificate against Server Host (unless Overridden)
+	if cfg.ServerName == "" && c.Server != nil {
+		cfg.ServerName = c.Server.Host
+	}
+	return cfg, nil
+}
+
+// Config Build TLS Client Configuration (Loads CA and Client Certificates)
+func (t *TLS) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	// [OPTIONAL] Private CA (DEFAULT: System Roots)
+	if t.CACert != "" {
+		pem, err := os.ReadFile(t.CACert)
+		if err != nil {
+			return nil, fmt.Errorf("%w: reading ca_cert [%v]", ErrInvalidTLS, err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%w: no certificates in ca_cert [%s]", ErrInvalidTLS, t.CACert)
+		}
+		cfg.RootCAs = pool
+	}
+
+	// Do we have a Client Certificate?
+	if t.ClientCert != "" || t.ClientKey != "" { // YES: Both Parts Required
+		if t.ClientCert == "" || t.ClientKey == "" {
+			return nil, fmt.Errorf("%w: client_cert and client_key must be set together", ErrInvalidTLS)
+		}
+
+		cert, err := tls.LoadX509KeyPair(t.ClientCert, t.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("%w: loading client certificate [%v]", ErrInvalidTLS, err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return cfg, nil
+}
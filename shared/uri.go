@@ -0,0 +1,119 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore vhost
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Replaces Password in Redacted URIs (Same as url.URL.Redacted)
+const RedactedPassword = "xxxxx"
+
+// URI Build AMQP URI for Connection (User and Password Default to guest)
+func (c *AMQPConnection) URI() (string, error) {
+	return c.buildURI(false)
+}
+
+// RedactedURI Build AMQP URI for Connection with Password Hidden
+func (c *AMQPConnection) RedactedURI() (string, error) {
+	return c.buildURI(true)
+}
+
+func (c *AMQPConnection) buildURI(redact bool) (string, error) {
+	// Do we have a User Defined?
+	user := c.User
+	if user == "" { // NO
+		user = "guest"
+	}
+
+	// Do we have a Password Defined?
+	password := c.Password
+	if password == "" { // NO
+		password = "guest"
+	}
+
+	// URI BUILT ACCORDING TO https://www.rabbitmq.com/uri-spec.html
+	// NOTE: url.URL Escapes Reserved Characters in Credentials and Virtual Host
+	u := &url.URL{
+		Scheme: c.Scheme(),
+		User:   url.UserPassword(user, password),
+	}
+
+	// CONNECTION COMPONENT of URI //
+
+	// Do we have a Servers Definition?
+	server := c.Server
+	if server == nil { // NO
+		return "", fmt.Errorf("%w: missing server", ErrInvalidServer)
+	}
+
+	// Get Server Host (IP or ADDRESS)
+	host := server.Host
+	if host == "" {
+		return "", fmt.Errorf("%w: missing host", ErrInvalidServer)
+	}
+
+	// Does Server Have Specific Port?
+	switch {
+	case server.Port != 0: // YES: Build Server Address
+		u.Host = net.JoinHostPort(host, strconv.Itoa(server.Port))
+	case strings.Contains(host, ":"): // NO: IPv6 Literal
+		u.Host = "[" + host + "]"
+	default:
+		u.Host = host
+	}
+
+	// [OPTIONAL] Virtual Host (i.e. "/" is Encoded as "%2F")
+	if c.VHost != "" {
+		u.Path = "/" + c.VHost
+		u.RawPath = "/" + url.PathEscape(c.VHost)
+	}
+
+	// [OPTIONAL] Server Options (Those the URI Spec Defines)
+	options, err := c.ConnectionOptions()
+	if err != nil {
+		return "", err
+	}
+	u.RawQuery = options.Query().Encode()
+
+	// Should we hide the Password?
+	if redact { // YES
+		return u.Redacted(), nil
+	}
+	return u.String(), nil
+}
+
+// URIs List of Server URIs for Queue (Invalid Server Definitions are Skipped)
+func (q *Queue) URIs() []string {
+	return q.uris(false)
+}
+
+// RedactedURIs List of Server URIs for Queue, with Passwords Hidden
+func (q *Queue) RedactedURIs() []string {
+	return q.uris(true)
+}
+
+func (q *Queue) uris(redact bool) []string {
+	l := make([]string, 0, len(q.Servers))
+	for i := range q.Servers {
+		uri, err := q.Servers[i].buildURI(redact)
+		if err == nil {
+			l = append(l, uri)
+		}
+	}
+
+	return l
+}
@@ -0,0 +1,67 @@
+package shared
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore vhost
+
+import (
+	"strings"
+	"testing"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+func TestURIEscapesCredentialsAndVHost(t *testing.T) {
+	c := &AMQPConnection{
+		User:     "app@tenant",
+		Password: "p@ss:w/rd?#%",
+		Server:   &Server{Host: "rabbit.local", Port: 5672},
+		VHost:    "/",
+	}
+
+	uri, err := c.URI()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Does the Client Parse back the Same Settings?
+	u, err := amqp.ParseURI(uri)
+	if err != nil {
+		t.Fatalf("client can't parse [%s]: %v", uri, err)
+	}
+	if u.Username != c.User || u.Password != c.Password || u.Host != "rabbit.local" || u.Port != 5672 || u.Vhost != "/" {
+		t.Errorf("parsed %+v from [%s]", u, uri)
+	}
+
+	redacted, err := c.RedactedURI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(redacted, "p%40ss") || redacted != strings.Replace(uri, "p%40ss%3Aw%2Frd%3F%23%25", RedactedPassword, 1) {
+		t.Errorf("redacted [%s] does not match [%s]", redacted, uri)
+	}
+}
+
+func TestURIIPv6Host(t *testing.T) {
+	for _, port := range []int{0, 5671} {
+		c := &AMQPConnection{Server: &Server{Host: "::1", Port: port}}
+
+		uri, err := c.URI()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		u, err := amqp.ParseURI(uri)
+		if err != nil || u.Host != "::1" {
+			t.Errorf("parsed [%+v] from [%s] (%v)", u, uri, err)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package sqlstore
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// cSpell:ignore bytea sqlstore
+
+// Dialect SQL Differences between Databases (Shared by the database/sql Stores)
+type Dialect struct {
+	Name        string             // Dialect Name
+	Placeholder func(n int) string // Bind Parameter for 'n'th (1 based) Argument
+	BlobType    string             // Column Type for Message Body
+	SkipLocked  string             // Row Lock Clause Skipping Rows Locked by Others ("": Unsupported)
+
+	// Insert Skipping a Row whose Key Exists (Format Arguments: Table, Columns, Values)
+	InsertIgnore string
+}
+
+var (
+	DialectMySQL = &Dialect{
+		Name:        "mysql",
+		Placeholder: func(int) string { return "?" },
+		BlobType:    "LONGBLOB",
+		SkipLocked:  " FOR UPDATE SKIP LOCKED", // MySQL 8.0+
+
+		InsertIgnore: "INSERT IGNORE INTO %s (%s) VALUES (%s)",
+	}
+
+	DialectPostgres = &Dialect{
+		Name:        "postgres",
+		Placeholder: func(n int) string { return fmt.Sprintf("$%d", n) },
+		BlobType:    "BYTEA",
+		SkipLocked:  " FOR UPDATE SKIP LOCKED",
+
+		InsertIgnore: "INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING",
+	}
+
+	// NOTE: SQLite Serializes Writers, Rows need no Locking
+	DialectSQLite = &Dialect{
+		Name:        "sqlite",
+		Placeholder: func(int) string { return "?" },
+		BlobType:    "BLOB",
+
+		InsertIgnore: "INSERT INTO %s (%s) VALUES (%s) ON CONFLICT DO NOTHING", // SQLite 3.24+
+	}
+)
+
+// Execer Common to *sql.DB and *sql.Tx
+type Execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
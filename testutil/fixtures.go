@@ -0,0 +1,112 @@
+package testutil
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore testutil
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+)
+
+// Channel Name used by Fixtures
+const fixtureChannel = "testutil"
+
+// Queue Declared for a Test
+type QueueFixture struct {
+	conn *queue.AMQPServerConnection
+	name string // Queue Name (without Prefix)
+}
+
+// UniquePrefix Prefix that Isolates Queues of Concurrent Test Runs
+func UniquePrefix(base string) string {
+	return fmt.Sprintf("%s-%d", base, time.Now().UnixNano())
+}
+
+// NewQueueFixture Declare Queue on Connection (Connection Prefix is Applied)
+func NewQueueFixture(conn *queue.AMQPServerConnection, name string) (*QueueFixture, error) {
+	_, err := conn.OpenQueueChannel(fixtureChannel, name, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return &QueueFixture{conn: conn, name: name}, nil
+}
+
+// Name Queue Name (as Passed to Connection Methods)
+func (f *QueueFixture) Name() string {
+	return f.name
+}
+
+// FullName Queue Name on Broker (Prefix Applied)
+func (f *QueueFixture) FullName() string {
+	n, _ := f.conn.QueueName(f.name)
+	return n
+}
+
+// Publish JSON Message to Queue
+func (f *QueueFixture) Publish(msg interface{}) error {
+	return f.conn.QueuePublishJSON(fixtureChannel, f.name, msg)
+}
+
+// WaitForMessage Wait for Message on Queue (Message is Acknowledged)
+func (f *QueueFixture) WaitForMessage(timeout time.Duration) (*amqp.Delivery, error) {
+	return WaitForMessage(f.conn, f.name, timeout)
+}
+
+// Purge Remove all Messages from Queue
+func (f *QueueFixture) Purge() error {
+	ch, err := f.conn.OpenQueueChannel(fixtureChannel, f.name, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.QueuePurge(f.FullName(), false)
+	return err
+}
+
+// Delete Remove Queue from Broker
+func (f *QueueFixture) Delete() error {
+	ch, err := f.conn.OpenQueueChannel(fixtureChannel, f.name, false)
+	if err != nil {
+		return err
+	}
+
+	_, err = ch.QueueDelete(f.FullName(), false, false, false)
+	return err
+}
+
+// WaitForMessage Wait for Message on Queue (Message is Acknowledged)
+func WaitForMessage(conn *queue.AMQPServerConnection, name string, timeout time.Duration) (*amqp.Delivery, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		d, err := conn.QueueRetrieve(fixtureChannel, name)
+		if err != nil {
+			return nil, err
+		}
+
+		// Did we Receive a Message?
+		if d != nil { // YES
+			return d, d.Ack(false)
+		}
+
+		// Have we Waited Long Enough?
+		if time.Now().After(deadline) { // YES: Abort
+			return nil, errors.New("[WaitForMessage] Timed out Waiting for Message")
+		}
+
+		time.Sleep(50 * time.Millisecond)
+	}
+}
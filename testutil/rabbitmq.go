@@ -0,0 +1,229 @@
+package testutil
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore testutil
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// Environment Variables Controlling the Harness
+const (
+	EnvHost  = "QI_TEST_AMQP_HOST"  // Use Existing Broker instead of Docker
+	EnvPort  = "QI_TEST_AMQP_PORT"  // [OPTIONAL] Existing Broker Port (DEFAULT 5672)
+	EnvImage = "QI_TEST_AMQP_IMAGE" // [OPTIONAL] Docker Image (DEFAULT rabbitmq:3-alpine)
+)
+
+const defaultImage = "rabbitmq:3-alpine"
+
+// ErrNoDocker Docker CLI Missing or Daemon not Running
+var ErrNoDocker = errors.New("[StartRabbitMQ] Docker not Available")
+
+// RabbitMQ Broker used for Integration Tests
+type RabbitMQ struct {
+	container string // Docker Container ID ("" if Broker is External)
+	Host      string // Broker Host
+	Port      int    // Broker AMQP Port
+	User      string // Broker User
+	Password  string // Broker Password
+}
+
+// StartRabbitMQ Start Broker Container (or Attach to Broker in Environment)
+//
+// Caller must Stop the Broker (see RequireRabbitMQ for Tests).
+func StartRabbitMQ(timeout time.Duration) (*RabbitMQ, error) {
+	return startRabbitMQ(timeout, nil)
+}
+
+// startRabbitMQ Start Broker, Calling 'started' as soon as a Container Exists
+func startRabbitMQ(timeout time.Duration, started func(r *RabbitMQ)) (*RabbitMQ, error) {
+	r := &RabbitMQ{
+		User:     "guest",
+		Password: "guest",
+	}
+
+	// Do we have an External Broker?
+	if host := os.Getenv(EnvHost); host != "" { // YES: Use it
+		r.Host = host
+		r.Port = 5672
+		if p := os.Getenv(EnvPort); p != "" {
+			port, err := strconv.Atoi(p)
+			if err != nil {
+				return nil, fmt.Errorf("[StartRabbitMQ] Invalid %s [%s]", EnvPort, p)
+			}
+			r.Port = port
+		}
+
+		return r, r.waitReady(timeout)
+	}
+
+	// Do we have Docker?
+	if !dockerAvailable() { // NO
+		return nil, ErrNoDocker
+	}
+
+	image := os.Getenv(EnvImage)
+	if image == "" {
+		image = defaultImage
+	}
+
+	// Start Container with Random Host Port
+	out, err := exec.Command("docker", "run", "-d", "--rm", "-p", "127.0.0.1::5672", image).Output()
+	if err != nil {
+		return nil, fmt.Errorf("[StartRabbitMQ] Failed to Start Container [%v]", err)
+	}
+	r.container = strings.TrimSpace(string(out))
+
+	// Register Removal before anything else can Fail
+	if started != nil {
+		started(r)
+	}
+
+	// Find Mapped Port (Format "127.0.0.1:49153")
+	out, err = exec.Command("docker", "port", r.container, "5672/tcp").Output()
+	if err != nil {
+		r.Stop()
+		return nil, fmt.Errorf("[StartRabbitMQ] Failed to Get Container Port [%v]", err)
+	}
+
+	mapping := strings.SplitN(strings.TrimSpace(string(out)), "\n", 2)[0]
+	i := strings.LastIndex(mapping, ":")
+	r.Host = mapping[:i]
+	r.Port, err = strconv.Atoi(mapping[i+1:])
+	if err != nil {
+		r.Stop()
+		return nil, fmt.Errorf("[StartRabbitMQ] Invalid Container Port [%s]", mapping)
+	}
+
+	// Wait for Broker to Accept Connections
+	err = r.waitReady(timeout)
+	if err != nil {
+		r.Stop()
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// RequireRabbitMQ Start Broker for Test (Skips Test if Docker is not Available)
+//
+// Any Other Failure (Container won't Start, Broker never Ready) Fails the
+// Test: a Broken Environment must not Pass as Skipped. The Container is Removed when the Test and its Subtests Finish, even if
+// the Broker Never Becomes Ready.
+func RequireRabbitMQ(tb testing.TB) *RabbitMQ {
+	tb.Helper()
+
+	r, err := startRabbitMQ(60*time.Second, func(r *RabbitMQ) {
+		tb.Cleanup(func() {
+			if err := r.Stop(); err != nil {
+				tb.Logf("failed removing RabbitMQ container: %v", err)
+			}
+		})
+	})
+	// Is Docker Missing?
+	if errors.Is(err, ErrNoDocker) { // YES
+		tb.Skipf("RabbitMQ not available: %v", err)
+	}
+	if err != nil {
+		tb.Fatalf("RabbitMQ failed to start: %v", err)
+	}
+
+	return r
+}
+
+// dockerAvailable Is the Docker CLI Installed and its Daemon Reachable?
+func dockerAvailable() bool {
+	// Do we have the CLI?
+	if _, err := exec.LookPath("docker"); err != nil { // NO
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return exec.CommandContext(ctx, "docker", "info", "--format", "{{.ServerVersion}}").Run() == nil
+}
+
+func (r *RabbitMQ) waitReady(timeout time.Duration) error {
+	uri, _ := r.Connection().URI()
+	deadline := time.Now().Add(timeout)
+	for {
+		c, err := amqp.Dial(uri)
+		if err == nil {
+			return c.Close()
+		}
+
+		// Have we Waited Long Enough?
+		if time.Now().After(deadline) { // YES: Abort
+			return fmt.Errorf("[RabbitMQ] Broker not Ready [%v]", r.Connection().RedactError(err))
+		}
+
+		time.Sleep(500 * time.Millisecond)
+	}
+}
+
+// Stop Remove Broker Container (No-op for External Brokers)
+func (r *RabbitMQ) Stop() error {
+	// Did we Start a Container?
+	if r.container == "" { // NO
+		return nil
+	}
+
+	err := exec.Command("docker", "rm", "-f", r.container).Run()
+	r.container = ""
+	return err
+}
+
+// Connection Server Settings for Broker
+func (r *RabbitMQ) Connection() *shared.AMQPConnection {
+	return &shared.AMQPConnection{
+		User:     r.User,
+		Password: r.Password,
+		Server: &shared.Server{
+			Host: r.Host,
+			Port: r.Port,
+		},
+	}
+}
+
+// Config Queue Configuration for Broker
+func (r *RabbitMQ) Config(prefix string) *shared.Queue {
+	return &shared.Queue{
+		Servers:     []shared.AMQPConnection{*r.Connection()},
+		QueuePrefix: prefix,
+	}
+}
+
+// Connect Open Connection to Broker using Prefix
+func (r *RabbitMQ) Connect(prefix string) (*queue.AMQPServerConnection, error) {
+	c := &queue.AMQPServerConnection{}
+	c.SetConnection(r.Config(prefix).Servers)
+	c.SetPrefix(prefix)
+
+	_, err := c.OpenConnection()
+	if err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
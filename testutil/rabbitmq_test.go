@@ -0,0 +1,47 @@
+package testutil
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+// cSpell:ignore testutil
+
+import (
+	"testing"
+	"time"
+)
+
+// TestRabbitMQRoundTrip Integration Test (Skipped without Docker or QI_TEST_AMQP_HOST)
+func TestRabbitMQRoundTrip(t *testing.T) {
+	r := RequireRabbitMQ(t)
+
+	c, err := r.Connect(UniquePrefix("testutil"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.CloseConnection()
+
+	f, err := NewQueueFixture(c, "round-trip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Delete()
+
+	if err = f.Publish(map[string]string{"text": "hello"}); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := f.WaitForMessage(5 * time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(d.Body) != `{"text":"hello"}` {
+		t.Errorf("body [%s]", d.Body)
+	}
+}
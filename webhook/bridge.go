@@ -0,0 +1,240 @@
+package webhook
+
+/*
+ * This file is part of the ObjectVault Project.
+ * Copyright (C) 2020-2022 Paulo Ferreira <vault at sourcenotes.org>
+ *
+ * This work is published under the GNU AGPLv3.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program.  If not, see <https://www.gnu.org/licenses/>.
+ */
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+
+	"github.com/objectvault/queue-interface/queue"
+	"github.com/objectvault/queue-interface/shared"
+)
+
+// cSpell:ignore hmac
+
+// Request Headers Sent with Every Delivery
+const (
+	HeaderSignature = "X-ObjectVault-Signature" // sha256=<hex hmac of "timestamp.body">
+	HeaderTimestamp = "X-ObjectVault-Timestamp" // Unix Seconds
+	HeaderMessageID = "X-ObjectVault-Message-Id"
+)
+
+// BridgeChannel Channel Used by Bridge Consumers
+const BridgeChannel = "webhook"
+
+// ErrPermanent Delivery Rejected by Receiver (Retrying won't Help)
+var ErrPermanent = errors.New("[Bridge] Permanent Delivery Failure")
+
+// defaultLogger Used when no Logger is Set
+var defaultLogger queue.Logger = queue.NewStdLogger(nil, queue.LevelInfo)
+
+// Bridge POSTs Queue Messages to an HTTP Endpoint
+type Bridge struct {
+	url     string              // Target URL
+	secret  []byte              // [OPTIONAL] HMAC Signing Secret
+	client  *http.Client        // HTTP Client
+	retry   *shared.RetryPolicy // Retry/Backoff for Temporary Failures
+	headers http.Header         // [OPTIONAL] Extra Request Headers
+	poll    time.Duration       // Wait between Retrieves when Queue is Empty
+	logger  queue.Logger        // [OPTIONAL] Structured Logger (DEFAULT: Standard Logger)
+}
+
+func NewBridge(url string, secret string) *Bridge {
+	return &Bridge{
+		url:     url,
+		secret:  []byte(secret),
+		client:  &http.Client{Timeout: 30 * time.Second},
+		retry:   shared.ExponentialRetry(time.Second, 30*time.Second, 5),
+		headers: http.Header{},
+		poll:    time.Second,
+	}
+}
+
+func (b *Bridge) SetHTTPClient(c *http.Client) *Bridge {
+	if c != nil {
+		b.client = c
+	}
+	return b
+}
+
+func (b *Bridge) SetRetryPolicy(p *shared.RetryPolicy) *Bridge {
+	if p != nil {
+		b.retry = p
+	}
+	return b
+}
+
+// SetHeader Extra Header Sent with Every Request (i.e. Authorization)
+func (b *Bridge) SetHeader(name string, value string) *Bridge {
+	b.headers.Set(name, value)
+	return b
+}
+
+// Logger Bridge Logger (Standard Logger if not Set)
+func (b *Bridge) Logger() queue.Logger {
+	// Do we have a Logger?
+	if b.logger == nil { // NO: Use Standard Logger
+		return defaultLogger
+	}
+	return b.logger
+}
+
+// SetLogger Structured Logger (nil: Standard Logger)
+func (b *Bridge) SetLogger(l queue.Logger) *Bridge {
+	b.logger = l
+	return b
+}
+
+// Sign Signature for Body Sent at Timestamp
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	h.Write([]byte("."))
+	h.Write(body)
+	return "sha256=" + hex.EncodeToString(h.Sum(nil))
+}
+
+// Verify Check Signature (for Receivers Written in Go)
+func Verify(secret []byte, timestamp int64, body []byte, signature string) bool {
+	return hmac.Equal([]byte(Sign(secret, timestamp, body)), []byte(signature))
+}
+
+// Deliver POST Message, Retrying Temporary Failures as per Policy
+func (b *Bridge) Deliver(ctx context.Context, d *amqp.Delivery) error {
+	body, err := payload(d)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+
+	id := queue.MessageID(d)
+	for attempt := 1; ; attempt++ {
+		err = b.post(ctx, id, body)
+		if err == nil || errors.Is(err, ErrPermanent) || !b.retry.CanRetry(attempt) {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(b.retry.Delay(attempt)):
+		}
+	}
+}
+
+// Handler Bridge as Consumer Handler
+func (b *Bridge) Handler() queue.Handler {
+	return b.Deliver
+}
+
+// Run Consume Queue until Context is Cancelled (Permanent Failures are Dead Lettered)
+func (b *Bridge) Run(ctx context.Context, c *queue.AMQPServerConnection, name string) error {
+	for {
+		// Has Caller Stopped the Bridge?
+		if err := ctx.Err(); err != nil { // YES
+			return err
+		}
+
+		d, err := c.QueueRetrieve(BridgeChannel, name)
+		if err != nil {
+			return err
+		}
+
+		// Is Queue Empty?
+		if d == nil { // YES: Wait
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(b.poll):
+			}
+			continue
+		}
+
+		err = b.Deliver(ctx, d)
+		switch {
+		case err == nil:
+			err = d.Ack(false)
+		case errors.Is(err, ErrPermanent):
+			b.Logger().Warn("[Bridge] Rejecting Message", queue.F("message_id", queue.MessageID(d)), queue.F("error", err))
+			err = d.Nack(false, false)
+		default:
+			b.Logger().Warn("[Bridge] Requeueing Message", queue.F("message_id", queue.MessageID(d)), queue.F("error", err))
+			err = d.Nack(false, true)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (b *Bridge) post(ctx context.Context, id string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrPermanent, err)
+	}
+
+	for k, v := range b.headers {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if id != "" {
+		req.Header.Set(HeaderMessageID, id)
+	}
+
+	// Do we Sign Requests?
+	if len(b.secret) > 0 { // YES
+		ts := time.Now().Unix()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(HeaderSignature, Sign(b.secret, ts, body))
+	}
+
+	res, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+
+	switch {
+	case res.StatusCode < 300:
+		return nil
+	case res.StatusCode == http.StatusTooManyRequests || res.StatusCode >= 500:
+		return fmt.Errorf("[Bridge] Receiver Returned [%d]", res.StatusCode)
+	default:
+		return fmt.Errorf("%w: Receiver Returned [%d]", ErrPermanent, res.StatusCode)
+	}
+}
+
+// payload JSON Document Posted for Delivery
+func payload(d *amqp.Delivery) ([]byte, error) {
+	// Is Body JSON?
+	if json.Valid(d.Body) { // YES: Post as is
+		return d.Body, nil
+	}
+
+	// Wrap Non-JSON Bodies
+	return json.Marshal(struct {
+		ContentType string `json:"content_type"`
+		Body        string `json:"body"`
+	}{d.ContentType, string(d.Body)})
+}